@@ -37,6 +37,8 @@ const peerHostAlias = envOrDefault('PEER_HOST_ALIAS', 'peer0.org1.example.com');
 
 const utf8Decoder = new TextDecoder();
 
+// Outdated: this flow registers an order for a user without a platform contract or a trading
+// contract, which the chaincode now rejects. See the README for the current onboarding flow.
 async function main(): Promise<void> {
 
     await displayInputParameters();
@@ -98,7 +100,10 @@ async function main(): Promise<void> {
             paymentID:  1,  
             paymentType: "Buy",
             totalAmount: 100,
+            userId: 6,
             paymentDetailID: 2,
+            orderId: newOrder.id,
+            bidMatchId: '',
             debitedFrom: 6,
             creditedTo: 7,
             totalUnitCost: 10,
@@ -180,21 +185,32 @@ async function newSigner(): Promise<Signer> {
  */
  async function RecordPayment(contract: Contract, payment: Payment): Promise<void> {
     console.log('\n--> Submit Transaction: RecordPayment');
-    await contract.submitTransaction(
-        'RecordPayment',
-        payment.paymentID.toString(),
-        payment.paymentType.toString(), // Assuming bidStatus is an enum value
-        payment.totalAmount.toString(), // Assuming id is the corresponding field for payment.ID
-        payment.paymentDetailID.toString(),
-        payment.debitedFrom.toString(),
-        payment.creditedTo.toString(),
-        payment.totalUnitCost.toString(),
-        payment.platformFee.toString(),
-        payment.tokenAmount.toString(),
-        payment.bidRefundAmount.toString(),
-        payment.platformFeeRefundAmount.toString(),
-        payment.penaltyFromSeller.toString() // Assuming action is an enum value
-    );
+    // The payment detail is private data and travels in the transient map, salted for its public hash.
+    const paymentDetail = {
+        debitedFrom: payment.debitedFrom.toString(),
+        creditedTo: payment.creditedTo.toString(),
+        totalUnitCost: payment.totalUnitCost,
+        platformFee: payment.platformFee,
+        tokenAmount: payment.tokenAmount,
+        bidRefundAmount: payment.bidRefundAmount,
+        platformFeeRefundAmount: payment.platformFeeRefundAmount,
+        penaltyFromSeller: payment.penaltyFromSeller,
+    };
+    await contract.submit('RecordPayment', {
+        arguments: [
+            payment.paymentID.toString(),
+            payment.paymentType.toString(),
+            payment.totalAmount.toString(),
+            payment.userId.toString(),
+            payment.paymentDetailID.toString(),
+            payment.orderId.toString(),
+            payment.bidMatchId,
+        ],
+        transientData: {
+            paymentDetail: JSON.stringify(paymentDetail),
+            salt: crypto.randomBytes(32).toString('hex'),
+        },
+    });
 
     console.log('*** Transaction committed successfully');
 }
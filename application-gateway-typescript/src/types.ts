@@ -19,7 +19,10 @@ export type Payment = {
     paymentID: number;
     paymentType: string;
     totalAmount: number;
+    userId: number;
     paymentDetailID: number;
+    orderId: number;
+    bidMatchId: string;
     debitedFrom: number;
     creditedTo: number;
     totalUnitCost: number;
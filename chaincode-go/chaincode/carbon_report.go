@@ -0,0 +1,285 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                           Carbon Report Methods                            */
+/* -------------------------------------------------------------------------- */
+
+// SetEmissionFactor sets the CO2 emitted per unit of energy from a source. Sources are matched
+// regardless of case.
+//
+// Inputs - Array of strings
+//
+//	0       ,    1
+//	Source  ,    KgCO2PerUnit
+//	"Grid"  ,    "0.42"
+func SetEmissionFactor(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting SetEmissionFactor")
+
+	if len(args) != 2 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 2.")
+	}
+
+	err := requireRole(stub, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	source := strings.TrimSpace(args[0])
+	if source == "" {
+		return statusResponse(StatusInvalidArgument, "Source must be a non-empty string.")
+	}
+	kgCO2PerUnit, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse KgCO2PerUnit: "+err.Error())
+	}
+	if kgCO2PerUnit < 0 {
+		return statusResponse(StatusInvalidArgument, "KgCO2PerUnit can not be negative.")
+	}
+
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	factor := EmissionFactor{KgCO2PerUnit: kgCO2PerUnit, Source: source, UpdatedOn: now}
+	factorAsBytes, _ := json.Marshal(factor)
+	err = stub.PutState(getEmissionFactorKey(source), factorAsBytes)
+	if err != nil {
+		return shim.Error("Could not store emission factor: " + err.Error())
+	}
+
+	fmt.Println("- end SetEmissionFactor")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// ReadEmissionFactors returns every emission factor.
+func ReadEmissionFactors(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadEmissionFactors")
+
+	if len(args) != 0 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 0.")
+	}
+
+	factors, err := getEmissionFactors(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	list := []EmissionFactor{}
+	for _, factor := range factors {
+		list = append(list, factor)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Source < list[j].Source })
+	factorsAsBytes, _ := json.Marshal(list)
+
+	fmt.Println("- end ReadEmissionFactors")
+	return shim.Success(factorsAsBytes)
+}
+
+// ReadCarbonReport returns the emissions attributed to and avoided by a participant's purchases
+// first settled in a date range. Energy delivered by a seller is attributed the factor of the seller's
+// Source and avoids the difference to the grid factor; grid imports are attributed the grid
+// factor. Sellers without a known factor count as grid energy.
+//
+// Inputs - Array of strings
+//
+//	0      ,    1                  ,    2
+//	UserID ,    From (unix seconds),    To (unix seconds, exclusive)
+func ReadCarbonReport(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadCarbonReport")
+
+	if len(args) != 3 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 3 (UserID, From, To)")
+	}
+
+	report := CarbonReport{BySlot: []CarbonFigures{}, ByCounterparty: []CarbonFigures{}, UserID: args[0]}
+	var err error
+	report.From, err = strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse From: "+err.Error())
+	}
+	report.To, err = strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse To: "+err.Error())
+	}
+	if report.To <= report.From {
+		return statusResponse(StatusInvalidArgument, "To must be after From.")
+	}
+
+	factors, err := getEmissionFactors(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	gridFactor, ok := factors[strings.ToLower(GridSource)]
+	if !ok {
+		return statusResponse(StatusNotFound, "No emission factor set for source "+GridSource+".")
+	}
+
+	energyBids, err := getStatesByPrefix(stub, "EnergyBid_")
+	if err != nil {
+		return shim.Error("Failed to scan energy bids: " + err.Error())
+	}
+	bySlot := make(map[string]*CarbonFigures)
+	byCounterparty := make(map[string]*CarbonFigures)
+	sources := make(map[string]string)
+	for _, kv := range energyBids {
+		var energyBid EnergyBid
+		err = json.Unmarshal(kv.Value, &energyBid)
+		if err != nil {
+			return shim.Error("Failed to unmarshal energy bid " + kv.Key + ": " + err.Error())
+		}
+		// Reprocessing resets CreatedOn, so deliveries are dated by their first settlement as on
+		// the invoice. Energy bids settled before SettledOn was recorded fall back to CreatedOn.
+		settledOn := energyBid.SettledOn
+		if settledOn == 0 {
+			settledOn = energyBid.CreatedOn
+		}
+		if settledOn < report.From || settledOn >= report.To {
+			continue
+		}
+		bidMatch, err := getBidMatch(stub, energyBid.BidMatchID)
+		if err != nil {
+			return errorResponse(err)
+		}
+		if bidMatch.BuyerUserId != report.UserID {
+			continue
+		}
+
+		source, ok := sources[bidMatch.SellerUserId]
+		if !ok {
+			source, err = getUserSource(stub, bidMatch.SellerUserId)
+			if err != nil {
+				return errorResponse(err)
+			}
+			sources[bidMatch.SellerUserId] = source
+		}
+		sellerFactor, ok := factors[strings.ToLower(source)]
+		if !ok {
+			sellerFactor = gridFactor
+		}
+
+		delivered := CarbonFigures{
+			AttributedKgCO2: energyBid.BuyerBroughtUnitFromSeller * sellerFactor.KgCO2PerUnit,
+			AvoidedKgCO2:    energyBid.BuyerBroughtUnitFromSeller * (gridFactor.KgCO2PerUnit - sellerFactor.KgCO2PerUnit),
+			DeliveredUnits:  energyBid.BuyerBroughtUnitFromSeller,
+		}
+		imported := CarbonFigures{
+			AttributedKgCO2: energyBid.BuyerBroughtUnitFromGrid * gridFactor.KgCO2PerUnit,
+			GridImportUnits: energyBid.BuyerBroughtUnitFromGrid,
+		}
+		for _, figures := range []CarbonFigures{delivered, imported} {
+			addCarbonFigures(&report.Total, figures)
+			addCarbonFigures(getCarbonFigures(bySlot, bidMatch.BidSlot), figures)
+		}
+		addCarbonFigures(getCarbonFigures(byCounterparty, bidMatch.SellerUserId), delivered)
+		addCarbonFigures(getCarbonFigures(byCounterparty, GridSource), imported)
+	}
+
+	report.BySlot = sortedCarbonFigures(bySlot)
+	report.ByCounterparty = sortedCarbonFigures(byCounterparty)
+	reportAsBytes, _ := json.Marshal(report)
+
+	fmt.Println("- end ReadCarbonReport")
+	return shim.Success(reportAsBytes)
+}
+
+// getEmissionFactorKey returns the state key of the emission factor of a source
+func getEmissionFactorKey(source string) string {
+	return "EmissionFactor_" + strings.ToLower(source)
+}
+
+// getEmissionFactors returns every emission factor by lower case source
+func getEmissionFactors(stub shim.ChaincodeStubInterface) (map[string]EmissionFactor, error) {
+	kvs, err := getStatesByPrefix(stub, "EmissionFactor_")
+	if err != nil {
+		return nil, errors.New("Failed to scan emission factors: " + err.Error())
+	}
+	factors := make(map[string]EmissionFactor)
+	for _, kv := range kvs {
+		var factor EmissionFactor
+		err = json.Unmarshal(kv.Value, &factor)
+		if err != nil {
+			return nil, errors.New("Failed to unmarshal emission factor " + kv.Key + ": " + err.Error())
+		}
+		factors[strings.ToLower(factor.Source)] = factor
+	}
+	return factors, nil
+}
+
+// getUserSource returns the energy source of a User or EnterpriseUser
+func getUserSource(stub shim.ChaincodeStubInterface, userID string) (string, error) {
+	userAsBytes, err := stub.GetState(userID)
+	if err != nil {
+		return "", errors.New("Error accessing state: " + err.Error())
+	}
+	if userAsBytes == nil {
+		return "", nil
+	}
+	var user struct {
+		Source string `json:"source"`
+	}
+	err = json.Unmarshal(userAsBytes, &user)
+	if err != nil {
+		return "", errors.New("Failed to unmarshal user: " + err.Error())
+	}
+	return strings.TrimSpace(user.Source), nil
+}
+
+// getCarbonFigures returns the figures of a key, adding them to the map when missing
+func getCarbonFigures(figures map[string]*CarbonFigures, key string) *CarbonFigures {
+	if _, ok := figures[key]; !ok {
+		figures[key] = &CarbonFigures{Key: key}
+	}
+	return figures[key]
+}
+
+// addCarbonFigures adds figures to a total
+func addCarbonFigures(total *CarbonFigures, figures CarbonFigures) {
+	total.AttributedKgCO2 += figures.AttributedKgCO2
+	total.AvoidedKgCO2 += figures.AvoidedKgCO2
+	total.DeliveredUnits += figures.DeliveredUnits
+	total.GridImportUnits += figures.GridImportUnits
+}
+
+// sortedCarbonFigures lists the figures of a map by key, leaving out keys without units
+func sortedCarbonFigures(figures map[string]*CarbonFigures) []CarbonFigures {
+	list := []CarbonFigures{}
+	for _, f := range figures {
+		if f.DeliveredUnits == 0 && f.GridImportUnits == 0 {
+			continue
+		}
+		list = append(list, *f)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list
+}
@@ -0,0 +1,96 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCarbonReport(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	stub.Creator = newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+
+	put := func(key string, value interface{}) {
+		valueAsBytes, _ := json.Marshal(value)
+		stub.MockTransactionStart("put")
+		stub.PutState(key, valueAsBytes)
+		stub.MockTransactionEnd("put")
+	}
+	put("100", User{ID: "100", Source: "Grid"})
+	put("101", User{ID: "101", Source: "solar"})
+	put("102", User{ID: "102", Source: "Diesel"})
+	put("BidMatch_M1", BidMatch{ID: "M1", BidSlot: "slotA", BuyerUserId: "100", SellerUserId: "101"})
+	put("BidMatch_M2", BidMatch{ID: "M2", BidSlot: "slotB", BuyerUserId: "100", SellerUserId: "102"})
+	put("BidMatch_M3", BidMatch{ID: "M3", BidSlot: "slotA", BuyerUserId: "102", SellerUserId: "101"})
+	put("EnergyBid_E1", EnergyBid{ID: "E1", BidMatchID: "M1", BuyerBroughtUnitFromSeller: 10, BuyerBroughtUnitFromGrid: 2, CreatedOn: 1000})
+	put("EnergyBid_E2", EnergyBid{ID: "E2", BidMatchID: "M2", BuyerBroughtUnitFromSeller: 4, CreatedOn: 1500})
+	put("EnergyBid_E3", EnergyBid{ID: "E3", BidMatchID: "M3", BuyerBroughtUnitFromSeller: 7, CreatedOn: 1500})
+	put("EnergyBid_E4", EnergyBid{ID: "E4", BidMatchID: "M1", BuyerBroughtUnitFromSeller: 9, CreatedOn: 5000})
+
+	readCarbonReport := func(txID string, from int64, to int64) (CarbonReport, string) {
+		response := stub.MockInvoke(txID, [][]byte{[]byte("ReadCarbonReport"), []byte("100"),
+			[]byte(strconv.FormatInt(from, 10)), []byte(strconv.FormatInt(to, 10))})
+		var report CarbonReport
+		json.Unmarshal(response.GetPayload(), &report)
+		return report, response.GetMessage()
+	}
+
+	// Test Case 1: The grid factor is required as the baseline
+	t.Run("Missing Grid Factor", func(t *testing.T) {
+		_, message := readCarbonReport("1", 0, 2000)
+		assert.Contains(t, message, "No emission factor set for source Grid")
+	})
+
+	// Test Case 2: Only admins set emission factors
+	t.Run("Unauthorized", func(t *testing.T) {
+		stub.Creator = newCreator(t, "Org2MSP", nil)
+		response := stub.MockInvoke("2", [][]byte{[]byte("SetEmissionFactor"), []byte("Grid"), []byte("0.5")})
+		assert.Equal(t, StatusUnauthorized, response.GetStatus(), "Function unexpectedly succeeded")
+	})
+
+	// Test Case 3: Purchases are broken down by slot and counterparty
+	t.Run("Report", func(t *testing.T) {
+		stub.Creator = newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+		for i, factor := range [][]string{{"Grid", "0.5"}, {"Solar", "0.05"}} {
+			response := stub.MockInvoke(strconv.Itoa(3+i), [][]byte{[]byte("SetEmissionFactor"), []byte(factor[0]), []byte(factor[1])})
+			assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		}
+
+		report, message := readCarbonReport("5", 0, 2000)
+		assert.Empty(t, message, "Unexpected error")
+		assert.Equal(t, 14.0, report.Total.DeliveredUnits, "Delivered units mismatch")
+		assert.Equal(t, 2.0, report.Total.GridImportUnits, "Grid import units mismatch")
+		assert.InDelta(t, 3.5, report.Total.AttributedKgCO2, 0.0001, "Attributed emissions mismatch")
+		assert.InDelta(t, 4.5, report.Total.AvoidedKgCO2, 0.0001, "Avoided emissions mismatch")
+
+		if assert.Len(t, report.BySlot, 2, "Slot count mismatch") {
+			assert.Equal(t, "slotA", report.BySlot[0].Key, "Slot mismatch")
+			assert.InDelta(t, 1.5, report.BySlot[0].AttributedKgCO2, 0.0001, "Slot emissions mismatch")
+		}
+		if assert.Len(t, report.ByCounterparty, 3, "Counterparty count mismatch") {
+			assert.Equal(t, "101", report.ByCounterparty[0].Key, "Counterparty mismatch")
+			assert.InDelta(t, 4.5, report.ByCounterparty[0].AvoidedKgCO2, 0.0001, "Counterparty avoided emissions mismatch")
+			assert.Equal(t, "102", report.ByCounterparty[1].Key, "Counterparty mismatch")
+			assert.Equal(t, 0.0, report.ByCounterparty[1].AvoidedKgCO2, "Unknown source avoided emissions")
+			assert.Equal(t, GridSource, report.ByCounterparty[2].Key, "Grid counterparty mismatch")
+		}
+	})
+
+	// Test Case 4: Reprocessed deliveries stay in the period of their first settlement
+	t.Run("Reprocessed Delivery", func(t *testing.T) {
+		put("EnergyBid_E5", EnergyBid{ID: "E5", BidMatchID: "M1", BuyerBroughtUnitFromSeller: 3, CreatedOn: 5000, SettledOn: 1200})
+
+		report, message := readCarbonReport("6", 0, 2000)
+		assert.Empty(t, message, "Unexpected error")
+		assert.Equal(t, 17.0, report.Total.DeliveredUnits, "Delivered units mismatch")
+
+		report, message = readCarbonReport("7", 4000, 6000)
+		assert.Empty(t, message, "Unexpected error")
+		assert.Equal(t, 9.0, report.Total.DeliveredUnits, "Delivered units mismatch")
+	})
+}
@@ -0,0 +1,360 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                            Certificate Methods                             */
+/* -------------------------------------------------------------------------- */
+
+// TransferCertificate moves a quantity of an active certificate to another participant. Only
+// the holder or an admin can transfer; transferring less than the whole certificate splits it.
+//
+// Inputs - Array of strings
+//
+//	0            ,    1         ,    2
+//	CertificateID,    ToUserID  ,    Quantity
+//	"GO-7"       ,    "21"      ,    "2.5"
+func TransferCertificate(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting TransferCertificate")
+
+	if len(args) != 3 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 3.")
+	}
+
+	certificate, err := getCertificate(stub, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+	err = requireUserOrRole(stub, certificate.HolderID, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+	err = checkCertificateActive(certificate)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	toUserID := args[1]
+	toUserAsBytes, err := stub.GetState(toUserID)
+	if err != nil || toUserAsBytes == nil {
+		return statusResponse(StatusNotFound, "User with ID "+toUserID+" not found")
+	}
+	if toUserID == certificate.HolderID {
+		return statusResponse(StatusConflict, "Certificate "+certificate.ID+" is already held by user "+toUserID+".")
+	}
+	quantity, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse Quantity: "+err.Error())
+	}
+	if quantity <= 0 || quantity-certificate.Quantity > fillTolerance {
+		return statusResponse(StatusInvalidArgument, "Quantity must be positive and at most the "+strconv.FormatFloat(certificate.Quantity, 'f', -1, 64)+
+			" units of certificate "+certificate.ID+".")
+	}
+
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	transferred := certificate
+	if certificate.Quantity-quantity > fillTolerance {
+		// Split off the transferred units into a new certificate.
+		certificate.Splits++
+		certificate.Quantity -= quantity
+		certificate.UpdatedOn = now
+
+		split := *certificate
+		split.CreatedOn = now
+		split.ID = certificate.ID + CertificateSplitSeparator + strconv.Itoa(certificate.Splits)
+		split.ParentID = certificate.ID
+		split.Quantity = quantity
+		split.Splits = 0
+		transferred = &split
+
+		err = putCertificate(stub, certificate)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+	transferred.HolderID = toUserID
+	transferred.UpdatedOn = now
+	err = putCertificate(stub, transferred)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end TransferCertificate")
+	return shim.Success([]byte(transferred.ID))
+}
+
+// RetireCertificate retires a certificate on behalf of its holder, claiming its renewable
+// energy. Only the holder or an admin can retire, and a certificate is only retired once.
+//
+// Inputs - Array of strings
+//
+//	0
+//	CertificateID
+func RetireCertificate(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting RetireCertificate")
+
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	certificate, err := getCertificate(stub, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+	err = requireUserOrRole(stub, certificate.HolderID, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+	err = checkCertificateActive(certificate)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	certificate.RetiredOn, err = getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	certificate.RetiredBy = certificate.HolderID
+	certificate.Status = CertificateRetired
+	certificate.UpdatedOn = certificate.RetiredOn
+	err = putCertificate(stub, certificate)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end RetireCertificate")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// ReadCertificate returns a certificate.
+//
+// Inputs - Array of strings
+//
+//	0
+//	CertificateID
+func ReadCertificate(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadCertificate")
+
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	certificate, err := getCertificate(stub, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+	certificateAsBytes, _ := json.Marshal(certificate)
+
+	fmt.Println("- end ReadCertificate")
+	return shim.Success(certificateAsBytes)
+}
+
+// ReadRetiredCertificates reports the certificates a participant retired in a period.
+//
+// Inputs - Array of strings
+//
+//	   0    ,    1
+//	userID  , period (YYYY-MM)
+func ReadRetiredCertificates(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadRetiredCertificates")
+
+	if len(args) != 2 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 2 (UserID, Period)")
+	}
+
+	periodStart, err := time.Parse("2006-01", args[1])
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse Period, expecting YYYY-MM: "+err.Error())
+	}
+	start := periodStart.Unix()
+	end := periodStart.AddDate(0, 1, 0).Unix()
+
+	kvs, err := getStatesByPrefix(stub, "Certificate_")
+	if err != nil {
+		return shim.Error("Failed to scan certificates: " + err.Error())
+	}
+	report := RetirementReport{Certificates: []Certificate{}, Period: args[1], UserID: args[0]}
+	for _, kv := range kvs {
+		var certificate Certificate
+		err = json.Unmarshal(kv.Value, &certificate)
+		if err != nil {
+			return shim.Error("Failed to unmarshal certificate " + kv.Key + ": " + err.Error())
+		}
+		if certificate.Status != CertificateRetired || certificate.RetiredBy != report.UserID ||
+			certificate.RetiredOn < start || certificate.RetiredOn >= end {
+			continue
+		}
+		report.Certificates = append(report.Certificates, certificate)
+		report.Quantity += certificate.Quantity
+	}
+	reportAsBytes, _ := json.Marshal(report)
+
+	fmt.Println("- end ReadRetiredCertificates")
+	return shim.Success(reportAsBytes)
+}
+
+// CertificateSplitSeparator joins the ID of a certificate and the number of a split of it.
+// Energy bid IDs can not contain it, so split IDs never collide with minted certificates.
+const CertificateSplitSeparator = "~"
+
+// getCertificateID returns the ID of the certificate minted for an energy bid
+func getCertificateID(energyBidID string) string {
+	return "GO-" + energyBidID
+}
+
+// getCertificate reads a Certificate from state
+func getCertificate(stub shim.ChaincodeStubInterface, certificateID string) (*Certificate, error) {
+	certificateAsBytes, err := stub.GetState("Certificate_" + certificateID)
+	if err != nil {
+		return nil, errors.New("Failed to fetch Certificate with ID " + certificateID + " from the ledger: " + err.Error())
+	}
+	if certificateAsBytes == nil {
+		return nil, newStatusError(StatusNotFound, "Certificate with ID "+certificateID+" not found.")
+	}
+
+	var certificate Certificate
+	err = json.Unmarshal(certificateAsBytes, &certificate)
+	if err != nil {
+		return nil, errors.New("Failed to unmarshal Certificate: " + err.Error())
+	}
+	return &certificate, nil
+}
+
+// putCertificate writes a Certificate to state
+func putCertificate(stub shim.ChaincodeStubInterface, certificate *Certificate) error {
+	certificateAsBytes, _ := json.Marshal(certificate)
+	err := stub.PutState("Certificate_"+certificate.ID, certificateAsBytes)
+	if err != nil {
+		return errors.New("Could not store certificate " + certificate.ID + ": " + err.Error())
+	}
+	return nil
+}
+
+// checkCertificateActive rejects certificates that were already retired
+func checkCertificateActive(certificate *Certificate) error {
+	if certificate.Status == CertificateRetired {
+		return newStatusError(StatusConflict, "Certificate "+certificate.ID+" was already retired by user "+certificate.RetiredBy+
+			" on "+strconv.FormatInt(certificate.RetiredOn, 10)+".")
+	}
+	return nil
+}
+
+// isRenewableSource reports whether a User.Source earns guarantees of origin
+func isRenewableSource(source string) bool {
+	for _, renewable := range RenewableSources {
+		if strings.EqualFold(strings.TrimSpace(source), renewable) {
+			return true
+		}
+	}
+	return false
+}
+
+// mintCertificate mints the guarantee of origin for the energy an energy bid settles from a
+// renewable seller to the buyer. Settling the bid again updates the quantity as long as the
+// buyer still holds the whole certificate.
+func mintCertificate(stub shim.ChaincodeStubInterface, energyBid *EnergyBid, bidMatch *BidMatch) error {
+	var seller struct {
+		MeterID string `json:"meterId"`
+		Source  string `json:"source"`
+	}
+	sellerAsBytes, err := stub.GetState(bidMatch.SellerUserId)
+	if err != nil {
+		return errors.New("Error accessing state: " + err.Error())
+	}
+	if sellerAsBytes != nil {
+		err = json.Unmarshal(sellerAsBytes, &seller)
+		if err != nil {
+			return errors.New("Failed to unmarshal user: " + err.Error())
+		}
+	}
+
+	certificateID := getCertificateID(energyBid.ID)
+	existingAsBytes, err := stub.GetState("Certificate_" + certificateID)
+	if err != nil {
+		return errors.New("Error accessing state: " + err.Error())
+	}
+	quantity := energyBid.SellerSoldUnitToBuyer
+	if !isRenewableSource(seller.Source) {
+		quantity = 0
+	}
+
+	now, err := getTxTime(stub)
+	if err != nil {
+		return err
+	}
+	var certificate Certificate
+	if existingAsBytes != nil {
+		err = json.Unmarshal(existingAsBytes, &certificate)
+		if err != nil {
+			return errors.New("Failed to unmarshal Certificate: " + err.Error())
+		}
+		if certificate.Quantity == quantity {
+			return nil
+		}
+		if certificate.Status != CertificateActive || certificate.Splits > 0 || certificate.HolderID != bidMatch.BuyerUserId {
+			return errors.New("Certificate " + certificate.ID + " of EnergyBid " + energyBid.ID +
+				" was already transferred or retired, the delivered units can no longer change.")
+		}
+		if quantity == 0 {
+			return stub.DelState("Certificate_" + certificate.ID)
+		}
+	} else {
+		if quantity == 0 {
+			return nil
+		}
+		certificate = Certificate{
+			CreatedOn:   now,
+			EnergyBidID: energyBid.ID,
+			HolderID:    bidMatch.BuyerUserId,
+			ID:          certificateID,
+			ProducerID:  bidMatch.SellerUserId,
+			SlotID:      bidMatch.BidSlot,
+			Source:      strings.TrimSpace(seller.Source),
+			Status:      CertificateActive,
+		}
+		certificate.MeterID = seller.MeterID
+		sellOrder, err := getOrder(stub, bidMatch.TransactionSellID)
+		if err == nil && sellOrder.MeterID != "" {
+			certificate.MeterID = sellOrder.MeterID
+		}
+		certificate.Issuer, err = getCallerMSPID(stub)
+		if err != nil {
+			return err
+		}
+	}
+	certificate.Quantity = quantity
+	certificate.UpdatedOn = now
+	return putCertificate(stub, &certificate)
+}
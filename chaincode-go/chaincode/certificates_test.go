@@ -0,0 +1,110 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertificates(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	admin := newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+	buyer := newCreator(t, "Org2MSP", nil)
+	stub.Creator = admin
+
+	enableTrading(t, stub, "90", BuyAction)
+	enableTrading(t, stub, "91", SellAction)
+	updateUser := func(userID string, mspID string, source string, meterID string) {
+		var user User
+		userAsBytes, _ := stub.GetState(userID)
+		json.Unmarshal(userAsBytes, &user)
+		user.MSPID, user.Source, user.MeterID = mspID, source, meterID
+		userAsBytes, _ = json.Marshal(user)
+		stub.MockTransactionStart("user")
+		stub.PutState(userID, userAsBytes)
+		stub.MockTransactionEnd("user")
+	}
+	updateUser("90", "Org2MSP", "Grid", "meter-90")
+	updateUser("91", "Org3MSP", "Solar", "meter-91")
+	putOrder(t, stub, Order{ID: "B1", BidStatus: "BidCreated", TotalQuantity: 10, RemainingQuantity: 10, UserID: "90", UserAction: BuyAction})
+	putOrder(t, stub, Order{ID: "S1", BidStatus: "BidCreated", TotalQuantity: 10, RemainingQuantity: 10, UserID: "91", UserAction: SellAction})
+	putBidMatch(t, stub, BidMatch{ID: "M1", BidSlot: "slot5", BidUnitPrice: 10, BuyerUserId: "90", OriginalBidUnits: 10, SellerUserId: "91", TransactionBuyID: "B1", TransactionSellID: "S1"})
+
+	// Test Case 1: Settling solar energy mints a certificate to the buyer
+	t.Run("Mint", func(t *testing.T) {
+		assertOK(t, invoke(stub, "1", "ProcessEnergyBid", "E1", "M1", "10", "10", "0", "0", "8", "8", "0", "0", "2", "delivered"))
+
+		certificate, err := getCertificate(stub, "GO-E1")
+		assert.NoError(t, err, "Certificate not minted")
+		assert.Equal(t, "90", certificate.HolderID, "Holder mismatch")
+		assert.Equal(t, "91", certificate.ProducerID, "Producer mismatch")
+		assert.Equal(t, 8.0, certificate.Quantity, "Quantity mismatch")
+		assert.Equal(t, "Solar", certificate.Source, "Source mismatch")
+		assert.Equal(t, "slot5", certificate.SlotID, "Slot mismatch")
+		assert.Equal(t, "meter-91", certificate.MeterID, "Meter mismatch")
+		assert.Equal(t, "Org1MSP", certificate.Issuer, "Issuer mismatch")
+	})
+
+	// Test Case 2: Only the holder can transfer, a partial transfer splits the certificate
+	t.Run("Transfer", func(t *testing.T) {
+		stub.Creator = newCreator(t, "Org3MSP", nil)
+		response := invoke(stub, "2", "TransferCertificate", "GO-E1", "91", "3")
+		assert.Equal(t, StatusUnauthorized, response.GetStatus(), "Function unexpectedly succeeded")
+
+		stub.Creator = buyer
+		response = invoke(stub, "3", "TransferCertificate", "GO-E1", "91", "3")
+		assertOK(t, response)
+		assert.Equal(t, "GO-E1~1", string(response.GetPayload()), "Split ID mismatch")
+
+		certificate, _ := getCertificate(stub, "GO-E1")
+		assert.Equal(t, 5.0, certificate.Quantity, "Remaining quantity mismatch")
+		split, _ := getCertificate(stub, "GO-E1~1")
+		assert.Equal(t, "91", split.HolderID, "Split holder mismatch")
+		assert.Equal(t, 3.0, split.Quantity, "Split quantity mismatch")
+	})
+
+	// Test Case 3: A certificate can only be retired once
+	t.Run("Retire", func(t *testing.T) {
+		stub.Creator = buyer
+		assertOK(t, invoke(stub, "4", "RetireCertificate", "GO-E1"))
+
+		response := invoke(stub, "5", "RetireCertificate", "GO-E1")
+		assert.Equal(t, StatusConflict, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "was already retired")
+
+		response = invoke(stub, "6", "TransferCertificate", "GO-E1", "91", "1")
+		assert.Equal(t, StatusConflict, response.GetStatus(), "Retired certificate transferred")
+	})
+
+	// Test Case 4: Retired certificates are reported per period
+	t.Run("Retired Per Period", func(t *testing.T) {
+		response := invoke(stub, "7", "ReadRetiredCertificates", "90", time.Now().UTC().Format("2006-01"))
+		assertOK(t, response)
+
+		var report RetirementReport
+		json.Unmarshal(response.GetPayload(), &report)
+		assert.Len(t, report.Certificates, 1, "Certificate count mismatch")
+		assert.Equal(t, 5.0, report.Quantity, "Retired quantity mismatch")
+	})
+
+	// Test Case 5: A settlement whose certificate moved on can no longer change
+	t.Run("Settlement Changed After Transfer", func(t *testing.T) {
+		stub.Creator = admin
+		response := invoke(stub, "8", "ProcessEnergyBid", "E1", "M1", "10", "10", "0", "0", "6", "6", "0", "0", "4", "corrected")
+
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "already transferred or retired")
+	})
+
+	// Test Case 6: Energy bid IDs can not take the IDs of certificate splits
+	t.Run("Energy Bid ID With Split Separator", func(t *testing.T) {
+		response := invoke(stub, "9", "ProcessEnergyBid", "E1~2", "M1", "10", "10", "0", "0", "8", "8", "0", "0", "2", "delivered")
+
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "can not contain ~")
+	})
+}
@@ -0,0 +1,386 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                          Contract Template Methods                         */
+/* -------------------------------------------------------------------------- */
+
+// PublishContractTemplate publishes a new version of a contract template.
+//
+// Inputs - Array of strings
+//
+//	     0      ,     1        ,    2     ,       3        ,      4
+//	templateID  , contractType , version  ,  documentHash  , effectiveDate
+//	"terms"     , "Platform"   ,   "2"    , "9f86d08188..." , "1700000000"
+func PublishContractTemplate(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting PublishContractTemplate")
+
+	if len(args) != 5 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 5.")
+	}
+
+	err := sanitize_arguments(args)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Invalid argument: "+err.Error())
+	}
+
+	err = requireRole(stub, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	var template ContractTemplate
+	template.ID = args[0]
+	template.ContractType = args[1]
+	if template.ContractType != PlatformContractType && template.ContractType != TradingContractType {
+		return statusResponse(StatusInvalidArgument, "Invalid contract type. It should be "+PlatformContractType+" or "+TradingContractType+".")
+	}
+	template.Version, err = strconv.Atoi(args[2])
+	if err != nil || template.Version < 1 {
+		return statusResponse(StatusInvalidArgument, "Version must be a positive integer.")
+	}
+	template.DocumentHash = args[3]
+	template.EffectiveDate, err = strconv.ParseInt(args[4], 10, 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse EffectiveDate: "+err.Error())
+	}
+	template.CreatedOn, err = getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// Versions are immutable and only ever increase.
+	versions, err := getContractTemplateVersions(stub, template.ID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	for _, existing := range versions {
+		if existing.Version >= template.Version {
+			return statusResponse(StatusConflict, "Template "+template.ID+" already has version "+strconv.Itoa(existing.Version)+", new versions must be higher.")
+		}
+		if existing.ContractType != template.ContractType {
+			return statusResponse(StatusConflict, "Template "+template.ID+" is a "+existing.ContractType+" template.")
+		}
+	}
+
+	templateAsBytes, _ := json.Marshal(template)
+	err = stub.PutState(getContractTemplateKey(template.ID, template.Version), templateAsBytes)
+	if err != nil {
+		return shim.Error("Could not store contract template: " + err.Error())
+	}
+
+	fmt.Println("- end PublishContractTemplate")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// ReadContractTemplate returns a template version, or the version in effect when only the
+// template ID is given.
+func ReadContractTemplate(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadContractTemplate")
+
+	// We expect 1 or 2 arguments: the template ID and optionally the version.
+	if len(args) != 1 && len(args) != 2 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1 or 2.")
+	}
+
+	var template *ContractTemplate
+	if len(args) == 2 {
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return statusResponse(StatusInvalidArgument, "Failed to parse Version: "+err.Error())
+		}
+		template, err = getContractTemplate(stub, args[0], version)
+		if err != nil {
+			return errorResponse(err)
+		}
+	} else {
+		now, err := getTxTime(stub)
+		if err != nil {
+			return errorResponse(err)
+		}
+		template, err = getEffectiveContractTemplate(stub, args[0], now)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	templateAsBytes, _ := json.Marshal(template)
+
+	fmt.Println("- end ReadContractTemplate")
+	return shim.Success(templateAsBytes)
+}
+
+// getContractTemplateKey zero pads the version so the versions of a template sort in order
+func getContractTemplateKey(templateID string, version int) string {
+	return fmt.Sprintf("ContractTemplate_%s_%06d", templateID, version)
+}
+
+func getContractTemplate(stub shim.ChaincodeStubInterface, templateID string, version int) (*ContractTemplate, error) {
+	templateAsBytes, err := stub.GetState(getContractTemplateKey(templateID, version))
+	if err != nil {
+		return nil, errors.New("Error accessing state: " + err.Error())
+	}
+	if templateAsBytes == nil {
+		return nil, newStatusError(StatusNotFound, "Contract template "+templateID+" version "+strconv.Itoa(version)+" not found.")
+	}
+
+	var template ContractTemplate
+	err = json.Unmarshal(templateAsBytes, &template)
+	if err != nil {
+		return nil, errors.New("Failed to unmarshal contract template: " + err.Error())
+	}
+	return &template, nil
+}
+
+// getContractTemplateVersions returns all the versions of a template in increasing order
+func getContractTemplateVersions(stub shim.ChaincodeStubInterface, templateID string) ([]ContractTemplate, error) {
+	results, err := getStatesByPrefix(stub, "ContractTemplate_"+templateID+"_")
+	if err != nil {
+		return nil, errors.New("Failed to scan contract templates: " + err.Error())
+	}
+
+	var versions []ContractTemplate
+	for _, kv := range results {
+		var template ContractTemplate
+		err = json.Unmarshal(kv.Value, &template)
+		if err != nil {
+			return nil, errors.New("Failed to unmarshal contract template: " + err.Error())
+		}
+		// The prefix also matches templates whose ID extends this one.
+		if template.ID == templateID {
+			versions = append(versions, template)
+		}
+	}
+	return versions, nil
+}
+
+// getEffectiveContractTemplate returns the highest version of a template in effect at the given time
+func getEffectiveContractTemplate(stub shim.ChaincodeStubInterface, templateID string, at int64) (*ContractTemplate, error) {
+	versions, err := getContractTemplateVersions(stub, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	var effective *ContractTemplate
+	for i := range versions {
+		if versions[i].EffectiveDate <= at {
+			effective = &versions[i]
+		}
+	}
+	if effective == nil {
+		return nil, errors.New("Contract template " + templateID + " has no version in effect.")
+	}
+	return effective, nil
+}
+
+// ContractSigningMessage returns the message a user signs to accept a template version. It ties
+// the signature to the user and the version, so it can't be replayed for another user or version.
+func ContractSigningMessage(userID string, templateID string, version int, documentHash string, terms ...string) string {
+	parts := append([]string{userID, templateID, strconv.Itoa(version), documentHash}, terms...)
+	return strings.Join(parts, "|")
+}
+
+// TradingContractTerms returns the terms a trading contract signature covers besides the template
+// version: the actions, the expiry date and the revision of the user's trading contract, the number
+// of entries in its history (0 when the user has none). A signature therefore can't be replayed
+// with other terms or once the contract has changed.
+func TradingContractTerms(actions []string, expiresOn int64, revision int) []string {
+	return []string{strings.Join(actions, ","), strconv.FormatInt(expiresOn, 10), strconv.Itoa(revision)}
+}
+
+// verifyContractSignature checks that a user signed the ContractSigningMessage of a template
+// version and the given terms with the key registered on the user's profile, and returns the
+// signed template.
+func verifyContractSignature(stub shim.ChaincodeStubInterface, userID string, templateID string, version int, contractType string, signature string, terms ...string) (*ContractTemplate, error) {
+	template, err := getContractTemplate(stub, templateID, version)
+	if err != nil {
+		return nil, err
+	}
+	if template.ContractType != contractType {
+		return nil, newStatusError(StatusInvalidArgument, "Contract template "+templateID+" is not a "+contractType+" template.")
+	}
+
+	userAsBytes, err := stub.GetState(userID)
+	if err != nil || userAsBytes == nil {
+		return nil, newStatusError(StatusNotFound, "User with ID "+userID+" not found")
+	}
+	var user User
+	err = json.Unmarshal(userAsBytes, &user)
+	if err != nil {
+		return nil, errors.New("Failed to unmarshal user: " + err.Error())
+	}
+	if user.PublicKey == "" {
+		return nil, newStatusError(StatusInvalidArgument, "User with ID "+userID+" has no registered key")
+	}
+
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, newStatusError(StatusInvalidArgument, "Failed to decode signature: "+err.Error())
+	}
+	message := ContractSigningMessage(userID, templateID, version, template.DocumentHash, terms...)
+	err = verifySignature(user.PublicKey, []byte(message), signatureBytes)
+	if err != nil {
+		return nil, newStatusError(StatusInvalidArgument, "Signature of user "+userID+" over contract template "+templateID+" version "+strconv.Itoa(version)+" is invalid: "+err.Error())
+	}
+	return template, nil
+}
+
+// parsePublicKey parses a PEM encoded PKIX public key
+func parsePublicKey(publicKeyPEM string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return nil, errors.New("public key is not PEM encoded")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// verifySignature verifies an ECDSA (ASN.1), RSA (PKCS #1 v1.5) or Ed25519 signature. ECDSA and
+// RSA signatures are over the SHA-256 digest of the message.
+func verifySignature(publicKeyPEM string, message []byte, signature []byte) error {
+	publicKey, err := parsePublicKey(publicKeyPEM)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(message)
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], signature) {
+			return errors.New("signature verification failed")
+		}
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, message, signature) {
+			return errors.New("signature verification failed")
+		}
+	default:
+		return errors.New("unsupported public key type")
+	}
+	return nil
+}
+
+// checkContractCurrent fails when a newer version of the template a contract was signed on has
+// taken effect, in which case the user has to accept the new version before trading. Contracts
+// signed before templates existed have to be accepted again on the version in effect.
+func checkContractCurrent(stub shim.ChaincodeStubInterface, templateID string, signedVersion int, at int64) error {
+	if templateID == "" {
+		return errors.New("Contract was not signed on a contract template and has to be accepted again.")
+	}
+	effective, err := getEffectiveContractTemplate(stub, templateID, at)
+	if err != nil {
+		return err
+	}
+	if effective.Version > signedVersion {
+		return errors.New("Contract template " + templateID + " version " + strconv.Itoa(effective.Version) + " is in effect, version " + strconv.Itoa(signedVersion) + " has to be accepted again.")
+	}
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                               Contract Checks                              */
+/* -------------------------------------------------------------------------- */
+
+// checkTradingContracts fails unless the user holds an active platform contract and an active,
+// non-expired trading contract allowing the action. A contract is only active while it is signed
+// on the template version currently in effect. The error names the missing contract.
+func checkTradingContracts(stub shim.ChaincodeStubInterface, userID string, action string) error {
+	now, err := getTxTime(stub)
+	if err != nil {
+		return err
+	}
+
+	platformKey := "PlatformContract_" + userID
+	var platformContract PlatformContract
+	found, err := getContract(stub, platformKey, &platformContract)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return newStatusError(StatusConflict, "User "+userID+" has no platform contract ("+platformKey+").")
+	}
+	err = checkContractCurrent(stub, platformContract.TemplateID, platformContract.TemplateVersion, now)
+	if err != nil {
+		return newStatusError(StatusConflict, "Platform contract "+platformKey+" is not active: "+err.Error())
+	}
+
+	if action != BuyAction && action != SellAction {
+		return newStatusError(StatusInvalidArgument, "Invalid action "+action+". It should be "+BuyAction+" or "+SellAction+".")
+	}
+	tradingKey := getTradingContractKey(userID)
+	var tradingContract TradingContract
+	found, err = getContract(stub, tradingKey, &tradingContract)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return newStatusError(StatusConflict, "User "+userID+" has no trading contract ("+tradingKey+").")
+	}
+	if tradingContract.Status != ContractActive {
+		return newStatusError(StatusConflict, "Trading contract "+tradingKey+" is "+tradingContract.Status+".")
+	}
+	if tradingContract.ExpiresOn <= now {
+		return newStatusError(StatusConflict, "Trading contract "+tradingKey+" has expired.")
+	}
+	if !containsString(tradingContract.Actions, action) {
+		return newStatusError(StatusConflict, "Trading contract "+tradingKey+" does not allow the "+action+" action.")
+	}
+	err = checkContractCurrent(stub, tradingContract.TemplateID, tradingContract.TemplateVersion, now)
+	if err != nil {
+		return newStatusError(StatusConflict, "Trading contract "+tradingKey+" is not active: "+err.Error())
+	}
+	return nil
+}
+
+// getContract reads a contract into the given struct and reports whether it exists
+func getContract(stub shim.ChaincodeStubInterface, key string, contract interface{}) (bool, error) {
+	contractAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return false, errors.New("Error accessing state: " + err.Error())
+	}
+	if contractAsBytes == nil {
+		return false, nil
+	}
+	err = json.Unmarshal(contractAsBytes, contract)
+	if err != nil {
+		return false, errors.New("Failed to unmarshal contract " + key + ": " + err.Error())
+	}
+	return true, nil
+}
@@ -0,0 +1,255 @@
+package chaincode
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// newSigningKey returns a user key pair together with the PEM encoded public key
+func newSigningKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err.Error())
+	}
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal public key: %s", err.Error())
+	}
+	return key, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER}))
+}
+
+// signDocumentHash returns the base64 encoded signature of a signing message
+func signDocumentHash(t *testing.T, key *ecdsa.PrivateKey, documentHash string) string {
+	digest := sha256.Sum256([]byte(documentHash))
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("Failed to sign document hash: %s", err.Error())
+	}
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+// publishTemplate publishes a template version as an admin, keeping the current caller
+func publishTemplate(t *testing.T, stub *shimtest.MockStub, templateID string, contractType string, version int, documentHash string, effectiveDate int64) {
+	creator := stub.Creator
+	defer func() { stub.Creator = creator }()
+
+	stub.Creator = newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+	response := stub.MockInvoke("publish", [][]byte{
+		[]byte("PublishContractTemplate"),
+		[]byte(templateID),
+		[]byte(contractType),
+		[]byte(strconv.Itoa(version)),
+		[]byte(documentHash),
+		[]byte(strconv.FormatInt(effectiveDate, 10)),
+	})
+	if response.GetStatus() != shim.OK {
+		t.Fatalf("Failed to publish contract template: %s", response.GetMessage())
+	}
+}
+
+// enableTrading registers the user with a key and signs the platform contract and the trading
+// contracts for the given actions, publishing the templates on first use.
+func enableTrading(t *testing.T, stub *shimtest.MockStub, userID string, actions ...string) {
+	signingKey, publicKeyPEM := newSigningKey(t)
+	user := User{ID: userID, MSPID: "Org1MSP", PublicKey: publicKeyPEM}
+	userBytes, _ := json.Marshal(user)
+	stub.MockTransactionStart("enable")
+	err := stub.PutState(userID, userBytes)
+	stub.MockTransactionEnd("enable")
+	if err != nil {
+		t.Fatalf("Failed to put the user into the stub: %s", err.Error())
+	}
+
+	templates := map[string]string{PlatformContractType: "platform-terms", TradingContractType: "trading-terms"}
+	for contractType, templateID := range templates {
+		templateAsBytes, _ := stub.GetState(getContractTemplateKey(templateID, 1))
+		if templateAsBytes == nil {
+			publishTemplate(t, stub, templateID, contractType, 1, templateID+"-hash", 0)
+		}
+	}
+
+	response := stub.MockInvoke("enable", [][]byte{
+		[]byte("SignPlatformContract"), []byte(userID), []byte("platform-terms"), []byte("1"),
+		[]byte(signDocumentHash(t, signingKey, ContractSigningMessage(userID, "platform-terms", 1, "platform-terms-hash"))),
+	})
+	if response.GetStatus() != shim.OK {
+		t.Fatalf("Failed to sign platform contract: %s", response.GetMessage())
+	}
+	if len(actions) == 0 {
+		return
+	}
+	actionsAsBytes, _ := json.Marshal(actions)
+	expiresOn := time.Now().AddDate(1, 0, 0).Unix()
+	terms := TradingContractTerms(actions, expiresOn, 0)
+	response = stub.MockInvoke("enable", [][]byte{
+		[]byte("SignTradingContract"), []byte(userID), []byte("trading-terms"), []byte("1"),
+		[]byte(signDocumentHash(t, signingKey, ContractSigningMessage(userID, "trading-terms", 1, "trading-terms-hash", terms...))), actionsAsBytes,
+		[]byte(strconv.FormatInt(expiresOn, 10)),
+	})
+	if response.GetStatus() != shim.OK {
+		t.Fatalf("Failed to sign trading contract: %s", response.GetMessage())
+	}
+}
+
+func TestCheckTradingContracts(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	stub.Creator = newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+
+	registerOrder := func(txID string, userID string, action string) string {
+		response := stub.MockInvoke(txID, [][]byte{
+			[]byte("RegisterOrder"), []byte("1"), []byte("BidCreated"), []byte(txID), []byte("0"), []byte("200"), []byte("payment5"),
+			[]byte("slot1"), []byte("300"), []byte("3.5"), []byte(userID), []byte("50"), []byte(action),
+		})
+		return response.GetMessage()
+	}
+
+	// Test Case 1: No platform contract
+	t.Run("Missing Platform Contract", func(t *testing.T) {
+		assert.Contains(t, registerOrder("1", "20", BuyAction), "PlatformContract_20")
+	})
+
+	// Test Case 2: Platform contract but no trading contract
+	t.Run("Missing Trading Contract", func(t *testing.T) {
+		enableTrading(t, stub, "23")
+		assert.Contains(t, registerOrder("1", "23", BuyAction), "TradingContract_23")
+	})
+
+	// Test Case 3: Trading contract without the action
+	t.Run("Action Not Allowed", func(t *testing.T) {
+		enableTrading(t, stub, "21", SellAction)
+		assert.Contains(t, registerOrder("2", "21", BuyAction), "does not allow the Buy action")
+		assert.Empty(t, registerOrder("3", "21", SellAction))
+	})
+
+	// Test Case 4: Settlement checks both parties of the match
+	t.Run("Settlement Requires Both Parties", func(t *testing.T) {
+		enableTrading(t, stub, "22", BuyAction)
+		putOrder(t, stub, Order{ID: "Buy6", BidStatus: "BidCreated", TotalQuantity: 10, RemainingQuantity: 10, UserID: "22", UserAction: BuyAction})
+		putOrder(t, stub, Order{ID: "Sell7", BidStatus: "BidCreated", TotalQuantity: 10, RemainingQuantity: 10, UserID: "21", UserAction: SellAction})
+		response := stub.MockInvoke("4", [][]byte{
+			[]byte("ProcessBidMatch"), []byte("120"), []byte("slot1"), []byte("BidCreated"), []byte("100"), []byte("22"),
+			[]byte("2.5"), []byte("Match1"), []byte("3.5"), []byte("21"), []byte("Buy6"), []byte("Sell7"),
+		})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		// A new trading template version in effect suspends trading until it is signed again.
+		publishTemplate(t, stub, "trading-terms", TradingContractType, 2, "trading-terms-hash-v2", 0)
+		response = stub.MockInvoke("5", [][]byte{
+			[]byte("ProcessEnergyBid"), []byte("EnergyBid1"), []byte("Match1"), []byte("10.5"), []byte("8.7"), []byte("5.0"), []byte("7.2"),
+			[]byte("3.0"), []byte("6.5"), []byte("4.8"), []byte("9.2"), []byte("2.1"), []byte("Reason1"),
+		})
+		assert.Equal(t, StatusConflict, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "TradingContract_22")
+	})
+
+	// Test Case 5: Contracts signed before templates existed have to be accepted again
+	t.Run("Contract Without Template", func(t *testing.T) {
+		enableTrading(t, stub, "24", BuyAction)
+		contractAsBytes, _ := json.Marshal(PlatformContract{UserID: "24", SignedContractHash: "legacy-hash"})
+		stub.MockTransactionStart("setup")
+		_ = stub.PutState("PlatformContract_24", contractAsBytes)
+		stub.MockTransactionEnd("setup")
+
+		assert.Contains(t, registerOrder("6", "24", BuyAction), "accepted again")
+	})
+}
+
+func TestPublishContractTemplate(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	past := time.Now().Add(-time.Hour).Unix()
+	future := time.Now().Add(time.Hour).Unix()
+
+	publishTemplate(t, stub, "terms", PlatformContractType, 1, "HASH_V1", past)
+	publishTemplate(t, stub, "terms", PlatformContractType, 2, "HASH_V2", future)
+
+	// Test Case 1: The version in effect is returned when no version is given
+	t.Run("Read Effective Template", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{[]byte("ReadContractTemplate"), []byte("terms")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var template ContractTemplate
+		err := json.Unmarshal(response.GetPayload(), &template)
+		assert.NoError(t, err, "Error unmarshalling template")
+		assert.Equal(t, 1, template.Version, "Version 2 is not in effect yet")
+
+		response = stub.MockInvoke("2", [][]byte{[]byte("ReadContractTemplate"), []byte("terms"), []byte("2")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	})
+
+	// Test Case 2: Versions only increase
+	t.Run("Republish Existing Version", func(t *testing.T) {
+		stub.Creator = newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+		response := stub.MockInvoke("3", [][]byte{
+			[]byte("PublishContractTemplate"), []byte("terms"), []byte(PlatformContractType), []byte("2"), []byte("OTHER"), []byte("0"),
+		})
+
+		assert.Equal(t, StatusConflict, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "must be higher")
+	})
+
+	// Test Case 3: Only admins publish templates
+	t.Run("Non-admin Caller", func(t *testing.T) {
+		stub.Creator = newCreator(t, "Org2MSP", nil)
+		response := stub.MockInvoke("4", [][]byte{
+			[]byte("PublishContractTemplate"), []byte("terms"), []byte(PlatformContractType), []byte("3"), []byte("HASH_V3"), []byte("0"),
+		})
+
+		assert.Equal(t, StatusUnauthorized, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not authorized")
+	})
+}
+
+func TestRegisterUserKey(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	stub.Creator = newCreator(t, "Org2MSP", nil)
+
+	response := stub.MockInvoke("1", [][]byte{
+		[]byte("UpdateUserProfile"), []byte("6"), []byte("Prosumer"), []byte("MeterId 6"), []byte("Solar"), []byte("false"),
+	})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+	_, publicKeyPEM := newSigningKey(t)
+
+	// Test Case 1: Successfully register the key
+	t.Run("Successfully Register Key", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{[]byte("RegisterUserKey"), []byte("6"), []byte(publicKeyPEM)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var user User
+		userAsBytes, _ := stub.GetState("6")
+		_ = json.Unmarshal(userAsBytes, &user)
+		assert.Equal(t, publicKeyPEM, user.PublicKey, "Public key mismatch")
+		assert.Equal(t, "MeterId 6", user.MeterID, "Profile fields lost")
+	})
+
+	// Test Case 2: Other orgs cannot register keys for the user
+	t.Run("Caller From Another Org", func(t *testing.T) {
+		stub.Creator = newCreator(t, "Org3MSP", nil)
+		response := stub.MockInvoke("3", [][]byte{[]byte("RegisterUserKey"), []byte("6"), []byte(publicKeyPEM)})
+
+		assert.Equal(t, StatusUnauthorized, response.GetStatus(), "Function unexpectedly succeeded")
+	})
+
+	// Test Case 3: Invalid key
+	t.Run("Invalid Key", func(t *testing.T) {
+		stub.Creator = newCreator(t, "Org2MSP", nil)
+		response := stub.MockInvoke("4", [][]byte{[]byte("RegisterUserKey"), []byte("6"), []byte("not a key")})
+
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "Invalid public key")
+	})
+}
@@ -0,0 +1,581 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                              Dispute Methods                               */
+/* -------------------------------------------------------------------------- */
+
+// Indexes of the disputes that are Open or UnderReview, stored as composite keys
+// "<index>\x00<referenced ID>\x00<dispute ID>". An entry is removed when its dispute is decided.
+const ActiveDisputesByMatchIndex = "bidMatch~dispute"
+const ActiveDisputesByTargetIndex = "target~dispute"
+
+// RaiseDispute opens a dispute of a party against an EnergyBid, BidMatch or Payment. A target
+// has at most one undecided dispute, and the match it belongs to can not be settled until the
+// dispute is decided.
+//
+// Inputs - Array of strings
+//
+//	0         ,    1      ,    2          ,    3        ,    4                   ,    5
+//	DisputeID ,    UserID ,    TargetType ,    TargetID ,    Reason              ,    Evidence hashes as JSON
+//	"D1"      ,    "20"   ,    "EnergyBid",    "7"      ,    "meter under-read"  ,    ["9f86d0...", "..."]
+func RaiseDispute(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting RaiseDispute")
+
+	if len(args) != 6 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 6.")
+	}
+
+	disputeID := args[0]
+	userID := args[1]
+	err := requireUserOrRole(stub, userID, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	existingAsBytes, err := stub.GetState("Dispute_" + disputeID)
+	if err != nil {
+		return shim.Error("Error accessing state: " + err.Error())
+	}
+	if existingAsBytes != nil {
+		return statusResponse(StatusConflict, "Dispute with ID "+disputeID+" already exists.")
+	}
+
+	dispute := Dispute{ID: disputeID, RaisedBy: userID, Reason: args[4], TargetID: args[3], TargetType: args[2]}
+	parties, err := getDisputeParties(stub, &dispute)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if !containsString(parties, userID) {
+		return statusResponse(StatusUnauthorized, "User "+userID+" is not a party of "+dispute.TargetType+" "+dispute.TargetID+".")
+	}
+
+	active, err := hasActiveDisputes(stub, ActiveDisputesByTargetIndex, dispute.TargetType+"_"+dispute.TargetID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if active {
+		return statusResponse(StatusConflict, dispute.TargetType+" "+dispute.TargetID+" already has an undecided dispute.")
+	}
+
+	var hashes []string
+	err = json.Unmarshal([]byte(args[5]), &hashes)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse Evidence hashes: "+err.Error())
+	}
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	for _, hash := range hashes {
+		err = addEvidence(&dispute, userID, hash, now)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	dispute.CreatedOn = now
+	appendDisputeTransition(stub, &dispute, DisputeOpen, dispute.Reason, now)
+	err = putDispute(stub, &dispute)
+	if err != nil {
+		return errorResponse(err)
+	}
+	err = setActiveDisputeIndexes(stub, &dispute, true)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end RaiseDispute")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// AddDisputeEvidence adds the hash of a supporting document to an undecided dispute. Parties of
+// the disputed record and arbiters can add evidence.
+//
+// Inputs - Array of strings
+//
+//	0         ,    1      ,    2
+//	DisputeID ,    UserID ,    SHA-256 hash (hex)
+func AddDisputeEvidence(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting AddDisputeEvidence")
+
+	if len(args) != 3 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 3.")
+	}
+
+	dispute, err := getDispute(stub, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+	userID := args[1]
+	if requireRole(stub, ArbiterRole) != nil {
+		err = requireUserOrRole(stub, userID, AdminRole)
+		if err != nil {
+			return errorResponse(err)
+		}
+		parties, err := getDisputeParties(stub, dispute)
+		if err != nil {
+			return errorResponse(err)
+		}
+		if !containsString(parties, userID) {
+			return statusResponse(StatusUnauthorized, "User "+userID+" is not a party of dispute "+dispute.ID+".")
+		}
+	}
+	if !isDisputeActive(dispute) {
+		return statusResponse(StatusConflict, "Dispute "+dispute.ID+" is "+dispute.Status+" and takes no more evidence.")
+	}
+
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	err = addEvidence(dispute, userID, args[2], now)
+	if err != nil {
+		return errorResponse(err)
+	}
+	dispute.UpdatedOn = now
+	err = putDispute(stub, dispute)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end AddDisputeEvidence")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// ReviewDispute moves an open dispute under review. It can only be called by an arbiter.
+//
+// Inputs - Array of strings
+//
+//	0
+//	DisputeID
+func ReviewDispute(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReviewDispute")
+
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	err := requireRole(stub, ArbiterRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+	dispute, err := getDispute(stub, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+	if dispute.Status != DisputeOpen {
+		return statusResponse(StatusConflict, "Dispute "+dispute.ID+" is "+dispute.Status+", only Open disputes can be reviewed.")
+	}
+
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	appendDisputeTransition(stub, dispute, DisputeUnderReview, "", now)
+	err = putDispute(stub, dispute)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end ReviewDispute")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// ResolveDispute decides a dispute under review in favour of adjustments. Every adjustment is
+// recorded as a Payment of PaymentType "Adjustment" linked to the dispute, its match and, for a
+// disputed payment, the original payment. It can only be called by an arbiter and releases the
+// settlement of the match.
+//
+// Inputs - Array of strings
+//
+//	0         ,    1          ,    2
+//	DisputeID ,    Resolution ,    Adjustments as JSON
+//	"D1"      ,    "re-read"  ,    [{"paymentId": "ADJ1", "userId": "20", "amount": -12.5}]
+func ResolveDispute(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ResolveDispute")
+
+	if len(args) != 3 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 3.")
+	}
+
+	err := requireRole(stub, ArbiterRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+	dispute, err := getDispute(stub, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+	if dispute.Status != DisputeUnderReview {
+		return statusResponse(StatusConflict, "Dispute "+dispute.ID+" is "+dispute.Status+", only disputes UnderReview can be resolved.")
+	}
+
+	var adjustments []DisputeAdjustment
+	err = json.Unmarshal([]byte(args[2]), &adjustments)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse Adjustments: "+err.Error())
+	}
+	parties, err := getDisputeParties(stub, dispute)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	for _, adjustment := range adjustments {
+		payment, err := newAdjustmentPayment(stub, dispute, parties, adjustment, now)
+		if err != nil {
+			return errorResponse(err)
+		}
+		paymentAsBytes, _ := json.Marshal(payment)
+		err = stub.PutState("Payment_"+payment.ID, paymentAsBytes)
+		if err != nil {
+			return shim.Error("Could not store payment: " + err.Error())
+		}
+		err = setSettlementEndorsement(stub, "Payment_"+payment.ID, payment.UserID)
+		if err != nil {
+			return errorResponse(err)
+		}
+		err = putPaymentIndexes(stub, payment)
+		if err != nil {
+			return errorResponse(err)
+		}
+		dispute.AdjustmentPaymentIDs = append(dispute.AdjustmentPaymentIDs, payment.ID)
+	}
+
+	dispute.Resolution = args[1]
+	appendDisputeTransition(stub, dispute, DisputeResolved, dispute.Resolution, now)
+	err = putDispute(stub, dispute)
+	if err != nil {
+		return errorResponse(err)
+	}
+	err = setActiveDisputeIndexes(stub, dispute, false)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	eventAsBytes, _ := json.Marshal(map[string]interface{}{"disputeId": dispute.ID, "adjustmentPaymentIds": dispute.AdjustmentPaymentIDs})
+	err = stub.SetEvent("DisputeResolved", eventAsBytes)
+	if err != nil {
+		return shim.Error("Could not emit DisputeResolved event: " + err.Error())
+	}
+
+	fmt.Println("- end ResolveDispute")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// RejectDispute decides an undecided dispute without adjustments. It can only be called by an
+// arbiter and releases the settlement of the match.
+//
+// Inputs - Array of strings
+//
+//	0         ,    1
+//	DisputeID ,    Reason
+func RejectDispute(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting RejectDispute")
+
+	if len(args) != 2 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 2.")
+	}
+
+	err := requireRole(stub, ArbiterRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+	dispute, err := getDispute(stub, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+	if !isDisputeActive(dispute) {
+		return statusResponse(StatusConflict, "Dispute "+dispute.ID+" was already "+dispute.Status+".")
+	}
+
+	dispute.Resolution = args[1]
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	appendDisputeTransition(stub, dispute, DisputeRejected, dispute.Resolution, now)
+	err = putDispute(stub, dispute)
+	if err != nil {
+		return errorResponse(err)
+	}
+	err = setActiveDisputeIndexes(stub, dispute, false)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end RejectDispute")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// ReadDispute returns a dispute.
+//
+// Inputs - Array of strings
+//
+//	0
+//	DisputeID
+func ReadDispute(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadDispute")
+
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	dispute, err := getDispute(stub, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+	disputeAsBytes, _ := json.Marshal(dispute)
+
+	fmt.Println("- end ReadDispute")
+	return shim.Success(disputeAsBytes)
+}
+
+// getDispute reads a Dispute from state
+func getDispute(stub shim.ChaincodeStubInterface, disputeID string) (*Dispute, error) {
+	disputeAsBytes, err := stub.GetState("Dispute_" + disputeID)
+	if err != nil {
+		return nil, errors.New("Failed to fetch Dispute with ID " + disputeID + " from the ledger: " + err.Error())
+	}
+	if disputeAsBytes == nil {
+		return nil, newStatusError(StatusNotFound, "Dispute with ID "+disputeID+" not found.")
+	}
+
+	var dispute Dispute
+	err = json.Unmarshal(disputeAsBytes, &dispute)
+	if err != nil {
+		return nil, errors.New("Failed to unmarshal Dispute: " + err.Error())
+	}
+	return &dispute, nil
+}
+
+// putDispute writes a Dispute to state
+func putDispute(stub shim.ChaincodeStubInterface, dispute *Dispute) error {
+	disputeAsBytes, _ := json.Marshal(dispute)
+	err := stub.PutState("Dispute_"+dispute.ID, disputeAsBytes)
+	if err != nil {
+		return errors.New("Could not store dispute " + dispute.ID + ": " + err.Error())
+	}
+	return nil
+}
+
+// isDisputeActive reports whether a dispute is still to be decided
+func isDisputeActive(dispute *Dispute) bool {
+	return dispute.Status == DisputeOpen || dispute.Status == DisputeUnderReview
+}
+
+// appendDisputeTransition moves a dispute to a status and records the change in its history
+func appendDisputeTransition(stub shim.ChaincodeStubInterface, dispute *Dispute, status string, reason string, now int64) {
+	dispute.History = append(dispute.History, ContractTransition{
+		FromStatus: dispute.Status,
+		Reason:     reason,
+		ToStatus:   status,
+		TxID:       stub.GetTxID(),
+		UpdatedOn:  now,
+	})
+	dispute.Status = status
+	dispute.UpdatedOn = now
+}
+
+// addEvidence appends a hex encoded SHA-256 evidence hash to a dispute
+func addEvidence(dispute *Dispute, userID string, hash string, now int64) error {
+	decoded, err := hex.DecodeString(hash)
+	if err != nil || len(decoded) != 32 {
+		return newStatusError(StatusInvalidArgument, "Evidence hash "+hash+" is not a hex encoded SHA-256 hash.")
+	}
+	for _, evidence := range dispute.Evidence {
+		if evidence.Hash == hash {
+			return newStatusError(StatusConflict, "Evidence "+hash+" was already submitted to dispute "+dispute.ID+".")
+		}
+	}
+	dispute.Evidence = append(dispute.Evidence, DisputeEvidence{Hash: hash, SubmittedBy: userID, SubmittedOn: now})
+	return nil
+}
+
+// getDisputeParties returns the users that are party of the disputed record and sets the match
+// the record belongs to on the dispute
+func getDisputeParties(stub shim.ChaincodeStubInterface, dispute *Dispute) ([]string, error) {
+	var parties []string
+	matchID := ""
+	switch dispute.TargetType {
+	case BidMatchTarget:
+		matchID = dispute.TargetID
+	case EnergyBidTarget:
+		energyBidAsBytes, err := stub.GetState("EnergyBid_" + dispute.TargetID)
+		if err != nil {
+			return nil, errors.New("Error accessing state: " + err.Error())
+		}
+		if energyBidAsBytes == nil {
+			return nil, newStatusError(StatusNotFound, "EnergyBid with ID "+dispute.TargetID+" not found.")
+		}
+		var energyBid EnergyBid
+		err = json.Unmarshal(energyBidAsBytes, &energyBid)
+		if err != nil {
+			return nil, errors.New("Failed to unmarshal EnergyBid: " + err.Error())
+		}
+		matchID = energyBid.BidMatchID
+	case PaymentTarget:
+		payment, err := getPayment(stub, dispute.TargetID)
+		if err != nil {
+			return nil, err
+		}
+		parties = append(parties, payment.UserID)
+		matchID = payment.BidMatchID
+	default:
+		return nil, newStatusError(StatusInvalidArgument, "Invalid TargetType "+dispute.TargetType+". It should be "+EnergyBidTarget+", "+
+			BidMatchTarget+" or "+PaymentTarget+".")
+	}
+
+	if matchID != "" {
+		bidMatch, err := getBidMatch(stub, matchID)
+		if err != nil {
+			return nil, err
+		}
+		parties = append(parties, bidMatch.BuyerUserId, bidMatch.SellerUserId)
+	}
+	dispute.BidMatchID = matchID
+	return parties, nil
+}
+
+// newAdjustmentPayment validates an adjustment of a dispute resolution and builds its payment.
+// The payment refers to the order the user holds in the disputed match, or to the order of the
+// disputed payment.
+func newAdjustmentPayment(stub shim.ChaincodeStubInterface, dispute *Dispute, parties []string, adjustment DisputeAdjustment, now int64) (*Payment, error) {
+	if adjustment.PaymentID == "" || adjustment.Amount == 0 {
+		return nil, newStatusError(StatusInvalidArgument, "Adjustments need a paymentId and a non-zero amount.")
+	}
+	if !containsString(parties, adjustment.UserID) {
+		return nil, newStatusError(StatusInvalidArgument, "Adjustment "+adjustment.PaymentID+" is for user "+adjustment.UserID+
+			", who is not a party of dispute "+dispute.ID+".")
+	}
+	existingAsBytes, err := stub.GetState("Payment_" + adjustment.PaymentID)
+	if err != nil {
+		return nil, errors.New("Error accessing state: " + err.Error())
+	}
+	if existingAsBytes != nil {
+		return nil, newStatusError(StatusConflict, "Payment with ID "+adjustment.PaymentID+" already exists.")
+	}
+
+	payment := Payment{
+		BidMatchID:  dispute.BidMatchID,
+		CreatedOn:   now,
+		DisputeID:   dispute.ID,
+		ID:          adjustment.PaymentID,
+		PaymentType: AdjustmentPaymentType,
+		TotalAmount: adjustment.Amount,
+		UserID:      adjustment.UserID,
+	}
+	if dispute.TargetType == PaymentTarget {
+		original, err := getPayment(stub, dispute.TargetID)
+		if err != nil {
+			return nil, err
+		}
+		payment.OriginalPaymentID = original.ID
+		payment.OrderID = original.OrderID
+	}
+	// The adjusted user's own side of the match names the order, whoever made the disputed payment.
+	if dispute.BidMatchID != "" {
+		bidMatch, err := getBidMatch(stub, dispute.BidMatchID)
+		if err != nil {
+			return nil, err
+		}
+		if adjustment.UserID == bidMatch.BuyerUserId {
+			payment.OrderID = bidMatch.TransactionBuyID
+		} else {
+			payment.OrderID = bidMatch.TransactionSellID
+		}
+	}
+	return &payment, nil
+}
+
+// setActiveDisputeIndexes adds an undecided dispute to the indexes of its target and match, or
+// removes a decided one
+func setActiveDisputeIndexes(stub shim.ChaincodeStubInterface, dispute *Dispute, active bool) error {
+	indexes := [][]string{{ActiveDisputesByTargetIndex, dispute.TargetType + "_" + dispute.TargetID}}
+	if dispute.BidMatchID != "" {
+		indexes = append(indexes, []string{ActiveDisputesByMatchIndex, dispute.BidMatchID})
+	}
+	for _, index := range indexes {
+		indexKey, err := stub.CreateCompositeKey(index[0], []string{index[1], dispute.ID})
+		if err != nil {
+			return errors.New("Could not create " + index[0] + " index key: " + err.Error())
+		}
+		if active {
+			// Only the key is needed, the value can not be nil.
+			err = stub.PutState(indexKey, []byte{0x00})
+		} else {
+			err = stub.DelState(indexKey)
+		}
+		if err != nil {
+			return errors.New("Could not update " + index[0] + " index: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// hasActiveDisputes reports whether an index holds undecided disputes for the referenced ID
+func hasActiveDisputes(stub shim.ChaincodeStubInterface, index string, referenceID string) (bool, error) {
+	iterator, err := stub.GetStateByPartialCompositeKey(index, []string{referenceID})
+	if err != nil {
+		return false, errors.New("Failed to query " + index + " index: " + err.Error())
+	}
+	defer iterator.Close()
+	return iterator.HasNext(), nil
+}
+
+// checkMatchNotDisputed rejects the settlement of a match with undecided disputes
+func checkMatchNotDisputed(stub shim.ChaincodeStubInterface, bidMatchID string) error {
+	disputed, err := hasActiveDisputes(stub, ActiveDisputesByMatchIndex, bidMatchID)
+	if err != nil {
+		return err
+	}
+	if disputed {
+		return newStatusError(StatusConflict, "BidMatch "+bidMatchID+" is disputed, its settlement is frozen until the dispute is decided.")
+	}
+	return nil
+}
+
+// checkPaymentNotDisputed rejects refunds of a payment with undecided disputes, which are settled
+// through the adjustments of the dispute resolution
+func checkPaymentNotDisputed(stub shim.ChaincodeStubInterface, paymentID string) error {
+	disputed, err := hasActiveDisputes(stub, ActiveDisputesByTargetIndex, PaymentTarget+"_"+paymentID)
+	if err != nil {
+		return err
+	}
+	if disputed {
+		return newStatusError(StatusConflict, "Payment "+paymentID+" is disputed, it can not be refunded until the dispute is decided.")
+	}
+	return nil
+}
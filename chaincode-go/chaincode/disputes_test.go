@@ -0,0 +1,156 @@
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisputes(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	admin := newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+	arbiter := newCreator(t, "Org1MSP", map[string]string{RoleAttribute: ArbiterRole})
+	buyer := newCreator(t, "Org2MSP", nil)
+	stub.Creator = admin
+
+	enableTrading(t, stub, "120", BuyAction)
+	enableTrading(t, stub, "121", SellAction)
+	var user User
+	userAsBytes, _ := stub.GetState("120")
+	json.Unmarshal(userAsBytes, &user)
+	user.MSPID = "Org2MSP"
+	userAsBytes, _ = json.Marshal(user)
+	stub.MockTransactionStart("user")
+	stub.PutState("120", userAsBytes)
+	stub.MockTransactionEnd("user")
+	putBidMatch(t, stub, BidMatch{ID: "M1", BidSlot: "slot1", BidUnitPrice: 10, BuyerUserId: "120", OriginalBidUnits: 10,
+		SellerUserId: "121", TransactionBuyID: "B1", TransactionSellID: "S1"})
+
+	processEnergyBid := func(txID string) pb.Response {
+		return invoke(stub, txID, "ProcessEnergyBid", "E1", "M1", "10", "10", "0", "0", "6", "6", "0", "0", "4", "")
+	}
+	hash := func(document string) string {
+		sum := sha256.Sum256([]byte(document))
+		return hex.EncodeToString(sum[:])
+	}
+
+	recordPayment := func(txID string, paymentID string) pb.Response {
+		detailAsBytes, _ := json.Marshal(PaymentDetail{DebitedFrom: "120", CreditedTo: "121", TotalUnitCost: 60})
+		stub.TransientMap = map[string][]byte{"paymentDetail": detailAsBytes, "salt": []byte("salt" + txID)}
+		defer func() { stub.TransientMap = nil }()
+		return invoke(stub, txID, "RecordPayment", paymentID, "Buy", "60", "120", "PD"+paymentID, "B1", "M1")
+	}
+
+	assertOK(t, processEnergyBid("1"))
+
+	// Test Case 1: Only parties of the record can raise a dispute
+	t.Run("Not A Party", func(t *testing.T) {
+		response := invoke(stub, "2", "RaiseDispute", "D0", "122", EnergyBidTarget, "E1", "short delivery", "[]")
+		assert.Equal(t, StatusUnauthorized, response.GetStatus(), "Function unexpectedly succeeded")
+	})
+
+	// Test Case 2: A raised dispute freezes the settlement of its match
+	t.Run("Raise", func(t *testing.T) {
+		stub.Creator = buyer
+		assertOK(t, invoke(stub, "3", "RaiseDispute", "D1", "120", EnergyBidTarget, "E1", "short delivery", `["`+hash("meter log")+`"]`))
+
+		response := invoke(stub, "4", "RaiseDispute", "D2", "120", EnergyBidTarget, "E1", "again", "[]")
+		assert.Contains(t, response.GetMessage(), "already has an undecided dispute")
+
+		stub.Creator = admin
+		response = processEnergyBid("5")
+		assert.Equal(t, StatusConflict, response.GetStatus(), "Disputed match settled")
+		assert.Contains(t, response.GetMessage(), "settlement is frozen")
+
+		putOrder(t, stub, Order{ID: "B1", BidStatus: "BidCreated", UserID: "120", UserAction: BuyAction})
+		response = recordPayment("5.1", "P0")
+		assert.Equal(t, StatusConflict, response.GetStatus(), "Payment recorded for a disputed match")
+		assert.Contains(t, response.GetMessage(), "settlement is frozen")
+	})
+
+	// Test Case 3: Evidence has to be a SHA-256 hash
+	t.Run("Evidence", func(t *testing.T) {
+		stub.Creator = buyer
+		response := invoke(stub, "6", "AddDisputeEvidence", "D1", "120", "not-a-hash")
+		assert.Contains(t, response.GetMessage(), "is not a hex encoded SHA-256 hash")
+
+		assertOK(t, invoke(stub, "7", "AddDisputeEvidence", "D1", "120", hash("photo")))
+	})
+
+	// Test Case 4: Only arbiters decide, resolutions produce linked adjustment payments
+	t.Run("Resolve", func(t *testing.T) {
+		stub.Creator = buyer
+		response := invoke(stub, "8", "ReviewDispute", "D1")
+		assert.Contains(t, response.GetMessage(), "role arbiter required")
+
+		stub.Creator = arbiter
+		response = invoke(stub, "9", "ResolveDispute", "D1", "refund", "[]")
+		assert.Contains(t, response.GetMessage(), "only disputes UnderReview can be resolved")
+
+		assertOK(t, invoke(stub, "10", "ReviewDispute", "D1"))
+		assertOK(t, invoke(stub, "11", "ResolveDispute", "D1", "seller credits the buyer",
+			`[{"paymentId": "ADJ1", "userId": "120", "amount": -20}, {"paymentId": "ADJ2", "userId": "121", "amount": 20}]`))
+		<-stub.ChaincodeEventsChannel
+
+		dispute, err := getDispute(stub, "D1")
+		assert.NoError(t, err, "Error reading dispute")
+		assert.Equal(t, DisputeResolved, dispute.Status, "Status mismatch")
+		assert.Len(t, dispute.Evidence, 2, "Evidence count mismatch")
+		assert.Len(t, dispute.History, 3, "History length mismatch")
+		assert.Equal(t, []string{"ADJ1", "ADJ2"}, dispute.AdjustmentPaymentIDs, "Adjustment payments mismatch")
+
+		payment, _ := getPayment(stub, "ADJ1")
+		assert.Equal(t, AdjustmentPaymentType, payment.PaymentType, "Payment type mismatch")
+		assert.Equal(t, "D1", payment.DisputeID, "Dispute link mismatch")
+		assert.Equal(t, "B1", payment.OrderID, "Order link mismatch")
+		payments, _ := getIndexedPayments(stub, PaymentsByBidMatchIndex, "M1")
+		assert.Len(t, payments, 2, "Adjustments not indexed by match")
+	})
+
+	// Test Case 5: Deciding the dispute releases the settlement
+	t.Run("Released", func(t *testing.T) {
+		stub.Creator = admin
+		assertOK(t, processEnergyBid("12"))
+
+		stub.Creator = arbiter
+		response := invoke(stub, "13", "RejectDispute", "D1", "late")
+		assert.Contains(t, response.GetMessage(), "was already Resolved")
+	})
+
+	// Test Case 6: A disputed payment is settled by the dispute, not refunded
+	t.Run("Disputed Payment Refund", func(t *testing.T) {
+		stub.Creator = admin
+		assertOK(t, recordPayment("14", "P1"))
+
+		stub.Creator = buyer
+		assertOK(t, invoke(stub, "15", "RaiseDispute", "D3", "120", PaymentTarget, "P1", "double charge", "[]"))
+
+		stub.Creator = admin
+		refundAsBytes, _ := json.Marshal(PaymentDetail{BidRefundAmount: 10})
+		stub.TransientMap = map[string][]byte{"refund": refundAsBytes, "salt": []byte("salt16")}
+		response := invoke(stub, "16", "RefundPayment", "R1", "P1", "PDR1")
+		stub.TransientMap = nil
+		assert.Equal(t, StatusConflict, response.GetStatus(), "Disputed payment refunded")
+		assert.Contains(t, response.GetMessage(), "can not be refunded until the dispute is decided")
+	})
+
+	// Test Case 7: Adjustments of a disputed payment link the order of the adjusted party
+	t.Run("Payment Adjustment Orders", func(t *testing.T) {
+		stub.Creator = arbiter
+		assertOK(t, invoke(stub, "17", "ReviewDispute", "D3"))
+		assertOK(t, invoke(stub, "18", "ResolveDispute", "D3", "seller returns the double charge",
+			`[{"paymentId": "ADJ3", "userId": "120", "amount": 60}, {"paymentId": "ADJ4", "userId": "121", "amount": -60}]`))
+		<-stub.ChaincodeEventsChannel
+
+		payment, _ := getPayment(stub, "ADJ3")
+		assert.Equal(t, "P1", payment.OriginalPaymentID, "Original payment link mismatch")
+		assert.Equal(t, "B1", payment.OrderID, "Buyer order link mismatch")
+		payment, _ = getPayment(stub, "ADJ4")
+		assert.Equal(t, "S1", payment.OrderID, "Seller order link mismatch")
+	})
+}
@@ -0,0 +1,220 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                            Endorsement Methods                             */
+/* -------------------------------------------------------------------------- */
+
+// RotateEndorsementPolicy replaces the key-level endorsement policy of a ledger key, so peers of
+// all the given orgs have to endorse its future changes. The transaction itself still has to
+// satisfy the current policy of the key. Only admins can rotate policies.
+//
+// Inputs - Array of strings
+//
+//	 0  ,      1
+//	Key , Orgs (JSON array of MSP IDs)
+func RotateEndorsementPolicy(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting RotateEndorsementPolicy")
+
+	if len(args) != 2 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 2.")
+	}
+
+	err := requireRole(stub, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	key := args[0]
+	valueAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return shim.Error("Error accessing state: " + err.Error())
+	}
+	if valueAsBytes == nil {
+		return statusResponse(StatusNotFound, "Key "+key+" not found.")
+	}
+
+	var orgs []string
+	err = json.Unmarshal([]byte(args[1]), &orgs)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to unmarshal orgs: "+err.Error())
+	}
+	if len(orgs) == 0 {
+		return statusResponse(StatusInvalidArgument, "At least one org is required.")
+	}
+	for _, org := range orgs {
+		if org == "" {
+			return statusResponse(StatusInvalidArgument, "Orgs must be non-empty MSP IDs.")
+		}
+	}
+
+	err = setKeyEndorsement(stub, key, orgs...)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	policy, err := getKeyEndorsement(stub, key)
+	if err != nil {
+		return errorResponse(err)
+	}
+	policyAsBytes, _ := json.Marshal(policy)
+	err = stub.SetEvent("EndorsementPolicyRotated", policyAsBytes)
+	if err != nil {
+		return shim.Error("Could not emit EndorsementPolicyRotated event: " + err.Error())
+	}
+
+	fmt.Println("- end RotateEndorsementPolicy")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// ReadEndorsementPolicy returns the effective endorsement policy of a ledger key.
+//
+// Inputs - Array of strings
+//
+//	 0
+//	Key
+func ReadEndorsementPolicy(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadEndorsementPolicy")
+
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	policy, err := getKeyEndorsement(stub, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+	policyAsBytes, _ := json.Marshal(policy)
+
+	fmt.Println("- end ReadEndorsementPolicy")
+	return shim.Success(policyAsBytes)
+}
+
+// setKeyEndorsement requires the peers of all orgs to endorse changes to the key. Empty org
+// names are ignored; without any org the key keeps its current policy.
+func setKeyEndorsement(stub shim.ChaincodeStubInterface, key string, orgs ...string) error {
+	var mspIDs []string
+	for _, org := range orgs {
+		if org != "" {
+			mspIDs = append(mspIDs, org)
+		}
+	}
+	if len(mspIDs) == 0 {
+		return nil
+	}
+
+	ep, err := statebased.NewStateEP(nil)
+	if err != nil {
+		return errors.New("Failed to create endorsement policy: " + err.Error())
+	}
+	err = ep.AddOrgs(statebased.RoleTypePeer, mspIDs...)
+	if err != nil {
+		return errors.New("Failed to add orgs to endorsement policy: " + err.Error())
+	}
+	policy, err := ep.Policy()
+	if err != nil {
+		return errors.New("Failed to marshal endorsement policy: " + err.Error())
+	}
+	err = stub.SetStateValidationParameter(key, policy)
+	if err != nil {
+		return errors.New("Could not set endorsement policy of " + key + ": " + err.Error())
+	}
+	return nil
+}
+
+// getKeyEndorsement reads the key-level endorsement policy of a key
+func getKeyEndorsement(stub shim.ChaincodeStubInterface, key string) (*EndorsementPolicy, error) {
+	policy := EndorsementPolicy{Key: key, Orgs: []string{}}
+	policyAsBytes, err := stub.GetStateValidationParameter(key)
+	if err != nil {
+		return nil, errors.New("Failed to get endorsement policy of " + key + ": " + err.Error())
+	}
+	if len(policyAsBytes) == 0 {
+		return &policy, nil
+	}
+
+	ep, err := statebased.NewStateEP(policyAsBytes)
+	if err != nil {
+		return nil, errors.New("Failed to unmarshal endorsement policy: " + err.Error())
+	}
+	policy.KeyLevel = true
+	policy.Orgs = ep.ListOrgs()
+	sort.Strings(policy.Orgs)
+	return &policy, nil
+}
+
+// getOwnerOrg returns the org of a user, empty for users registered before orgs were recorded
+func getOwnerOrg(stub shim.ChaincodeStubInterface, userID string) (string, error) {
+	userAsBytes, err := stub.GetState(userID)
+	if err != nil {
+		return "", errors.New("Error accessing state: " + err.Error())
+	}
+	if userAsBytes == nil {
+		return "", newStatusError(StatusNotFound, "User with ID "+userID+" not found")
+	}
+
+	var user User
+	err = json.Unmarshal(userAsBytes, &user)
+	if err != nil {
+		return "", errors.New("Failed to unmarshal user: " + err.Error())
+	}
+	return user.MSPID, nil
+}
+
+// setOwnerEndorsement requires the org of the owning user to endorse changes to the key.
+// Records of users without an org keep the chaincode endorsement policy.
+func setOwnerEndorsement(stub shim.ChaincodeStubInterface, key string, userID string) error {
+	org, err := getOwnerOrg(stub, userID)
+	if err != nil {
+		return err
+	}
+	return setKeyEndorsement(stub, key, org)
+}
+
+// setSettlementEndorsement requires the orgs of all parties and the grid operator org to
+// endorse changes to the settlement record stored under key.
+func setSettlementEndorsement(stub shim.ChaincodeStubInterface, key string, userIDs ...string) error {
+	config, err := getMarketConfig(stub)
+	if err != nil {
+		return errors.New("Failed to load market config: " + err.Error())
+	}
+
+	orgs := []string{config.GridOperatorMSPID}
+	for _, userID := range userIDs {
+		org, err := getOwnerOrg(stub, userID)
+		if err != nil {
+			return err
+		}
+		orgs = append(orgs, org)
+	}
+	return setKeyEndorsement(stub, key, orgs...)
+}
@@ -0,0 +1,89 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndorsementPolicies(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	admin := newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+	member := newCreator(t, "Org2MSP", nil)
+	stub.Creator = admin
+
+	enableTrading(t, stub, "140", BuyAction)
+	enableTrading(t, stub, "141", SellAction)
+	for userID, mspID := range map[string]string{"140": "Org2MSP", "141": "Org3MSP"} {
+		var user User
+		userAsBytes, _ := stub.GetState(userID)
+		json.Unmarshal(userAsBytes, &user)
+		user.MSPID = mspID
+		userAsBytes, _ = json.Marshal(user)
+		stub.MockTransactionStart("user")
+		stub.PutState(userID, userAsBytes)
+		stub.MockTransactionEnd("user")
+	}
+
+	readPolicy := func(t *testing.T, key string) EndorsementPolicy {
+		response := invoke(stub, "read", "ReadEndorsementPolicy", key)
+		assertOK(t, response)
+		var policy EndorsementPolicy
+		json.Unmarshal(response.GetPayload(), &policy)
+		return policy
+	}
+
+	// Test Case 1: A new profile requires the endorsement of the org that registered it
+	t.Run("Profile", func(t *testing.T) {
+		stub.Creator = member
+		assertOK(t, invoke(stub, "1", "UpdateUserProfile", "142", "Residential", "MTR142", "Solar", "false"))
+
+		policy := readPolicy(t, "142")
+		assert.True(t, policy.KeyLevel, "No key-level policy set")
+		assert.Equal(t, []string{"Org2MSP"}, policy.Orgs, "Profile orgs mismatch")
+	})
+
+	// Test Case 2: A new order requires the endorsement of its owner's org
+	t.Run("Order", func(t *testing.T) {
+		stub.Creator = admin
+		assertOK(t, invoke(stub, "2", "RegisterOrder", "", "BidCreated", "O1", "false", "100", "", "slot1", "10", "10", "140", "4102444800", BuyAction))
+
+		assert.Equal(t, []string{"Org2MSP"}, readPolicy(t, "Order_O1").Orgs, "Order orgs mismatch")
+	})
+
+	// Test Case 3: Settlement records also require the grid operator's endorsement
+	t.Run("Settlement", func(t *testing.T) {
+		assertOK(t, invoke(stub, "3", "UpdateMarketConfig", `{"gridOperatorMspId": "GridMSP"}`))
+		assertOK(t, invoke(stub, "4", "RegisterOrder", "", "BidCreated", "S1", "false", "50", "", "slot1", "5", "10", "141", "4102444800", SellAction))
+		assertOK(t, invoke(stub, "5", "ProcessBidMatch", "1700000000", "slot1", "BidAccepted", "10", "140", "0", "M1", "5", "141", "O1", "S1"))
+
+		assert.Equal(t, []string{"GridMSP", "Org2MSP", "Org3MSP"}, readPolicy(t, "BidMatch_M1").Orgs, "Match orgs mismatch")
+	})
+
+	// Test Case 4: Keys without a key-level policy fall back to the chaincode policy
+	t.Run("Chaincode Policy", func(t *testing.T) {
+		policy := readPolicy(t, "MarketConfig")
+		assert.False(t, policy.KeyLevel, "Unexpected key-level policy")
+		assert.Empty(t, policy.Orgs, "Unexpected orgs")
+	})
+
+	// Test Case 5: Only admins can rotate the policy of an existing key
+	t.Run("Rotate", func(t *testing.T) {
+		stub.Creator = member
+		response := invoke(stub, "6", "RotateEndorsementPolicy", "Order_O1", `["Org4MSP"]`)
+		assert.Contains(t, response.GetMessage(), "role admin required")
+
+		stub.Creator = admin
+		response = invoke(stub, "7", "RotateEndorsementPolicy", "Order_O2", `["Org4MSP"]`)
+		assert.Contains(t, response.GetMessage(), "Key Order_O2 not found.")
+		response = invoke(stub, "8", "RotateEndorsementPolicy", "Order_O1", `[]`)
+		assert.Contains(t, response.GetMessage(), "At least one org is required.")
+
+		assertOK(t, invoke(stub, "9", "RotateEndorsementPolicy", "Order_O1", `["Org4MSP", "Org2MSP"]`))
+		event := <-stub.ChaincodeEventsChannel
+		assert.Equal(t, "EndorsementPolicyRotated", event.GetEventName(), "Event name mismatch")
+		assert.Equal(t, []string{"Org2MSP", "Org4MSP"}, readPolicy(t, "Order_O1").Orgs, "Rotated orgs mismatch")
+	})
+}
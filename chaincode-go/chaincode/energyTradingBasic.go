@@ -0,0 +1,907 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+
+wit"License"); you may not use this file except in compliance the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// SimpleChaincode example simple Chaincode implementation
+type SimpleChaincode struct {
+	contractapi.Contract
+}
+
+// ============================================================================================================================
+// User Definitions - The ledger with user
+// ============================================================================================================================
+
+// User represents the schema for the user table.
+// Location and Contact are kept in the private data collection of the user's org; on
+// public state they are left empty and only their salted hashes are stored.
+// ErasedOn is set once the user's personal data was erased and the ID pseudonymized.
+// PublicKey is the PEM encoded key the user signs contracts with.
+// ZoneID is the grid zone (feeder) the user is connected to.
+type User struct {
+	ID           string `json:"id"`
+	Category     string `json:"category"`
+	Contact      string `json:"contact"`
+	ContactHash  string `json:"contactHash"`
+	CreatedOn    int64  `json:"createdOn"`
+	ErasedOn     int64  `json:"erasedOn"`
+	IsAdmin      bool   `json:"isAdmin"`
+	Location     string `json:"location"`
+	LocationHash string `json:"locationHash"`
+	MeterID      string `json:"meterId"`
+	MSPID        string `json:"mspId"`
+	PublicKey    string `json:"publicKey"`
+	Source       string `json:"source"`
+	UpdatedOn    int64  `json:"updatedOn"`
+	ZoneID       string `json:"zoneId"`
+}
+
+// Enterprise User represents the schema for the user table.
+type EnterpriseUser struct {
+	ID           string   `json:"id"`
+	Category     string   `json:"category"`
+	Contact      string   `json:"contact"`
+	ContactHash  string   `json:"contactHash"`
+	CreatedOn    int64    `json:"createdOn"`
+	ErasedOn     int64    `json:"erasedOn"`
+	IsAdmin      bool     `json:"isAdmin"`
+	Location     string   `json:"location"`
+	LocationHash string   `json:"locationHash"`
+	MeterIDs     []string `json:"meterIds"` // Changed from MeterID to MeterIDs and now accepts a slice of strings
+	MSPID        string   `json:"mspId"`
+	PublicKey    string   `json:"publicKey"`
+	Source       string   `json:"source"`
+	UpdatedOn    int64    `json:"updatedOn"`
+	ZoneID       string   `json:"zoneId"`
+}
+
+// UserPrivateDetails holds the personal data of a user in the private data collection
+// shared by the user's org and the operator.
+type UserPrivateDetails struct {
+	Contact  string `json:"contact"`
+	ID       string `json:"id"`
+	Location string `json:"location"`
+	Salt     string `json:"salt"`
+}
+
+// ErasureCertificate records the erasure of a participant's personal data. It only
+// refers to the pseudonymous ID the participant's records were moved to.
+type ErasureCertificate struct {
+	ErasedFields         []string `json:"erasedFields"`
+	ErasedOn             int64    `json:"erasedOn"`
+	ID                   string   `json:"id"`
+	PseudonymizedRecords int      `json:"pseudonymizedRecords"`
+	TxID                 string   `json:"txId"`
+}
+
+// PlatformContract records a user's acceptance of a ContractTemplate version.
+// SignedContractHash is the DocumentHash of the template that was signed and
+// Signature the user's base64 encoded signature over it.
+type PlatformContract struct {
+	UserID             string `json:"userId"`
+	SignedContractHash string `json:"signedContractHash"`
+	Signature          string `json:"signature"`
+	TemplateID         string `json:"templateId"`
+	TemplateVersion    int    `json:"templateVersion"`
+	CreatedOn          int64  `json:"createdOn"`
+	UpdatedOn          int64  `json:"updatedOn"`
+}
+
+// TradingContract records a user's acceptance of a trading ContractTemplate version and the
+// trading actions (Buy, Sell) it allows. A user has one trading contract, which moves between
+// Active, Suspended, Revoked and Expired; every transition is appended to History.
+type TradingContract struct {
+	Actions            []string             `json:"actions"`
+	CreatedOn          int64                `json:"createdOn"`
+	ExpiresOn          int64                `json:"expiresOn"`
+	History            []ContractTransition `json:"history"`
+	SignedContractHash string               `json:"signedContractHash"`
+	Signature          string               `json:"signature"`
+	Status             string               `json:"status"`
+	TemplateID         string               `json:"templateId"`
+	TemplateVersion    int                  `json:"templateVersion"`
+	UpdatedOn          int64                `json:"updatedOn"`
+	UserID             string               `json:"userId"`
+}
+
+// ContractTransition is one state change of a TradingContract.
+type ContractTransition struct {
+	FromStatus string `json:"fromStatus"`
+	Reason     string `json:"reason"`
+	ToStatus   string `json:"toStatus"`
+	TxID       string `json:"txId"`
+	UpdatedOn  int64  `json:"updatedOn"`
+}
+
+// ContractTemplate is a version of the contract terms published by the admins.
+// The version with the highest number whose EffectiveDate has passed is in effect;
+// contracts signed on an older version have to be signed again before trading.
+type ContractTemplate struct {
+	ContractType  string `json:"contractType"` // Platform or Trading
+	CreatedOn     int64  `json:"createdOn"`
+	DocumentHash  string `json:"documentHash"`
+	EffectiveDate int64  `json:"effectiveDate"`
+	ID            string `json:"id"`
+	Version       int    `json:"version"`
+}
+
+// ============================================================================================================================
+// Trading Definitions - The ledger with user
+// ============================================================================================================================
+
+// Order captures the details of an energy buy or sell bid.
+// It includes attributes like total quantity, unit cost, and the total order cost.
+// Struct fields are arranged alphabetically to ensure determinism across languages.
+// Note: While Golang maintains field order when marshaling to JSON, it doesn't auto-sort them.
+//
+// An order can be filled by several bid matches: BidMatchIDs lists the matches referencing it
+// (as TransactionBuyID or TransactionSellID), FilledQuantity and RemainingQuantity split the
+// TotalQuantity, and AverageFillPrice is the volume-weighted BidUnitPrice of the fills.
+// Orders of the same price are matched in PriorityTime order; an amendment raising the quantity
+// or changing the price moves it to the time of the amendment. CancelledValue is the value at
+// UnitCost of the unfilled quantity of a buy order withdrawn by cancellation. The ledger holds no
+// funds against PaymentID; returning money already paid goes through RefundPayment.
+// OrderType decides the price an order trades at: a Limit order at UnitCost or better, a Market
+// order at the best available price up to its ProtectionPrice. TimeInForce decides how long and
+// in which pieces it can be filled. OnMarketPrice is kept for older clients and not interpreted;
+// ReferencePriceID names the oracle reference price of the slot the order was priced against.
+// MeterID optionally names the meter the order is delivered from or to; its grid zone then
+// takes precedence over the zone of the user.
+type Order struct {
+	AverageFillPrice  float64  `json:"averageFillPrice"`
+	BidMatchID        string   `json:"bidMatchId"`
+	BidMatchIDs       []string `json:"bidMatchIds"`
+	BidStatus         string   `json:"bidStatus"`
+	CancelledValue    float64  `json:"cancelledValue"`
+	CreatedOn         int64    `json:"createdOn"`
+	FilledQuantity    float64  `json:"filledQuantity"`
+	ID                string   `json:"id"`
+	MeterID           string   `json:"meterId"`
+	OnMarketPrice     string   `json:"onMarketPrice"`
+	OrderCost         float64  `json:"orderCost"`
+	OrderType         string   `json:"orderType"`
+	PaymentID         string   `json:"paymentId"`
+	PriorityTime      int64    `json:"priorityTime"`
+	ProtectionPrice   float64  `json:"protectionPrice"`
+	ReferencePriceID  string   `json:"referencePriceId"`
+	RemainingQuantity float64  `json:"remainingQuantity"`
+	SlotID            string   `json:"slotId"`
+	SlotExecDate      int64    `json:"slotExecDate"`
+	TimeInForce       string   `json:"timeInForce"`
+	TotalQuantity     int64    `json:"totalQuantity"`
+	UnitCost          float64  `json:"unitCost"`
+	UpdatedOn         int64    `json:"updatedOn"`
+	UserAction        string   `json:"action"`
+	UserID            string   `json:"userId"`
+}
+
+// BidMatch records the details of a matched bid in the energy market.
+// Struct fields are alphabetically ordered for cross-language determinism.
+// NetworkCharge is the network-use charge for carrying the SettledBidUnits the seller delivered
+// to the buyer on the energy bids of the match, recomputed at every settlement and billed to the
+// buyer; it is zero until the first settlement. CurtailedBidUnits is the part of the matched
+// units cut to keep within the capacity of the zones.
+type BidMatch struct {
+	BidMatchTms          int64   `json:"bidMatchTms"`
+	BidSlot              string  `json:"bidSlot"`
+	BidStatus            string  `json:"bidStatus"`
+	BidUnitPrice         int64   `json:"bidUnitPrice"`
+	BuyerUserId          string  `json:"buyerUserId"`
+	BuyerZoneID          string  `json:"buyerZoneId"`
+	CurtailedBidUnits    float64 `json:"curtailedBidUnits"`
+	DeliveredBidUnits    float64 `json:"deliveredBidUnits"`
+	ID                   string  `json:"id"`
+	NetworkCharge        float64 `json:"networkCharge"`
+	NetworkChargePerUnit float64 `json:"networkChargePerUnit"`
+	OriginalBidUnits     float64 `json:"originalBidUnits"`
+	SellerUserId         string  `json:"sellerUserId"`
+	SellerZoneID         string  `json:"sellerZoneId"`
+	SettledBidUnits      float64 `json:"settledBidUnits"`
+	TransactionBuyID     string  `json:"transactionBuyId"`
+	TransactionSellID    string  `json:"transactionSellId"`
+}
+
+// EnergyBid records the details of a executed bid in the energy market.
+// Struct fields are alphabetically ordered for cross-language determinism.
+// ReferencePriceID names the oracle reference price of the slot the bid was settled against.
+type EnergyBid struct {
+	ID                         string  `json:"id"`
+	BidMatchID                 string  `json:"bidMatchId"`
+	InitialBidUnits            float64 `json:"initialBidUnits"`
+	AcceptedBidUnits           float64 `json:"acceptedBidUnits"`
+	BuyerMeterUnit             float64 `json:"buyerMeterUnit"`
+	SellerMeterUnit            float64 `json:"sellerMeterUnit"`
+	BuyerBroughtUnitFromSeller float64 `json:"buyerBroughtUnitFromSeller"`
+	SellerSoldUnitToBuyer      float64 `json:"sellerSoldUnitToBuyer"`
+	SellerSoldUnitToGrid       float64 `json:"sellerSoldUnitToGrid"`
+	BuyerSoldUnitToGrid        float64 `json:"buyerSoldUnitToGrid"`
+	BuyerBroughtUnitFromGrid   float64 `json:"buyerBroughtUnitFromGrid"`
+	Reason                     string  `json:"reason"`
+	CreatedOn                  int64   `json:"createdOn"`
+	PlatformFee                float64 `json:"platformFee"`
+	FeeScheduleVersion         int     `json:"feeScheduleVersion"`
+	ReferencePriceID           string  `json:"referencePriceId"`
+	SettledOn                  int64   `json:"settledOn"` // first settlement, kept when the bid is reprocessed
+}
+
+// Payment logs transaction details for energy market payments.
+// Struct fields are alphabetically ordered for cross-language determinism.
+// Refunds are reversal payments of PaymentType "Refund": OriginalPaymentID points from the
+// reversal to the refunded payment, RefundPaymentIDs and RefundedAmount of the refunded payment
+// point back and hold the refunded total. FeeScheduleVersion is the FeeSchedule the PlatformFee
+// of the PaymentDetail was computed with, 0 when none was in effect. Adjustments are payments of
+// PaymentType "Adjustment" produced by resolving the Dispute DisputeID; a positive TotalAmount
+// charges the user, a negative one credits it.
+type Payment struct {
+	BidMatchID         string   `json:"bidMatchId"`
+	CreatedOn          int64    `json:"createdOn"`
+	DisputeID          string   `json:"disputeId"`
+	ID                 string   `json:"id"`
+	PaymentDetailID    string   `json:"paymentDetail"`
+	PaymentType        string   `json:"paymentType"`
+	TotalAmount        float64  `json:"totalAmount"`
+	UserID             string   `json:"userId"`
+	OrderID            string   `json:"orderId"`
+	OriginalPaymentID  string   `json:"originalPaymentId"`
+	RefundedAmount     float64  `json:"refundedAmount"`
+	RefundPaymentIDs   []string `json:"refundPaymentIds"`
+	UpdatedOn          int64    `json:"updatedOn"`
+	FeeScheduleVersion int      `json:"feeScheduleVersion"`
+}
+
+const RefundPaymentType = "Refund"
+const AdjustmentPaymentType = "Adjustment"
+
+// PaymentDetail captures more granular transaction information.
+// It includes attributes like the amount refunded, fees applied, and transaction parties.
+// Struct fields are arranged alphabetically for consistent representation.
+type PaymentDetail struct {
+	ID                      string  `json:"id"`
+	DebitedFrom             string  `json:"debitedFrom"`
+	CreditedTo              string  `json:"creditedTo"`
+	TotalUnitCost           float64 `json:"totalUnitCost"`
+	PlatformFee             float64 `json:"platformFee"`
+	TokenAmount             float64 `json:"tokenAmount"`
+	BidRefundAmount         float64 `json:"bidRefundAmount"`
+	PlatformFeeRefundAmount float64 `json:"platformFeeRefundAmount"`
+	TokenAmountRefund       float64 `json:"tokenAmountRefund"`
+	PenaltyFromSeller       float64 `json:"penaltyFromSeller"`
+}
+
+// Invoice is the statement of one participant for a billing period (a calendar month, UTC).
+// Positive line item amounts are charged to the participant and negative ones credited; Total
+// is their sum. ContentHash is the hex encoded SHA-256 of the JSON encoded UserID, Period,
+// LineItems and Total, so a rendered copy can be checked against the ledger. The fees and
+// penalties come from private payment details, so the invoice is stored in the collection of the
+// participant's org and only a PrivateDataHash holding its ContentHash is public.
+type Invoice struct {
+	ContentHash string            `json:"contentHash"`
+	CreatedOn   int64             `json:"createdOn"`
+	ID          string            `json:"id"`
+	LineItems   []InvoiceLineItem `json:"lineItems"`
+	Period      string            `json:"period"` // YYYY-MM
+	Total       float64           `json:"total"`
+	TxID        string            `json:"txId"`
+	UserID      string            `json:"userId"`
+}
+
+// InvoiceLineItem is one charge or credit of an Invoice. Reference is the ID of the EnergyBid or
+// Payment it comes from.
+type InvoiceLineItem struct {
+	Amount    float64 `json:"amount"`
+	Quantity  float64 `json:"quantity"`
+	Reference string  `json:"reference"`
+	Type      string  `json:"type"`
+	UnitPrice float64 `json:"unitPrice"`
+}
+
+// Invoice line item types
+const (
+	EnergyPurchaseItem = "EnergyPurchase"
+	EnergySaleItem     = "EnergySale"
+	GridImportItem     = "GridImport"
+	GridExportItem     = "GridExport"
+	SettlementFeeItem  = "SettlementFee"
+	PaymentFeeItem     = "PaymentFee"
+	PenaltyItem        = "Penalty"
+	RefundItem         = "Refund"
+	AdjustmentItem     = "Adjustment"
+	NetworkChargeItem  = "NetworkCharge"
+)
+
+// ============================================================================================================================
+// Grid Definitions - zones of the distribution grid and the charges for using it
+// ============================================================================================================================
+
+// MeterZone ties a meter of a user to the grid zone it is connected to.
+type MeterZone struct {
+	MeterID   string `json:"meterId"`
+	UpdatedOn int64  `json:"updatedOn"`
+	UserID    string `json:"userId"`
+	ZoneID    string `json:"zoneId"`
+}
+
+// NetworkTariff is the network-use charge per unit of energy carried from FromZone to ToZone.
+// FromZone and ToZone are equal for trades that stay inside a feeder.
+type NetworkTariff struct {
+	ChargePerUnit float64 `json:"chargePerUnit"`
+	FromZone      string  `json:"fromZone"`
+	ToZone        string  `json:"toZone"`
+	UpdatedOn     int64   `json:"updatedOn"`
+}
+
+// ZoneCapacity is the maximum energy a zone can import from and export to other zones in a
+// slot, maintained by the grid operator. The capacity with an empty SlotID applies to every
+// slot without its own.
+type ZoneCapacity struct {
+	MaxExport float64 `json:"maxExport"`
+	MaxImport float64 `json:"maxImport"`
+	SlotID    string  `json:"slotId"`
+	UpdatedOn int64   `json:"updatedOn"`
+	ZoneID    string  `json:"zoneId"`
+}
+
+// ZoneUtilization is the energy matched across the boundary of a zone in a slot. Trades inside
+// a zone do not use its capacity.
+type ZoneUtilization struct {
+	Exported float64 `json:"exported"`
+	Imported float64 `json:"imported"`
+	SlotID   string  `json:"slotId"`
+	ZoneID   string  `json:"zoneId"`
+}
+
+// ZoneUtilizationReport is returned by ReadZoneUtilization. Capacity fields are -1 for a zone
+// without capacity limits.
+type ZoneUtilizationReport struct {
+	AvailableExport float64 `json:"availableExport"`
+	AvailableImport float64 `json:"availableImport"`
+	Exported        float64 `json:"exported"`
+	Imported        float64 `json:"imported"`
+	MaxExport       float64 `json:"maxExport"`
+	MaxImport       float64 `json:"maxImport"`
+	SlotID          string  `json:"slotId"`
+	ZoneID          string  `json:"zoneId"`
+}
+
+// ReliabilityScore tracks how much of the accepted units a seller actually delivered. Score is
+// the average delivery ratio of its energy bids, weighted by Weight, which decays exponentially
+// with the time since UpdatedOn.
+type ReliabilityScore struct {
+	Deliveries int     `json:"deliveries"`
+	Score      float64 `json:"score"`
+	UpdatedOn  int64   `json:"updatedOn"`
+	UserID     string  `json:"userId"`
+	Weight     float64 `json:"weight"`
+}
+
+// MatchCandidate is a resting order a counterparty could be matched with, as ranked by
+// ReadMatchCandidates. LandedPrice includes the network charge per unit for a buyer, and
+// MaxUnits limits a cross-zone candidate to the remaining zone capacity (-1 without limit).
+// Reliability is the score of the candidate's user.
+type MatchCandidate struct {
+	LandedPrice          float64 `json:"landedPrice"`
+	MaxUnits             float64 `json:"maxUnits"`
+	NetworkChargePerUnit float64 `json:"networkChargePerUnit"`
+	Order                Order   `json:"order"`
+	Reliability          float64 `json:"reliability"`
+	SameZone             bool    `json:"sameZone"`
+	ZoneID               string  `json:"zoneId"`
+}
+
+// ============================================================================================================================
+// Dispute Definitions - disagreements about settled energy and payments
+// ============================================================================================================================
+
+// Dispute is raised by a party of an EnergyBid, BidMatch or Payment and decided by an arbiter.
+// It moves from Open to UnderReview and ends Resolved or Rejected; every change is appended to
+// History. While a dispute is Open or UnderReview the settlement of BidMatchID, the match the
+// target belongs to, is frozen. Resolving it records the AdjustmentPaymentIDs.
+type Dispute struct {
+	AdjustmentPaymentIDs []string             `json:"adjustmentPaymentIds"`
+	BidMatchID           string               `json:"bidMatchId"`
+	CreatedOn            int64                `json:"createdOn"`
+	Evidence             []DisputeEvidence    `json:"evidence"`
+	History              []ContractTransition `json:"history"`
+	ID                   string               `json:"id"`
+	RaisedBy             string               `json:"raisedBy"`
+	Reason               string               `json:"reason"`
+	Resolution           string               `json:"resolution"`
+	Status               string               `json:"status"`
+	TargetID             string               `json:"targetId"`
+	TargetType           string               `json:"targetType"`
+	UpdatedOn            int64                `json:"updatedOn"`
+}
+
+// DisputeEvidence is the hash of a document supporting a dispute; the document stays off-chain.
+type DisputeEvidence struct {
+	Hash        string `json:"hash"`
+	SubmittedBy string `json:"submittedBy"`
+	SubmittedOn int64  `json:"submittedOn"`
+}
+
+// DisputeAdjustment is one adjustment payment of a dispute resolution.
+type DisputeAdjustment struct {
+	Amount    float64 `json:"amount"`
+	PaymentID string  `json:"paymentId"`
+	UserID    string  `json:"userId"`
+}
+
+// Dispute statuses
+const (
+	DisputeOpen        = "Open"
+	DisputeUnderReview = "UnderReview"
+	DisputeResolved    = "Resolved"
+	DisputeRejected    = "Rejected"
+)
+
+// Dispute target types
+const (
+	EnergyBidTarget = "EnergyBid"
+	BidMatchTarget  = "BidMatch"
+	PaymentTarget   = "Payment"
+)
+
+// ============================================================================================================================
+// Certificate Definitions - guarantees of origin for delivered renewable energy
+// ============================================================================================================================
+
+// Certificate is a guarantee of origin for Quantity units of renewable energy delivered by the
+// producer in a slot. It is minted to the buyer when an EnergyBid settles energy from a renewable
+// Source; Issuer is the org that submitted the settlement. Transferring part of a certificate
+// splits it, the new certificate refers to it as ParentID. A retired certificate can not be
+// transferred or retired again.
+type Certificate struct {
+	CreatedOn   int64   `json:"createdOn"`
+	EnergyBidID string  `json:"energyBidId"`
+	HolderID    string  `json:"holderId"`
+	ID          string  `json:"id"`
+	Issuer      string  `json:"issuer"`
+	MeterID     string  `json:"meterId"`
+	ParentID    string  `json:"parentId"`
+	ProducerID  string  `json:"producerId"`
+	Quantity    float64 `json:"quantity"`
+	RetiredBy   string  `json:"retiredBy"`
+	RetiredOn   int64   `json:"retiredOn"`
+	SlotID      string  `json:"slotId"`
+	Source      string  `json:"source"`
+	Splits      int     `json:"splits"`
+	Status      string  `json:"status"`
+	UpdatedOn   int64   `json:"updatedOn"`
+}
+
+// Certificate statuses
+const (
+	CertificateActive  = "Active"
+	CertificateRetired = "Retired"
+)
+
+// RenewableSources are the User.Source values that earn guarantees of origin.
+var RenewableSources = []string{"Solar", "Wind", "Hydro", "Biomass", "Geothermal"}
+
+// RetirementReport lists the certificates a participant retired in a period.
+type RetirementReport struct {
+	Certificates []Certificate `json:"certificates"`
+	Period       string        `json:"period"`
+	Quantity     float64       `json:"quantity"`
+	UserID       string        `json:"userId"`
+}
+
+// ============================================================================================================================
+// Carbon Definitions - emission factors and the carbon accounting of purchases
+// ============================================================================================================================
+
+// EmissionFactor is the CO2 emitted per unit of energy from a source, maintained by the admins.
+// The factor of GridSource applies to grid imports and is the baseline avoided emissions are
+// measured against.
+type EmissionFactor struct {
+	KgCO2PerUnit float64 `json:"kgCo2PerUnit"`
+	Source       string  `json:"source"`
+	UpdatedOn    int64   `json:"updatedOn"`
+}
+
+// GridSource is the source of energy imported from the grid.
+const GridSource = "Grid"
+
+// CarbonFigures are the delivered units and emissions of purchases. Key is the slot or the
+// counterparty they are broken down by, GridSource for grid imports.
+type CarbonFigures struct {
+	AttributedKgCO2 float64 `json:"attributedKgCo2"`
+	AvoidedKgCO2    float64 `json:"avoidedKgCo2"`
+	DeliveredUnits  float64 `json:"deliveredUnits"`
+	GridImportUnits float64 `json:"gridImportUnits"`
+	Key             string  `json:"key"`
+}
+
+// CarbonReport is returned by ReadCarbonReport for the purchases of a participant settled
+// between From (inclusive) and To (exclusive).
+type CarbonReport struct {
+	ByCounterparty []CarbonFigures `json:"byCounterparty"`
+	BySlot         []CarbonFigures `json:"bySlot"`
+	From           int64           `json:"from"`
+	To             int64           `json:"to"`
+	Total          CarbonFigures   `json:"total"`
+	UserID         string          `json:"userId"`
+}
+
+// ============================================================================================================================
+// Oracle Definitions - reference prices published by authorized price oracles
+// ============================================================================================================================
+
+// PriceOracle is an identity registered by the admins to publish reference prices for Markets.
+// Publications have to come from an identity of MSPID carrying the oracle role and be signed with
+// the PEM encoded PublicKey.
+type PriceOracle struct {
+	CreatedOn int64    `json:"createdOn"`
+	ID        string   `json:"id"`
+	Markets   []string `json:"markets"`
+	MSPID     string   `json:"mspId"`
+	PublicKey string   `json:"publicKey"`
+	Status    string   `json:"status"`
+	UpdatedOn int64    `json:"updatedOn"`
+}
+
+const OracleActive = "Active"
+const OracleRevoked = "Revoked"
+
+// ReferencePrice is a price published by an oracle for a slot of a market. Signature is the
+// base64 signature of the oracle over "<ID>|<Market>|<SlotID>|<Price>". PreviousPriceID links
+// to the publication for the same slot and market it superseded.
+type ReferencePrice struct {
+	CreatedOn       int64   `json:"createdOn"`
+	ID              string  `json:"id"`
+	Market          string  `json:"market"`
+	OracleID        string  `json:"oracleId"`
+	PreviousPriceID string  `json:"previousPriceId"`
+	Price           float64 `json:"price"`
+	Signature       string  `json:"signature"`
+	SlotID          string  `json:"slotId"`
+}
+
+// ============================================================================================================================
+// Endorsement Definitions - key-level endorsement policies of participant-owned records
+// ============================================================================================================================
+
+// EndorsementPolicy is the effective endorsement policy of a ledger key, returned by
+// ReadEndorsementPolicy. KeyLevel is false when no key-level policy is set, in which case the
+// chaincode endorsement policy applies and Orgs is empty. Otherwise peers of all Orgs have to
+// endorse changes to the key.
+type EndorsementPolicy struct {
+	Key      string   `json:"key"`
+	KeyLevel bool     `json:"keyLevel"`
+	Orgs     []string `json:"orgs"`
+}
+
+// ============================================================================================================================
+// Market Definitions - governance parameters shared by all participants
+// ============================================================================================================================
+
+// FeeSchedule is a version of the platform fee rules published by the admins. The version with
+// the highest number whose EffectiveDate has passed applies. The fee on an amount is
+// Percentage percent of it plus FlatFee, where the best matching tier replaces both, and
+// is kept between MinFee and MaxFee (no maximum when MaxFee is 0).
+type FeeSchedule struct {
+	CreatedOn     int64     `json:"createdOn"`
+	EffectiveDate int64     `json:"effectiveDate"`
+	FlatFee       float64   `json:"flatFee"`
+	MaxFee        float64   `json:"maxFee"`
+	MinFee        float64   `json:"minFee"`
+	Percentage    float64   `json:"percentage"`
+	Tiers         []FeeTier `json:"tiers"`
+	Version       int       `json:"version"`
+}
+
+// FeeTier applies to participants of the Category (any category when empty) whose payments in
+// the current month reached MinMonthlyVolume. Of several matching tiers the one with the highest
+// MinMonthlyVolume applies, a tier naming the category winning a tie.
+type FeeTier struct {
+	Category         string  `json:"category"`
+	FlatFee          float64 `json:"flatFee"`
+	MinMonthlyVolume float64 `json:"minMonthlyVolume"`
+	Percentage       float64 `json:"percentage"`
+}
+
+// MarketConfig holds the market wide parameters maintained by the admins.
+// Missing values fall back to the defaults defined below.
+// Orders can be cancelled or amended until GateClosureSeconds before the SlotExecDate of their slot.
+// Bid matches exceeding the remaining zone capacity are rejected, or curtailed to it when
+// CurtailOverCapacity is set. Sell orders for PremiumSlots need a reliability score of at least
+// PremiumMinReliability; past deliveries weigh half as much every ReliabilityHalfLifeSeconds.
+// Settlement records require the endorsement of GridOperatorMSPID besides the owner orgs.
+// A reference price may deviate at most MaxPriceDeviation (a fraction, 0 for no bound) from
+// the price it supersedes. Orders and settlements may only cite reference prices of Market.
+type MarketConfig struct {
+	CurtailOverCapacity        bool     `json:"curtailOverCapacity"`
+	GateClosureSeconds         int64    `json:"gateClosureSeconds"`
+	GridOperatorMSPID          string   `json:"gridOperatorMspId"`
+	Market                     string   `json:"market"`
+	MaxOrderBatchSize          int      `json:"maxOrderBatchSize"`
+	MaxPriceDeviation          float64  `json:"maxPriceDeviation"`
+	OperatorMSPID              string   `json:"operatorMspId"`
+	PremiumMinReliability      float64  `json:"premiumMinReliability"`
+	PremiumSlots               []string `json:"premiumSlots"`
+	ReliabilityHalfLifeSeconds int64    `json:"reliabilityHalfLifeSeconds"`
+	UpdatedOn                  int64    `json:"updatedOn"`
+}
+
+// OrderBatchResult is returned by RegisterOrders and emitted as the OrdersRegistered event.
+// CancelledOrderIDs lists the FillOrKill orders of the batch that could not be filled on arrival.
+type OrderBatchResult struct {
+	CancelledOrderIDs []string `json:"cancelledOrderIds,omitempty"`
+	OrderIDs          []string `json:"orderIds"`
+	TxID              string   `json:"txId"`
+}
+
+const MarketConfigKey = "MarketConfig"
+
+// DefaultMaxOrderBatchSize is used until an admin stores a MarketConfig, and
+// OrderBatchSizeCeiling keeps a single transaction well within the peer message limits.
+const DefaultMaxOrderBatchSize = 100
+const OrderBatchSizeCeiling = 1000
+
+// DefaultMarket is the market orders trade in until the admins configure another one.
+const DefaultMarket = "DayAhead"
+
+// DefaultGateClosureSeconds closes a slot for order changes an hour before its execution.
+const DefaultGateClosureSeconds = 3600
+
+// DefaultReliabilityHalfLifeSeconds halves the weight of past deliveries every 30 days.
+const DefaultReliabilityHalfLifeSeconds = 30 * 24 * 3600
+
+// DefaultOperatorMSPID is the org running the platform. It is a member of every
+// private data collection (see collections_config.json).
+const DefaultOperatorMSPID = "Org1MSP"
+
+// DefaultGridOperatorMSPID lets the platform operator endorse settlements until the admins
+// name the grid operator org.
+const DefaultGridOperatorMSPID = DefaultOperatorMSPID
+
+const PlatformContractType = "Platform"
+const TradingContractType = "Trading"
+
+const BuyAction = "Buy"
+const SellAction = "Sell"
+
+// Trading contract states
+const ContractActive = "Active"
+const ContractSuspended = "Suspended"
+const ContractRevoked = "Revoked"
+const ContractExpired = "Expired"
+
+// OrderCancelledStatus is the BidStatus of an order withdrawn before it was executed.
+const OrderCancelledStatus = "BidCancelled"
+
+// Order types
+const (
+	LimitOrder  = "Limit"
+	MarketOrder = "Market"
+)
+
+// Time in force of an order. GoodTillSlot orders rest until their slot executes, FillOrKill
+// orders are cancelled on arrival unless resting orders can fill them completely, and both
+// FillOrKill and AllOrNone orders are only filled by a single match for their whole quantity.
+const (
+	GoodTillSlot = "GoodTillSlot"
+	FillOrKill   = "FillOrKill"
+	AllOrNone    = "AllOrNone"
+)
+
+// PrivateDataHash is the public state record of a value kept in a private data collection.
+// Hash is the hex encoded SHA-256 of the salt followed by the value (its JSON encoding for
+// records), so counterparties holding the value and salt can verify it without the value
+// being on the channel.
+type PrivateDataHash struct {
+	Collection string `json:"collection"`
+	Hash       string `json:"hash"`
+	ID         string `json:"id"`
+	OwnerMSPID string `json:"ownerMspId"`
+}
+
+// PrivatePaymentDetail is the PaymentDetail as stored in the private data collection.
+type PrivatePaymentDetail struct {
+	PaymentDetail
+	Salt string `json:"salt"`
+}
+
+// ============================================================================================================================
+// Prefix Definitions - For creating composite keys and avoid id overlap (for future use)
+// ============================================================================================================================
+
+const BuyBidPrefix = "BuyBid"
+const SellBidPrefix = "SellBid"
+
+// ============================================================================================================================
+// Init - initialize the chaincode (needed for interface) - returning empty success response
+// ============================================================================================================================
+func (t *SimpleChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+// ============================================================================================================================
+// Invoke - Our entry point for Invocations
+// ============================================================================================================================
+func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	function, args := stub.GetFunctionAndParameters()
+	fmt.Println(" ")
+	fmt.Println("starting invoke, for - " + function)
+
+	// Handle different functions
+	if function == "Write" {
+		return Write(stub, args)
+	} else if function == "UpdateUserProfile" {
+		return UpdateUserProfile(stub, args)
+	} else if function == "UpdateEnterpriseUserProfile" {
+		return UpdateEnterpriseUserProfile(stub, args)
+	} else if function == "SignPlatformContract" {
+		return SignPlatformContract(stub, args)
+	} else if function == "SignTradingContract" {
+		return SignTradingContract(stub, args)
+	} else if function == "RenewTradingContract" {
+		return RenewTradingContract(stub, args)
+	} else if function == "SuspendTradingContract" {
+		return SuspendTradingContract(stub, args)
+	} else if function == "ReinstateTradingContract" {
+		return ReinstateTradingContract(stub, args)
+	} else if function == "RevokeTradingContract" {
+		return RevokeTradingContract(stub, args)
+	} else if function == "ExpireTradingContracts" {
+		return ExpireTradingContracts(stub, args)
+	} else if function == "RegisterUserKey" {
+		return RegisterUserKey(stub, args)
+	} else if function == "PublishContractTemplate" {
+		return PublishContractTemplate(stub, args)
+	} else if function == "ReadContractTemplate" {
+		return ReadContractTemplate(stub, args)
+	} else if function == "RecordPayment" {
+		return RecordPayment(stub, args)
+	} else if function == "RefundPayment" {
+		return RefundPayment(stub, args)
+	} else if function == "RegisterOrder" {
+		return RegisterOrder(stub, args)
+	} else if function == "CancelOrder" {
+		return CancelOrder(stub, args)
+	} else if function == "AmendOrder" {
+		return AmendOrder(stub, args)
+	} else if function == "RegisterOrders" {
+		return RegisterOrders(stub, args)
+	} else if function == "ProcessBidMatch" {
+		return ProcessBidMatch(stub, args)
+	} else if function == "ProcessEnergyBid" {
+		return ProcessEnergyBid(stub, args)
+	} else if function == "ReadUserProfile" {
+		return ReadUserProfile(stub, args)
+	} else if function == "ReadEnterpriseUserProfile" {
+		return ReadEnterpriseUserProfile(stub, args)
+	} else if function == "ReadPlatformContract" {
+		return ReadPlatformContract(stub, args)
+	} else if function == "ReadTradingContract" {
+		return ReadTradingContract(stub, args)
+	} else if function == "ReadPayment" {
+		return ReadPayment(stub, args)
+	} else if function == "ReadPaymentDetail" {
+		return ReadPaymentDetail(stub, args)
+	} else if function == "ReadPaymentsForOrder" {
+		return ReadPaymentsForOrder(stub, args)
+	} else if function == "ReadPaymentsForBidMatch" {
+		return ReadPaymentsForBidMatch(stub, args)
+	} else if function == "ReadPaymentsForUser" {
+		return ReadPaymentsForUser(stub, args)
+	} else if function == "ReindexPayments" {
+		return ReindexPayments(stub, args)
+	} else if function == "ReadPaymentDetailHash" {
+		return ReadPaymentDetailHash(stub, args)
+	} else if function == "ReadOrder" {
+		return ReadOrder(stub, args)
+	} else if function == "ReadBidMatch" {
+		return ReadBidMatch(stub, args)
+	} else if function == "ReadEnergyBid" {
+		return ReadEnergyBid(stub, args)
+	} else if function == "GenerateInvoice" {
+		return GenerateInvoice(stub, args)
+	} else if function == "ReadInvoice" {
+		return ReadInvoice(stub, args)
+	} else if function == "ReadInvoiceHash" {
+		return ReadInvoiceHash(stub, args)
+	} else if function == "PublishFeeSchedule" {
+		return PublishFeeSchedule(stub, args)
+	} else if function == "ReadFeeSchedule" {
+		return ReadFeeSchedule(stub, args)
+	} else if function == "AssignGridZone" {
+		return AssignGridZone(stub, args)
+	} else if function == "SetNetworkTariff" {
+		return SetNetworkTariff(stub, args)
+	} else if function == "ReadNetworkTariff" {
+		return ReadNetworkTariff(stub, args)
+	} else if function == "SetZoneCapacity" {
+		return SetZoneCapacity(stub, args)
+	} else if function == "ReadZoneUtilization" {
+		return ReadZoneUtilization(stub, args)
+	} else if function == "ReadMatchCandidates" {
+		return ReadMatchCandidates(stub, args)
+	} else if function == "TransferCertificate" {
+		return TransferCertificate(stub, args)
+	} else if function == "RetireCertificate" {
+		return RetireCertificate(stub, args)
+	} else if function == "ReadCertificate" {
+		return ReadCertificate(stub, args)
+	} else if function == "ReadRetiredCertificates" {
+		return ReadRetiredCertificates(stub, args)
+	} else if function == "SetEmissionFactor" {
+		return SetEmissionFactor(stub, args)
+	} else if function == "ReadEmissionFactors" {
+		return ReadEmissionFactors(stub, args)
+	} else if function == "ReadCarbonReport" {
+		return ReadCarbonReport(stub, args)
+	} else if function == "ReadReliabilityScore" {
+		return ReadReliabilityScore(stub, args)
+	} else if function == "RaiseDispute" {
+		return RaiseDispute(stub, args)
+	} else if function == "AddDisputeEvidence" {
+		return AddDisputeEvidence(stub, args)
+	} else if function == "ReviewDispute" {
+		return ReviewDispute(stub, args)
+	} else if function == "ResolveDispute" {
+		return ResolveDispute(stub, args)
+	} else if function == "RejectDispute" {
+		return RejectDispute(stub, args)
+	} else if function == "ReadDispute" {
+		return ReadDispute(stub, args)
+	} else if function == "RegisterPriceOracle" {
+		return RegisterPriceOracle(stub, args)
+	} else if function == "RevokePriceOracle" {
+		return RevokePriceOracle(stub, args)
+	} else if function == "PublishReferencePrice" {
+		return PublishReferencePrice(stub, args)
+	} else if function == "ReadReferencePrice" {
+		return ReadReferencePrice(stub, args)
+	} else if function == "ReadReferencePriceHistory" {
+		return ReadReferencePriceHistory(stub, args)
+	} else if function == "RotateEndorsementPolicy" {
+		return RotateEndorsementPolicy(stub, args)
+	} else if function == "ReadEndorsementPolicy" {
+		return ReadEndorsementPolicy(stub, args)
+	} else if function == "UpdateMarketConfig" {
+		return UpdateMarketConfig(stub, args)
+	} else if function == "ReadMarketConfig" {
+		return ReadMarketConfig(stub, args)
+	} else if function == "EraseParticipantData" {
+		return EraseParticipantData(stub, args)
+	} else if function == "ReadErasureCertificate" {
+		return ReadErasureCertificate(stub, args)
+	}
+
+	// error out
+	fmt.Println("Received unknown invoke function name - " + function)
+	return statusResponse(StatusInvalidArgument, "Received unknown invoke function name - '"+function+"'")
+}
+
+// ============================================================================================================================
+// Query - legacy function (needed for interface)
+// ============================================================================================================================
+func (t *SimpleChaincode) Query(stub shim.ChaincodeStubInterface) pb.Response {
+	return statusResponse(StatusInvalidArgument, "Unknown supported call - Query()")
+}
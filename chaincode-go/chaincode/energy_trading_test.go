@@ -0,0 +1,1341 @@
+package chaincode
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/attrmgr"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+// newCreator builds a serialized X.509 identity for the given MSP carrying the given CA
+// attributes, to be used as the MockStub Creator.
+func newCreator(t *testing.T, mspID string, attrs map[string]string) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err.Error())
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "testUser"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	if len(attrs) > 0 {
+		attrsAsBytes, _ := json.Marshal(attrmgr.Attributes{Attrs: attrs})
+		template.ExtraExtensions = []pkix.Extension{{Id: attrmgr.AttrOID, Value: attrsAsBytes}}
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %s", err.Error())
+	}
+
+	creator, err := proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   mspID,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal identity: %s", err.Error())
+	}
+	return creator
+}
+
+// putOrder writes an order straight to the stub, bypassing the trading contract checks
+func putOrder(t *testing.T, stub *shimtest.MockStub, order Order) {
+	orderAsBytes, _ := json.Marshal(order)
+	stub.MockTransactionStart("putOrder")
+	err := stub.PutState("Order_"+order.ID, orderAsBytes)
+	stub.MockTransactionEnd("putOrder")
+	if err != nil {
+		t.Fatalf("Failed to put the order into the stub: %s", err.Error())
+	}
+}
+
+// assertOK fails the test when the chaincode returned an error
+func assertOK(t *testing.T, response pb.Response) {
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+}
+
+// invoke invokes a chaincode function with string arguments
+func invoke(stub *shimtest.MockStub, txID string, function string, args ...string) pb.Response {
+	invokeArgs := [][]byte{[]byte(function)}
+	for _, arg := range args {
+		invokeArgs = append(invokeArgs, []byte(arg))
+	}
+	return stub.MockInvoke(txID, invokeArgs)
+}
+
+// putBidMatch writes a bid match straight to the stub, bypassing the trading contract checks
+func putBidMatch(t *testing.T, stub *shimtest.MockStub, bidMatch BidMatch) {
+	bidMatchAsBytes, _ := json.Marshal(bidMatch)
+	stub.MockTransactionStart("putBidMatch")
+	err := stub.PutState("BidMatch_"+bidMatch.ID, bidMatchAsBytes)
+	stub.MockTransactionEnd("putBidMatch")
+	if err != nil {
+		t.Fatalf("Failed to put the bid match into the stub: %s", err.Error())
+	}
+}
+
+// func TestWrite(t *testing.T) {
+// 	// Create a mock stub
+// 	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+
+// 	// Positive Test Case: Writing a key-value pair
+// 	t.Run("Successfully Write key-value pair", func(t *testing.T) {
+// 		// Invoke the 'Write' function with a key and a value
+// 		response := stub.MockInvoke("1", [][]byte{[]byte("Write"), []byte("TestKey"), []byte("TestValue")})
+
+// 		// Assert that the function completed successfully
+// 		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+// 		// Fetch the value for the key from the ledger and check it
+// 		value, err := stub.GetState("TestKey")
+// 		assert.NoError(t, err, "Error getting value from ledger")
+// 		assert.Equal(t, "TestValue", string(value), "Incorrect value retrieved from ledger")
+// 	})
+
+// 	// Negative Test Case: Wrong number of arguments
+// 	t.Run("Invalid number of arguments", func(t *testing.T) {
+// 		response := stub.MockInvoke("2", [][]byte{[]byte("Write"), []byte("OnlyOneArg")})
+
+// 		// Assert that the function did not complete successfully
+// 		assert.NotEqual(t, shim.OK, response.GetStatus(), "Function unexpectedly succeeded")
+// 	})
+// }
+
+func TestUpdateUserProfile(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+
+	stub.Creator = newCreator(t, "Org2MSP", nil)
+
+	// Test Case 1: Successfully Update User Profile
+	// Test Case 1: Successfully Update User Profile
+	t.Run("Successfully Update User Profile", func(t *testing.T) {
+		stub.TransientMap = map[string][]byte{
+			"location": []byte("Location 1"), // Location field
+			"salt":     []byte("salt1"),
+		}
+		response := stub.MockInvoke("1", [][]byte{
+			[]byte("UpdateUserProfile"),
+			[]byte("1"),         // UserID field
+			[]byte("Prosumer"),  // Category field
+			[]byte("MeterId 1"), // MeterID field
+			[]byte("Solar"),     // Energy Source field
+			[]byte("true"),      // IsAdmin field
+		})
+		stub.TransientMap = nil
+
+		// Assert the function completed successfully
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		// Assert the function completed successfully
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		// Test Case 1.1: Read Updated User Profile
+		response = stub.MockInvoke("2", [][]byte{
+			[]byte("ReadUserProfile"),
+			[]byte("1"), // UserID field
+		})
+
+		// Assert the function completed successfully
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), "Failed to read user profile")
+
+		// Check if the read user profile matches the updated values
+		var readUser User
+		err := json.Unmarshal(response.GetPayload(), &readUser)
+		assert.NoError(t, err, "Error unmarshalling read user")
+		assert.Equal(t, "Location 1", readUser.Location, "Incorrect value retrieved from ledger")
+		assert.True(t, readUser.IsAdmin, "Incorrect value retrieved from ledger for IsAdmin")
+		assert.Equal(t, "Org2MSP", readUser.MSPID, "Incorrect org recorded for user")
+
+		// The location is kept off public state, only its salted hash is stored there.
+		publicAsBytes, _ := stub.GetState("1")
+		var publicUser User
+		_ = json.Unmarshal(publicAsBytes, &publicUser)
+		assert.Empty(t, publicUser.Location, "Location stored on public state")
+		assert.Equal(t, saltedHash("salt1", []byte("Location 1")), publicUser.LocationHash, "Location hash mismatch")
+	})
+
+	// Test Case 1.2: Other orgs do not see the location
+	t.Run("Location Hidden From Other Orgs", func(t *testing.T) {
+		stub.Creator = newCreator(t, "Org3MSP", nil)
+		defer func() { stub.Creator = newCreator(t, "Org2MSP", nil) }()
+
+		response := stub.MockInvoke("3", [][]byte{
+			[]byte("ReadUserProfile"),
+			[]byte("1"), // UserID field
+		})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), "Failed to read user profile")
+
+		var readUser User
+		err := json.Unmarshal(response.GetPayload(), &readUser)
+		assert.NoError(t, err, "Error unmarshalling read user")
+		assert.Empty(t, readUser.Location, "Location returned to an unauthorized org")
+		assert.NotEmpty(t, readUser.LocationHash, "Location hash missing")
+	})
+
+	// Test Case 2: Incorrect Number of Arguments
+	t.Run("Incorrect Number of Arguments", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{
+			[]byte("UpdateUserProfile"),
+			[]byte("1"),
+			[]byte("Prosumer"),
+		})
+
+		// Assert the function did not complete successfully
+		assert.NotEqual(t, shim.OK, response.GetStatus(), "Function unexpectedly succeeded")
+	})
+}
+
+func TestUpdateEnterpriseUserProfile(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+
+	stub.Creator = newCreator(t, "Org2MSP", nil)
+
+	// Test Case 1: Successfully Update Enterprise User Profile
+	t.Run("Successfully Update Enterprise User Profile", func(t *testing.T) {
+		stub.TransientMap = map[string][]byte{
+			"location": []byte("Location 1"), // Location field
+			"salt":     []byte("salt1"),
+		}
+		response := stub.MockInvoke("1", [][]byte{
+			[]byte("UpdateEnterpriseUserProfile"),
+			[]byte("1"),                          // UserID field
+			[]byte("Prosumer"),                   // Category field
+			[]byte(`["MeterId 1", "MeterId 2"]`), // MeterIDs field, updated to support multiple meter IDs
+			[]byte("Solar"),                      // Energy Source field
+			[]byte("true"),                       // IsAdmin field
+		})
+		stub.TransientMap = nil
+
+		// Assert the function completed successfully
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		// Test Case 1.1: Read Updated Enterprise User Profile
+		response = stub.MockInvoke("2", [][]byte{
+			[]byte("ReadEnterpriseUserProfile"),
+			[]byte("1"), // UserID field
+		})
+
+		// Assert the function completed successfully
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), "Failed to read enterprise user profile")
+
+		// Check if the read enterprise user profile matches the updated values
+		var readUser EnterpriseUser
+		err := json.Unmarshal(response.GetPayload(), &readUser)
+		assert.NoError(t, err, "Error unmarshalling read enterprise user")
+		assert.Equal(t, "Location 1", readUser.Location, "Incorrect value retrieved from ledger for location")
+		assert.True(t, readUser.IsAdmin, "Incorrect value retrieved from ledger for IsAdmin")
+		assert.Len(t, readUser.MeterIDs, 2, "Incorrect number of MeterIDs after reading")
+		assert.Contains(t, readUser.MeterIDs, "MeterId 1", "MeterId 1 not found in MeterIDs after reading")
+		assert.Contains(t, readUser.MeterIDs, "MeterId 2", "MeterId 2 not found in MeterIDs after reading")
+	})
+
+	// Test Case 2: Incorrect Number of Arguments
+	t.Run("Incorrect Number of Arguments", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{
+			[]byte("UpdateEnterpriseUserProfile"),
+			[]byte("1"),
+			[]byte("Prosumer"),
+			// Notice we're not providing enough arguments as expected
+		})
+
+		// Assert the function did not complete successfully
+		assert.NotEqual(t, shim.OK, response.GetStatus(), "Function unexpectedly succeeded")
+	})
+}
+
+func TestSignPlatformContract(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+
+	key := "12345"
+	id := key
+	signingKey, publicKeyPEM := newSigningKey(t)
+
+	// Creating a dummy user with a registered key to be used in the tests.
+	user := User{ID: id, PublicKey: publicKeyPEM}
+	userBytes, _ := json.Marshal(user)
+	// Start a transaction
+	stub.MockTransactionStart("1")
+	err := stub.PutState(key, userBytes)
+	if err != nil {
+		t.Fatalf("Failed to put the user into the stub: %s", err.Error())
+	}
+	stub.MockTransactionEnd("1")
+
+	publishTemplate(t, stub, "terms", PlatformContractType, 1, "A7324HAS7234SADF734JSDF", 0)
+	signature := signDocumentHash(t, signingKey, ContractSigningMessage("12345", "terms", 1, "A7324HAS7234SADF734JSDF"))
+
+	// Test Case 1: Successfully Sign Platform Contract
+	t.Run("Successfully Sign Platform Contract", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{[]byte("SignPlatformContract"), []byte("12345"), []byte("terms"), []byte("1"), []byte(signature)}) // UserID field
+
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		contractAsBytes, err := stub.GetState("PlatformContract_12345")
+		assert.NoError(t, err, "Error getting value from ledger")
+
+		var contract PlatformContract
+		err = json.Unmarshal(contractAsBytes, &contract)
+		assert.NoError(t, err, "Error unmarshalling contract")
+		assert.Equal(t, string("12345"), contract.UserID, "Incorrect UserID in contract")
+		assert.Equal(t, "A7324HAS7234SADF734JSDF", contract.SignedContractHash, "Incorrect contract hash")
+		assert.Equal(t, 1, contract.TemplateVersion, "Incorrect template version")
+	})
+
+	// Test Case 2: Incorrect Number of Arguments
+	t.Run("Incorrect Number of Arguments", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{[]byte("SignPlatformContract"), []byte("12345"), []byte("A7324HAS7234SADF734JSDF")})
+
+		assert.NotEqual(t, shim.OK, response.GetStatus(), "Function unexpectedly succeeded")
+	})
+
+	// Test Case 3: Non-existing User
+	t.Run("Non-existing User", func(t *testing.T) {
+		response := stub.MockInvoke("3", [][]byte{[]byte("SignPlatformContract"), []byte("54321"), []byte("terms"), []byte("1"), []byte(signature)})
+
+		assert.NotEqual(t, shim.OK, response.GetStatus(), "Function unexpectedly succeeded")
+	})
+
+	// Test Case 4: Invalid User ID
+	t.Run("Invalid User ID", func(t *testing.T) {
+		response := stub.MockInvoke("4", [][]byte{[]byte("SignPlatformContract"), []byte("InvalidUserID"), []byte("terms"), []byte("1"), []byte(signature)})
+
+		assert.NotEqual(t, shim.OK, response.GetStatus(), "Function unexpectedly succeeded")
+	})
+
+	// Test Case 5: Signature made with another key
+	t.Run("Invalid Signature", func(t *testing.T) {
+		otherKey, _ := newSigningKey(t)
+		response := stub.MockInvoke("5", [][]byte{[]byte("SignPlatformContract"), []byte("12345"), []byte("terms"), []byte("1"), []byte(signDocumentHash(t, otherKey, ContractSigningMessage("12345", "terms", 1, "A7324HAS7234SADF734JSDF")))})
+
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "invalid")
+	})
+
+	// Test Case 6: Unknown template version
+	t.Run("Unknown Template Version", func(t *testing.T) {
+		response := stub.MockInvoke("6", [][]byte{[]byte("SignPlatformContract"), []byte("12345"), []byte("terms"), []byte("9"), []byte(signature)})
+
+		assert.Equal(t, StatusNotFound, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not found")
+	})
+
+	// Test Case 7: A new template version in effect requires accepting it again before trading
+	t.Run("Superseded Template Blocks Trading", func(t *testing.T) {
+		publishTemplate(t, stub, "terms", PlatformContractType, 2, "NEWTERMSHASH", 0)
+		stub.Creator = newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+
+		response := stub.MockInvoke("7", [][]byte{
+			[]byte("RegisterOrder"), []byte("1"), []byte("BidCreated"), []byte("4"), []byte("0"), []byte("200"), []byte("payment5"),
+			[]byte("slot1234"), []byte("300"), []byte("3.5"), []byte("12345"), []byte("50"), []byte("Buy"),
+		})
+		assert.Equal(t, StatusConflict, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "accepted again")
+
+		response = stub.MockInvoke("8", [][]byte{[]byte("SignPlatformContract"), []byte("12345"), []byte("terms"), []byte("2"), []byte(signDocumentHash(t, signingKey, ContractSigningMessage("12345", "terms", 2, "NEWTERMSHASH")))})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	})
+
+	// Test Case 8: A signature can't be replayed for another user holding the same key
+	t.Run("Replayed Signature Of Another User", func(t *testing.T) {
+		other := User{ID: "67890", PublicKey: publicKeyPEM}
+		otherBytes, _ := json.Marshal(other)
+		stub.MockTransactionStart("9")
+		_ = stub.PutState(other.ID, otherBytes)
+		stub.MockTransactionEnd("9")
+
+		response := stub.MockInvoke("10", [][]byte{[]byte("SignPlatformContract"), []byte("67890"), []byte("terms"), []byte("1"), []byte(signature)})
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "invalid")
+	})
+}
+
+// TestReadPlatformContract tests the ReadPlatformContract function
+func TestReadPlatformContract(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+
+	key := "12345"
+	id := key
+
+	// Creating a dummy user and platform contract to be used in the tests.
+	user := User{ID: id}
+	userBytes, _ := json.Marshal(user)
+	contract := PlatformContract{
+		UserID:             id,
+		SignedContractHash: "A7324HAS7234SADF734JSDF",
+	}
+	contractBytes, _ := json.Marshal(contract)
+
+	// Start a transaction to put user and platform contract into the ledger
+	stub.MockTransactionStart("tx1")
+	err := stub.PutState(key, userBytes)
+	if err != nil {
+		t.Fatalf("Failed to put the user into the stub: %s", err.Error())
+	}
+	contractKey := "PlatformContract_" + id
+	err = stub.PutState(contractKey, contractBytes)
+	if err != nil {
+		t.Fatalf("Failed to put the platform contract into the stub: %s", err.Error())
+	}
+	stub.MockTransactionEnd("tx1")
+
+	// Test Case: Successfully Read Platform Contract
+	t.Run("Successfully Read Platform Contract", func(t *testing.T) {
+		response := stub.MockInvoke("tx2", [][]byte{[]byte("ReadPlatformContract"), []byte(id)})
+
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), "Unexpected error during contract read")
+
+		var readContract PlatformContract
+		err := json.Unmarshal(response.GetPayload(), &readContract)
+		assert.NoError(t, err, "Error unmarshalling read contract")
+		assert.Equal(t, id, readContract.UserID, "UserID does not match")
+		assert.Equal(t, "A7324HAS7234SADF734JSDF", readContract.SignedContractHash, "Contract hash does not match")
+	})
+}
+
+func TestTradingContract(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+
+	userID := "12345"
+	contractHash := "HASH1234"
+
+	signingKey, publicKeyPEM := newSigningKey(t)
+	publishTemplate(t, stub, "trading-terms", TradingContractType, 1, contractHash, 0)
+	expiry := time.Now().AddDate(1, 0, 0).Unix()
+	terms := TradingContractTerms([]string{BuyAction, SellAction}, expiry, 0)
+	signature := signDocumentHash(t, signingKey, ContractSigningMessage(userID, "trading-terms", 1, contractHash, terms...))
+
+	// Start a transaction to put user into state
+	stub.MockTransactionStart("txSetUp")
+	user := User{ID: userID, PublicKey: publicKeyPEM}
+	userBytes, _ := json.Marshal(user)
+	err := stub.PutState(userID, userBytes)
+	if err != nil {
+		t.Fatalf("Failed to put the user into the stub: %s", err.Error())
+	}
+	stub.MockTransactionEnd("txSetUp")
+
+	expiresOn := []byte(strconv.FormatInt(expiry, 10))
+
+	// Test Case: Attempt to sign a contract for an unknown action
+	t.Run("Attempt to sign contract for unknown action", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{
+			[]byte("SignTradingContract"),
+			[]byte(userID),
+			[]byte("trading-terms"),
+			[]byte("1"),
+			[]byte(signature),
+			[]byte(`["BidCreated"]`),
+			expiresOn,
+		})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "Invalid action")
+	})
+
+	// Test Case: The signature covers the actions and the expiry date
+	t.Run("Attempt to sign with other terms", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{
+			[]byte("SignTradingContract"),
+			[]byte(userID),
+			[]byte("trading-terms"),
+			[]byte("1"),
+			[]byte(signature),
+			[]byte(`["Sell"]`),
+			expiresOn,
+		})
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "invalid")
+	})
+
+	// Test Case: Sign and read the trading contract
+	t.Run("Sign and read trading contract", func(t *testing.T) {
+		signResp := stub.MockInvoke("2", [][]byte{
+			[]byte("SignTradingContract"),
+			[]byte(userID),
+			[]byte("trading-terms"),
+			[]byte("1"),
+			[]byte(signature),
+			[]byte(`["Buy","Sell"]`),
+			expiresOn,
+		})
+		assert.Equal(t, int32(shim.OK), signResp.GetStatus(), fmt.Sprintf("Signing contract failed: %s", signResp.GetMessage()))
+
+		readResp := stub.MockInvoke("2", [][]byte{
+			[]byte("ReadTradingContract"),
+			[]byte(userID),
+		})
+		assert.Equal(t, int32(shim.OK), readResp.GetStatus(), "Reading contract failed")
+
+		var readContract TradingContract
+		err := json.Unmarshal(readResp.GetPayload(), &readContract)
+		assert.NoError(t, err, "Failed to unmarshal contract")
+		assert.Equal(t, userID, readContract.UserID, "UserID mismatch")
+		assert.Equal(t, contractHash, readContract.SignedContractHash, "Contract hash mismatch")
+		assert.Equal(t, []string{BuyAction, SellAction}, readContract.Actions, "Contract actions mismatch")
+		assert.Equal(t, ContractActive, readContract.Status, "Contract status mismatch")
+	})
+
+	// Test Case: A user holds a single trading contract
+	t.Run("Attempt to sign a second contract", func(t *testing.T) {
+		response := stub.MockInvoke("3", [][]byte{
+			[]byte("SignTradingContract"),
+			[]byte(userID),
+			[]byte("trading-terms"),
+			[]byte("1"),
+			[]byte(signature),
+			[]byte(`["Buy"]`),
+			expiresOn,
+		})
+		assert.Equal(t, StatusConflict, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "RenewTradingContract")
+	})
+
+	// Test Case: Attempt to read non-existing contract
+	t.Run("Attempt to read non-existing contract", func(t *testing.T) {
+		response := stub.MockInvoke("4", [][]byte{
+			[]byte("ReadTradingContract"),
+			[]byte("54321"),
+		})
+		assert.NotEqual(t, shim.OK, response.GetStatus(), "Expected failure when reading non-existing contract")
+	})
+}
+
+func TestRecordPayment(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	stub.Creator = newCreator(t, "Org2MSP", nil)
+
+	// Register the paying user so the payment detail lands in the Org2MSP collection.
+	response := stub.MockInvoke("1", [][]byte{
+		[]byte("UpdateUserProfile"), []byte("6"), []byte("Prosumer"), []byte("MeterId 6"), []byte("Solar"), []byte("false"),
+	})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+	detail := PaymentDetail{DebitedFrom: "6", CreditedTo: "7", TotalUnitCost: 10, PlatformFee: 1.5, PenaltyFromSeller: 2}
+	detailAsBytes, _ := json.Marshal(detail)
+
+	putOrder(t, stub, Order{ID: "4", BidMatchID: "BidMatch1", BidStatus: "BidCreated", UserID: "6", UserAction: BuyAction})
+	putBidMatch(t, stub, BidMatch{ID: "BidMatch1", BuyerUserId: "6", SellerUserId: "7"})
+	putBidMatch(t, stub, BidMatch{ID: "BidMatch2", BuyerUserId: "8", SellerUserId: "7"})
+
+	// Test Case 1: Successfully record a payment with a private payment detail
+	t.Run("Successfully Record Payment", func(t *testing.T) {
+		stub.TransientMap = map[string][]byte{"paymentDetail": detailAsBytes, "salt": []byte("salt1")}
+		response := stub.MockInvoke("2", [][]byte{
+			[]byte("RecordPayment"),
+			[]byte("1"),         // paymentID
+			[]byte("Buy"),       // paymentType
+			[]byte("100"),       // totalAmount
+			[]byte("6"),         // userID
+			[]byte("2"),         // paymentDetailID
+			[]byte("4"),         // orderID
+			[]byte("BidMatch1"), // bidMatchID
+		})
+		stub.TransientMap = nil
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("3", [][]byte{[]byte("ReadPaymentDetail"), []byte("2")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var readDetail PrivatePaymentDetail
+		err := json.Unmarshal(response.GetPayload(), &readDetail)
+		assert.NoError(t, err, "Error unmarshalling payment detail")
+		assert.Equal(t, "6", readDetail.DebitedFrom, "DebitedFrom mismatch")
+		assert.Equal(t, 0.0, readDetail.TokenAmountRefund, "TokenAmountRefund mismatch")
+
+		// Counterparties can verify the detail against the public hash.
+		response = stub.MockInvoke("4", [][]byte{[]byte("ReadPaymentDetailHash"), []byte("2")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var pdHash PrivateDataHash
+		err = json.Unmarshal(response.GetPayload(), &pdHash)
+		assert.NoError(t, err, "Error unmarshalling payment detail hash")
+		valueAsBytes, _ := json.Marshal(readDetail.PaymentDetail)
+		assert.Equal(t, saltedHash(readDetail.Salt, valueAsBytes), pdHash.Hash, "Payment detail hash mismatch")
+		assert.Equal(t, "Org2MSPPrivateCollection", pdHash.Collection, "Collection mismatch")
+	})
+
+	// Test Case 2: Other orgs cannot read the payment detail
+	t.Run("Unauthorized Payment Detail Read", func(t *testing.T) {
+		stub.Creator = newCreator(t, "Org3MSP", nil)
+		response := stub.MockInvoke("5", [][]byte{[]byte("ReadPaymentDetail"), []byte("2")})
+
+		assert.Equal(t, StatusUnauthorized, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not authorized")
+	})
+
+	// Test Case 3: Payment detail missing from the transient map
+	t.Run("Missing Transient Payment Detail", func(t *testing.T) {
+		stub.Creator = newCreator(t, "Org2MSP", nil)
+		response := stub.MockInvoke("6", [][]byte{
+			[]byte("RecordPayment"), []byte("3"), []byte("Buy"), []byte("100"), []byte("6"), []byte("4"), []byte("4"), []byte(""),
+		})
+
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "paymentDetail")
+	})
+
+	// Test Case 4: Payments for unknown orders or unrelated bid matches are rejected
+	t.Run("Invalid Payment References", func(t *testing.T) {
+		stub.TransientMap = map[string][]byte{"paymentDetail": detailAsBytes, "salt": []byte("salt3")}
+		defer func() { stub.TransientMap = nil }()
+
+		response := stub.MockInvoke("7", [][]byte{
+			[]byte("RecordPayment"), []byte("3"), []byte("Buy"), []byte("100"), []byte("6"), []byte("4"), []byte("99"), []byte(""),
+		})
+		assert.Equal(t, StatusNotFound, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "Order with ID 99 not found")
+
+		response = stub.MockInvoke("8", [][]byte{
+			[]byte("RecordPayment"), []byte("3"), []byte("Buy"), []byte("100"), []byte("6"), []byte("4"), []byte("4"), []byte("BidMatch2"),
+		})
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "does not involve")
+
+		response = stub.MockInvoke("8_1", [][]byte{
+			[]byte("RecordPayment"), []byte("3"), []byte("Buy"), []byte("100"), []byte("7"), []byte("4"), []byte("4"), []byte(""),
+		})
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "is not the user of Order 4")
+
+		response = stub.MockInvoke("9", [][]byte{
+			[]byte("RecordPayment"), []byte("1"), []byte("Buy"), []byte("100"), []byte("6"), []byte("4"), []byte("4"), []byte(""),
+		})
+		assert.Equal(t, StatusConflict, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "already exists")
+	})
+
+	// Test Case 5: Payments can be found through their order, bid match and user
+	t.Run("Read Payments For Order, BidMatch and User", func(t *testing.T) {
+		stub.TransientMap = map[string][]byte{"paymentDetail": detailAsBytes, "salt": []byte("salt5")}
+		response := stub.MockInvoke("10", [][]byte{
+			[]byte("RecordPayment"), []byte("5"), []byte("Fee"), []byte("2"), []byte("6"), []byte("6"), []byte("4"), []byte(""),
+		})
+		stub.TransientMap = nil
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var payments []Payment
+		response = stub.MockInvoke("11", [][]byte{[]byte("ReadPaymentsForOrder"), []byte("4")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		err := json.Unmarshal(response.GetPayload(), &payments)
+		assert.NoError(t, err, "Error unmarshalling payments")
+		assert.Len(t, payments, 2, "Payments for order mismatch")
+
+		response = stub.MockInvoke("12", [][]byte{[]byte("ReadPaymentsForBidMatch"), []byte("BidMatch1")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		err = json.Unmarshal(response.GetPayload(), &payments)
+		assert.NoError(t, err, "Error unmarshalling payments")
+		assert.Len(t, payments, 1, "Payments for bid match mismatch")
+		assert.Equal(t, "1", payments[0].ID, "Payment ID mismatch")
+		assert.Equal(t, "4", payments[0].OrderID, "OrderID mismatch")
+
+		response = stub.MockInvoke("13", [][]byte{[]byte("ReadPaymentsForUser"), []byte("6")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		err = json.Unmarshal(response.GetPayload(), &payments)
+		assert.NoError(t, err, "Error unmarshalling payments")
+		assert.Len(t, payments, 2, "Payments for user mismatch")
+
+		// Only the user's org and admins list the payments of a user.
+		stub.Creator = newCreator(t, "Org3MSP", nil)
+		response = stub.MockInvoke("14", [][]byte{[]byte("ReadPaymentsForUser"), []byte("6")})
+		assert.Equal(t, StatusUnauthorized, response.GetStatus(), "Payments listed for another org")
+		stub.Creator = newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+		response = stub.MockInvoke("14", [][]byte{[]byte("ReadPaymentsForUser"), []byte("6")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		stub.Creator = newCreator(t, "Org2MSP", nil)
+	})
+
+	// Test Case 5.1: Reindexing lists payments recorded before the user index
+	t.Run("Reindex Payments", func(t *testing.T) {
+		indexKey, _ := stub.CreateCompositeKey(PaymentsByUserIndex, []string{"6", "1"})
+		stub.MockTransactionStart("drop")
+		stub.DelState(indexKey)
+		stub.MockTransactionEnd("drop")
+		payments, _ := getIndexedPayments(stub, PaymentsByUserIndex, "6")
+		assert.Len(t, payments, 1, "Index entry not dropped")
+
+		response := stub.MockInvoke("15", [][]byte{[]byte("ReindexPayments")})
+		assert.Equal(t, StatusUnauthorized, response.GetStatus(), "Non-admin reindexed payments")
+
+		stub.Creator = newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+		response = stub.MockInvoke("15", [][]byte{[]byte("ReindexPayments")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		stub.Creator = newCreator(t, "Org2MSP", nil)
+
+		payments, _ = getIndexedPayments(stub, PaymentsByUserIndex, "6")
+		assert.Len(t, payments, 2, "Payments for user mismatch after reindexing")
+	})
+
+	// Test Case 6: Reserved payment types and negative amounts are rejected
+	t.Run("Reserved Payment Types and Negative Amounts", func(t *testing.T) {
+		stub.TransientMap = map[string][]byte{"paymentDetail": detailAsBytes, "salt": []byte("salt6")}
+		defer func() { stub.TransientMap = nil }()
+
+		for i, paymentType := range []string{RefundPaymentType, AdjustmentPaymentType} {
+			response := stub.MockInvoke(fmt.Sprintf("15-%d", i), [][]byte{
+				[]byte("RecordPayment"), []byte("6"), []byte(paymentType), []byte("100"), []byte("6"), []byte("7"), []byte("4"), []byte(""),
+			})
+			assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+			assert.Contains(t, response.GetMessage(), "is reserved")
+		}
+
+		response := stub.MockInvoke("16", [][]byte{
+			[]byte("RecordPayment"), []byte("6"), []byte("Buy"), []byte("-100"), []byte("6"), []byte("7"), []byte("4"), []byte(""),
+		})
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "can not be negative")
+	})
+
+	// Test Case 7: Refund totals supplied by the caller are ignored
+	t.Run("Refund Totals Start At Zero", func(t *testing.T) {
+		refunded := detail
+		refunded.BidRefundAmount = 10
+		refunded.PlatformFeeRefundAmount = 1
+		refunded.TokenAmountRefund = 3
+		refundedAsBytes, _ := json.Marshal(refunded)
+		stub.TransientMap = map[string][]byte{"paymentDetail": refundedAsBytes, "salt": []byte("salt7")}
+		response := stub.MockInvoke("17", [][]byte{
+			[]byte("RecordPayment"), []byte("7"), []byte("Buy"), []byte("100"), []byte("6"), []byte("8"), []byte("4"), []byte(""),
+		})
+		stub.TransientMap = nil
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("18", [][]byte{[]byte("ReadPaymentDetail"), []byte("8")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var readDetail PrivatePaymentDetail
+		err := json.Unmarshal(response.GetPayload(), &readDetail)
+		assert.NoError(t, err, "Error unmarshalling payment detail")
+		assert.Equal(t, 0.0, readDetail.BidRefundAmount, "BidRefundAmount mismatch")
+		assert.Equal(t, 0.0, readDetail.PlatformFeeRefundAmount, "PlatformFeeRefundAmount mismatch")
+		assert.Equal(t, 0.0, readDetail.TokenAmountRefund, "TokenAmountRefund mismatch")
+	})
+}
+
+func TestRefundPayment(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	stub.Creator = newCreator(t, "Org2MSP", nil)
+
+	response := stub.MockInvoke("1", [][]byte{
+		[]byte("UpdateUserProfile"), []byte("6"), []byte("Prosumer"), []byte("MeterId 6"), []byte("Solar"), []byte("false"),
+	})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+	// A flat platform fee of 1 is charged on the payment.
+	stub.Creator = newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+	response = stub.MockInvoke("1", [][]byte{[]byte("PublishFeeSchedule"), []byte(`{"version": 1, "flatFee": 1}`)})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	stub.Creator = newCreator(t, "Org2MSP", nil)
+
+	putOrder(t, stub, Order{ID: "4", BidStatus: "BidCreated", UserID: "6", UserAction: BuyAction})
+	detailAsBytes, _ := json.Marshal(PaymentDetail{DebitedFrom: "6", CreditedTo: "7", TotalUnitCost: 10, TokenAmount: 4})
+	stub.TransientMap = map[string][]byte{"paymentDetail": detailAsBytes, "salt": []byte("salt1")}
+	response = stub.MockInvoke("2", [][]byte{
+		[]byte("RecordPayment"), []byte("1"), []byte("Buy"), []byte("15"), []byte("6"), []byte("2"), []byte("4"), []byte(""),
+	})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+	refund := func(txID string, refundPaymentID string, paymentDetailID string, amounts PaymentDetail) pb.Response {
+		amountsAsBytes, _ := json.Marshal(amounts)
+		stub.TransientMap = map[string][]byte{"refund": amountsAsBytes, "salt": []byte("salt" + txID)}
+		defer func() { stub.TransientMap = nil }()
+		return stub.MockInvoke(txID, [][]byte{[]byte("RefundPayment"), []byte(refundPaymentID), []byte("1"), []byte(paymentDetailID)})
+	}
+
+	// Test Case 1: Only admins can refund
+	t.Run("Non-admin Caller", func(t *testing.T) {
+		response := refund("3", "R1", "R1", PaymentDetail{BidRefundAmount: 5})
+
+		assert.Equal(t, StatusUnauthorized, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not authorized")
+	})
+
+	// Test Case 2: A partial refund creates a reversal linked both ways
+	t.Run("Successfully Refund Payment", func(t *testing.T) {
+		stub.Creator = newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+		response := refund("4", "R1", "R1", PaymentDetail{BidRefundAmount: 5, PlatformFeeRefundAmount: 1})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		original, err := getPayment(stub, "1")
+		assert.NoError(t, err, "Error reading original payment")
+		assert.Equal(t, []string{"R1"}, original.RefundPaymentIDs, "Refund link mismatch")
+		assert.Equal(t, 6.0, original.RefundedAmount, "Refunded amount mismatch")
+
+		reversal, err := getPayment(stub, "R1")
+		assert.NoError(t, err, "Error reading reversal payment")
+		assert.Equal(t, "1", reversal.OriginalPaymentID, "Original payment link mismatch")
+		assert.Equal(t, RefundPaymentType, reversal.PaymentType, "Payment type mismatch")
+		assert.Equal(t, 6.0, reversal.TotalAmount, "Reversal amount mismatch")
+
+		response = stub.MockInvoke("5", [][]byte{[]byte("ReadPaymentDetail"), []byte("2")})
+		var originalDetail PrivatePaymentDetail
+		_ = json.Unmarshal(response.GetPayload(), &originalDetail)
+		assert.Equal(t, 5.0, originalDetail.BidRefundAmount, "Refunded unit cost mismatch")
+		assert.Equal(t, 1.0, originalDetail.PlatformFeeRefundAmount, "Refunded platform fee mismatch")
+	})
+
+	// Test Case 3: Cumulative refunds are capped by the original amounts
+	t.Run("Refund Exceeds Original", func(t *testing.T) {
+		response := refund("6", "R2", "R2", PaymentDetail{BidRefundAmount: 6})
+
+		assert.Equal(t, StatusConflict, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "exceeds")
+
+		response = refund("7", "R2", "R2", PaymentDetail{BidRefundAmount: 5, TokenAmountRefund: 4})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	})
+
+	// Test Case 4: Refunds can not be refunded
+	t.Run("Refund of a Refund", func(t *testing.T) {
+		stub.TransientMap = map[string][]byte{"refund": []byte(`{"bidRefundAmount": 1}`), "salt": []byte("salt8")}
+		response := stub.MockInvoke("8", [][]byte{[]byte("RefundPayment"), []byte("R3"), []byte("R1"), []byte("R3")})
+		stub.TransientMap = nil
+
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "is a refund")
+	})
+}
+
+func TestRegisterOrder(t *testing.T) {
+	// Mock stub creation
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	enableTrading(t, stub, "6", BuyAction)
+	stub.Creator = newCreator(t, "Org1MSP", nil)
+
+	// Test Case 1: Successfully register a new order
+	t.Run("Successfully Register a New Order", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{
+			[]byte("RegisterOrder"),
+			[]byte("1"),          // bidMatchID
+			[]byte("BidCreated"), // bidStatus
+			[]byte("4"),          // orderID
+			[]byte("0"),          // onMarketPrice
+			[]byte("200"),        // orderCost
+			[]byte("payment5"),   // paymentID
+			[]byte("slot1234"),   // slotID
+			[]byte("300"),        // totalQuantity
+			[]byte("3.5"),        // unitCost
+			[]byte("6"),          // userID
+			[]byte("50"),         // slotExecDate
+			[]byte("Buy"),        // action
+		})
+
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		orderAsBytes, err := stub.GetState("Order_4")
+		assert.NoError(t, err, "Error getting order from ledger")
+
+		var order Order
+		err = json.Unmarshal(orderAsBytes, &order)
+		assert.NoError(t, err, "Error unmarshalling order")
+
+		assert.Equal(t, string("4"), order.ID, "Order ID mismatch")
+		assert.Equal(t, string("1"), order.BidMatchID, "BidMatchID mismatch")
+	})
+
+	// Test Case 2: Provide incorrect number of arguments
+	t.Run("Incorrect Number of Arguments", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{
+			[]byte("RegisterOrder"),
+			[]byte("1"),
+		})
+
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "Incorrect number of arguments")
+	})
+
+	// Test Case 3: An existing order can not be registered again
+	t.Run("Existing Order Is Not Overwritten", func(t *testing.T) {
+		response := stub.MockInvoke("3", [][]byte{
+			[]byte("RegisterOrder"),
+			[]byte("1"),          // bidMatchID
+			[]byte("BidCreated"), // bidStatus
+			[]byte("4"),          // orderID
+			[]byte("0"),          // onMarketPrice
+			[]byte("200"),        // orderCost
+			[]byte("payment5"),   // paymentID
+			[]byte("slot1235"),   // slotID
+			[]byte("300"),        // totalQuantity
+			[]byte("3.5"),        // unitCost
+			[]byte("6"),          // userID
+			[]byte("50"),         // slotExecDate
+			[]byte("Buy"),        // action
+		})
+
+		assert.Equal(t, StatusConflict, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "already exists")
+
+		order, err := getOrder(stub, "4")
+		assert.NoError(t, err, "Error getting order from ledger")
+		assert.Equal(t, "slot1234", order.SlotID, "Existing order was overwritten")
+	})
+
+	// Test Case 4: Identities of other orgs can not register orders for the user
+	t.Run("Caller of Another Org", func(t *testing.T) {
+		stub.Creator = newCreator(t, "Org2MSP", nil)
+		defer func() { stub.Creator = newCreator(t, "Org1MSP", nil) }()
+
+		response := stub.MockInvoke("4", [][]byte{
+			[]byte("RegisterOrder"), []byte("1"), []byte("BidCreated"), []byte("5"), []byte("0"), []byte("200"), []byte("payment5"),
+			[]byte("slot1234"), []byte("300"), []byte("3.5"), []byte("6"), []byte("50"), []byte("Buy"),
+		})
+		assert.Equal(t, StatusUnauthorized, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not authorized")
+
+		orderAsBytes, _ := stub.GetState("Order_5")
+		assert.Nil(t, orderAsBytes, "Order of an unauthorized caller was written")
+	})
+}
+
+func TestRegisterOrders(t *testing.T) {
+	// Mock stub creation
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	enableTrading(t, stub, "6", BuyAction)
+	enableTrading(t, stub, "7", SellAction)
+	stub.Creator = newCreator(t, "Org1MSP", nil)
+
+	orders := []Order{
+		{ID: "10", BidStatus: "BidCreated", SlotID: "slot1", TotalQuantity: 100, UnitCost: 3.5, UserID: "6", UserAction: "Buy"},
+		{ID: "11", BidStatus: "BidCreated", SlotID: "slot1", TotalQuantity: 50, UnitCost: 3.2, UserID: "7", UserAction: "Sell"},
+	}
+
+	// Test Case 1: Successfully register a batch of orders
+	t.Run("Successfully Register a Batch of Orders", func(t *testing.T) {
+		ordersAsBytes, _ := json.Marshal(orders)
+		response := stub.MockInvoke("1", [][]byte{[]byte("RegisterOrders"), ordersAsBytes})
+
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var result OrderBatchResult
+		err := json.Unmarshal(response.GetPayload(), &result)
+		assert.NoError(t, err, "Error unmarshalling batch result")
+		assert.Equal(t, []string{"10", "11"}, result.OrderIDs, "Order IDs mismatch")
+
+		event := <-stub.ChaincodeEventsChannel
+		assert.Equal(t, "OrdersRegistered", event.GetEventName(), "Event name mismatch")
+
+		orderAsBytes, err := stub.GetState("Order_11")
+		assert.NoError(t, err, "Error getting order from ledger")
+		var order Order
+		err = json.Unmarshal(orderAsBytes, &order)
+		assert.NoError(t, err, "Error unmarshalling order")
+		assert.Equal(t, "7", order.UserID, "UserID mismatch")
+	})
+
+	// Test Case 2: One invalid order rejects the whole batch
+	t.Run("Invalid Order Rejects the Batch", func(t *testing.T) {
+		invalid := []Order{
+			{ID: "12", BidStatus: "BidCreated", SlotID: "slot1", TotalQuantity: 10, UserID: "6", UserAction: "Buy"},
+			{ID: "13", BidStatus: "BidExecuted", SlotID: "slot1", TotalQuantity: 10, UserID: "6", UserAction: "Buy"},
+		}
+		ordersAsBytes, _ := json.Marshal(invalid)
+		response := stub.MockInvoke("2", [][]byte{[]byte("RegisterOrders"), ordersAsBytes})
+
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "index 1")
+
+		orderAsBytes, _ := stub.GetState("Order_12")
+		assert.Nil(t, orderAsBytes, "Order from a rejected batch was written")
+	})
+
+	// Test Case 3: Duplicate IDs within a batch
+	t.Run("Duplicate Order IDs", func(t *testing.T) {
+		order := orders[0]
+		order.ID = "14"
+		duplicate := []Order{order, order}
+		ordersAsBytes, _ := json.Marshal(duplicate)
+		response := stub.MockInvoke("3", [][]byte{[]byte("RegisterOrders"), ordersAsBytes})
+
+		assert.Equal(t, StatusConflict, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "duplicates")
+	})
+
+	// Test Case 3.1: Existing orders can not be registered again
+	t.Run("Existing Order IDs", func(t *testing.T) {
+		existing := orders[1]
+		existing.UnitCost = 1
+		ordersAsBytes, _ := json.Marshal([]Order{existing})
+		response := stub.MockInvoke("3a", [][]byte{[]byte("RegisterOrders"), ordersAsBytes})
+
+		assert.Equal(t, StatusConflict, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "(11) already exists")
+
+		order, _ := getOrder(stub, "11")
+		assert.Equal(t, 3.2, order.UnitCost, "Existing order was overwritten")
+	})
+
+	// Test Case 3.2: Identities of other orgs can not register orders for the users
+	t.Run("Caller of Another Org", func(t *testing.T) {
+		stub.Creator = newCreator(t, "Org2MSP", nil)
+		order := orders[0]
+		order.ID = "15"
+		ordersAsBytes, _ := json.Marshal([]Order{order})
+		response := stub.MockInvoke("3b", [][]byte{[]byte("RegisterOrders"), ordersAsBytes})
+
+		assert.Equal(t, StatusUnauthorized, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "index 0 (15): Caller is not authorized")
+	})
+
+	// Test Case 4: Batch larger than the configured limit
+	t.Run("Batch Exceeds Configured Size", func(t *testing.T) {
+		stub.Creator = newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+		response := stub.MockInvoke("4", [][]byte{[]byte("UpdateMarketConfig"), []byte(`{"maxOrderBatchSize": 1}`)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		ordersAsBytes, _ := json.Marshal(orders)
+		response = stub.MockInvoke("5", [][]byte{[]byte("RegisterOrders"), ordersAsBytes})
+
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "maximum batch size of 1")
+	})
+}
+
+func TestUpdateMarketConfig(t *testing.T) {
+	// Mock stub creation
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+
+	// Test Case 1: Defaults are returned before any update
+	t.Run("Read Default Market Config", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{[]byte("ReadMarketConfig")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var config MarketConfig
+		err := json.Unmarshal(response.GetPayload(), &config)
+		assert.NoError(t, err, "Error unmarshalling market config")
+		assert.Equal(t, DefaultMaxOrderBatchSize, config.MaxOrderBatchSize, "Default batch size mismatch")
+	})
+
+	// Test Case 2: Callers without the admin role are rejected
+	t.Run("Non-admin Caller", func(t *testing.T) {
+		stub.Creator = newCreator(t, "Org1MSP", nil)
+		response := stub.MockInvoke("2", [][]byte{[]byte("UpdateMarketConfig"), []byte(`{"maxOrderBatchSize": 10}`)})
+
+		assert.Equal(t, StatusUnauthorized, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not authorized")
+	})
+
+	// Test Case 3: Limit above the ceiling
+	t.Run("Batch Size Above Ceiling", func(t *testing.T) {
+		stub.Creator = newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+		response := stub.MockInvoke("3", [][]byte{[]byte("UpdateMarketConfig"), []byte(`{"maxOrderBatchSize": 100000}`)})
+
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+	})
+}
+
+func TestProcessBidMatch(t *testing.T) {
+	// Mock stub creation
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	enableTrading(t, stub, "Buyer4", BuyAction)
+	enableTrading(t, stub, "Seller5", SellAction)
+	putOrder(t, stub, Order{ID: "Buy6", BidStatus: "BidCreated", TotalQuantity: 10, RemainingQuantity: 10, UserID: "Buyer4", UserAction: BuyAction})
+	putOrder(t, stub, Order{ID: "Sell7", BidStatus: "BidCreated", TotalQuantity: 10, RemainingQuantity: 10, UserID: "Seller5", UserAction: SellAction})
+
+	// Test Case 1: Successfully process a new BidMatch
+	t.Run("Successfully Process a New BidMatch", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{
+			[]byte("ProcessBidMatch"),
+			[]byte("120"),        // bidMatchTms
+			[]byte("Slot1"),      // slotID
+			[]byte("BidCreated"), // bidStatus
+			[]byte("100"),        // bidUnitPrice
+			[]byte("Buyer4"),     // buyerUserID
+			[]byte("2.5"),        // deliveredBidUnits
+			[]byte("BidMatch1"),  // bidMatchID
+			[]byte("3.5"),        // originalBidUnits
+			[]byte("Seller5"),    // sellerUserID
+			[]byte("Buy6"),       // transactionBuyID
+			[]byte("Sell7"),      // transactionSellID
+		})
+
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), "Unexpected error: "+response.GetMessage())
+
+		bidMatchAsBytes, err := stub.GetState("BidMatch_BidMatch1")
+		assert.NoError(t, err, "Error getting BidMatch from ledger")
+
+		var bidMatch BidMatch
+		err = json.Unmarshal(bidMatchAsBytes, &bidMatch)
+		assert.NoError(t, err, "Error unmarshalling BidMatch")
+
+		assert.Equal(t, string("BidMatch1"), bidMatch.ID, "BidMatch ID mismatch")
+		assert.Equal(t, string("Buyer4"), bidMatch.BuyerUserId, "BuyerUserId mismatch")
+	})
+
+	// Test Case 2: Provide incorrect number of arguments
+	t.Run("Incorrect Number of Arguments", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{
+			[]byte("ProcessBidMatch"),
+			[]byte("1"),
+		})
+
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "Incorrect number of arguments")
+	})
+}
+
+func TestProcessEnergyBid(t *testing.T) {
+	// Mock stub creation
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+
+	// Settlement needs a BidMatch between two parties allowed to trade.
+	enableTrading(t, stub, "Buyer4", BuyAction)
+	enableTrading(t, stub, "Seller5", SellAction)
+	putOrder(t, stub, Order{ID: "Buy6", BidStatus: "BidCreated", TotalQuantity: 10, RemainingQuantity: 10, UserID: "Buyer4", UserAction: BuyAction})
+	putOrder(t, stub, Order{ID: "Sell7", BidStatus: "BidCreated", TotalQuantity: 10, RemainingQuantity: 10, UserID: "Seller5", UserAction: SellAction})
+	response := stub.MockInvoke("0", [][]byte{
+		[]byte("ProcessBidMatch"), []byte("120"), []byte("Slot1"), []byte("BidCreated"), []byte("100"), []byte("Buyer4"),
+		[]byte("2.5"), []byte("BidMatch1"), []byte("3.5"), []byte("Seller5"), []byte("Buy6"), []byte("Sell7"),
+	})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+	// Test Case 1: Successfully process an EnergyBid
+	t.Run("Successfully Process EnergyBid", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{
+			[]byte("ProcessEnergyBid"),
+			[]byte("EnergyBid1"), // energyBidID
+			[]byte("BidMatch1"),  // bidMatchID
+			[]byte("10.5"),       // initialBidUnits
+			[]byte("8.7"),        // acceptedBidUnits
+			[]byte("5.0"),        // buyerMeterUnit
+			[]byte("7.2"),        // sellerMeterUnit
+			[]byte("3.0"),        // buyerBroughtUnitFromSeller
+			[]byte("6.5"),        // sellerSoldUnitToBuyer
+			[]byte("4.8"),        // sellerSoldUnitToGrid
+			[]byte("9.2"),        // buyerSoldUnitToGrid
+			[]byte("2.1"),        // buyerBroughtUnitFromGrid
+			[]byte("Reason1"),    // reason
+		})
+
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		energyBidAsBytes, err := stub.GetState("EnergyBid_EnergyBid1")
+		assert.NoError(t, err, "Error getting EnergyBid from ledger")
+
+		var energyBid EnergyBid
+		err = json.Unmarshal(energyBidAsBytes, &energyBid)
+		assert.NoError(t, err, "Error unmarshalling EnergyBid")
+
+		assert.Equal(t, "EnergyBid1", energyBid.ID, "EnergyBid ID mismatch")
+		assert.Equal(t, 10.5, energyBid.InitialBidUnits, "InitialBidUnits mismatch")
+		assert.NotZero(t, energyBid.SettledOn, "SettledOn not set")
+		// Add assertions for other fields
+	})
+
+	// Test Case 1.1: Reprocessing an EnergyBid keeps its first settlement time
+	t.Run("Reprocess EnergyBid", func(t *testing.T) {
+		energyBid := EnergyBid{ID: "EnergyBid2", BidMatchID: "BidMatch1", SettledOn: 1000}
+		stub.MockTransactionStart("setup")
+		energyBidAsBytes, _ := json.Marshal(energyBid)
+		_ = stub.PutState("EnergyBid_EnergyBid2", energyBidAsBytes)
+		stub.MockTransactionEnd("setup")
+
+		response := stub.MockInvoke("1a", [][]byte{
+			[]byte("ProcessEnergyBid"), []byte("EnergyBid2"), []byte("BidMatch1"), []byte("10.5"), []byte("8.7"), []byte("5.0"),
+			[]byte("7.2"), []byte("3.0"), []byte("6.5"), []byte("4.8"), []byte("9.2"), []byte("2.1"), []byte("Reason2"),
+		})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		energyBidAsBytes, _ = stub.GetState("EnergyBid_EnergyBid2")
+		_ = json.Unmarshal(energyBidAsBytes, &energyBid)
+		assert.Equal(t, "Reason2", energyBid.Reason, "Reason mismatch")
+		assert.Equal(t, int64(1000), energyBid.SettledOn, "SettledOn overwritten")
+	})
+
+	// Test Case 2: Provide incorrect number of arguments
+	t.Run("Incorrect Number of Arguments", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{
+			[]byte("ProcessEnergyBid"),
+			[]byte("1"),
+		})
+
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "Incorrect number of arguments")
+	})
+}
+
+func TestReadOrder(t *testing.T) {
+	// Mock stub creation
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	enableTrading(t, stub, "6", SellAction)
+	stub.Creator = newCreator(t, "Org1MSP", nil)
+
+	// Registering a new order
+	response := stub.MockInvoke("1", [][]byte{
+		[]byte("RegisterOrder"),
+		[]byte("1"),          // bidMatchID
+		[]byte("BidCreated"), // bidStatus
+		[]byte("4"),          // orderID
+		[]byte("0"),          // onMarketPrice
+		[]byte("200"),        // orderCost
+		[]byte("payment5"),   // paymentID
+		[]byte("slot1237"),   // slotID
+		[]byte("300"),        // totalQuantity
+		[]byte("3.5"),        // unitCost
+		[]byte("6"),          // userID
+		[]byte("50"),         // slotExecDate
+		[]byte("Sell"),       // action
+	})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+	// Test Case: Successfully read an order
+	t.Run("Successfully Read an Order", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{
+			[]byte("ReadOrder"),
+			[]byte("4"), // orderID
+		})
+
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var order Order
+		err := json.Unmarshal(response.GetPayload(), &order)
+		assert.NoError(t, err, "Error unmarshalling order")
+
+		assert.Equal(t, string("4"), order.ID, "Order ID mismatch")
+		assert.Equal(t, string("1"), order.BidMatchID, "BidMatchID mismatch")
+	})
+
+	// Test Case: Try to read an order that doesn't exist
+	t.Run("Try to Read Nonexistent Order", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{
+			[]byte("ReadOrder"),
+			[]byte("99"), // orderID
+		})
+
+		assert.Equal(t, StatusNotFound, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not found")
+	})
+}
+
+func TestReadBidMatch(t *testing.T) {
+	// Mock stub creation
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	enableTrading(t, stub, "Buyer4", BuyAction)
+	enableTrading(t, stub, "Seller5", SellAction)
+	putOrder(t, stub, Order{ID: "Buy6", BidStatus: "BidCreated", TotalQuantity: 10, RemainingQuantity: 10, UserID: "Buyer4", UserAction: BuyAction})
+	putOrder(t, stub, Order{ID: "Sell7", BidStatus: "BidCreated", TotalQuantity: 10, RemainingQuantity: 10, UserID: "Seller5", UserAction: SellAction})
+
+	// Registering a new BidMatch
+	response := stub.MockInvoke("1", [][]byte{
+		[]byte("ProcessBidMatch"),
+		[]byte("120"),        // bidMatchTms
+		[]byte("Slot2"),      // slotID
+		[]byte("BidCreated"), // bidStatus
+		[]byte("100"),        // bidUnitPrice
+		[]byte("Buyer4"),     // buyerUserID
+		[]byte("2.5"),        // deliveredBidUnits
+		[]byte("BidMatch1"),  // bidMatchID
+		[]byte("3.5"),        // originalBidUnits
+		[]byte("Seller5"),    // sellerUserID
+		[]byte("Buy6"),       // transactionBuyID
+		[]byte("Sell7"),      // transactionSellID
+	})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+	// Test Case 1: Successfully read a BidMatch
+	t.Run("Successfully Read a BidMatch", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{
+			[]byte("ReadBidMatch"),
+			[]byte("BidMatch1"), // bidMatchID
+		})
+
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var bidMatch BidMatch
+		err := json.Unmarshal(response.GetPayload(), &bidMatch)
+		assert.NoError(t, err, "Error unmarshalling BidMatch")
+
+		assert.Equal(t, string("BidMatch1"), bidMatch.ID, "BidMatch ID mismatch")
+		assert.Equal(t, string("Buyer4"), bidMatch.BuyerUserId, "BuyerUserId mismatch")
+	})
+
+	// Test Case 2: Try to read a BidMatch that doesn't exist
+	t.Run("Try to Read Nonexistent BidMatch", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{
+			[]byte("ReadBidMatch"),
+			[]byte("99"), // bidMatchID
+		})
+
+		assert.Equal(t, StatusNotFound, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not found")
+	})
+}
+
+func TestReadEnergyBid(t *testing.T) {
+	// Mock stub creation
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+
+	// Settlement needs a BidMatch between two parties allowed to trade.
+	enableTrading(t, stub, "Buyer4", BuyAction)
+	enableTrading(t, stub, "Seller5", SellAction)
+	putOrder(t, stub, Order{ID: "Buy6", BidStatus: "BidCreated", TotalQuantity: 10, RemainingQuantity: 10, UserID: "Buyer4", UserAction: BuyAction})
+	putOrder(t, stub, Order{ID: "Sell7", BidStatus: "BidCreated", TotalQuantity: 10, RemainingQuantity: 10, UserID: "Seller5", UserAction: SellAction})
+	response := stub.MockInvoke("0", [][]byte{
+		[]byte("ProcessBidMatch"), []byte("120"), []byte("Slot1"), []byte("BidCreated"), []byte("100"), []byte("Buyer4"),
+		[]byte("2.5"), []byte("BidMatch1"), []byte("3.5"), []byte("Seller5"), []byte("Buy6"), []byte("Sell7"),
+	})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+	// Registering a new BidMatch
+	response = stub.MockInvoke("1", [][]byte{
+		[]byte("ProcessEnergyBid"),
+		[]byte("EnergyBid1"), // energyBidID
+		[]byte("BidMatch1"),  // bidMatchID
+		[]byte("10.5"),       // initialBidUnits
+		[]byte("8.7"),        // acceptedBidUnits
+		[]byte("5.0"),        // buyerMeterUnit
+		[]byte("7.2"),        // sellerMeterUnit
+		[]byte("3.0"),        // buyerBroughtUnitFromSeller
+		[]byte("6.5"),        // sellerSoldUnitToBuyer
+		[]byte("4.8"),        // sellerSoldUnitToGrid
+		[]byte("9.2"),        // buyerSoldUnitToGrid
+		[]byte("2.1"),        // buyerBroughtUnitFromGrid
+		[]byte("Reason1"),    // reason
+	})
+
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+	// Test Case 1: Successfully read a BidMatch
+	t.Run("Successfully Read a BidMatch", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{
+			[]byte("ReadEnergyBid"),
+			[]byte("EnergyBid1"), // bidMatchID
+		})
+
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var energyBid EnergyBid
+		err := json.Unmarshal(response.GetPayload(), &energyBid)
+		assert.NoError(t, err, "Error unmarshalling EnergyBid")
+
+		assert.Equal(t, string("EnergyBid1"), energyBid.ID, "EnergyBid ID mismatch")
+		assert.Equal(t, string("BidMatch1"), energyBid.BidMatchID, "BuyerUserId mismatch")
+	})
+
+	// Test Case 2: Try to read a EnergyBid that doesn't exist
+	t.Run("Try to Read Nonexistent EnergyBid", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{
+			[]byte("ReadEnergyBid"),
+			[]byte("EnergyBid2"), // bidMatchID
+		})
+
+		assert.Equal(t, StatusNotFound, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not found")
+	})
+}
@@ -0,0 +1,569 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                              Erasure Methods                               */
+/* -------------------------------------------------------------------------- */
+
+// EraseParticipantData erases the personal data of a participant (GDPR right to erasure).
+// The private details are purged from the collection of the participant's org, together with
+// their history in the private data store, which requires Fabric v2.5 peers and the V2_5
+// application capability. The public
+// profile is stripped of personal fields and moved to a pseudonymous ID, and the financial
+// records are kept but rewritten to reference that pseudonymous ID only. An ErasureCertificate
+// is stored and emitted as the ParticipantDataErased event.
+func EraseParticipantData(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting EraseParticipantData")
+
+	// We expect 1 argument: the user ID.
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1 (UserID).")
+	}
+
+	err := requireRole(stub, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	userID := args[0]
+	userAsBytes, err := stub.GetState(userID)
+	if err != nil {
+		return shim.Error("Error accessing state: " + err.Error())
+	}
+	if userAsBytes == nil {
+		return statusResponse(StatusNotFound, "User with ID "+userID+" not found")
+	}
+
+	var user User
+	err = json.Unmarshal(userAsBytes, &user)
+	if err != nil {
+		return shim.Error("Failed to unmarshal user: " + err.Error())
+	}
+	if user.ErasedOn != 0 {
+		return statusResponse(StatusConflict, "User with ID "+userID+" has already been erased")
+	}
+
+	pseudonymID := getPseudonymID(userID, stub.GetTxID())
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// Purge the personal data and its history from the private data collection.
+	if user.MSPID != "" && (user.LocationHash != "" || user.ContactHash != "") {
+		err = stub.PurgePrivateData(getCollectionName(user.MSPID), "User_"+userID)
+		if err != nil {
+			return shim.Error("Could not purge user private details: " + err.Error())
+		}
+	}
+
+	// Strip the public profile and move it to the pseudonymous ID. The raw fields are used
+	// so that enterprise profiles are handled the same way.
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(userAsBytes, &fields)
+	if err != nil {
+		return shim.Error("Failed to unmarshal user: " + err.Error())
+	}
+	erasedFields := []string{"location", "contact"}
+	fields["id"], _ = json.Marshal(pseudonymID)
+	fields["location"], _ = json.Marshal("")
+	fields["locationHash"], _ = json.Marshal("")
+	fields["contact"], _ = json.Marshal("")
+	fields["contactHash"], _ = json.Marshal("")
+	if _, ok := fields["meterId"]; ok {
+		fields["meterId"], _ = json.Marshal("")
+		erasedFields = append(erasedFields, "meterId")
+	}
+	if _, ok := fields["meterIds"]; ok {
+		fields["meterIds"], _ = json.Marshal([]string{})
+		erasedFields = append(erasedFields, "meterIds")
+	}
+	fields["erasedOn"], _ = json.Marshal(now)
+	fields["updatedOn"], _ = json.Marshal(now)
+
+	err = stub.DelState(userID)
+	if err != nil {
+		return shim.Error("Could not delete user: " + err.Error())
+	}
+	profileAsBytes, _ := json.Marshal(fields)
+	err = stub.PutState(pseudonymID, profileAsBytes)
+	if err != nil {
+		return shim.Error("Could not store pseudonymized user: " + err.Error())
+	}
+	err = setKeyEndorsement(stub, pseudonymID, user.MSPID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	records, err := pseudonymizeRecords(stub, userID, pseudonymID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	certificate := ErasureCertificate{
+		ErasedFields:         erasedFields,
+		ErasedOn:             now,
+		ID:                   pseudonymID,
+		PseudonymizedRecords: records,
+		TxID:                 stub.GetTxID(),
+	}
+	certificateAsBytes, _ := json.Marshal(certificate)
+	err = stub.PutState("ErasureCertificate_"+pseudonymID, certificateAsBytes)
+	if err != nil {
+		return shim.Error("Could not store erasure certificate: " + err.Error())
+	}
+	err = stub.SetEvent("ParticipantDataErased", certificateAsBytes)
+	if err != nil {
+		return shim.Error("Could not emit ParticipantDataErased event: " + err.Error())
+	}
+
+	fmt.Println("- end EraseParticipantData")
+	return shim.Success(certificateAsBytes)
+}
+
+func ReadErasureCertificate(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadErasureCertificate")
+
+	// We expect 1 argument: the pseudonymous ID of the erased user.
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	certificateAsBytes, err := stub.GetState("ErasureCertificate_" + args[0])
+	if err != nil {
+		return shim.Error("Error accessing state: " + err.Error())
+	}
+	if certificateAsBytes == nil {
+		return statusResponse(StatusNotFound, "ErasureCertificate for ID "+args[0]+" not found.")
+	}
+
+	fmt.Println("- end ReadErasureCertificate")
+	return shim.Success(certificateAsBytes)
+}
+
+// getPseudonymID derives the pseudonymous ID of an erased user. The transaction ID is mixed
+// in so the pseudonym cannot be recomputed from the user ID alone.
+func getPseudonymID(userID string, txID string) string {
+	hash := sha256.Sum256([]byte(userID + "_" + txID))
+	return "Erased_" + hex.EncodeToString(hash[:8])
+}
+
+// pseudonymizeRecords rewrites the orders, payments, payment details, bid matches, contracts,
+// invoices, certificates, disputes and reliability score of a user to its pseudonymous ID and
+// deletes its meter zones. It returns the number of records changed.
+func pseudonymizeRecords(stub shim.ChaincodeStubInterface, userID string, pseudonymID string) (int, error) {
+	count := 0
+
+	orders, err := getStatesByPrefix(stub, "Order_")
+	if err != nil {
+		return count, fmt.Errorf("Failed to scan orders: %s", err.Error())
+	}
+	for _, kv := range orders {
+		var order Order
+		if json.Unmarshal(kv.Value, &order) != nil || order.UserID != userID {
+			continue
+		}
+		order.UserID = pseudonymID
+		orderAsBytes, _ := json.Marshal(order)
+		err = stub.PutState(kv.Key, orderAsBytes)
+		if err != nil {
+			return count, fmt.Errorf("Could not store order %s: %s", order.ID, err.Error())
+		}
+		count++
+	}
+
+	payments, err := getStatesByPrefix(stub, "Payment_")
+	if err != nil {
+		return count, fmt.Errorf("Failed to scan payments: %s", err.Error())
+	}
+	for _, kv := range payments {
+		var payment Payment
+		if json.Unmarshal(kv.Value, &payment) != nil {
+			continue
+		}
+		// Payments of other users can name the user as counterparty in their detail.
+		movedDetail, err := pseudonymizePaymentDetail(stub, &payment, userID, pseudonymID)
+		if err != nil {
+			return count, err
+		}
+		if payment.UserID != userID {
+			if movedDetail {
+				paymentAsBytes, _ := json.Marshal(payment)
+				err = stub.PutState(kv.Key, paymentAsBytes)
+				if err != nil {
+					return count, fmt.Errorf("Could not store payment %s: %s", payment.ID, err.Error())
+				}
+				count++
+			}
+			continue
+		}
+		payment.UserID = pseudonymID
+		paymentAsBytes, _ := json.Marshal(payment)
+		err = stub.PutState(kv.Key, paymentAsBytes)
+		if err != nil {
+			return count, fmt.Errorf("Could not store payment %s: %s", payment.ID, err.Error())
+		}
+		count++
+
+		// The user index entry is keyed by user ID, so it is moved to the pseudonymous ID.
+		indexKey, err := stub.CreateCompositeKey(PaymentsByUserIndex, []string{userID, payment.ID})
+		if err != nil {
+			return count, fmt.Errorf("Could not create %s index key: %s", PaymentsByUserIndex, err.Error())
+		}
+		err = stub.DelState(indexKey)
+		if err != nil {
+			return count, fmt.Errorf("Could not delete %s index: %s", PaymentsByUserIndex, err.Error())
+		}
+		indexKey, err = stub.CreateCompositeKey(PaymentsByUserIndex, []string{pseudonymID, payment.ID})
+		if err != nil {
+			return count, fmt.Errorf("Could not create %s index key: %s", PaymentsByUserIndex, err.Error())
+		}
+		err = stub.PutState(indexKey, []byte{0x00})
+		if err != nil {
+			return count, fmt.Errorf("Could not store %s index: %s", PaymentsByUserIndex, err.Error())
+		}
+	}
+
+	bidMatches, err := getStatesByPrefix(stub, "BidMatch_")
+	if err != nil {
+		return count, fmt.Errorf("Failed to scan bid matches: %s", err.Error())
+	}
+	for _, kv := range bidMatches {
+		var bidMatch BidMatch
+		if json.Unmarshal(kv.Value, &bidMatch) != nil || (bidMatch.BuyerUserId != userID && bidMatch.SellerUserId != userID) {
+			continue
+		}
+		if bidMatch.BuyerUserId == userID {
+			bidMatch.BuyerUserId = pseudonymID
+		}
+		if bidMatch.SellerUserId == userID {
+			bidMatch.SellerUserId = pseudonymID
+		}
+		bidMatchAsBytes, _ := json.Marshal(bidMatch)
+		err = stub.PutState(kv.Key, bidMatchAsBytes)
+		if err != nil {
+			return count, fmt.Errorf("Could not store BidMatch %s: %s", bidMatch.ID, err.Error())
+		}
+		count++
+	}
+
+	// Contracts are keyed by user ID, so they are moved to keys of the pseudonymous ID.
+	contracts, err := getStatesByPrefix(stub, "PlatformContract_")
+	if err != nil {
+		return count, fmt.Errorf("Failed to scan platform contracts: %s", err.Error())
+	}
+	tradingContracts, err := getStatesByPrefix(stub, "TradingContract_")
+	if err != nil {
+		return count, fmt.Errorf("Failed to scan trading contracts: %s", err.Error())
+	}
+	contracts = append(contracts, tradingContracts...)
+	for _, kv := range contracts {
+		var contract PlatformContract
+		if json.Unmarshal(kv.Value, &contract) != nil || contract.UserID != userID {
+			continue
+		}
+		var fields map[string]json.RawMessage
+		err = json.Unmarshal(kv.Value, &fields)
+		if err != nil {
+			return count, fmt.Errorf("Failed to unmarshal contract %s: %s", kv.Key, err.Error())
+		}
+		fields["userId"], _ = json.Marshal(pseudonymID)
+		contractAsBytes, _ := json.Marshal(fields)
+
+		err = stub.DelState(kv.Key)
+		if err != nil {
+			return count, fmt.Errorf("Could not delete contract %s: %s", kv.Key, err.Error())
+		}
+		err = stub.PutState(strings.TrimSuffix(kv.Key, userID)+pseudonymID, contractAsBytes)
+		if err != nil {
+			return count, fmt.Errorf("Could not store contract %s: %s", kv.Key, err.Error())
+		}
+		count++
+	}
+
+	invoices, err := pseudonymizeInvoices(stub, userID, pseudonymID)
+	count += invoices
+	if err != nil {
+		return count, err
+	}
+
+	certificates, err := pseudonymizeCertificates(stub, userID, pseudonymID)
+	count += certificates
+	if err != nil {
+		return count, err
+	}
+
+	disputes, err := pseudonymizeDisputes(stub, userID, pseudonymID)
+	count += disputes
+	if err != nil {
+		return count, err
+	}
+
+	reliability, err := pseudonymizeReliabilityScore(stub, userID, pseudonymID)
+	count += reliability
+	if err != nil {
+		return count, err
+	}
+
+	meterZones, err := deleteMeterZones(stub, userID)
+	count += meterZones
+	if err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
+
+// pseudonymizePaymentDetail moves the private detail of a payment that names the user as payer
+// or payee to a new ID with the pseudonymous ID, whichever org's collection it lives in. The
+// original detail and its history are purged. It reports whether the detail was moved.
+func pseudonymizePaymentDetail(stub shim.ChaincodeStubInterface, payment *Payment, userID string, pseudonymID string) (bool, error) {
+	if payment.PaymentDetailID == "" {
+		return false, nil
+	}
+	pdHash, err := getPaymentDetailHash(stub, payment.PaymentDetailID)
+	if err != nil {
+		if isStatus(err, StatusNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	pd, err := getPrivatePaymentDetail(stub, pdHash)
+	if err != nil {
+		if isStatus(err, StatusNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if pd.DebitedFrom != userID && pd.CreditedTo != userID {
+		return false, nil
+	}
+
+	if pd.DebitedFrom == userID {
+		pd.DebitedFrom = pseudonymID
+	}
+	if pd.CreditedTo == userID {
+		pd.CreditedTo = pseudonymID
+	}
+	pd.ID = getPseudonymID(pdHash.ID, stub.GetTxID())
+	err = putPrivatePaymentDetail(stub, pdHash.OwnerMSPID, *pd)
+	if err != nil {
+		return false, err
+	}
+	err = stub.PurgePrivateData(pdHash.Collection, "PaymentDetail_"+pdHash.ID)
+	if err != nil {
+		return false, fmt.Errorf("Could not purge payment detail %s: %s", pdHash.ID, err.Error())
+	}
+	err = stub.DelState("PaymentDetail_" + pdHash.ID)
+	if err != nil {
+		return false, fmt.Errorf("Could not delete payment detail hash %s: %s", pdHash.ID, err.Error())
+	}
+	payment.PaymentDetailID = pd.ID
+	return true, nil
+}
+
+// pseudonymizeCertificates rewrites the certificates a user produced, holds or retired to its
+// pseudonymous ID. The meter of produced certificates is cleared with the meters of the profile.
+func pseudonymizeCertificates(stub shim.ChaincodeStubInterface, userID string, pseudonymID string) (int, error) {
+	count := 0
+
+	certificates, err := getStatesByPrefix(stub, "Certificate_")
+	if err != nil {
+		return count, fmt.Errorf("Failed to scan certificates: %s", err.Error())
+	}
+	for _, kv := range certificates {
+		var certificate Certificate
+		if json.Unmarshal(kv.Value, &certificate) != nil {
+			continue
+		}
+		changed := false
+		if certificate.HolderID == userID {
+			certificate.HolderID = pseudonymID
+			changed = true
+		}
+		if certificate.ProducerID == userID {
+			certificate.ProducerID = pseudonymID
+			certificate.MeterID = ""
+			changed = true
+		}
+		if certificate.RetiredBy == userID {
+			certificate.RetiredBy = pseudonymID
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		err = putCertificate(stub, &certificate)
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// pseudonymizeDisputes rewrites the disputes a user raised or added evidence to, to its
+// pseudonymous ID
+func pseudonymizeDisputes(stub shim.ChaincodeStubInterface, userID string, pseudonymID string) (int, error) {
+	count := 0
+
+	disputes, err := getStatesByPrefix(stub, "Dispute_")
+	if err != nil {
+		return count, fmt.Errorf("Failed to scan disputes: %s", err.Error())
+	}
+	for _, kv := range disputes {
+		var dispute Dispute
+		if json.Unmarshal(kv.Value, &dispute) != nil {
+			continue
+		}
+		changed := false
+		if dispute.RaisedBy == userID {
+			dispute.RaisedBy = pseudonymID
+			changed = true
+		}
+		for i := range dispute.Evidence {
+			if dispute.Evidence[i].SubmittedBy == userID {
+				dispute.Evidence[i].SubmittedBy = pseudonymID
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		err = putDispute(stub, &dispute)
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// pseudonymizeReliabilityScore moves the reliability score of a user to the key of its
+// pseudonymous ID
+func pseudonymizeReliabilityScore(stub shim.ChaincodeStubInterface, userID string, pseudonymID string) (int, error) {
+	scoreAsBytes, err := stub.GetState("Reliability_" + userID)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to fetch reliability score: %s", err.Error())
+	}
+	if scoreAsBytes == nil {
+		return 0, nil
+	}
+	var score ReliabilityScore
+	err = json.Unmarshal(scoreAsBytes, &score)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to unmarshal reliability score: %s", err.Error())
+	}
+
+	score.UserID = pseudonymID
+	scoreAsBytes, _ = json.Marshal(score)
+	err = stub.DelState("Reliability_" + userID)
+	if err != nil {
+		return 0, fmt.Errorf("Could not delete reliability score: %s", err.Error())
+	}
+	err = stub.PutState("Reliability_"+pseudonymID, scoreAsBytes)
+	if err != nil {
+		return 0, fmt.Errorf("Could not store reliability score: %s", err.Error())
+	}
+	return 1, nil
+}
+
+// deleteMeterZones deletes the zone assignments of the meters of a user. Their keys carry the
+// meter IDs, which are erased from the profile.
+func deleteMeterZones(stub shim.ChaincodeStubInterface, userID string) (int, error) {
+	count := 0
+
+	meterZones, err := getStatesByPrefix(stub, "MeterZone_")
+	if err != nil {
+		return count, fmt.Errorf("Failed to scan meter zones: %s", err.Error())
+	}
+	for _, kv := range meterZones {
+		var meterZone MeterZone
+		if json.Unmarshal(kv.Value, &meterZone) != nil || meterZone.UserID != userID {
+			continue
+		}
+		err = stub.DelState(kv.Key)
+		if err != nil {
+			return count, fmt.Errorf("Could not delete meter zone %s: %s", kv.Key, err.Error())
+		}
+		count++
+	}
+	return count, nil
+}
+
+// pseudonymizeInvoices moves the invoices of a user to keys of its pseudonymous ID. The private
+// copies under the user ID are purged; the content hash changes with the user ID.
+func pseudonymizeInvoices(stub shim.ChaincodeStubInterface, userID string, pseudonymID string) (int, error) {
+	count := 0
+
+	invoiceHashes, err := getStatesByPrefix(stub, getInvoiceKey(userID, ""))
+	if err != nil {
+		return count, fmt.Errorf("Failed to scan invoices: %s", err.Error())
+	}
+	for _, kv := range invoiceHashes {
+		var invoiceHash PrivateDataHash
+		err = json.Unmarshal(kv.Value, &invoiceHash)
+		if err != nil {
+			return count, fmt.Errorf("Failed to unmarshal invoice hash %s: %s", kv.Key, err.Error())
+		}
+		invoiceAsBytes, err := stub.GetPrivateData(invoiceHash.Collection, kv.Key)
+		if err != nil {
+			return count, fmt.Errorf("Failed to fetch invoice %s: %s", kv.Key, err.Error())
+		}
+		var invoice Invoice
+		if invoiceAsBytes == nil || json.Unmarshal(invoiceAsBytes, &invoice) != nil {
+			continue
+		}
+		// The prefix also matches the invoices of users whose ID extends this one.
+		if invoice.UserID != userID {
+			continue
+		}
+
+		invoice.UserID = pseudonymID
+		invoice.ID = getInvoiceKey(pseudonymID, invoice.Period)
+		invoice.ContentHash = getInvoiceContentHash(&invoice)
+		_, err = putPrivateInvoice(stub, invoiceHash.OwnerMSPID, &invoice)
+		if err != nil {
+			return count, err
+		}
+		err = stub.PurgePrivateData(invoiceHash.Collection, kv.Key)
+		if err != nil {
+			return count, fmt.Errorf("Could not purge invoice %s: %s", kv.Key, err.Error())
+		}
+		err = stub.DelState(kv.Key)
+		if err != nil {
+			return count, fmt.Errorf("Could not delete invoice hash %s: %s", kv.Key, err.Error())
+		}
+		count++
+	}
+	return count, nil
+}
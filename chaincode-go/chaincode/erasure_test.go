@@ -0,0 +1,323 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+// privateDataStub adds the private data purge the MockStub does not implement.
+type privateDataStub struct {
+	*shimtest.MockStub
+	args [][]byte
+}
+
+func (stub *privateDataStub) GetArgs() [][]byte {
+	return stub.args
+}
+
+func (stub *privateDataStub) GetFunctionAndParameters() (string, []string) {
+	var params []string
+	for _, arg := range stub.args[1:] {
+		params = append(params, string(arg))
+	}
+	return string(stub.args[0]), params
+}
+
+func (stub *privateDataStub) PurgePrivateData(collection string, key string) error {
+	delete(stub.PvtState[collection], key)
+	return nil
+}
+
+// invokeWithPrivateDataPurge invokes the chaincode like MockInvoke, with PurgePrivateData available
+func invokeWithPrivateDataPurge(stub *shimtest.MockStub, txID string, args [][]byte) pb.Response {
+	stub.MockTransactionStart(txID)
+	defer stub.MockTransactionEnd(txID)
+	return new(SimpleChaincode).Invoke(&privateDataStub{MockStub: stub, args: args})
+}
+
+func TestEraseParticipantData(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	stub.Creator = newCreator(t, "Org2MSP", nil)
+
+	// Register a user with personal data, an order and a payment.
+	stub.TransientMap = map[string][]byte{"location": []byte("Location 6"), "contact": []byte("user6@example.com"), "salt": []byte("salt6")}
+	response := stub.MockInvoke("1", [][]byte{
+		[]byte("UpdateUserProfile"), []byte("6"), []byte("Prosumer"), []byte("MeterId 6"), []byte("Solar"), []byte("false"),
+	})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+	putOrder(t, stub, Order{ID: "4", BidStatus: "BidCreated", UserID: "6", UserAction: BuyAction})
+	detailAsBytes, _ := json.Marshal(PaymentDetail{DebitedFrom: "6", CreditedTo: "7", TotalUnitCost: 10})
+	stub.TransientMap = map[string][]byte{"paymentDetail": detailAsBytes, "salt": []byte("salt1")}
+	response = stub.MockInvoke("2", [][]byte{
+		[]byte("RecordPayment"), []byte("1"), []byte("Buy"), []byte("100"), []byte("6"), []byte("2"), []byte("4"), []byte(""),
+	})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+	// A payment of another user names the user as payee.
+	response = stub.MockInvoke("2_1", [][]byte{
+		[]byte("UpdateUserProfile"), []byte("7"), []byte("Prosumer"), []byte("MeterId 7"), []byte("Solar"), []byte("false"),
+	})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	putOrder(t, stub, Order{ID: "5", BidStatus: "BidCreated", UserID: "7", UserAction: BuyAction})
+	detailAsBytes, _ = json.Marshal(PaymentDetail{DebitedFrom: "7", CreditedTo: "6", TotalUnitCost: 5})
+	stub.TransientMap = map[string][]byte{"paymentDetail": detailAsBytes, "salt": []byte("salt3")}
+	response = stub.MockInvoke("2_2", [][]byte{
+		[]byte("RecordPayment"), []byte("3"), []byte("Buy"), []byte("50"), []byte("7"), []byte("3"), []byte("5"), []byte(""),
+	})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	stub.TransientMap = nil
+
+	// Test Case 1: Callers without the admin role are rejected
+	t.Run("Non-admin Caller", func(t *testing.T) {
+		response := invokeWithPrivateDataPurge(stub, "3", [][]byte{[]byte("EraseParticipantData"), []byte("6")})
+
+		assert.Equal(t, StatusUnauthorized, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not authorized")
+	})
+
+	// Test Case 2: Successfully erase the participant's personal data
+	t.Run("Successfully Erase Participant Data", func(t *testing.T) {
+		stub.Creator = newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+		response := invokeWithPrivateDataPurge(stub, "4", [][]byte{[]byte("EraseParticipantData"), []byte("6")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var certificate ErasureCertificate
+		err := json.Unmarshal(response.GetPayload(), &certificate)
+		assert.NoError(t, err, "Error unmarshalling erasure certificate")
+		assert.Equal(t, 3, certificate.PseudonymizedRecords, "Pseudonymized record count mismatch")
+
+		event := <-stub.ChaincodeEventsChannel
+		assert.Equal(t, "ParticipantDataErased", event.GetEventName(), "Event name mismatch")
+
+		// The personal data is gone from the private collection and the public profile.
+		assert.Nil(t, stub.PvtState["Org2MSPPrivateCollection"]["User_6"], "Private details were not deleted")
+		userAsBytes, _ := stub.GetState("6")
+		assert.Nil(t, userAsBytes, "Profile still stored under the user ID")
+
+		var user User
+		profileAsBytes, _ := stub.GetState(certificate.ID)
+		err = json.Unmarshal(profileAsBytes, &user)
+		assert.NoError(t, err, "Error unmarshalling pseudonymized user")
+		assert.Empty(t, user.MeterID, "MeterID kept on the pseudonymized profile")
+		assert.Empty(t, user.ContactHash, "Contact hash kept on the pseudonymized profile")
+		assert.NotZero(t, user.ErasedOn, "ErasedOn not set")
+
+		// The financial records are kept under the pseudonymous ID.
+		var payment Payment
+		paymentAsBytes, _ := stub.GetState("Payment_1")
+		_ = json.Unmarshal(paymentAsBytes, &payment)
+		assert.Equal(t, certificate.ID, payment.UserID, "Payment not pseudonymized")
+
+		response = stub.MockInvoke("5", [][]byte{[]byte("ReadPaymentDetail"), []byte(payment.PaymentDetailID)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		var pd PrivatePaymentDetail
+		_ = json.Unmarshal(response.GetPayload(), &pd)
+		assert.Equal(t, certificate.ID, pd.DebitedFrom, "Payment detail not pseudonymized")
+
+		// The counterparty's payment detail is moved as well, and the originals are purged.
+		paymentAsBytes, _ = stub.GetState("Payment_3")
+		_ = json.Unmarshal(paymentAsBytes, &payment)
+		assert.Equal(t, "7", payment.UserID, "Payment of the counterparty moved")
+		response = stub.MockInvoke("5_1", [][]byte{[]byte("ReadPaymentDetail"), []byte(payment.PaymentDetailID)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		_ = json.Unmarshal(response.GetPayload(), &pd)
+		assert.Equal(t, certificate.ID, pd.CreditedTo, "Counterparty payment detail not pseudonymized")
+		for _, id := range []string{"2", "3"} {
+			assert.Nil(t, stub.PvtState["Org2MSPPrivateCollection"]["PaymentDetail_"+id], "Original payment detail not purged")
+			hashAsBytes, _ := stub.GetState("PaymentDetail_" + id)
+			assert.Nil(t, hashAsBytes, "Original payment detail hash kept")
+		}
+
+		response = stub.MockInvoke("6", [][]byte{[]byte("ReadErasureCertificate"), []byte(certificate.ID)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		// The user index of payments follows the payments to the pseudonymous ID.
+		payments, _ := getIndexedPayments(stub, PaymentsByUserIndex, certificate.ID)
+		assert.Len(t, payments, 1, "Payment not indexed under the pseudonymous ID")
+		payments, _ = getIndexedPayments(stub, PaymentsByUserIndex, "6")
+		assert.Empty(t, payments, "Payment still indexed under the erased user ID")
+	})
+
+	// Test Case 3: Unknown user
+	t.Run("Unknown User", func(t *testing.T) {
+		response := invokeWithPrivateDataPurge(stub, "7", [][]byte{[]byte("EraseParticipantData"), []byte("6")})
+
+		assert.Equal(t, StatusNotFound, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not found")
+	})
+}
+
+// eraseParticipant erases a user as admin and returns the erasure certificate
+func eraseParticipant(t *testing.T, stub *shimtest.MockStub, userID string) ErasureCertificate {
+	stub.Creator = newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+	response := invokeWithPrivateDataPurge(stub, "erase"+userID, [][]byte{[]byte("EraseParticipantData"), []byte(userID)})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	<-stub.ChaincodeEventsChannel
+
+	var certificate ErasureCertificate
+	err := json.Unmarshal(response.GetPayload(), &certificate)
+	assert.NoError(t, err, "Error unmarshalling erasure certificate")
+	return certificate
+}
+
+// putErasableUser stores a user of Org2MSP without private details
+func putErasableUser(t *testing.T, stub *shimtest.MockStub, userID string) {
+	stub.MockTransactionStart("setup")
+	defer stub.MockTransactionEnd("setup")
+	userAsBytes, _ := json.Marshal(User{ID: userID, MSPID: "Org2MSP"})
+	err := stub.PutState(userID, userAsBytes)
+	assert.NoError(t, err, "Error storing user")
+}
+
+func TestEraseParticipantInvoices(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	putErasableUser(t, stub, "6")
+
+	invoice := Invoice{ID: getInvoiceKey("6", "2024-05"), UserID: "6", Period: "2024-05", Total: 12.5}
+	invoice.ContentHash = getInvoiceContentHash(&invoice)
+	// The invoice key of user "6_1" starts with the invoice prefix of user "6".
+	other := Invoice{ID: getInvoiceKey("6_1", "2024-05"), UserID: "6_1", Period: "2024-05", Total: 3}
+	other.ContentHash = getInvoiceContentHash(&other)
+	stub.MockTransactionStart("setup")
+	_, err := putPrivateInvoice(stub, "Org2MSP", &invoice)
+	assert.NoError(t, err, "Error storing invoice")
+	_, err = putPrivateInvoice(stub, "Org2MSP", &other)
+	stub.MockTransactionEnd("setup")
+	assert.NoError(t, err, "Error storing invoice")
+
+	// Test Case 1: The invoice moves to the pseudonymous ID and the private copy is purged
+	certificate := eraseParticipant(t, stub, "6")
+	assert.Equal(t, 1, certificate.PseudonymizedRecords, "Pseudonymized record count mismatch")
+
+	invoiceHashAsBytes, _ := stub.GetState(getInvoiceKey("6", "2024-05"))
+	assert.Nil(t, invoiceHashAsBytes, "Invoice hash still stored under the user ID")
+	assert.Nil(t, stub.PvtState["Org2MSPPrivateCollection"][getInvoiceKey("6", "2024-05")], "Invoice still stored under the user ID")
+
+	response := stub.MockInvoke("1", [][]byte{[]byte("ReadInvoice"), []byte(certificate.ID), []byte("2024-05")})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	var erased Invoice
+	_ = json.Unmarshal(response.GetPayload(), &erased)
+	assert.Equal(t, certificate.ID, erased.UserID, "Invoice not pseudonymized")
+	assert.Equal(t, 12.5, erased.Total, "Invoice total changed")
+	assert.Equal(t, getInvoiceContentHash(&erased), erased.ContentHash, "Content hash not recomputed")
+
+	// Test Case 2: Invoices of other users sharing the key prefix are kept
+	invoiceHashAsBytes, _ = stub.GetState(getInvoiceKey("6_1", "2024-05"))
+	assert.NotNil(t, invoiceHashAsBytes, "Invoice of another user was moved")
+}
+
+func TestEraseParticipantMeterZones(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	putErasableUser(t, stub, "6")
+
+	stub.MockTransactionStart("setup")
+	for _, meterZone := range []MeterZone{{MeterID: "meter-6", UserID: "6", ZoneID: "feeder-1"}, {MeterID: "meter-7", UserID: "7", ZoneID: "feeder-1"}} {
+		meterZoneAsBytes, _ := json.Marshal(meterZone)
+		assert.NoError(t, stub.PutState("MeterZone_"+meterZone.MeterID, meterZoneAsBytes), "Error storing meter zone")
+	}
+	stub.MockTransactionEnd("setup")
+
+	// Test Case 1: Only the meter zones of the erased user are deleted
+	certificate := eraseParticipant(t, stub, "6")
+	assert.Equal(t, 1, certificate.PseudonymizedRecords, "Pseudonymized record count mismatch")
+
+	meterZoneAsBytes, _ := stub.GetState("MeterZone_meter-6")
+	assert.Nil(t, meterZoneAsBytes, "Meter zone of the erased user kept")
+	meterZoneAsBytes, _ = stub.GetState("MeterZone_meter-7")
+	assert.NotNil(t, meterZoneAsBytes, "Meter zone of another user deleted")
+}
+
+func TestEraseParticipantCertificates(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	putErasableUser(t, stub, "6")
+
+	stub.MockTransactionStart("setup")
+	for _, certificate := range []Certificate{
+		{ID: "C1", HolderID: "7", MeterID: "meter-6", ProducerID: "6", Status: CertificateActive},
+		{ID: "C2", HolderID: "6", MeterID: "meter-8", ProducerID: "8", RetiredBy: "6", Status: CertificateRetired},
+		{ID: "C3", HolderID: "7", MeterID: "meter-8", ProducerID: "8", Status: CertificateActive},
+	} {
+		assert.NoError(t, putCertificate(stub, &certificate), "Error storing certificate")
+	}
+	stub.MockTransactionEnd("setup")
+
+	// Test Case 1: Produced, held and retired certificates move to the pseudonymous ID
+	certificate := eraseParticipant(t, stub, "6")
+	assert.Equal(t, 2, certificate.PseudonymizedRecords, "Pseudonymized record count mismatch")
+
+	produced, err := getCertificate(stub, "C1")
+	assert.NoError(t, err, "Error reading certificate")
+	assert.Equal(t, certificate.ID, produced.ProducerID, "Producer not pseudonymized")
+	assert.Empty(t, produced.MeterID, "Producer meter kept")
+	assert.Equal(t, "7", produced.HolderID, "Holder of another user changed")
+
+	retired, err := getCertificate(stub, "C2")
+	assert.NoError(t, err, "Error reading certificate")
+	assert.Equal(t, certificate.ID, retired.HolderID, "Holder not pseudonymized")
+	assert.Equal(t, certificate.ID, retired.RetiredBy, "Retiring user not pseudonymized")
+	assert.Equal(t, "meter-8", retired.MeterID, "Meter of another producer cleared")
+
+	other, err := getCertificate(stub, "C3")
+	assert.NoError(t, err, "Error reading certificate")
+	assert.Equal(t, "8", other.ProducerID, "Certificate of another user changed")
+}
+
+func TestEraseParticipantReliabilityScore(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	putErasableUser(t, stub, "6")
+
+	stub.MockTransactionStart("setup")
+	scoreAsBytes, _ := json.Marshal(ReliabilityScore{Deliveries: 3, Score: 0.9, UserID: "6", Weight: 3})
+	assert.NoError(t, stub.PutState("Reliability_6", scoreAsBytes), "Error storing reliability score")
+	stub.MockTransactionEnd("setup")
+
+	// Test Case 1: The score moves to the pseudonymous ID
+	certificate := eraseParticipant(t, stub, "6")
+	assert.Equal(t, 1, certificate.PseudonymizedRecords, "Pseudonymized record count mismatch")
+
+	scoreAsBytes, _ = stub.GetState("Reliability_6")
+	assert.Nil(t, scoreAsBytes, "Reliability score still stored under the user ID")
+
+	score, err := getReliabilityScore(stub, certificate.ID)
+	assert.NoError(t, err, "Error reading reliability score")
+	assert.Equal(t, certificate.ID, score.UserID, "Reliability score not pseudonymized")
+	assert.Equal(t, 3, score.Deliveries, "Deliveries changed")
+	assert.Equal(t, 0.9, score.Score, "Score changed")
+}
+
+func TestEraseParticipantDisputes(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	putErasableUser(t, stub, "6")
+
+	stub.MockTransactionStart("setup")
+	for _, dispute := range []Dispute{
+		{ID: "D1", RaisedBy: "6", Evidence: []DisputeEvidence{{Hash: "h1", SubmittedBy: "6"}, {Hash: "h2", SubmittedBy: "7"}}},
+		{ID: "D2", RaisedBy: "7", Evidence: []DisputeEvidence{{Hash: "h3", SubmittedBy: "6"}}},
+		{ID: "D3", RaisedBy: "7"},
+	} {
+		assert.NoError(t, putDispute(stub, &dispute), "Error storing dispute")
+	}
+	stub.MockTransactionEnd("setup")
+
+	// Test Case 1: Raised disputes and submitted evidence move to the pseudonymous ID
+	certificate := eraseParticipant(t, stub, "6")
+	assert.Equal(t, 2, certificate.PseudonymizedRecords, "Pseudonymized record count mismatch")
+
+	raised, err := getDispute(stub, "D1")
+	assert.NoError(t, err, "Error reading dispute")
+	assert.Equal(t, certificate.ID, raised.RaisedBy, "Raising user not pseudonymized")
+	assert.Equal(t, certificate.ID, raised.Evidence[0].SubmittedBy, "Evidence submitter not pseudonymized")
+	assert.Equal(t, "7", raised.Evidence[1].SubmittedBy, "Evidence of another user changed")
+
+	answered, err := getDispute(stub, "D2")
+	assert.NoError(t, err, "Error reading dispute")
+	assert.Equal(t, "7", answered.RaisedBy, "Raising user of another dispute changed")
+	assert.Equal(t, certificate.ID, answered.Evidence[0].SubmittedBy, "Evidence submitter not pseudonymized")
+}
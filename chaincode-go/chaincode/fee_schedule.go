@@ -0,0 +1,270 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                            Fee Schedule Methods                            */
+/* -------------------------------------------------------------------------- */
+
+// PublishFeeSchedule publishes a new version of the platform fee schedule. Versions are
+// immutable; a change is published as a higher version with a later EffectiveDate.
+//
+// Inputs - Array of strings
+//
+//	          0
+//	FeeSchedule as JSON
+//	{"version": 2, "effectiveDate": 1700000000, "percentage": 1.5, "flatFee": 0.1, "minFee": 0.2, "maxFee": 25,
+//	 "tiers": [{"category": "Enterprise", "minMonthlyVolume": 10000, "percentage": 1}]}
+func PublishFeeSchedule(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting PublishFeeSchedule")
+
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	err := requireRole(stub, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	var schedule FeeSchedule
+	err = json.Unmarshal([]byte(args[0]), &schedule)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse FeeSchedule: "+err.Error())
+	}
+	err = validateFeeSchedule(&schedule)
+	if err != nil {
+		return errorResponse(err)
+	}
+	schedule.CreatedOn, err = getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// Versions only ever increase.
+	versions, err := getFeeScheduleVersions(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if len(versions) > 0 && versions[len(versions)-1].Version >= schedule.Version {
+		return statusResponse(StatusConflict, "Fee schedule version "+strconv.Itoa(versions[len(versions)-1].Version)+" exists, new versions must be higher.")
+	}
+
+	scheduleAsBytes, _ := json.Marshal(schedule)
+	err = stub.PutState(getFeeScheduleKey(schedule.Version), scheduleAsBytes)
+	if err != nil {
+		return shim.Error("Could not store fee schedule: " + err.Error())
+	}
+
+	fmt.Println("- end PublishFeeSchedule")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// ReadFeeSchedule returns a fee schedule version, or the version in effect when no version is given.
+func ReadFeeSchedule(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadFeeSchedule")
+
+	// We expect 0 or 1 argument: optionally the version.
+	if len(args) > 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 0 or 1.")
+	}
+
+	if len(args) == 1 {
+		version, err := strconv.Atoi(args[0])
+		if err != nil {
+			return statusResponse(StatusInvalidArgument, "Failed to parse Version: "+err.Error())
+		}
+		scheduleAsBytes, err := stub.GetState(getFeeScheduleKey(version))
+		if err != nil {
+			return shim.Error("Error accessing state: " + err.Error())
+		}
+		if scheduleAsBytes == nil {
+			return statusResponse(StatusNotFound, "Fee schedule version "+args[0]+" not found.")
+		}
+		fmt.Println("- end ReadFeeSchedule")
+		return shim.Success(scheduleAsBytes)
+	}
+
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	schedule, err := getEffectiveFeeSchedule(stub, now)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if schedule == nil {
+		return statusResponse(StatusNotFound, "No fee schedule is in effect.")
+	}
+	scheduleAsBytes, _ := json.Marshal(schedule)
+
+	fmt.Println("- end ReadFeeSchedule")
+	return shim.Success(scheduleAsBytes)
+}
+
+// validateFeeSchedule checks the values of a new fee schedule
+func validateFeeSchedule(schedule *FeeSchedule) error {
+	if schedule.Version < 1 {
+		return newStatusError(StatusInvalidArgument, "Version must be a positive integer.")
+	}
+	if schedule.Percentage < 0 || schedule.Percentage > 100 || schedule.FlatFee < 0 {
+		return newStatusError(StatusInvalidArgument, "Percentage must be between 0 and 100 and FlatFee can not be negative.")
+	}
+	if schedule.MinFee < 0 || schedule.MaxFee < 0 || (schedule.MaxFee > 0 && schedule.MaxFee < schedule.MinFee) {
+		return newStatusError(StatusInvalidArgument, "MinFee and MaxFee can not be negative and MaxFee can not be below MinFee.")
+	}
+	for i, tier := range schedule.Tiers {
+		if tier.Percentage < 0 || tier.Percentage > 100 || tier.FlatFee < 0 || tier.MinMonthlyVolume < 0 {
+			return newStatusError(StatusInvalidArgument, "Tier at index "+strconv.Itoa(i)+" has a negative value or a percentage above 100.")
+		}
+	}
+	return nil
+}
+
+// getFeeScheduleKey zero pads the version so the versions sort in order
+func getFeeScheduleKey(version int) string {
+	return fmt.Sprintf("FeeSchedule_%06d", version)
+}
+
+// getFeeScheduleVersions returns all the fee schedule versions in increasing order
+func getFeeScheduleVersions(stub shim.ChaincodeStubInterface) ([]FeeSchedule, error) {
+	results, err := getStatesByPrefix(stub, "FeeSchedule_")
+	if err != nil {
+		return nil, errors.New("Failed to scan fee schedules: " + err.Error())
+	}
+
+	var versions []FeeSchedule
+	for _, kv := range results {
+		var schedule FeeSchedule
+		err = json.Unmarshal(kv.Value, &schedule)
+		if err != nil {
+			return nil, errors.New("Failed to unmarshal fee schedule: " + err.Error())
+		}
+		versions = append(versions, schedule)
+	}
+	return versions, nil
+}
+
+// getEffectiveFeeSchedule returns the highest fee schedule version in effect at the given
+// time, or nil when none is
+func getEffectiveFeeSchedule(stub shim.ChaincodeStubInterface, at int64) (*FeeSchedule, error) {
+	versions, err := getFeeScheduleVersions(stub)
+	if err != nil {
+		return nil, err
+	}
+
+	var effective *FeeSchedule
+	for i := range versions {
+		if versions[i].EffectiveDate <= at {
+			effective = &versions[i]
+		}
+	}
+	return effective, nil
+}
+
+// computePlatformFee returns the fee the user pays on an amount under the fee schedule in effect
+// at the transaction time, together with the version applied. Without a schedule in effect there
+// is no fee and the version is 0.
+func computePlatformFee(stub shim.ChaincodeStubInterface, userID string, amount float64) (float64, int, error) {
+	now, err := getTxTime(stub)
+	if err != nil {
+		return 0, 0, err
+	}
+	schedule, err := getEffectiveFeeSchedule(stub, now)
+	if err != nil || schedule == nil {
+		return 0, 0, err
+	}
+
+	percentage := schedule.Percentage
+	flatFee := schedule.FlatFee
+	if len(schedule.Tiers) > 0 {
+		userAsBytes, err := stub.GetState(userID)
+		if err != nil || userAsBytes == nil {
+			return 0, 0, newStatusError(StatusNotFound, "User with ID "+userID+" not found")
+		}
+		var user User
+		err = json.Unmarshal(userAsBytes, &user)
+		if err != nil {
+			return 0, 0, errors.New("Failed to unmarshal user: " + err.Error())
+		}
+		volume, err := getMonthlyPaymentVolume(stub, userID, now)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		var tier *FeeTier
+		for i := range schedule.Tiers {
+			candidate := &schedule.Tiers[i]
+			if (candidate.Category != "" && candidate.Category != user.Category) || candidate.MinMonthlyVolume > volume {
+				continue
+			}
+			if tier == nil || candidate.MinMonthlyVolume > tier.MinMonthlyVolume ||
+				(candidate.MinMonthlyVolume == tier.MinMonthlyVolume && tier.Category == "" && candidate.Category != "") {
+				tier = candidate
+			}
+		}
+		if tier != nil {
+			percentage = tier.Percentage
+			flatFee = tier.FlatFee
+		}
+	}
+
+	fee := amount*percentage/100 + flatFee
+	fee = math.Max(fee, schedule.MinFee)
+	if schedule.MaxFee > 0 {
+		fee = math.Min(fee, schedule.MaxFee)
+	}
+	// Fees are charged in cents.
+	return roundAmount(fee), schedule.Version, nil
+}
+
+// getMonthlyPaymentVolume sums the user's payments, net of refunds, recorded in the calendar
+// month (UTC) of the given transaction time. Payments are found through the user index and
+// bucketed by their CreatedOn, which is the transaction time of RecordPayment.
+func getMonthlyPaymentVolume(stub shim.ChaincodeStubInterface, userID string, at int64) (float64, error) {
+	month := time.Unix(at, 0).UTC().Format("200601")
+
+	payments, err := getIndexedPayments(stub, PaymentsByUserIndex, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	volume := 0.0
+	for _, payment := range payments {
+		if payment.PaymentType == RefundPaymentType || payment.PaymentType == AdjustmentPaymentType ||
+			time.Unix(payment.CreatedOn, 0).UTC().Format("200601") != month {
+			continue
+		}
+		volume += payment.TotalAmount - payment.RefundedAmount
+	}
+	return volume, nil
+}
@@ -0,0 +1,100 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishFeeSchedule(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	admin := newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+
+	// Test Case 1: Callers without the admin role are rejected
+	t.Run("Non-admin Caller", func(t *testing.T) {
+		stub.Creator = newCreator(t, "Org1MSP", nil)
+		response := stub.MockInvoke("1", [][]byte{[]byte("PublishFeeSchedule"), []byte(`{"version": 1, "percentage": 2}`)})
+
+		assert.Equal(t, StatusUnauthorized, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not authorized")
+	})
+
+	// Test Case 2: The version in effect is returned, future versions are not applied yet
+	t.Run("Read Effective Fee Schedule", func(t *testing.T) {
+		stub.Creator = admin
+		response := stub.MockInvoke("2", [][]byte{[]byte("PublishFeeSchedule"), []byte(`{"version": 1, "percentage": 2, "minFee": 0.5}`)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		future := fmt.Sprintf(`{"version": 2, "percentage": 3, "effectiveDate": %d}`, time.Now().AddDate(0, 1, 0).Unix())
+		response = stub.MockInvoke("3", [][]byte{[]byte("PublishFeeSchedule"), []byte(future)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("4", [][]byte{[]byte("ReadFeeSchedule")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		var schedule FeeSchedule
+		err := json.Unmarshal(response.GetPayload(), &schedule)
+		assert.NoError(t, err, "Error unmarshalling fee schedule")
+		assert.Equal(t, 1, schedule.Version, "Effective version mismatch")
+	})
+
+	// Test Case 3: Versions only increase and caps have to be consistent
+	t.Run("Invalid Fee Schedules", func(t *testing.T) {
+		response := stub.MockInvoke("5", [][]byte{[]byte("PublishFeeSchedule"), []byte(`{"version": 2, "percentage": 1}`)})
+		assert.Equal(t, StatusConflict, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "must be higher")
+
+		response = stub.MockInvoke("6", [][]byte{[]byte("PublishFeeSchedule"), []byte(`{"version": 3, "minFee": 5, "maxFee": 1}`)})
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+	})
+}
+
+func TestComputePlatformFee(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	stub.Creator = newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+
+	// Tiers for enterprises and for any participant above a monthly volume.
+	schedule := `{"version": 1, "percentage": 2, "flatFee": 0.1, "minFee": 0.5, "maxFee": 10, "tiers": [
+		{"category": "Enterprise", "percentage": 1},
+		{"minMonthlyVolume": 1000, "percentage": 0.5}]}`
+	response := stub.MockInvoke("1", [][]byte{[]byte("PublishFeeSchedule"), []byte(schedule)})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+	stub.MockTransactionStart("setup")
+	for id, category := range map[string]string{"6": "Prosumer", "7": "Enterprise"} {
+		userAsBytes, _ := json.Marshal(User{ID: id, Category: category})
+		_ = stub.PutState(id, userAsBytes)
+	}
+	payment := Payment{ID: "1", UserID: "8", TotalAmount: 2000, CreatedOn: time.Now().Unix()}
+	paymentAsBytes, _ := json.Marshal(payment)
+	_ = stub.PutState("Payment_1", paymentAsBytes)
+	_ = putPaymentIndexes(stub, &payment)
+	userAsBytes, _ := json.Marshal(User{ID: "8", Category: "Prosumer"})
+	_ = stub.PutState("8", userAsBytes)
+
+	cases := []struct {
+		name   string
+		userID string
+		amount float64
+		fee    float64
+	}{
+		{"Base Rate", "6", 100, 2.1},
+		{"Minimum Cap", "6", 10, 0.5},
+		{"Maximum Cap", "6", 1000, 10},
+		{"Category Tier", "7", 100, 1},
+		{"Volume Tier", "8", 100, 0.5},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fee, version, err := computePlatformFee(stub, c.userID, c.amount)
+			assert.NoError(t, err, "Error computing fee")
+			assert.Equal(t, 1, version, "Fee schedule version mismatch")
+			assert.Equal(t, c.fee, fee, "Fee mismatch")
+		})
+	}
+	stub.MockTransactionEnd("setup")
+}
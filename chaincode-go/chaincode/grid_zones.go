@@ -0,0 +1,437 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                             Grid Zone Methods                              */
+/* -------------------------------------------------------------------------- */
+
+// AssignGridZone connects a participant, or one of its meters, to a grid zone. Matches take the
+// zone of the meter named by an order and fall back to the zone of the participant.
+//
+// Inputs - Array of strings
+//
+//	0      ,    1      ,    2
+//	UserID ,    MeterID,    ZoneID
+//	"20"   ,    ""     ,    "feeder-7"
+func AssignGridZone(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting AssignGridZone")
+
+	if len(args) != 3 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 3.")
+	}
+
+	err := requireRole(stub, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	userID := args[0]
+	meterID := args[1]
+	zoneID := args[2]
+	if zoneID == "" {
+		return statusResponse(StatusInvalidArgument, "ZoneID must be a non-empty string.")
+	}
+
+	participant, err := getParticipantGrid(stub, userID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if meterID != "" {
+		if !participant.hasMeter(meterID) {
+			return statusResponse(StatusInvalidArgument, "Meter "+meterID+" does not belong to user "+userID+".")
+		}
+		meterZone := MeterZone{MeterID: meterID, UpdatedOn: now, UserID: userID, ZoneID: zoneID}
+		meterZoneAsBytes, _ := json.Marshal(meterZone)
+		err = stub.PutState("MeterZone_"+meterID, meterZoneAsBytes)
+		if err != nil {
+			return shim.Error("Could not store meter zone: " + err.Error())
+		}
+		fmt.Println("- end AssignGridZone")
+		return shim.Success([]byte(stub.GetTxID()))
+	}
+
+	// Enterprise users keep their own schema, so the profile is updated in the schema it was stored with.
+	var profile interface{} = &User{}
+	if participant.MeterIDs != nil {
+		profile = &EnterpriseUser{}
+	}
+	err = json.Unmarshal(participant.raw, profile)
+	if err != nil {
+		return shim.Error("Failed to unmarshal user: " + err.Error())
+	}
+	switch p := profile.(type) {
+	case *User:
+		p.ZoneID = zoneID
+		p.UpdatedOn = now
+	case *EnterpriseUser:
+		p.ZoneID = zoneID
+		p.UpdatedOn = now
+	}
+	profileAsBytes, _ := json.Marshal(profile)
+	err = stub.PutState(userID, profileAsBytes)
+	if err != nil {
+		return shim.Error("Could not store user: " + err.Error())
+	}
+
+	fmt.Println("- end AssignGridZone")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// SetNetworkTariff sets the network-use charge per unit for energy carried from one zone to
+// another. Intra-zone trades use the tariff with equal zones.
+//
+// Inputs - Array of strings
+//
+//	0         ,    1       ,    2
+//	FromZone  ,    ToZone  ,    ChargePerUnit
+//	"feeder-7",    "feeder-9",  "0.8"
+func SetNetworkTariff(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting SetNetworkTariff")
+
+	if len(args) != 3 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 3.")
+	}
+
+	err := requireRole(stub, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if args[0] == "" || args[1] == "" {
+		return statusResponse(StatusInvalidArgument, "FromZone and ToZone must be non-empty strings.")
+	}
+	chargePerUnit, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse ChargePerUnit: "+err.Error())
+	}
+	if chargePerUnit < 0 {
+		return statusResponse(StatusInvalidArgument, "ChargePerUnit can not be negative.")
+	}
+
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	tariffKey, err := getNetworkTariffKey(stub, args[0], args[1])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	tariff := NetworkTariff{ChargePerUnit: chargePerUnit, FromZone: args[0], ToZone: args[1], UpdatedOn: now}
+	tariffAsBytes, _ := json.Marshal(tariff)
+	err = stub.PutState(tariffKey, tariffAsBytes)
+	if err != nil {
+		return shim.Error("Could not store network tariff: " + err.Error())
+	}
+
+	fmt.Println("- end SetNetworkTariff")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// ReadNetworkTariff returns the network tariff from one zone to another.
+//
+// Inputs - Array of strings
+//
+//	0         ,    1
+//	FromZone  ,    ToZone
+func ReadNetworkTariff(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadNetworkTariff")
+
+	if len(args) != 2 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 2.")
+	}
+
+	tariffKey, err := getNetworkTariffKey(stub, args[0], args[1])
+	if err != nil {
+		return errorResponse(err)
+	}
+	tariffAsBytes, err := stub.GetState(tariffKey)
+	if err != nil {
+		return shim.Error("Error accessing state: " + err.Error())
+	}
+	if tariffAsBytes == nil {
+		return statusResponse(StatusNotFound, "NetworkTariff from "+args[0]+" to "+args[1]+" not found.")
+	}
+
+	fmt.Println("- end ReadNetworkTariff")
+	return shim.Success(tariffAsBytes)
+}
+
+// ReadMatchCandidates lists the resting orders an order can be matched with, best first. Buy
+// orders rank sellers by landed price, the price plus the network charge to the buyer's zone;
+// sell orders rank buyers by price. Equal prices prefer counterparties in the same zone, then
+// the more reliable counterparty and then the earlier PriorityTime. Counterparties in zones
+// without capacity left are left out.
+//
+// Inputs - Array of strings
+//
+//	0
+//	OrderID
+func ReadMatchCandidates(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadMatchCandidates")
+
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	order, err := getOrder(stub, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+	zoneID, err := getOrderZone(stub, order)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	resting, err := getRestingOrders(stub, order.SlotID, oppositeAction(order.UserAction))
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	candidates := []MatchCandidate{}
+	for _, other := range resting {
+		if other.UserID == order.UserID || !acceptsPrice(order, limitPrice(&other)) {
+			continue
+		}
+		otherZoneID, err := getOrderZone(stub, &other)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		candidate := MatchCandidate{Order: other, SameZone: zoneID != "" && zoneID == otherZoneID, ZoneID: otherZoneID}
+		fromZone, toZone := otherZoneID, zoneID
+		if order.UserAction == SellAction {
+			fromZone, toZone = zoneID, otherZoneID
+		}
+		candidate.MaxUnits, err = getAvailableCapacity(stub, fromZone, toZone, order.SlotID)
+		if err != nil {
+			return errorResponse(err)
+		}
+		if candidate.MaxUnits == 0 {
+			continue
+		}
+		score, err := getReliabilityScore(stub, other.UserID)
+		if err != nil {
+			return errorResponse(err)
+		}
+		candidate.Reliability = score.Score
+		candidate.LandedPrice = limitPrice(&other)
+		if order.UserAction == BuyAction {
+			candidate.NetworkChargePerUnit, err = getNetworkChargePerUnit(stub, otherZoneID, zoneID)
+			if err != nil {
+				return errorResponse(err)
+			}
+			candidate.LandedPrice += candidate.NetworkChargePerUnit
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.LandedPrice != b.LandedPrice {
+			if order.UserAction == BuyAction {
+				return a.LandedPrice < b.LandedPrice
+			}
+			return a.LandedPrice > b.LandedPrice
+		}
+		if a.SameZone != b.SameZone {
+			return a.SameZone
+		}
+		if a.Reliability != b.Reliability {
+			return a.Reliability > b.Reliability
+		}
+		return a.Order.PriorityTime < b.Order.PriorityTime
+	})
+
+	candidatesAsBytes, _ := json.Marshal(candidates)
+
+	fmt.Println("- end ReadMatchCandidates")
+	return shim.Success(candidatesAsBytes)
+}
+
+// participantGrid is the part of a User or EnterpriseUser profile that ties it to the grid.
+type participantGrid struct {
+	MeterID  string   `json:"meterId"`
+	MeterIDs []string `json:"meterIds"`
+	ZoneID   string   `json:"zoneId"`
+	raw      []byte
+}
+
+// hasMeter reports whether the meter belongs to the participant
+func (p *participantGrid) hasMeter(meterID string) bool {
+	return p.MeterID == meterID || containsString(p.MeterIDs, meterID)
+}
+
+// getParticipantGrid reads the grid details of a User or EnterpriseUser
+func getParticipantGrid(stub shim.ChaincodeStubInterface, userID string) (*participantGrid, error) {
+	userAsBytes, err := stub.GetState(userID)
+	if err != nil {
+		return nil, errors.New("Error accessing state: " + err.Error())
+	}
+	if userAsBytes == nil {
+		return nil, newStatusError(StatusNotFound, "User with ID "+userID+" not found.")
+	}
+
+	participant := participantGrid{raw: userAsBytes}
+	err = json.Unmarshal(userAsBytes, &participant)
+	if err != nil {
+		return nil, errors.New("Failed to unmarshal user: " + err.Error())
+	}
+	return &participant, nil
+}
+
+// getOrderZone returns the zone of the meter an order names, or else the zone of its user.
+// Participants never assigned to a zone have the empty zone.
+func getOrderZone(stub shim.ChaincodeStubInterface, order *Order) (string, error) {
+	if order.MeterID != "" {
+		meterZoneAsBytes, err := stub.GetState("MeterZone_" + order.MeterID)
+		if err != nil {
+			return "", errors.New("Error accessing state: " + err.Error())
+		}
+		if meterZoneAsBytes != nil {
+			var meterZone MeterZone
+			err = json.Unmarshal(meterZoneAsBytes, &meterZone)
+			if err != nil {
+				return "", errors.New("Failed to unmarshal meter zone: " + err.Error())
+			}
+			return meterZone.ZoneID, nil
+		}
+	}
+
+	participant, err := getParticipantGrid(stub, order.UserID)
+	if err != nil {
+		return "", err
+	}
+	return participant.ZoneID, nil
+}
+
+// getNetworkTariffKey returns the composite state key of the tariff from one zone to another, so
+// zone IDs containing the separator of a joined key can't collide
+func getNetworkTariffKey(stub shim.ChaincodeStubInterface, fromZone string, toZone string) (string, error) {
+	key, err := stub.CreateCompositeKey("NetworkTariff", []string{fromZone, toZone})
+	if err != nil {
+		return "", newStatusError(StatusInvalidArgument, "Invalid zone: "+err.Error())
+	}
+	return key, nil
+}
+
+// getNetworkChargePerUnit returns the charge per unit from one zone to another, zero when
+// either zone is unknown or no tariff was set for the pair
+func getNetworkChargePerUnit(stub shim.ChaincodeStubInterface, fromZone string, toZone string) (float64, error) {
+	if fromZone == "" || toZone == "" {
+		return 0, nil
+	}
+	tariffKey, err := getNetworkTariffKey(stub, fromZone, toZone)
+	if err != nil {
+		return 0, err
+	}
+	tariffAsBytes, err := stub.GetState(tariffKey)
+	if err != nil {
+		return 0, errors.New("Error accessing state: " + err.Error())
+	}
+	if tariffAsBytes == nil {
+		return 0, nil
+	}
+
+	var tariff NetworkTariff
+	err = json.Unmarshal(tariffAsBytes, &tariff)
+	if err != nil {
+		return 0, errors.New("Failed to unmarshal network tariff: " + err.Error())
+	}
+	return tariff.ChargePerUnit, nil
+}
+
+// checkOrderMeter checks that the meter an order names, if any, belongs to its user
+func checkOrderMeter(stub shim.ChaincodeStubInterface, order *Order) error {
+	if order.MeterID == "" {
+		return nil
+	}
+	participant, err := getParticipantGrid(stub, order.UserID)
+	if err != nil {
+		return err
+	}
+	if !participant.hasMeter(order.MeterID) {
+		return errors.New("Meter " + order.MeterID + " does not belong to user " + order.UserID + ".")
+	}
+	return nil
+}
+
+// attachNetworkCharge records the zones of both orders of a bid match, the charge per unit
+// carried from the seller's zone to the buyer's zone and the charge for the units settled so far
+func attachNetworkCharge(stub shim.ChaincodeStubInterface, bidMatch *BidMatch) error {
+	buyOrder, err := getOrder(stub, bidMatch.TransactionBuyID)
+	if err != nil {
+		return err
+	}
+	sellOrder, err := getOrder(stub, bidMatch.TransactionSellID)
+	if err != nil {
+		return err
+	}
+
+	bidMatch.BuyerZoneID, err = getOrderZone(stub, buyOrder)
+	if err != nil {
+		return err
+	}
+	bidMatch.SellerZoneID, err = getOrderZone(stub, sellOrder)
+	if err != nil {
+		return err
+	}
+	bidMatch.NetworkChargePerUnit, err = getNetworkChargePerUnit(stub, bidMatch.SellerZoneID, bidMatch.BuyerZoneID)
+	if err != nil {
+		return err
+	}
+	bidMatch.NetworkCharge = roundAmount(bidMatch.SettledBidUnits * bidMatch.NetworkChargePerUnit)
+	return nil
+}
+
+// settleNetworkCharge replaces the units an energy bid of the match delivered to the buyer and
+// recomputes the network charge of the match from the delivered units
+func settleNetworkCharge(stub shim.ChaincodeStubInterface, bidMatch *BidMatch, previousUnits float64, units float64) error {
+	if previousUnits == units {
+		return nil
+	}
+	bidMatch.SettledBidUnits = math.Max(bidMatch.SettledBidUnits-previousUnits+units, 0)
+	bidMatch.NetworkCharge = roundAmount(bidMatch.SettledBidUnits * bidMatch.NetworkChargePerUnit)
+
+	bidMatchAsBytes, _ := json.Marshal(bidMatch)
+	err := stub.PutState("BidMatch_"+bidMatch.ID, bidMatchAsBytes)
+	if err != nil {
+		return errors.New("Could not store BidMatch: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGridZones(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	stub.Creator = newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+
+	enableTrading(t, stub, "70", BuyAction)
+	enableTrading(t, stub, "71", SellAction)
+	enableTrading(t, stub, "72", SellAction)
+
+	assertOK(t, invoke(stub, "1", "AssignGridZone", "70", "", "feeder-1"))
+	assertOK(t, invoke(stub, "2", "AssignGridZone", "71", "", "feeder-1"))
+	assertOK(t, invoke(stub, "3", "AssignGridZone", "72", "", "feeder-2"))
+	assertOK(t, invoke(stub, "4", "SetNetworkTariff", "feeder-1", "feeder-1", "0.5"))
+	assertOK(t, invoke(stub, "5", "SetNetworkTariff", "feeder-2", "feeder-1", "2"))
+
+	putOrder(t, stub, Order{ID: "B1", BidStatus: "BidCreated", SlotID: "slot9", TotalQuantity: 10, RemainingQuantity: 10, UnitCost: 20, UserID: "70", UserAction: BuyAction})
+	putOrder(t, stub, Order{ID: "S1", BidStatus: "BidCreated", SlotID: "slot9", TotalQuantity: 10, RemainingQuantity: 10, UnitCost: 10, UserID: "71", UserAction: SellAction})
+	putOrder(t, stub, Order{ID: "S2", BidStatus: "BidCreated", SlotID: "slot9", TotalQuantity: 10, RemainingQuantity: 10, UnitCost: 9, UserID: "72", UserAction: SellAction})
+
+	// Test Case 1: Zones are only assigned to meters of the participant
+	t.Run("Foreign Meter", func(t *testing.T) {
+		response := invoke(stub, "6", "AssignGridZone", "70", "meter-x", "feeder-1")
+
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "does not belong to user 70")
+	})
+
+	// Test Case 1.1: Orders can only name meters of their user
+	t.Run("Foreign Order Meter", func(t *testing.T) {
+		response := invoke(stub, "6.1", "RegisterOrder", "", "BidCreated", "B2", "false", "100", "", "slot9", "10", "10", "70", "4102444800", BuyAction, "", "", "0", "", "meter-x")
+
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "Meter meter-x does not belong to user 70")
+	})
+
+	// Test Case 2: The network charge makes the same-zone seller the better candidate
+	t.Run("Match Candidates", func(t *testing.T) {
+		response := invoke(stub, "7", "ReadMatchCandidates", "B1")
+		assertOK(t, response)
+
+		var candidates []MatchCandidate
+		json.Unmarshal(response.GetPayload(), &candidates)
+		if assert.Len(t, candidates, 2, "Candidate count mismatch") {
+			assert.Equal(t, "S1", candidates[0].Order.ID, "Same-zone seller not preferred")
+			assert.True(t, candidates[0].SameZone, "Same zone flag mismatch")
+			assert.Equal(t, 10.5, candidates[0].LandedPrice, "Landed price mismatch")
+			assert.Equal(t, 11.0, candidates[1].LandedPrice, "Landed price mismatch")
+		}
+	})
+
+	// Test Case 3: The network charge of the zone pair is attached to the bid match
+	t.Run("BidMatch Network Charge", func(t *testing.T) {
+		assertOK(t, invoke(stub, "8", "ProcessBidMatch", "120", "slot9", "BidCreated", "9", "70", "0", "M1", "4", "72", "B1", "S2"))
+
+		bidMatch, err := getBidMatch(stub, "M1")
+		assert.NoError(t, err, "Error reading bid match")
+		assert.Equal(t, "feeder-1", bidMatch.BuyerZoneID, "Buyer zone mismatch")
+		assert.Equal(t, "feeder-2", bidMatch.SellerZoneID, "Seller zone mismatch")
+		assert.Equal(t, 2.0, bidMatch.NetworkChargePerUnit, "Charge per unit mismatch")
+		assert.Equal(t, 0.0, bidMatch.NetworkCharge, "Network charge before settlement")
+	})
+
+	// Test Case 4: The network charge follows the units delivered at settlement
+	t.Run("Settled Network Charge", func(t *testing.T) {
+		assertOK(t, invoke(stub, "9", "ProcessEnergyBid", "E1", "M1", "4", "4", "0", "0", "3", "3", "0", "0", "1", "short"))
+
+		bidMatch, err := getBidMatch(stub, "M1")
+		assert.NoError(t, err, "Error reading bid match")
+		assert.Equal(t, 3.0, bidMatch.SettledBidUnits, "Settled units mismatch")
+		assert.Equal(t, 6.0, bidMatch.NetworkCharge, "Network charge mismatch")
+
+		// A corrected settlement replaces the units of the energy bid instead of adding to them.
+		assertOK(t, invoke(stub, "10", "ProcessEnergyBid", "E1", "M1", "4", "4", "0", "0", "4", "4", "0", "0", "0", "corrected"))
+
+		bidMatch, err = getBidMatch(stub, "M1")
+		assert.NoError(t, err, "Error reading bid match")
+		assert.Equal(t, 4.0, bidMatch.SettledBidUnits, "Settled units mismatch")
+		assert.Equal(t, 8.0, bidMatch.NetworkCharge, "Network charge mismatch")
+	})
+
+	// Test Case 5: Zone IDs containing underscores don't share a tariff
+	t.Run("Tariff Keys", func(t *testing.T) {
+		assertOK(t, invoke(stub, "11", "SetNetworkTariff", "a", "b_c", "1"))
+		assertOK(t, invoke(stub, "12", "SetNetworkTariff", "a_b", "c", "3"))
+
+		response := invoke(stub, "13", "ReadNetworkTariff", "a", "b_c")
+		assertOK(t, response)
+		var tariff NetworkTariff
+		_ = json.Unmarshal(response.GetPayload(), &tariff)
+		assert.Equal(t, 1.0, tariff.ChargePerUnit, "Tariff was overwritten")
+	})
+}
@@ -0,0 +1,343 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                              Invoice Methods                               */
+/* -------------------------------------------------------------------------- */
+
+// GenerateInvoice aggregates a participant's settled matches, grid portions, fees, penalties and
+// refunds of a closed billing period into an Invoice. An invoice is never regenerated, so a
+// period can only be invoiced once. Only admins can generate invoices; the operator org reads
+// the fees and penalties from the private payment details. The invoice is stored in the
+// collection of the participant's org; the response and the InvoiceGenerated event carry its
+// public PrivateDataHash.
+//
+// Inputs - Array of strings
+//
+//	   0    ,    1
+//	userID  , period (YYYY-MM)
+func GenerateInvoice(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting GenerateInvoice")
+
+	if len(args) != 2 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 2 (UserID, Period)")
+	}
+	err := sanitize_arguments(args)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = requireRole(stub, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	userID := args[0]
+	ownerMSPID, err := getUserMSPID(stub, userID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	periodStart, err := time.Parse("2006-01", args[1])
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse Period, expecting YYYY-MM: "+err.Error())
+	}
+	start := periodStart.Unix()
+	end := periodStart.AddDate(0, 1, 0).Unix()
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if end > now {
+		return statusResponse(StatusInvalidArgument, "Billing period "+args[1]+" has not ended yet.")
+	}
+
+	invoiceKey := getInvoiceKey(userID, args[1])
+	existingAsBytes, err := stub.GetState(invoiceKey)
+	if err != nil {
+		return shim.Error("Error accessing state: " + err.Error())
+	}
+	if existingAsBytes != nil {
+		return statusResponse(StatusConflict, "Invoice for user "+userID+" and period "+args[1]+" has already been generated.")
+	}
+
+	var invoice Invoice
+	invoice.ID = invoiceKey
+	invoice.UserID = userID
+	invoice.Period = args[1]
+	invoice.LineItems = []InvoiceLineItem{}
+
+	settlementItems, err := getSettlementLineItems(stub, userID, start, end)
+	if err != nil {
+		return errorResponse(err)
+	}
+	paymentItems, err := getPaymentLineItems(stub, userID, start, end)
+	if err != nil {
+		return errorResponse(err)
+	}
+	invoice.LineItems = append(invoice.LineItems, settlementItems...)
+	invoice.LineItems = append(invoice.LineItems, paymentItems...)
+
+	for _, item := range invoice.LineItems {
+		invoice.Total += item.Amount
+	}
+	invoice.Total = roundAmount(invoice.Total)
+	invoice.ContentHash = getInvoiceContentHash(&invoice)
+	invoice.CreatedOn = now
+	invoice.TxID = stub.GetTxID()
+
+	invoiceHash, err := putPrivateInvoice(stub, ownerMSPID, &invoice)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	invoiceHashAsBytes, _ := json.Marshal(invoiceHash)
+	err = stub.SetEvent("InvoiceGenerated", invoiceHashAsBytes)
+	if err != nil {
+		return shim.Error("Could not emit InvoiceGenerated event: " + err.Error())
+	}
+
+	fmt.Println("- end GenerateInvoice")
+	return shim.Success(invoiceHashAsBytes)
+}
+
+// ReadInvoice returns the invoice of a participant for a billing period. Only the participant's
+// org and the operator org can read it.
+func ReadInvoice(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadInvoice")
+
+	// We expect 2 arguments: the user ID and the period.
+	if len(args) != 2 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 2.")
+	}
+
+	invoiceHash, err := getInvoiceHash(stub, args[0], args[1])
+	if err != nil {
+		return errorResponse(err)
+	}
+	if !isAuthorizedForPrivateData(stub, invoiceHash.OwnerMSPID) {
+		return statusResponse(StatusUnauthorized, "Caller is not authorized to read the invoice for user "+args[0]+" and period "+args[1]+".")
+	}
+
+	invoiceAsBytes, err := stub.GetPrivateData(invoiceHash.Collection, invoiceHash.ID)
+	if err != nil {
+		return shim.Error("Failed to fetch invoice " + invoiceHash.ID + ": " + err.Error())
+	}
+	if invoiceAsBytes == nil {
+		return statusResponse(StatusNotFound, "Invoice for user "+args[0]+" and period "+args[1]+" not found.")
+	}
+
+	fmt.Println("- end ReadInvoice")
+	return shim.Success(invoiceAsBytes)
+}
+
+// ReadInvoiceHash returns the public PrivateDataHash of an invoice, whose Hash is the
+// ContentHash any counterparty can check a rendered copy against.
+func ReadInvoiceHash(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadInvoiceHash")
+
+	// We expect 2 arguments: the user ID and the period.
+	if len(args) != 2 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 2.")
+	}
+
+	invoiceHash, err := getInvoiceHash(stub, args[0], args[1])
+	if err != nil {
+		return errorResponse(err)
+	}
+	invoiceHashAsBytes, _ := json.Marshal(invoiceHash)
+
+	fmt.Println("- end ReadInvoiceHash")
+	return shim.Success(invoiceHashAsBytes)
+}
+
+func getInvoiceKey(userID string, period string) string {
+	return "Invoice_" + userID + "_" + period
+}
+
+// putPrivateInvoice stores the invoice in the collection of the owner org and its content hash
+// on public state under the same key
+func putPrivateInvoice(stub shim.ChaincodeStubInterface, ownerMSPID string, invoice *Invoice) (PrivateDataHash, error) {
+	collection := getCollectionName(ownerMSPID)
+	invoiceHash := PrivateDataHash{
+		Collection: collection,
+		Hash:       invoice.ContentHash,
+		ID:         invoice.ID,
+		OwnerMSPID: ownerMSPID,
+	}
+
+	invoiceAsBytes, _ := json.Marshal(invoice)
+	err := stub.PutPrivateData(collection, invoice.ID, invoiceAsBytes)
+	if err != nil {
+		return invoiceHash, errors.New("Could not store invoice: " + err.Error())
+	}
+	invoiceHashAsBytes, _ := json.Marshal(invoiceHash)
+	err = stub.PutState(invoice.ID, invoiceHashAsBytes)
+	if err != nil {
+		return invoiceHash, errors.New("Could not store invoice hash: " + err.Error())
+	}
+	return invoiceHash, nil
+}
+
+// getInvoiceHash reads the public PrivateDataHash of an invoice
+func getInvoiceHash(stub shim.ChaincodeStubInterface, userID string, period string) (PrivateDataHash, error) {
+	var invoiceHash PrivateDataHash
+
+	invoiceHashAsBytes, err := stub.GetState(getInvoiceKey(userID, period))
+	if err != nil {
+		return invoiceHash, errors.New("Error accessing state: " + err.Error())
+	}
+	if invoiceHashAsBytes == nil {
+		return invoiceHash, newStatusError(StatusNotFound, "Invoice for user "+userID+" and period "+period+" not found.")
+	}
+
+	err = json.Unmarshal(invoiceHashAsBytes, &invoiceHash)
+	if err != nil {
+		return invoiceHash, errors.New("Failed to unmarshal invoice hash: " + err.Error())
+	}
+	return invoiceHash, nil
+}
+
+// getInvoiceContentHash hashes the billed content of an invoice
+func getInvoiceContentHash(invoice *Invoice) string {
+	content, _ := json.Marshal(struct {
+		UserID    string            `json:"userId"`
+		Period    string            `json:"period"`
+		LineItems []InvoiceLineItem `json:"lineItems"`
+		Total     float64           `json:"total"`
+	}{invoice.UserID, invoice.Period, invoice.LineItems, invoice.Total})
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
+
+// getSettlementLineItems bills the energy bids first settled in the period on matches of the
+// user. Grid portions are listed by quantity only, they are billed by the grid operator.
+func getSettlementLineItems(stub shim.ChaincodeStubInterface, userID string, start int64, end int64) ([]InvoiceLineItem, error) {
+	energyBids, err := getStatesByPrefix(stub, "EnergyBid_")
+	if err != nil {
+		return nil, errors.New("Failed to scan energy bids: " + err.Error())
+	}
+
+	var items []InvoiceLineItem
+	for _, kv := range energyBids {
+		var energyBid EnergyBid
+		err = json.Unmarshal(kv.Value, &energyBid)
+		if err != nil {
+			return nil, errors.New("Failed to unmarshal energy bid " + kv.Key + ": " + err.Error())
+		}
+		// Energy bids settled before SettledOn was recorded fall back to CreatedOn.
+		settledOn := energyBid.SettledOn
+		if settledOn == 0 {
+			settledOn = energyBid.CreatedOn
+		}
+		if settledOn < start || settledOn >= end {
+			continue
+		}
+		bidMatch, err := getBidMatch(stub, energyBid.BidMatchID)
+		if err != nil {
+			return nil, err
+		}
+		unitPrice := float64(bidMatch.BidUnitPrice)
+
+		if bidMatch.BuyerUserId == userID {
+			items = appendLineItem(items, EnergyPurchaseItem, energyBid.ID, energyBid.BuyerBroughtUnitFromSeller, unitPrice, energyBid.BuyerBroughtUnitFromSeller*unitPrice)
+			items = appendLineItem(items, GridImportItem, energyBid.ID, energyBid.BuyerBroughtUnitFromGrid, 0, 0)
+			items = appendLineItem(items, GridExportItem, energyBid.ID, energyBid.BuyerSoldUnitToGrid, 0, 0)
+			items = appendLineItem(items, SettlementFeeItem, energyBid.ID, 1, energyBid.PlatformFee, energyBid.PlatformFee)
+			items = appendLineItem(items, NetworkChargeItem, energyBid.ID, energyBid.BuyerBroughtUnitFromSeller, bidMatch.NetworkChargePerUnit,
+				energyBid.BuyerBroughtUnitFromSeller*bidMatch.NetworkChargePerUnit)
+		}
+		if bidMatch.SellerUserId == userID {
+			items = appendLineItem(items, EnergySaleItem, energyBid.ID, energyBid.SellerSoldUnitToBuyer, unitPrice, -energyBid.SellerSoldUnitToBuyer*unitPrice)
+			items = appendLineItem(items, GridExportItem, energyBid.ID, energyBid.SellerSoldUnitToGrid, 0, 0)
+		}
+	}
+	return items, nil
+}
+
+// getPaymentLineItems bills the fees and penalties of the user's payments in the period and
+// credits the refunds
+func getPaymentLineItems(stub shim.ChaincodeStubInterface, userID string, start int64, end int64) ([]InvoiceLineItem, error) {
+	payments, err := getIndexedPayments(stub, PaymentsByUserIndex, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []InvoiceLineItem
+	for _, payment := range payments {
+		if payment.CreatedOn < start || payment.CreatedOn >= end {
+			continue
+		}
+		if payment.PaymentType == RefundPaymentType {
+			items = appendLineItem(items, RefundItem, payment.ID, 1, payment.TotalAmount, -payment.TotalAmount)
+			continue
+		}
+		if payment.PaymentType == AdjustmentPaymentType {
+			items = appendLineItem(items, AdjustmentItem, payment.ID, 1, payment.TotalAmount, payment.TotalAmount)
+			continue
+		}
+
+		pdHash, err := getPaymentDetailHash(stub, payment.PaymentDetailID)
+		if err != nil {
+			return nil, err
+		}
+		pd, err := getPrivatePaymentDetail(stub, pdHash)
+		if err != nil {
+			return nil, err
+		}
+		items = appendLineItem(items, PaymentFeeItem, payment.ID, 1, pd.PlatformFee, pd.PlatformFee)
+		items = appendLineItem(items, PenaltyItem, payment.ID, 1, pd.PenaltyFromSeller, pd.PenaltyFromSeller)
+	}
+	return items, nil
+}
+
+// appendLineItem adds a line item unless there is nothing to bill. Grid items are listed by
+// quantity, the others only when they have an amount.
+func appendLineItem(items []InvoiceLineItem, itemType string, reference string, quantity float64, unitPrice float64, amount float64) []InvoiceLineItem {
+	if quantity == 0 || (amount == 0 && itemType != GridImportItem && itemType != GridExportItem) {
+		return items
+	}
+	return append(items, InvoiceLineItem{
+		Amount:    roundAmount(amount),
+		Quantity:  quantity,
+		Reference: reference,
+		Type:      itemType,
+		UnitPrice: unitPrice,
+	})
+}
+
+// roundAmount rounds money amounts to cents
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,128 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateInvoice(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+
+	// Records of the previous month, which is a closed billing period.
+	now := time.Now().UTC()
+	lastMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	period := lastMonth.Format("2006-01")
+	createdOn := lastMonth.Add(time.Hour).Unix()
+
+	stub.MockTransactionStart("setup")
+	for _, id := range []string{"6", "7"} {
+		userAsBytes, _ := json.Marshal(User{ID: id, MSPID: "Org2MSP"})
+		_ = stub.PutState(id, userAsBytes)
+	}
+	bidMatchAsBytes, _ := json.Marshal(BidMatch{ID: "BidMatch1", BidUnitPrice: 10, BuyerUserId: "6", SellerUserId: "7"})
+	_ = stub.PutState("BidMatch_BidMatch1", bidMatchAsBytes)
+	// The energy bid was reprocessed this month, it is still billed in the month it was settled.
+	energyBidAsBytes, _ := json.Marshal(EnergyBid{
+		ID: "EnergyBid1", BidMatchID: "BidMatch1", BuyerBroughtUnitFromSeller: 5, SellerSoldUnitToBuyer: 5,
+		BuyerBroughtUnitFromGrid: 2, PlatformFee: 1.5, CreatedOn: now.Unix(), SettledOn: createdOn,
+	})
+	_ = stub.PutState("EnergyBid_EnergyBid1", energyBidAsBytes)
+	err := putPrivatePaymentDetail(stub, "Org2MSP", PrivatePaymentDetail{PaymentDetail: PaymentDetail{ID: "2", PlatformFee: 0.5}, Salt: "salt"})
+	assert.NoError(t, err, "Error storing payment detail")
+	for _, payment := range []Payment{
+		{ID: "1", PaymentDetailID: "2", TotalAmount: 50, UserID: "6", CreatedOn: createdOn},
+		{ID: "R1", PaymentType: RefundPaymentType, TotalAmount: 2, UserID: "6", CreatedOn: createdOn},
+	} {
+		paymentAsBytes, _ := json.Marshal(payment)
+		_ = stub.PutState("Payment_"+payment.ID, paymentAsBytes)
+		err = putPaymentIndexes(stub, &payment)
+		assert.NoError(t, err, "Error indexing payment")
+	}
+	stub.MockTransactionEnd("setup")
+
+	// Test Case 1: Callers without the admin role are rejected
+	t.Run("Non-admin Caller", func(t *testing.T) {
+		stub.Creator = newCreator(t, "Org2MSP", nil)
+		response := stub.MockInvoke("1", [][]byte{[]byte("GenerateInvoice"), []byte("6"), []byte(period)})
+
+		assert.Equal(t, StatusUnauthorized, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not authorized")
+	})
+
+	// Test Case 2: The buyer is billed for energy, fees and credited for refunds
+	t.Run("Successfully Generate Invoice", func(t *testing.T) {
+		stub.Creator = newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+		response := stub.MockInvoke("2", [][]byte{[]byte("GenerateInvoice"), []byte("6"), []byte(period)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		<-stub.ChaincodeEventsChannel
+
+		var invoiceHash PrivateDataHash
+		err := json.Unmarshal(response.GetPayload(), &invoiceHash)
+		assert.NoError(t, err, "Error unmarshalling invoice hash")
+		assert.Equal(t, "Org2MSPPrivateCollection", invoiceHash.Collection, "Collection mismatch")
+
+		response = stub.MockInvoke("2a", [][]byte{[]byte("ReadInvoice"), []byte("6"), []byte(period)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		var invoice Invoice
+		err = json.Unmarshal(response.GetPayload(), &invoice)
+		assert.NoError(t, err, "Error unmarshalling invoice")
+
+		var types []string
+		for _, item := range invoice.LineItems {
+			types = append(types, item.Type)
+		}
+		assert.Equal(t, []string{EnergyPurchaseItem, GridImportItem, SettlementFeeItem, PaymentFeeItem, RefundItem}, types, "Line items mismatch")
+		assert.Equal(t, 50.0, invoice.Total, "Invoice total mismatch")
+		assert.Equal(t, getInvoiceContentHash(&invoice), invoice.ContentHash, "Content hash mismatch")
+		assert.Equal(t, invoice.ContentHash, invoiceHash.Hash, "Public hash mismatch")
+	})
+
+	// Test Case 2.1: Only the content hash is public, other orgs cannot read the invoice
+	t.Run("Private Invoice", func(t *testing.T) {
+		invoiceAsBytes, _ := stub.GetState(getInvoiceKey("6", period))
+		assert.NotContains(t, string(invoiceAsBytes), "lineItems", "Invoice content stored on public state")
+
+		stub.Creator = newCreator(t, "Org3MSP", nil)
+		defer func() { stub.Creator = newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole}) }()
+		response := stub.MockInvoke("2b", [][]byte{[]byte("ReadInvoice"), []byte("6"), []byte(period)})
+		assert.Equal(t, StatusUnauthorized, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not authorized")
+
+		response = stub.MockInvoke("2c", [][]byte{[]byte("ReadInvoiceHash"), []byte("6"), []byte(period)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	})
+
+	// Test Case 3: A period is invoiced only once
+	t.Run("Duplicate Invoice", func(t *testing.T) {
+		response := stub.MockInvoke("3", [][]byte{[]byte("GenerateInvoice"), []byte("6"), []byte(period)})
+
+		assert.Equal(t, StatusConflict, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "already been generated")
+	})
+
+	// Test Case 4: The seller is credited for the energy sold
+	t.Run("Seller Invoice", func(t *testing.T) {
+		response := stub.MockInvoke("4", [][]byte{[]byte("GenerateInvoice"), []byte("7"), []byte(period)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		<-stub.ChaincodeEventsChannel
+
+		response = stub.MockInvoke("5", [][]byte{[]byte("ReadInvoice"), []byte("7"), []byte(period)})
+		var invoice Invoice
+		_ = json.Unmarshal(response.GetPayload(), &invoice)
+		assert.Equal(t, -50.0, invoice.Total, "Invoice total mismatch")
+	})
+
+	// Test Case 5: Open periods can not be invoiced
+	t.Run("Open Billing Period", func(t *testing.T) {
+		response := stub.MockInvoke("6", [][]byte{[]byte("GenerateInvoice"), []byte("6"), []byte(now.Format("2006-01"))})
+
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "has not ended")
+	})
+}
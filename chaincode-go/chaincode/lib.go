@@ -0,0 +1,250 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// ==============================================================
+// Response Statuses - rejected invocations report why they were
+// rejected; peers treat every status from 400 up as an error
+// ==============================================================
+
+const StatusInvalidArgument int32 = 400
+const StatusUnauthorized int32 = 403
+const StatusNotFound int32 = 404
+const StatusConflict int32 = 409
+
+// statusError is an error reported with a response status other than shim.ERROR
+type statusError struct {
+	message string
+	status  int32
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+// newStatusError returns an error that errorResponse reports with the given status
+func newStatusError(status int32, message string) error {
+	return &statusError{message: message, status: status}
+}
+
+// prefixError prefixes the message of an error, keeping the status of a statusError
+func prefixError(prefix string, err error) error {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return newStatusError(statusErr.status, prefix+statusErr.message)
+	}
+	return errors.New(prefix + err.Error())
+}
+
+// isStatus reports whether err is a statusError with the given status
+func isStatus(err error, status int32) bool {
+	var statusErr *statusError
+	return errors.As(err, &statusErr) && statusErr.status == status
+}
+
+// statusResponse rejects an invocation with the given status
+func statusResponse(status int32, message string) pb.Response {
+	return pb.Response{Status: status, Message: message}
+}
+
+// errorResponse rejects an invocation with the status of err, or with shim.ERROR for errors
+// of the ledger and of stored records
+func errorResponse(err error) pb.Response {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusResponse(statusErr.status, statusErr.message)
+	}
+	return shim.Error(err.Error())
+}
+
+// ==============================================================
+// Input Sanitation - dumb input checking, look for empty strings
+// ==============================================================
+func sanitize_arguments(strs []string) error {
+	for i, val := range strs {
+		if len(val) <= 0 {
+			return newStatusError(StatusInvalidArgument, "Argument "+strconv.Itoa(i)+" must be a non-empty string")
+		}
+		if len(val) > 256 {
+			errMsg := "Argument " + strconv.Itoa(i) + " must be <= 256 characters"
+			return newStatusError(StatusInvalidArgument, errMsg)
+		}
+	}
+	return nil
+}
+
+// ==============================================================
+// State Scans - records are stored under "<Type>_<ID>" keys
+// ==============================================================
+
+// getStatesByPrefix returns every key starting with prefix together with its value.
+// The results are read completely before returning so callers may update the keys.
+func getStatesByPrefix(stub shim.ChaincodeStubInterface, prefix string) ([]*queryresult.KV, error) {
+	iterator, err := stub.GetStateByRange(prefix, prefix+string(utf8.MaxRune))
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	var results []*queryresult.KV
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, kv)
+	}
+	return results, nil
+}
+
+// ==============================================================
+// Transaction Time
+// ==============================================================
+
+// getTxTime returns the transaction timestamp in Unix seconds. Unlike time.Now() it is the
+// same on every endorsing peer, so it is used wherever a decision depends on the current time.
+func getTxTime(stub shim.ChaincodeStubInterface) (int64, error) {
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return 0, errors.New("Failed to get transaction timestamp: " + err.Error())
+	}
+	return timestamp.GetSeconds(), nil
+}
+
+// ==============================================================
+// Access Control - roles are issued by the Fabric CA as the
+// "role" attribute of the caller's enrollment certificate
+// ==============================================================
+
+const RoleAttribute = "role"
+const AdminRole = "admin"
+const GridOperatorRole = "gridOperator"
+const ArbiterRole = "arbiter"
+const OracleRole = "oracle"
+
+// requireRole fails unless the invoking identity carries the given role
+func requireRole(stub shim.ChaincodeStubInterface, role string) error {
+	err := cid.AssertAttributeValue(stub, RoleAttribute, role)
+	if err != nil {
+		return newStatusError(StatusUnauthorized, "Caller is not authorized, role "+role+" required: "+err.Error())
+	}
+	return nil
+}
+
+// requireUserOrRole fails unless the invoking identity belongs to the user's org or carries the role
+func requireUserOrRole(stub shim.ChaincodeStubInterface, userID string, role string) error {
+	if requireRole(stub, role) == nil {
+		return nil
+	}
+	userMSPID, err := getUserMSPID(stub, userID)
+	if err != nil {
+		return err
+	}
+	callerMSPID, err := getCallerMSPID(stub)
+	if err != nil {
+		return err
+	}
+	if callerMSPID != userMSPID {
+		return newStatusError(StatusUnauthorized, "Caller is not authorized, only identities of "+userMSPID+" or role "+role+" can act for user "+userID)
+	}
+	return nil
+}
+
+// containsString reports whether the slice holds the value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ==============================================================
+// Arithmetic functions to check for overflow and underflow
+// ==============================================================
+
+// add two number checking for overflow
+func add(b int, q int) (int, error) {
+
+	// Check overflow
+	var sum int
+	sum = q + b
+
+	if (sum < q) == (b >= 0 && q >= 0) {
+		return 0, fmt.Errorf("Math: addition overflow occurred %d + %d", b, q)
+	}
+
+	return sum, nil
+}
+
+// sub two number checking for overflow
+func sub(b int, q int) (int, error) {
+
+	// Check overflow
+	var diff int
+	diff = b - q
+
+	if (diff > b) == (b >= 0 && q >= 0) {
+		return 0, fmt.Errorf("Math: Subtraction overflow occurred  %d - %d", b, q)
+	}
+
+	return diff, nil
+}
+
+// add two float numbers checking for overflow
+func addFloat(b float32, q float32) (float32, error) {
+
+	// Check overflow
+	var sum float32
+	sum = q + b
+
+	if (sum < q) == (b >= 0 && q >= 0) {
+		return 0, fmt.Errorf("Math: addition overflow occurred %f + %f", b, q)
+	}
+
+	return sum, nil
+}
+
+// sub two float numbers checking for overflow
+func subFloat(b float32, q float32) (float32, error) {
+
+	// Check overflow
+	var diff float32
+	diff = b - q
+
+	if (diff > b) == (b >= 0 && q >= 0) {
+		return 0, fmt.Errorf("Math: Subtraction overflow occurred  %f - %f", b, q)
+	}
+
+	return diff, nil
+}
@@ -0,0 +1,445 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                               Oracle Methods                               */
+/* -------------------------------------------------------------------------- */
+
+// RegisterPriceOracle registers an oracle identity, or replaces the org, key and markets of a
+// registered one and reactivates it. Only admins can register oracles.
+//
+// Inputs - Array of strings
+//
+//	   0     ,   1   ,       2       ,              3
+//	OracleID , MSPID , PublicKey PEM , Markets (JSON array of market names)
+func RegisterPriceOracle(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting RegisterPriceOracle")
+
+	if len(args) != 4 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 4.")
+	}
+
+	err := requireRole(stub, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = sanitize_arguments(args[:3])
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Invalid argument: "+err.Error())
+	}
+	_, err = parsePublicKey(args[2])
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Invalid public key: "+err.Error())
+	}
+	var markets []string
+	err = json.Unmarshal([]byte(args[3]), &markets)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to unmarshal Markets: "+err.Error())
+	}
+	if len(markets) == 0 {
+		return statusResponse(StatusInvalidArgument, "At least one market is required.")
+	}
+
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	// Registering an existing oracle updates it and keeps its creation time.
+	oracleAsBytes, err := stub.GetState("PriceOracle_" + args[0])
+	if err != nil {
+		return shim.Error("Error accessing state: " + err.Error())
+	}
+	oracle := &PriceOracle{CreatedOn: now, ID: args[0]}
+	if oracleAsBytes != nil {
+		err = json.Unmarshal(oracleAsBytes, oracle)
+		if err != nil {
+			return shim.Error("Failed to unmarshal price oracle: " + err.Error())
+		}
+	}
+	oracle.Markets = markets
+	oracle.MSPID = args[1]
+	oracle.PublicKey = args[2]
+	oracle.Status = OracleActive
+	oracle.UpdatedOn = now
+
+	err = putPriceOracle(stub, oracle)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end RegisterPriceOracle")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// RevokePriceOracle stops an oracle from publishing. Its past publications stay valid.
+// Only admins can revoke oracles.
+//
+// Inputs - Array of strings
+//
+//	   0
+//	OracleID
+func RevokePriceOracle(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting RevokePriceOracle")
+
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	err := requireRole(stub, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	oracle, err := getPriceOracle(stub, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+	oracle.Status = OracleRevoked
+	oracle.UpdatedOn, err = getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = putPriceOracle(stub, oracle)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end RevokePriceOracle")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// PublishReferencePrice publishes the reference price of a slot in a market. The caller has to
+// carry the oracle role and belong to the org of an active oracle registered for the market, and
+// the price has to be signed with the oracle's key over "<PriceID>|<Market>|<SlotID>|<Price>",
+// Price as passed. The publication supersedes the previous one of the slot and market, and is
+// rejected when it deviates from it by more than MaxPriceDeviation.
+//
+// Inputs - Array of strings
+//
+//	   0    ,    1     ,   2    ,   3    ,   4   ,     5
+//	PriceID , OracleID , Market , SlotID , Price , Signature (base64)
+func PublishReferencePrice(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting PublishReferencePrice")
+
+	if len(args) != 6 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 6.")
+	}
+
+	err := sanitize_arguments(args)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Invalid argument: "+err.Error())
+	}
+	priceID, market, slotID := args[0], args[2], args[3]
+
+	err = requireRole(stub, OracleRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+	oracle, err := getPriceOracle(stub, args[1])
+	if err != nil {
+		return errorResponse(err)
+	}
+	if oracle.Status != OracleActive {
+		return shim.Error("Oracle " + oracle.ID + " is " + oracle.Status + ".")
+	}
+	callerMSPID, err := getCallerMSPID(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if callerMSPID != oracle.MSPID {
+		return statusResponse(StatusUnauthorized, "Caller is not authorized, only identities of "+oracle.MSPID+" can publish for oracle "+oracle.ID+".")
+	}
+	if !containsString(oracle.Markets, market) {
+		return shim.Error("Oracle " + oracle.ID + " is not registered for market " + market + ".")
+	}
+
+	existingPriceAsBytes, err := stub.GetState("ReferencePrice_" + priceID)
+	if err != nil {
+		return shim.Error("Error accessing state: " + err.Error())
+	}
+	if existingPriceAsBytes != nil {
+		return statusResponse(StatusConflict, "Reference price with ID "+priceID+" already exists.")
+	}
+
+	price, err := strconv.ParseFloat(args[4], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse Price: "+err.Error())
+	}
+	if price <= 0 {
+		return statusResponse(StatusInvalidArgument, "Price must be positive.")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(args[5])
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to decode signature: "+err.Error())
+	}
+	message := strings.Join([]string{priceID, market, slotID, args[4]}, "|")
+	err = verifySignature(oracle.PublicKey, []byte(message), signature)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Signature of oracle "+oracle.ID+" over reference price "+priceID+" is invalid: "+err.Error())
+	}
+
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	referencePrice := ReferencePrice{
+		CreatedOn: now,
+		ID:        priceID,
+		Market:    market,
+		OracleID:  oracle.ID,
+		Price:     price,
+		Signature: args[5],
+		SlotID:    slotID,
+	}
+
+	// The price may only move within the configured bounds of the price it supersedes.
+	previous, err := getLatestReferencePrice(stub, market, slotID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if previous != nil {
+		config, err := getMarketConfig(stub)
+		if err != nil {
+			return shim.Error("Failed to load market config: " + err.Error())
+		}
+		deviation := math.Abs(price-previous.Price) / previous.Price
+		if config.MaxPriceDeviation > 0 && deviation > config.MaxPriceDeviation {
+			return shim.Error("Reference price " + args[4] + " deviates more than " + strconv.FormatFloat(config.MaxPriceDeviation*100, 'f', -1, 64) + "% from " + previous.ID + ".")
+		}
+		referencePrice.PreviousPriceID = previous.ID
+	}
+
+	priceAsBytes, _ := json.Marshal(referencePrice)
+	err = stub.PutState("ReferencePrice_"+referencePrice.ID, priceAsBytes)
+	if err != nil {
+		return shim.Error("Could not store reference price: " + err.Error())
+	}
+	latestKey, err := getLatestReferencePriceKey(stub, market, slotID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	err = stub.PutState(latestKey, []byte(referencePrice.ID))
+	if err != nil {
+		return shim.Error("Could not store latest reference price: " + err.Error())
+	}
+
+	err = stub.SetEvent("ReferencePricePublished", priceAsBytes)
+	if err != nil {
+		return shim.Error("Could not emit ReferencePricePublished event: " + err.Error())
+	}
+
+	fmt.Println("- end PublishReferencePrice")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// ReadReferencePrice returns a reference price by its ID, or the latest reference price of a
+// slot in a market.
+//
+// Inputs - Array of strings
+//
+//	   0
+//	PriceID
+//
+//	   0   ,   1
+//	Market , SlotID
+func ReadReferencePrice(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadReferencePrice")
+
+	var referencePrice *ReferencePrice
+	var err error
+	if len(args) == 1 {
+		referencePrice, err = getReferencePrice(stub, args[0])
+	} else if len(args) == 2 {
+		referencePrice, err = getLatestReferencePrice(stub, args[0], args[1])
+		if err == nil && referencePrice == nil {
+			err = newStatusError(StatusNotFound, "No reference price published for slot "+args[1]+" in market "+args[0]+".")
+		}
+	} else {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1 or 2.")
+	}
+	if err != nil {
+		return errorResponse(err)
+	}
+	priceAsBytes, _ := json.Marshal(referencePrice)
+
+	fmt.Println("- end ReadReferencePrice")
+	return shim.Success(priceAsBytes)
+}
+
+// ReadReferencePriceHistory returns all reference prices published for a slot in a market,
+// oldest first.
+//
+// Inputs - Array of strings
+//
+//	   0   ,   1
+//	Market , SlotID
+func ReadReferencePriceHistory(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadReferencePriceHistory")
+
+	if len(args) != 2 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 2.")
+	}
+
+	history := []ReferencePrice{}
+	referencePrice, err := getLatestReferencePrice(stub, args[0], args[1])
+	for err == nil && referencePrice != nil {
+		history = append([]ReferencePrice{*referencePrice}, history...)
+		if referencePrice.PreviousPriceID == "" {
+			break
+		}
+		referencePrice, err = getReferencePrice(stub, referencePrice.PreviousPriceID)
+	}
+	if err != nil {
+		return errorResponse(err)
+	}
+	historyAsBytes, _ := json.Marshal(history)
+
+	fmt.Println("- end ReadReferencePriceHistory")
+	return shim.Success(historyAsBytes)
+}
+
+// getPriceOracle reads a registered oracle
+func getPriceOracle(stub shim.ChaincodeStubInterface, oracleID string) (*PriceOracle, error) {
+	oracleAsBytes, err := stub.GetState("PriceOracle_" + oracleID)
+	if err != nil {
+		return nil, errors.New("Error accessing state: " + err.Error())
+	}
+	if oracleAsBytes == nil {
+		return nil, newStatusError(StatusNotFound, "Price oracle with ID "+oracleID+" not found.")
+	}
+	var oracle PriceOracle
+	err = json.Unmarshal(oracleAsBytes, &oracle)
+	if err != nil {
+		return nil, errors.New("Failed to unmarshal price oracle: " + err.Error())
+	}
+	return &oracle, nil
+}
+
+// putPriceOracle stores an oracle
+func putPriceOracle(stub shim.ChaincodeStubInterface, oracle *PriceOracle) error {
+	oracleAsBytes, _ := json.Marshal(oracle)
+	err := stub.PutState("PriceOracle_"+oracle.ID, oracleAsBytes)
+	if err != nil {
+		return errors.New("Could not store price oracle: " + err.Error())
+	}
+	return nil
+}
+
+// getReferencePrice reads a published reference price
+func getReferencePrice(stub shim.ChaincodeStubInterface, priceID string) (*ReferencePrice, error) {
+	priceAsBytes, err := stub.GetState("ReferencePrice_" + priceID)
+	if err != nil {
+		return nil, errors.New("Error accessing state: " + err.Error())
+	}
+	if priceAsBytes == nil {
+		return nil, newStatusError(StatusNotFound, "Reference price with ID "+priceID+" not found.")
+	}
+	var referencePrice ReferencePrice
+	err = json.Unmarshal(priceAsBytes, &referencePrice)
+	if err != nil {
+		return nil, errors.New("Failed to unmarshal reference price: " + err.Error())
+	}
+	return &referencePrice, nil
+}
+
+// getLatestReferencePriceKey returns the composite state key pointing to the latest reference
+// price of a slot in a market, so markets and slots containing separators can not collide
+func getLatestReferencePriceKey(stub shim.ChaincodeStubInterface, market string, slotID string) (string, error) {
+	key, err := stub.CreateCompositeKey("LatestReferencePrice", []string{market, slotID})
+	if err != nil {
+		return "", newStatusError(StatusInvalidArgument, "Invalid market or slot: "+err.Error())
+	}
+	return key, nil
+}
+
+// getLatestReferencePrice reads the latest reference price of a slot in a market, nil when none
+// was published
+func getLatestReferencePrice(stub shim.ChaincodeStubInterface, market string, slotID string) (*ReferencePrice, error) {
+	latestKey, err := getLatestReferencePriceKey(stub, market, slotID)
+	if err != nil {
+		return nil, err
+	}
+	priceIDAsBytes, err := stub.GetState(latestKey)
+	if err != nil {
+		return nil, errors.New("Error accessing state: " + err.Error())
+	}
+	if priceIDAsBytes == nil {
+		return nil, nil
+	}
+	return getReferencePrice(stub, string(priceIDAsBytes))
+}
+
+// checkReferencePrice fails unless the reference price exists and was published for the slot in
+// the configured market. Records without a reference price are accepted.
+func checkReferencePrice(stub shim.ChaincodeStubInterface, priceID string, slotID string) error {
+	if priceID == "" {
+		return nil
+	}
+	referencePrice, err := getReferencePrice(stub, priceID)
+	if err != nil {
+		return err
+	}
+	if referencePrice.SlotID != slotID {
+		return newStatusError(StatusInvalidArgument, "Reference price "+priceID+" was published for slot "+referencePrice.SlotID+", not "+slotID+".")
+	}
+	config, err := getMarketConfig(stub)
+	if err != nil {
+		return errors.New("Failed to load market config: " + err.Error())
+	}
+	if referencePrice.Market != config.Market {
+		return newStatusError(StatusInvalidArgument, "Reference price "+priceID+" was published for market "+referencePrice.Market+", not "+config.Market+".")
+	}
+	return nil
+}
+
+// getOrderReferencePriceID returns the reference price an order was priced against, empty when
+// the order is unknown or has none
+func getOrderReferencePriceID(stub shim.ChaincodeStubInterface, orderID string) (string, error) {
+	orderAsBytes, err := stub.GetState("Order_" + orderID)
+	if err != nil {
+		return "", errors.New("Error accessing state: " + err.Error())
+	}
+	if orderAsBytes == nil {
+		return "", nil
+	}
+	var order Order
+	err = json.Unmarshal(orderAsBytes, &order)
+	if err != nil {
+		return "", errors.New("Failed to unmarshal order: " + err.Error())
+	}
+	return order.ReferencePriceID, nil
+}
@@ -0,0 +1,148 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceOracle(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	admin := newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+	oracleIdentity := newCreator(t, "OracleMSP", map[string]string{RoleAttribute: OracleRole})
+	strangerOracle := newCreator(t, "Org2MSP", map[string]string{RoleAttribute: OracleRole})
+	signingKey, publicKeyPEM := newSigningKey(t)
+	stub.Creator = admin
+
+	enableTrading(t, stub, "150", BuyAction)
+	enableTrading(t, stub, "151", SellAction)
+
+	publish := func(txID string, priceID string, slotID string, price string) pb.Response {
+		signature := signDocumentHash(t, signingKey, strings.Join([]string{priceID, "DayAhead", slotID, price}, "|"))
+		return invoke(stub, txID, "PublishReferencePrice", priceID, "OR1", "DayAhead", slotID, price, signature)
+	}
+
+	// Test Case 1: Only admins register oracles
+	t.Run("Register", func(t *testing.T) {
+		stub.Creator = oracleIdentity
+		response := invoke(stub, "1", "RegisterPriceOracle", "OR1", "OracleMSP", publicKeyPEM, `["DayAhead"]`)
+		assert.Contains(t, response.GetMessage(), "role admin required")
+
+		stub.Creator = admin
+		assertOK(t, invoke(stub, "2", "RegisterPriceOracle", "OR1", "OracleMSP", publicKeyPEM, `["DayAhead"]`))
+		assertOK(t, invoke(stub, "3", "UpdateMarketConfig", `{"maxPriceDeviation": 0.2}`))
+	})
+
+	// Test Case 2: Publications need the oracle's org, role, market and signature
+	t.Run("Publish", func(t *testing.T) {
+		stub.Creator = admin
+		response := publish("4", "P0", "slot1", "10")
+		assert.Contains(t, response.GetMessage(), "role oracle required")
+
+		stub.Creator = strangerOracle
+		response = publish("5", "P0", "slot1", "10")
+		assert.Contains(t, response.GetMessage(), "only identities of OracleMSP can publish")
+
+		stub.Creator = oracleIdentity
+		response = invoke(stub, "6", "PublishReferencePrice", "P0", "OR1", "DayAhead", "slot1", "10", signDocumentHash(t, signingKey, "P0|DayAhead|slot1|12"))
+		assert.Contains(t, response.GetMessage(), "is invalid")
+		response = invoke(stub, "7", "PublishReferencePrice", "P0", "OR1", "Intraday", "slot1", "10", signDocumentHash(t, signingKey, "P0|Intraday|slot1|10"))
+		assert.Contains(t, response.GetMessage(), "is not registered for market Intraday")
+
+		assertOK(t, publish("8", "P1", "slot1", "10"))
+		event := <-stub.ChaincodeEventsChannel
+		assert.Equal(t, "ReferencePricePublished", event.GetEventName(), "Event name mismatch")
+
+		response = publish("9", "P1", "slot1", "11")
+		assert.Contains(t, response.GetMessage(), "already exists")
+	})
+
+	// Test Case 3: Publications out of the deviation bounds are rejected, others kept in history
+	t.Run("History", func(t *testing.T) {
+		stub.Creator = oracleIdentity
+		response := publish("10", "P2", "slot1", "12.5")
+		assert.Contains(t, response.GetMessage(), "deviates more than 20% from P1")
+
+		assertOK(t, publish("11", "P2", "slot1", "11.5"))
+		<-stub.ChaincodeEventsChannel
+
+		response = invoke(stub, "12", "ReadReferencePrice", "DayAhead", "slot1")
+		assertOK(t, response)
+		var latest ReferencePrice
+		json.Unmarshal(response.GetPayload(), &latest)
+		assert.Equal(t, "P2", latest.ID, "Latest price mismatch")
+		assert.Equal(t, "P1", latest.PreviousPriceID, "Previous price mismatch")
+
+		response = invoke(stub, "13", "ReadReferencePriceHistory", "DayAhead", "slot1")
+		assertOK(t, response)
+		var history []ReferencePrice
+		json.Unmarshal(response.GetPayload(), &history)
+		assert.Len(t, history, 2, "History length mismatch")
+		assert.Equal(t, 10.0, history[0].Price, "Oldest price mismatch")
+	})
+
+	// Test Case 4: Orders and settlement reference the price by ID
+	t.Run("References", func(t *testing.T) {
+		stub.Creator = admin
+		response := invoke(stub, "14", "RegisterOrder", "", "BidCreated", "B1", "false", "100", "", "slot2", "10", "10", "150", "4102444800",
+			BuyAction, LimitOrder, GoodTillSlot, "0", "P2")
+		assert.Contains(t, response.GetMessage(), "was published for slot slot1, not slot2")
+
+		assertOK(t, invoke(stub, "15", "RegisterOrder", "", "BidCreated", "B1", "false", "100", "", "slot1", "10", "10", "150", "4102444800",
+			BuyAction, LimitOrder, GoodTillSlot, "0", "P2"))
+		assertOK(t, invoke(stub, "16", "RegisterOrder", "", "BidCreated", "S1", "false", "50", "", "slot1", "5", "10", "151", "4102444800", SellAction))
+		assertOK(t, invoke(stub, "17", "ProcessBidMatch", "1700000000", "slot1", "BidAccepted", "10", "150", "0", "M1", "5", "151", "B1", "S1"))
+		assertOK(t, invoke(stub, "18", "ProcessEnergyBid", "E1", "M1", "5", "5", "0", "0", "5", "5", "0", "0", "0", ""))
+
+		energyBidAsBytes, _ := stub.GetState("EnergyBid_E1")
+		var energyBid EnergyBid
+		json.Unmarshal(energyBidAsBytes, &energyBid)
+		assert.Equal(t, "P2", energyBid.ReferencePriceID, "Settlement reference price mismatch")
+	})
+
+	// Test Case 5: Revoked oracles can not publish
+	t.Run("Revoke", func(t *testing.T) {
+		stub.Creator = admin
+		assertOK(t, invoke(stub, "19", "RevokePriceOracle", "OR1"))
+
+		stub.Creator = oracleIdentity
+		response := publish("20", "P3", "slot2", "10")
+		assert.Contains(t, response.GetMessage(), "Oracle OR1 is Revoked.")
+	})
+
+	// Test Case 6: Registering an existing oracle reactivates it and keeps its creation time
+	t.Run("Register Again", func(t *testing.T) {
+		revoked, err := getPriceOracle(stub, "OR1")
+		assert.NoError(t, err, "Error reading oracle")
+
+		stub.Creator = admin
+		assertOK(t, invoke(stub, "21", "RegisterPriceOracle", "OR1", "OracleMSP", publicKeyPEM, `["DayAhead", "Intraday"]`))
+
+		oracle, err := getPriceOracle(stub, "OR1")
+		assert.NoError(t, err, "Error reading oracle")
+		assert.Equal(t, OracleActive, oracle.Status, "Status mismatch")
+		assert.Equal(t, revoked.CreatedOn, oracle.CreatedOn, "Creation time changed")
+		assert.Equal(t, []string{"DayAhead", "Intraday"}, oracle.Markets, "Markets mismatch")
+	})
+
+	// Test Case 7: Orders can only cite prices of the configured market, kept under composite keys
+	t.Run("Other Market", func(t *testing.T) {
+		stub.Creator = oracleIdentity
+		assertOK(t, invoke(stub, "22", "PublishReferencePrice", "P4", "OR1", "Intraday", "slot1", "10",
+			signDocumentHash(t, signingKey, "P4|Intraday|slot1|10")))
+
+		latestKey, _ := stub.CreateCompositeKey("LatestReferencePrice", []string{"Intraday", "slot1"})
+		priceIDAsBytes, _ := stub.GetState(latestKey)
+		assert.Equal(t, "P4", string(priceIDAsBytes), "Latest reference price mismatch")
+
+		stub.Creator = admin
+		response := invoke(stub, "23", "RegisterOrder", "", "BidCreated", "B2", "false", "100", "", "slot1", "10", "10", "150", "4102444800",
+			BuyAction, LimitOrder, GoodTillSlot, "0", "P4")
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "was published for market Intraday, not DayAhead")
+	})
+}
@@ -0,0 +1,180 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                Order Fills                                 */
+/* -------------------------------------------------------------------------- */
+
+// fillTolerance ignores float rounding when comparing fill quantities
+const fillTolerance = 0.000001
+
+// allocateBidMatch fills the buy and sell orders referenced by a bid match with its
+// OriginalBidUnits at its BidUnitPrice. When the match is processed again, the fills of the
+// previous version are released first and may be re-applied to orders that have closed since,
+// up to the quantity they held. All orders are validated before any is written.
+func allocateBidMatch(stub shim.ChaincodeStubInterface, previous *BidMatch, bidMatch *BidMatch) error {
+	orders := make(map[string]*Order)
+	released := make(map[string]float64)
+	var orderIDs []string
+	loadOrder := func(orderID string) (*Order, error) {
+		if order, ok := orders[orderID]; ok {
+			return order, nil
+		}
+		order, err := getOrder(stub, orderID)
+		if err != nil {
+			return nil, err
+		}
+		orders[orderID] = order
+		orderIDs = append(orderIDs, orderID)
+		return order, nil
+	}
+
+	if previous != nil {
+		for _, orderID := range []string{previous.TransactionBuyID, previous.TransactionSellID} {
+			order, err := loadOrder(orderID)
+			if err != nil {
+				return err
+			}
+			released[orderID] += releaseFill(order, previous)
+		}
+	}
+
+	sides := []struct {
+		orderID string
+		userID  string
+		action  string
+	}{
+		{bidMatch.TransactionBuyID, bidMatch.BuyerUserId, BuyAction},
+		{bidMatch.TransactionSellID, bidMatch.SellerUserId, SellAction},
+	}
+	matched := make([]*Order, len(sides))
+	for i, side := range sides {
+		order, err := loadOrder(side.orderID)
+		if err != nil {
+			return err
+		}
+		if order.UserID != side.userID || order.UserAction != side.action {
+			return errors.New("Order " + order.ID + " is not a " + side.action + " order of user " + side.userID + ".")
+		}
+		matched[i] = order
+	}
+	for i, order := range matched {
+		if isOrderOpen(order) {
+			err := checkFillTerms(stub, order, matched[1-i], bidMatch)
+			if err != nil {
+				return err
+			}
+		}
+		err := applyFill(order, bidMatch, released[order.ID])
+		if err != nil {
+			return err
+		}
+	}
+
+	now, err := getTxTime(stub)
+	if err != nil {
+		return err
+	}
+	for _, orderID := range orderIDs {
+		order := orders[orderID]
+		order.UpdatedOn = now
+		orderAsBytes, _ := json.Marshal(order)
+		err := stub.PutState("Order_"+order.ID, orderAsBytes)
+		if err != nil {
+			return errors.New("Could not store order " + order.ID + ": " + err.Error())
+		}
+	}
+	return nil
+}
+
+// applyFill adds the bid match to the fills of an order, rejecting over-allocation. A closed
+// order only takes back up to the quantity released, the fill the match held before it closed.
+func applyFill(order *Order, bidMatch *BidMatch, released float64) error {
+	quantity := bidMatch.OriginalBidUnits
+	if quantity <= 0 {
+		return errors.New("BidMatch " + bidMatch.ID + " must match a positive quantity.")
+	}
+	if !isOrderOpen(order) {
+		if released <= 0 {
+			return errors.New("Order " + order.ID + " is " + order.BidStatus + " and can not be filled.")
+		}
+		if quantity-released > fillTolerance {
+			return errors.New("Order " + order.ID + " is " + order.BidStatus + ", BidMatch " + bidMatch.ID + " can not fill more than the " +
+				strconv.FormatFloat(released, 'f', -1, 64) + " it held.")
+		}
+	} else if quantity-order.RemainingQuantity > fillTolerance {
+		return errors.New("BidMatch " + bidMatch.ID + " over-allocates Order " + order.ID + ": " +
+			strconv.FormatFloat(quantity, 'f', -1, 64) + " matched, " +
+			strconv.FormatFloat(order.RemainingQuantity, 'f', -1, 64) + " remaining.")
+	}
+
+	value := order.AverageFillPrice*order.FilledQuantity + quantity*float64(bidMatch.BidUnitPrice)
+	order.FilledQuantity += quantity
+	order.AverageFillPrice = value / order.FilledQuantity
+	order.BidMatchIDs = append(order.BidMatchIDs, bidMatch.ID)
+	if !isOrderOpen(order) {
+		return nil
+	}
+	return updateRemainingQuantity(order)
+}
+
+// releaseFill removes a bid match from the fills of an order and returns the quantity released.
+// The remaining quantity of a closed order stays as it closed.
+func releaseFill(order *Order, bidMatch *BidMatch) float64 {
+	for i, id := range order.BidMatchIDs {
+		if id != bidMatch.ID {
+			continue
+		}
+		order.BidMatchIDs = append(order.BidMatchIDs[:i:i], order.BidMatchIDs[i+1:]...)
+
+		value := order.AverageFillPrice*order.FilledQuantity - bidMatch.OriginalBidUnits*float64(bidMatch.BidUnitPrice)
+		order.FilledQuantity -= bidMatch.OriginalBidUnits
+		order.AverageFillPrice = 0
+		if order.FilledQuantity > fillTolerance {
+			order.AverageFillPrice = value / order.FilledQuantity
+		} else {
+			order.FilledQuantity = 0
+		}
+		if isOrderOpen(order) {
+			order.RemainingQuantity = float64(order.TotalQuantity) - order.FilledQuantity
+		}
+		return bidMatch.OriginalBidUnits
+	}
+	return 0
+}
+
+// updateRemainingQuantity derives the remaining quantity, rejecting a total below the filled quantity
+func updateRemainingQuantity(order *Order) error {
+	if order.FilledQuantity-float64(order.TotalQuantity) > fillTolerance {
+		return errors.New("Order " + order.ID + " has " + strconv.FormatFloat(order.FilledQuantity, 'f', -1, 64) +
+			" filled, TotalQuantity can not be lower.")
+	}
+	order.RemainingQuantity = float64(order.TotalQuantity) - order.FilledQuantity
+	return nil
+}
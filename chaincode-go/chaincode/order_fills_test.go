@@ -0,0 +1,100 @@
+package chaincode
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderFills(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+
+	enableTrading(t, stub, "40", BuyAction)
+	enableTrading(t, stub, "41", SellAction)
+	enableTrading(t, stub, "42", SellAction)
+	putOrder(t, stub, Order{ID: "B1", BidStatus: "BidCreated", TotalQuantity: 10, RemainingQuantity: 10, UserID: "40", UserAction: BuyAction})
+	putOrder(t, stub, Order{ID: "S1", BidStatus: "BidCreated", TotalQuantity: 6, RemainingQuantity: 6, UserID: "41", UserAction: SellAction})
+	putOrder(t, stub, Order{ID: "S2", BidStatus: "BidCreated", TotalQuantity: 8, RemainingQuantity: 8, UserID: "42", UserAction: SellAction})
+
+	processBidMatch := func(txID string, bidMatchID string, price string, units string, sellerID string, sellOrderID string) pb.Response {
+		return stub.MockInvoke(txID, [][]byte{
+			[]byte("ProcessBidMatch"), []byte("120"), []byte("slot1"), []byte("BidCreated"), []byte(price), []byte("40"),
+			[]byte("0"), []byte(bidMatchID), []byte(units), []byte(sellerID), []byte("B1"), []byte(sellOrderID),
+		})
+	}
+
+	// Test Case 1: One buy order filled by two sellers
+	t.Run("Partial Fills", func(t *testing.T) {
+		response := processBidMatch("1", "M1", "10", "6", "41", "S1")
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		response = processBidMatch("2", "M2", "20", "2", "42", "S2")
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		order, err := getOrder(stub, "B1")
+		assert.NoError(t, err, "Error reading order")
+		assert.Equal(t, []string{"M1", "M2"}, order.BidMatchIDs, "Bid matches mismatch")
+		assert.Equal(t, 8.0, order.FilledQuantity, "Filled quantity mismatch")
+		assert.Equal(t, 2.0, order.RemainingQuantity, "Remaining quantity mismatch")
+		assert.Equal(t, 12.5, order.AverageFillPrice, "Average fill price mismatch")
+
+		order, _ = getOrder(stub, "S1")
+		assert.Equal(t, 0.0, order.RemainingQuantity, "Remaining quantity mismatch")
+	})
+
+	// Test Case 2: Matching more than remains on an order is rejected
+	t.Run("Over-allocation", func(t *testing.T) {
+		response := processBidMatch("3", "M3", "10", "3", "42", "S2")
+
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "over-allocates Order B1")
+
+		order, _ := getOrder(stub, "S2")
+		assert.Equal(t, 6.0, order.RemainingQuantity, "Rejected match changed the sell order")
+	})
+
+	// Test Case 3: Processing a match again replaces its fill
+	t.Run("Updated BidMatch", func(t *testing.T) {
+		response := processBidMatch("4", "M2", "20", "4", "42", "S2")
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		order, _ := getOrder(stub, "B1")
+		assert.Equal(t, []string{"M1", "M2"}, order.BidMatchIDs, "Bid matches mismatch")
+		assert.Equal(t, 10.0, order.FilledQuantity, "Filled quantity mismatch")
+		assert.Equal(t, 14.0, order.AverageFillPrice, "Average fill price mismatch")
+	})
+
+	// Test Case 4: The order sides have to match the parties of the match
+	t.Run("Wrong Order Side", func(t *testing.T) {
+		response := stub.MockInvoke("5", [][]byte{
+			[]byte("ProcessBidMatch"), []byte("120"), []byte("slot1"), []byte("BidCreated"), []byte("10"), []byte("40"),
+			[]byte("0"), []byte("M4"), []byte("1"), []byte("42"), []byte("S2"), []byte("S2"),
+		})
+
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "is not a Buy order")
+	})
+
+	// Test Case 5: A cancelled order takes back the fill the match held, but no more
+	t.Run("Reprocessed Match on a Cancelled Order", func(t *testing.T) {
+		order, _ := getOrder(stub, "B1")
+		order.BidStatus = OrderCancelledStatus
+		order.RemainingQuantity = 0
+		putOrder(t, stub, *order)
+
+		response := processBidMatch("6", "M2", "20", "3", "42", "S2")
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		order, _ = getOrder(stub, "B1")
+		assert.Equal(t, []string{"M1", "M2"}, order.BidMatchIDs, "Bid matches mismatch")
+		assert.Equal(t, 9.0, order.FilledQuantity, "Filled quantity mismatch")
+		assert.Equal(t, 0.0, order.RemainingQuantity, "Cancelled order reopened")
+
+		response = processBidMatch("7", "M2", "20", "4", "42", "S2")
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "can not fill more than the 3 it held")
+	})
+}
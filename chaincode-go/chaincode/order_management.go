@@ -0,0 +1,207 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                              Order Management                              */
+/* -------------------------------------------------------------------------- */
+
+// ============================================================================================================================
+// CancelOrder - cancel the unmatched remaining quantity of an order
+//
+// Only the owner of the order or an admin can cancel, and only before the gate of its slot closes.
+// Fills already allocated by bid matches are kept. The cancelled quantity and, for a buy order,
+// its value are announced with an OrderCancelled event referencing the PaymentID of the order.
+//
+// Inputs - Array of strings
+//
+//	0
+//	OrderID
+//	"1"
+//
+// ============================================================================================================================
+func CancelOrder(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var err error
+	fmt.Println("starting CancelOrder")
+
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1")
+	}
+
+	order, err := getChangeableOrder(stub, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	event := cancelRemainingQuantity(order, "CancelOrder")
+	order.UpdatedOn, err = getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	orderAsBytes, _ := json.Marshal(order)
+	err = stub.PutState("Order_"+order.ID, orderAsBytes)
+	if err != nil {
+		return shim.Error("Could not store order: " + err.Error())
+	}
+
+	eventAsBytes, _ := json.Marshal(event)
+	err = stub.SetEvent("OrderCancelled", eventAsBytes)
+	if err != nil {
+		return shim.Error("Could not emit OrderCancelled event: " + err.Error())
+	}
+
+	fmt.Println("- end CancelOrder")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// ============================================================================================================================
+// AmendOrder - change the quantity and unit price of an open order
+//
+// Only the owner of the order or an admin can amend, and only before the gate of its slot closes.
+// The new TotalQuantity must exceed what is already filled. Raising the quantity or changing the
+// price moves the order to the back of the queue by resetting its PriorityTime; lowering the
+// quantity keeps its place.
+//
+// Inputs - Array of strings
+//
+//	0      ,    1           ,    2
+//	OrderID,    TotalQuantity,   UnitCost
+//	"1"    ,    "8"          ,   "0.25"
+//
+// ============================================================================================================================
+func AmendOrder(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var err error
+	fmt.Println("starting AmendOrder")
+
+	if len(args) != 3 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 3")
+	}
+
+	totalQuantity, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse TotalQuantity: "+err.Error())
+	}
+	unitCost, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse UnitCost: "+err.Error())
+	}
+	if unitCost < 0 {
+		return statusResponse(StatusInvalidArgument, "UnitCost can not be negative.")
+	}
+
+	order, err := getChangeableOrder(stub, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+	if float64(totalQuantity)-order.FilledQuantity <= fillTolerance {
+		return statusResponse(StatusInvalidArgument, "TotalQuantity of order "+order.ID+" must exceed its filled quantity of "+
+			strconv.FormatFloat(order.FilledQuantity, 'f', -1, 64)+", use CancelOrder to withdraw the remainder.")
+	}
+
+	losesPriority := totalQuantity > order.TotalQuantity || unitCost != order.UnitCost
+	order.TotalQuantity = totalQuantity
+	order.UnitCost = unitCost
+	order.OrderCost = float64(totalQuantity) * unitCost
+	err = updateRemainingQuantity(order)
+	if err != nil {
+		return errorResponse(err)
+	}
+	order.UpdatedOn, err = getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if losesPriority {
+		order.PriorityTime = order.UpdatedOn
+	}
+
+	orderAsBytes, _ := json.Marshal(order)
+	err = stub.PutState("Order_"+order.ID, orderAsBytes)
+	if err != nil {
+		return shim.Error("Could not store order: " + err.Error())
+	}
+
+	fmt.Println("- end AmendOrder")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// cancelRemainingQuantity cancels the unmatched quantity of an order, recording its value on a
+// buy order, and returns the payload of the OrderCancelled event.
+func cancelRemainingQuantity(order *Order, reason string) map[string]interface{} {
+	releasedQuantity := order.RemainingQuantity
+	cancelledValue := 0.0
+	if order.UserAction == BuyAction {
+		cancelledValue = releasedQuantity * order.UnitCost
+	}
+	order.BidStatus = OrderCancelledStatus
+	order.CancelledValue += cancelledValue
+	order.RemainingQuantity = 0
+
+	return map[string]interface{}{
+		"cancelledValue":   cancelledValue,
+		"orderId":          order.ID,
+		"paymentId":        order.PaymentID,
+		"reason":           reason,
+		"releasedQuantity": releasedQuantity,
+	}
+}
+
+// getChangeableOrder loads an order the caller may cancel or amend: the caller owns it or is an
+// admin, the order is open with unmatched quantity left and the gate of its slot has not closed.
+func getChangeableOrder(stub shim.ChaincodeStubInterface, orderID string) (*Order, error) {
+	order, err := getOrder(stub, orderID)
+	if err != nil {
+		return nil, err
+	}
+	err = requireUserOrRole(stub, order.UserID, AdminRole)
+	if err != nil {
+		return nil, err
+	}
+	if !isOrderOpen(order) {
+		return nil, newStatusError(StatusConflict, "Order "+order.ID+" is "+order.BidStatus+" and can no longer be changed.")
+	}
+	if order.RemainingQuantity <= fillTolerance {
+		return nil, newStatusError(StatusConflict, "Order "+order.ID+" has no unmatched quantity left.")
+	}
+
+	config, err := getMarketConfig(stub)
+	if err != nil {
+		return nil, err
+	}
+	now, err := getTxTime(stub)
+	if err != nil {
+		return nil, err
+	}
+	gateClosure := order.SlotExecDate - config.GateClosureSeconds
+	if now >= gateClosure {
+		return nil, newStatusError(StatusConflict, "Gate for slot "+order.SlotID+" of order "+order.ID+" closed at "+
+			strconv.FormatInt(gateClosure, 10)+".")
+	}
+	return order, nil
+}
@@ -0,0 +1,107 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderManagement(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+
+	userAsBytes, _ := json.Marshal(User{ID: "50", MSPID: "Org2MSP"})
+	stub.MockTransactionStart("user")
+	stub.PutState("50", userAsBytes)
+	stub.MockTransactionEnd("user")
+
+	owner := newCreator(t, "Org2MSP", nil)
+	admin := newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+	stranger := newCreator(t, "Org3MSP", nil)
+	slotExecDate := time.Now().Unix() + 2*DefaultGateClosureSeconds
+	putOrder(t, stub, Order{ID: "B1", BidStatus: "BidCreated", PaymentID: "P1", PriorityTime: 100, SlotExecDate: slotExecDate,
+		TotalQuantity: 10, FilledQuantity: 4, RemainingQuantity: 6, UnitCost: 2, UserID: "50", UserAction: BuyAction})
+	putOrder(t, stub, Order{ID: "B2", BidStatus: "BidCreated", PriorityTime: 100, SlotExecDate: time.Now().Unix() + 60,
+		TotalQuantity: 10, RemainingQuantity: 10, UnitCost: 2, UserID: "50", UserAction: BuyAction})
+
+	amendOrder := func(txID string, orderID string, quantity string, unitCost string) {
+		response := stub.MockInvoke(txID, [][]byte{[]byte("AmendOrder"), []byte(orderID), []byte(quantity), []byte(unitCost)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	}
+
+	// Test Case 1: Only the owner or an admin can change an order
+	t.Run("Unauthorized", func(t *testing.T) {
+		stub.Creator = stranger
+		response := stub.MockInvoke("1", [][]byte{[]byte("CancelOrder"), []byte("B1")})
+
+		assert.Equal(t, StatusUnauthorized, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "Caller is not authorized")
+	})
+
+	// Test Case 2: Lowering the quantity keeps the time priority
+	t.Run("Lower Quantity", func(t *testing.T) {
+		stub.Creator = owner
+		amendOrder("2", "B1", "8", "2")
+
+		order, _ := getOrder(stub, "B1")
+		assert.Equal(t, int64(8), order.TotalQuantity, "Total quantity mismatch")
+		assert.Equal(t, 4.0, order.RemainingQuantity, "Remaining quantity mismatch")
+		assert.Equal(t, int64(100), order.PriorityTime, "Priority unexpectedly lost")
+	})
+
+	// Test Case 3: Changing the price loses the time priority
+	t.Run("Change Price", func(t *testing.T) {
+		stub.Creator = admin
+		amendOrder("3", "B1", "8", "3")
+
+		order, _ := getOrder(stub, "B1")
+		assert.Equal(t, 3.0, order.UnitCost, "Unit cost mismatch")
+		assert.Equal(t, 24.0, order.OrderCost, "Order cost mismatch")
+		assert.Greater(t, order.PriorityTime, int64(100), "Priority not reset")
+	})
+
+	// Test Case 4: The filled quantity can not be amended away
+	t.Run("Below Filled Quantity", func(t *testing.T) {
+		stub.Creator = owner
+		response := stub.MockInvoke("4", [][]byte{[]byte("AmendOrder"), []byte("B1"), []byte("4"), []byte("3")})
+
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "must exceed its filled quantity")
+	})
+
+	// Test Case 5: Orders can not be changed after gate closure
+	t.Run("Gate Closed", func(t *testing.T) {
+		stub.Creator = owner
+		response := stub.MockInvoke("5", [][]byte{[]byte("CancelOrder"), []byte("B2")})
+
+		assert.Equal(t, StatusConflict, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "Gate for slot")
+	})
+
+	// Test Case 6: Cancelling withdraws the remaining quantity and records its value
+	t.Run("Cancel", func(t *testing.T) {
+		stub.Creator = owner
+		response := stub.MockInvoke("6", [][]byte{[]byte("CancelOrder"), []byte("B1")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		event := <-stub.ChaincodeEventsChannel
+		assert.Equal(t, "OrderCancelled", event.EventName, "Event name mismatch")
+		var payload map[string]interface{}
+		json.Unmarshal(event.Payload, &payload)
+		assert.Equal(t, "P1", payload["paymentId"], "Payment ID mismatch")
+		assert.Equal(t, 12.0, payload["cancelledValue"], "Cancelled value mismatch")
+
+		order, _ := getOrder(stub, "B1")
+		assert.Equal(t, OrderCancelledStatus, order.BidStatus, "Status mismatch")
+		assert.Equal(t, 0.0, order.RemainingQuantity, "Remaining quantity mismatch")
+		assert.Equal(t, 4.0, order.FilledQuantity, "Fills must be kept")
+		assert.Equal(t, 12.0, order.CancelledValue, "Cancelled value mismatch")
+
+		response = stub.MockInvoke("7", [][]byte{[]byte("CancelOrder"), []byte("B1")})
+		assert.Equal(t, StatusConflict, response.GetStatus(), "Cancelled order changed again")
+	})
+}
@@ -0,0 +1,191 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                Order Types                                 */
+/* -------------------------------------------------------------------------- */
+
+// validateOrderType checks the order type and time in force of an order and fills in the
+// defaults, a GoodTillSlot limit order. Market orders must carry a positive ProtectionPrice.
+func validateOrderType(order *Order) error {
+	if order.OrderType == "" {
+		order.OrderType = LimitOrder
+	}
+	if order.TimeInForce == "" {
+		order.TimeInForce = GoodTillSlot
+	}
+	if order.ProtectionPrice < 0 {
+		return newStatusError(StatusInvalidArgument, "ProtectionPrice can not be negative.")
+	}
+
+	switch order.OrderType {
+	case LimitOrder:
+		if order.UnitCost < 0 {
+			return newStatusError(StatusInvalidArgument, "Limit orders can not have a negative UnitCost.")
+		}
+	case MarketOrder:
+		if order.ProtectionPrice == 0 {
+			return newStatusError(StatusInvalidArgument, "Market orders must have a positive ProtectionPrice.")
+		}
+	default:
+		return newStatusError(StatusInvalidArgument, "Invalid OrderType "+order.OrderType+". It should be "+LimitOrder+" or "+MarketOrder+".")
+	}
+
+	switch order.TimeInForce {
+	case GoodTillSlot, FillOrKill, AllOrNone:
+	default:
+		return newStatusError(StatusInvalidArgument, "Invalid TimeInForce "+order.TimeInForce+". It should be "+GoodTillSlot+", "+
+			FillOrKill+" or "+AllOrNone+".")
+	}
+	return nil
+}
+
+// limitPrice is the worst price an order accepts: the UnitCost of a limit order and the
+// ProtectionPrice of a market order.
+func limitPrice(order *Order) float64 {
+	if order.OrderType == MarketOrder {
+		return order.ProtectionPrice
+	}
+	return order.UnitCost
+}
+
+// acceptsPrice reports whether a fill at price is within the limit of the order. Buy orders
+// accept prices up to their limit, sell orders prices down to it.
+func acceptsPrice(order *Order, price float64) bool {
+	if order.UserAction == BuyAction {
+		return price <= limitPrice(order)
+	}
+	return price >= limitPrice(order)
+}
+
+// getRestingOrders returns the open orders with unmatched quantity of a slot for one action
+func getRestingOrders(stub shim.ChaincodeStubInterface, slotID string, action string) ([]Order, error) {
+	kvs, err := getStatesByPrefix(stub, "Order_")
+	if err != nil {
+		return nil, errors.New("Failed to scan orders: " + err.Error())
+	}
+
+	var orders []Order
+	for _, kv := range kvs {
+		var order Order
+		err = json.Unmarshal(kv.Value, &order)
+		if err != nil {
+			return nil, errors.New("Failed to unmarshal order " + kv.Key + ": " + err.Error())
+		}
+		if order.SlotID == slotID && order.UserAction == action && isOrderOpen(&order) && order.RemainingQuantity > fillTolerance {
+			orders = append(orders, order)
+		}
+	}
+	return orders, nil
+}
+
+// oppositeAction is the action of the orders an order can be matched with
+func oppositeAction(action string) string {
+	if action == BuyAction {
+		return SellAction
+	}
+	return BuyAction
+}
+
+// isFillable reports whether the resting orders of other users in the slot can fill the whole
+// order at acceptable prices. It decides whether a FillOrKill order survives its arrival.
+func isFillable(stub shim.ChaincodeStubInterface, order *Order) (bool, error) {
+	resting, err := getRestingOrders(stub, order.SlotID, oppositeAction(order.UserAction))
+	if err != nil {
+		return false, err
+	}
+
+	available := 0.0
+	for i := range resting {
+		other := &resting[i]
+		if other.UserID == order.UserID {
+			continue
+		}
+		// Both limits have to overlap for the orders to trade.
+		if acceptsPrice(order, limitPrice(other)) {
+			available += other.RemainingQuantity
+		}
+	}
+	return available-order.RemainingQuantity > -fillTolerance, nil
+}
+
+// checkFillTerms verifies that a bid match respects the order type and time in force of one of
+// its orders; counterpart is the order on the other side of the match. Orders stored before
+// order types were introduced carry no OrderType and are not checked.
+func checkFillTerms(stub shim.ChaincodeStubInterface, order *Order, counterpart *Order, bidMatch *BidMatch) error {
+	if order.OrderType == "" {
+		return nil
+	}
+
+	price := float64(bidMatch.BidUnitPrice)
+	if !acceptsPrice(order, price) {
+		return errors.New("BidMatch " + bidMatch.ID + " price " + strconv.FormatFloat(price, 'f', -1, 64) +
+			" is outside the limit " + strconv.FormatFloat(limitPrice(order), 'f', -1, 64) + " of Order " + order.ID + ".")
+	}
+
+	if order.TimeInForce == FillOrKill || order.TimeInForce == AllOrNone {
+		if order.RemainingQuantity-bidMatch.OriginalBidUnits > fillTolerance {
+			return errors.New("Order " + order.ID + " is " + order.TimeInForce + " and must be filled completely by a single match.")
+		}
+	}
+	if order.TimeInForce == GoodTillSlot && order.SlotExecDate > 0 {
+		now, err := getTxTime(stub)
+		if err != nil {
+			return err
+		}
+		if now >= order.SlotExecDate {
+			return errors.New("Order " + order.ID + " expired with slot " + order.SlotID + ".")
+		}
+	}
+
+	if order.OrderType != MarketOrder {
+		return nil
+	}
+	// Market orders take the best prices first: no resting limit order of another user may
+	// offer a better price than the match.
+	resting, err := getRestingOrders(stub, order.SlotID, oppositeAction(order.UserAction))
+	if err != nil {
+		return err
+	}
+	for i := range resting {
+		other := &resting[i]
+		if other.ID == counterpart.ID || other.UserID == order.UserID || other.OrderType == MarketOrder {
+			continue
+		}
+		better := other.UnitCost < price
+		if order.UserAction == SellAction {
+			better = other.UnitCost > price
+		}
+		if better {
+			return errors.New("Order " + other.ID + " offers a better price than BidMatch " + bidMatch.ID +
+				" for market Order " + order.ID + ".")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderTypes(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+
+	enableTrading(t, stub, "60", BuyAction)
+	enableTrading(t, stub, "61", SellAction)
+	enableTrading(t, stub, "62", SellAction)
+	stub.Creator = newCreator(t, "Org1MSP", nil)
+
+	registerOrder := func(txID string, orderID string, userID string, action string, quantity string, unitCost string,
+		orderType string, timeInForce string, protectionPrice string) pb.Response {
+		return stub.MockInvoke(txID, [][]byte{
+			[]byte("RegisterOrder"), []byte(""), []byte("BidCreated"), []byte(orderID), []byte(""), []byte("0"), []byte(""),
+			[]byte("slot7"), []byte(quantity), []byte(unitCost), []byte(userID), []byte("0"), []byte(action),
+			[]byte(orderType), []byte(timeInForce), []byte(protectionPrice),
+		})
+	}
+	processBidMatch := func(txID string, bidMatchID string, price string, units string, sellerID string, buyOrderID string, sellOrderID string) pb.Response {
+		return stub.MockInvoke(txID, [][]byte{
+			[]byte("ProcessBidMatch"), []byte("120"), []byte("slot7"), []byte("BidCreated"), []byte(price), []byte("60"),
+			[]byte("0"), []byte(bidMatchID), []byte(units), []byte(sellerID), []byte(buyOrderID), []byte(sellOrderID),
+		})
+	}
+
+	// Test Case 1: Market orders need a protection price
+	t.Run("Market Without Protection", func(t *testing.T) {
+		response := registerOrder("1", "MB0", "60", BuyAction, "5", "0", MarketOrder, GoodTillSlot, "0")
+
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "positive ProtectionPrice")
+	})
+
+	// Test Case 2: A FillOrKill order is cancelled on arrival when the book can not fill it
+	t.Run("FillOrKill Cancelled", func(t *testing.T) {
+		assertOK(t, registerOrder("2", "S1", "61", SellAction, "4", "10", LimitOrder, GoodTillSlot, "0"))
+		assertOK(t, registerOrder("3", "FK1", "60", BuyAction, "6", "12", LimitOrder, FillOrKill, "0"))
+
+		event := <-stub.ChaincodeEventsChannel
+		assert.Equal(t, "OrderCancelled", event.EventName, "Event name mismatch")
+		order, _ := getOrder(stub, "FK1")
+		assert.Equal(t, OrderCancelledStatus, order.BidStatus, "FillOrKill order not cancelled")
+	})
+
+	// Test Case 3: Limit orders are not filled beyond their price
+	t.Run("Limit Price", func(t *testing.T) {
+		assertOK(t, registerOrder("4", "L1", "60", BuyAction, "4", "9", LimitOrder, GoodTillSlot, "0"))
+		response := processBidMatch("5", "M1", "10", "4", "61", "L1", "S1")
+
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "is outside the limit 9 of Order L1")
+	})
+
+	// Test Case 4: Market orders take the best resting price first
+	t.Run("Market Best Price", func(t *testing.T) {
+		assertOK(t, registerOrder("6", "S2", "62", SellAction, "4", "15", LimitOrder, GoodTillSlot, "0"))
+		assertOK(t, registerOrder("7", "MB1", "60", BuyAction, "4", "0", MarketOrder, GoodTillSlot, "20"))
+
+		response := processBidMatch("8", "M2", "15", "4", "62", "MB1", "S2")
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "Order S1 offers a better price")
+
+		assertOK(t, processBidMatch("9", "M3", "10", "4", "61", "MB1", "S1"))
+	})
+
+	// Test Case 5: AllOrNone orders are only filled completely
+	t.Run("AllOrNone", func(t *testing.T) {
+		assertOK(t, registerOrder("10", "AN1", "60", BuyAction, "6", "20", LimitOrder, AllOrNone, "0"))
+
+		response := processBidMatch("11", "M4", "15", "3", "62", "AN1", "S2")
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "must be filled completely")
+	})
+}
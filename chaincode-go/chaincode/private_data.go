@@ -0,0 +1,252 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// ============================================================================================================================
+// Private Data - personal and payment data is kept in a collection per participant org,
+// each shared only with the operator org (see collections_config.json)
+// ============================================================================================================================
+
+// getCollectionName returns the private data collection of the given org
+func getCollectionName(mspID string) string {
+	return mspID + "PrivateCollection"
+}
+
+// getCallerMSPID returns the MSP ID of the invoking identity
+func getCallerMSPID(stub shim.ChaincodeStubInterface) (string, error) {
+	mspID, err := cid.GetMSPID(stub)
+	if err != nil {
+		return "", errors.New("Failed to get caller MSP ID: " + err.Error())
+	}
+	return mspID, nil
+}
+
+// isAuthorizedForPrivateData reports whether the caller belongs to the owning org or the operator org
+func isAuthorizedForPrivateData(stub shim.ChaincodeStubInterface, ownerMSPID string) bool {
+	callerMSPID, err := getCallerMSPID(stub)
+	if err != nil {
+		return false
+	}
+	if callerMSPID == ownerMSPID {
+		return true
+	}
+
+	config, err := getMarketConfig(stub)
+	if err != nil {
+		return false
+	}
+	return callerMSPID == config.OperatorMSPID
+}
+
+// getTransientValue returns a value of the transient map, or nil if it was not passed
+func getTransientValue(stub shim.ChaincodeStubInterface, name string) ([]byte, error) {
+	transientMap, err := stub.GetTransient()
+	if err != nil {
+		return nil, errors.New("Failed to get transient map: " + err.Error())
+	}
+	value, ok := transientMap[name]
+	if !ok {
+		return nil, nil
+	}
+	if len(value) == 0 {
+		return nil, errors.New("Transient value " + name + " must be non-empty")
+	}
+	return value, nil
+}
+
+// saltedHash returns the hex encoded SHA-256 of the salt followed by the value
+func saltedHash(salt string, value []byte) string {
+	hash := sha256.Sum256(append([]byte(salt), value...))
+	return hex.EncodeToString(hash[:])
+}
+
+// getUserMSPID returns the org a registered user belongs to
+func getUserMSPID(stub shim.ChaincodeStubInterface, userID string) (string, error) {
+	userAsBytes, err := stub.GetState(userID)
+	if err != nil {
+		return "", errors.New("Error accessing state: " + err.Error())
+	}
+	if userAsBytes == nil {
+		return "", newStatusError(StatusNotFound, "User with ID "+userID+" not found")
+	}
+
+	var user User
+	err = json.Unmarshal(userAsBytes, &user)
+	if err != nil {
+		return "", errors.New("Failed to unmarshal user: " + err.Error())
+	}
+	if user.MSPID == "" {
+		return "", errors.New("User with ID " + userID + " has no org recorded")
+	}
+	return user.MSPID, nil
+}
+
+// putUserPrivateDetails moves the "location" and "contact" transient values, salted with the
+// "salt" transient value, to the collection of the user's org. Values not passed keep their
+// stored value. It returns the stored details, or nil when no personal data was passed.
+func putUserPrivateDetails(stub shim.ChaincodeStubInterface, mspID string, userID string) (*UserPrivateDetails, error) {
+	location, err := getTransientValue(stub, "location")
+	if err != nil {
+		return nil, err
+	}
+	contact, err := getTransientValue(stub, "contact")
+	if err != nil {
+		return nil, err
+	}
+	if location == nil && contact == nil {
+		return nil, nil
+	}
+	salt, err := getTransientValue(stub, "salt")
+	if err != nil {
+		return nil, err
+	}
+	if salt == nil {
+		return nil, errors.New("Transient value salt is required with location or contact")
+	}
+
+	details, err := getUserPrivateDetails(stub, mspID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if details == nil {
+		details = &UserPrivateDetails{ID: userID}
+	}
+	if location != nil {
+		details.Location = string(location)
+	}
+	if contact != nil {
+		details.Contact = string(contact)
+	}
+	details.Salt = string(salt)
+
+	detailsAsBytes, _ := json.Marshal(details)
+	err = stub.PutPrivateData(getCollectionName(mspID), "User_"+userID, detailsAsBytes)
+	if err != nil {
+		return nil, errors.New("Could not store user private details: " + err.Error())
+	}
+	return details, nil
+}
+
+// hashOf returns the salted hash of one of the private values, or an empty string if it is unset
+func (details UserPrivateDetails) hashOf(value string) string {
+	if value == "" {
+		return ""
+	}
+	return saltedHash(details.Salt, []byte(value))
+}
+
+// getUserPrivateDetails reads the private details of a user, or nil if none are stored
+func getUserPrivateDetails(stub shim.ChaincodeStubInterface, mspID string, userID string) (*UserPrivateDetails, error) {
+	detailsAsBytes, err := stub.GetPrivateData(getCollectionName(mspID), "User_"+userID)
+	if err != nil {
+		return nil, errors.New("Failed to read user private details: " + err.Error())
+	}
+	if detailsAsBytes == nil {
+		return nil, nil
+	}
+
+	var details UserPrivateDetails
+	err = json.Unmarshal(detailsAsBytes, &details)
+	if err != nil {
+		return nil, errors.New("Failed to unmarshal user private details: " + err.Error())
+	}
+	return &details, nil
+}
+
+// mergeUserPrivateDetails returns the public profile with the private values filled in
+// when the caller is authorized to see it, and the public profile unchanged otherwise
+func mergeUserPrivateDetails(stub shim.ChaincodeStubInterface, profileAsBytes []byte) ([]byte, error) {
+	var profile User
+	err := json.Unmarshal(profileAsBytes, &profile)
+	if err != nil {
+		return nil, errors.New("Failed to unmarshal user: " + err.Error())
+	}
+	if (profile.LocationHash == "" && profile.ContactHash == "") || !isAuthorizedForPrivateData(stub, profile.MSPID) {
+		return profileAsBytes, nil
+	}
+
+	details, err := getUserPrivateDetails(stub, profile.MSPID, profile.ID)
+	if err != nil || details == nil {
+		return profileAsBytes, err
+	}
+
+	// Work on the raw fields so enterprise profiles keep their own attributes.
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(profileAsBytes, &fields)
+	if err != nil {
+		return nil, errors.New("Failed to unmarshal user: " + err.Error())
+	}
+	fields["location"], _ = json.Marshal(details.Location)
+	fields["contact"], _ = json.Marshal(details.Contact)
+	return json.Marshal(fields)
+}
+
+// putPrivatePaymentDetail stores a PaymentDetail in the collection of the paying user's org
+// and its salted hash on public state
+func putPrivatePaymentDetail(stub shim.ChaincodeStubInterface, ownerMSPID string, pd PrivatePaymentDetail) error {
+	collection := getCollectionName(ownerMSPID)
+	privateAsBytes, _ := json.Marshal(pd)
+	err := stub.PutPrivateData(collection, "PaymentDetail_"+pd.ID, privateAsBytes)
+	if err != nil {
+		return errors.New("Could not store payment detail: " + err.Error())
+	}
+
+	valueAsBytes, _ := json.Marshal(pd.PaymentDetail)
+	pdHash := PrivateDataHash{
+		Collection: collection,
+		Hash:       saltedHash(pd.Salt, valueAsBytes),
+		ID:         pd.ID,
+		OwnerMSPID: ownerMSPID,
+	}
+	pdHashAsBytes, _ := json.Marshal(pdHash)
+	err = stub.PutState("PaymentDetail_"+pd.ID, pdHashAsBytes)
+	if err != nil {
+		return errors.New("Could not store payment detail hash: " + err.Error())
+	}
+	return nil
+}
+
+// getPrivatePaymentDetail reads a PaymentDetail from the collection it was stored in
+func getPrivatePaymentDetail(stub shim.ChaincodeStubInterface, pdHash PrivateDataHash) (*PrivatePaymentDetail, error) {
+	pdAsBytes, err := stub.GetPrivateData(pdHash.Collection, "PaymentDetail_"+pdHash.ID)
+	if err != nil {
+		return nil, errors.New("Failed to fetch PaymentDetail with ID " + pdHash.ID + ": " + err.Error())
+	}
+	if pdAsBytes == nil {
+		return nil, newStatusError(StatusNotFound, "PaymentDetail with ID "+pdHash.ID+" not found.")
+	}
+
+	var pd PrivatePaymentDetail
+	err = json.Unmarshal(pdAsBytes, &pd)
+	if err != nil {
+		return nil, errors.New("Failed to unmarshal payment detail: " + err.Error())
+	}
+	return &pd, nil
+}
@@ -0,0 +1,521 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                             User Read Methods                              */
+/* -------------------------------------------------------------------------- */
+
+func ReadUserProfile(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadUserProfile")
+
+	// We expect 1 argument: the user ID.
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	// Attempt to retrieve the user profile from the state using the user ID.
+	userProfileAsBytes, err := stub.GetState(args[0])
+	if err != nil {
+		return shim.Error("Error accessing state: " + err.Error())
+	}
+	if userProfileAsBytes == nil {
+		return statusResponse(StatusNotFound, "User with ID "+args[0]+" does not exist.")
+	}
+
+	// The location is only returned to the user's org and the operator.
+	userProfileAsBytes, err = mergeUserPrivateDetails(stub, userProfileAsBytes)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end ReadUserProfile")
+	return shim.Success(userProfileAsBytes)
+}
+
+func ReadEnterpriseUserProfile(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadEnterpriseUserProfile")
+
+	// We expect 1 argument: the user ID.
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	// Attempt to retrieve the user profile from the state using the user ID.
+	userProfileAsBytes, err := stub.GetState(args[0])
+	if err != nil {
+		return shim.Error("Error accessing state: " + err.Error())
+	}
+	if userProfileAsBytes == nil {
+		return statusResponse(StatusNotFound, "User with ID "+args[0]+" does not exist.")
+	}
+
+	// The location is only returned to the user's org and the operator.
+	userProfileAsBytes, err = mergeUserPrivateDetails(stub, userProfileAsBytes)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end ReadEnterpriseUserProfile")
+	return shim.Success(userProfileAsBytes)
+}
+
+func ReadPlatformContract(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadPlatformContract")
+
+	// We expect 1 argument: the user ID.
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	// Parsing the user ID.
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse User ID: "+err.Error())
+	}
+
+	// Attempt to retrieve the platform contract from the state using the user ID.
+	platformContractAsBytes, err := stub.GetState("PlatformContract_" + strconv.FormatInt(userID, 10))
+	if err != nil {
+		return shim.Error("Error accessing state: " + err.Error())
+	}
+	if platformContractAsBytes == nil {
+		return statusResponse(StatusNotFound, "Platform Contract for User with ID "+strconv.FormatInt(userID, 10)+" does not exist.")
+	}
+
+	fmt.Println("- end ReadPlatformContract")
+	return shim.Success(platformContractAsBytes)
+}
+
+func ReadTradingContract(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadTradingContract")
+
+	// We expect 1 argument: the user ID.
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	// Parsing the user ID.
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse User ID: "+err.Error())
+	}
+
+	// Attempt to retrieve the trading contract from the state using the user ID.
+	tradingContractAsBytes, err := stub.GetState(getTradingContractKey(strconv.FormatInt(userID, 10)))
+	if err != nil {
+		return shim.Error("Error accessing state: " + err.Error())
+	}
+	if tradingContractAsBytes == nil {
+		return statusResponse(StatusNotFound, "Trading Contract for User with ID "+strconv.FormatInt(userID, 10)+" does not exist.")
+	}
+
+	fmt.Println("- end ReadTradingContract")
+	return shim.Success(tradingContractAsBytes)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                           Payment Read Methods                             */
+/* -------------------------------------------------------------------------- */
+
+func ReadPayment(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadPayment")
+
+	// We expect 1 argument: the payment ID.
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	// Retrieve the payment ID from the arguments.
+	paymentID := args[0]
+
+	// Attempt to retrieve the payment from the state using the payment ID.
+	paymentAsBytes, err := stub.GetState("Payment_" + paymentID)
+	if err != nil {
+		return shim.Error("Error accessing state: " + err.Error())
+	}
+	if paymentAsBytes == nil {
+		return statusResponse(StatusNotFound, "Payment with ID "+paymentID+" does not exist.")
+	}
+
+	fmt.Println("- end ReadPayment")
+	return shim.Success(paymentAsBytes)
+}
+
+// ReadPaymentDetail returns the private PaymentDetail, salt included, to callers from the
+// paying user's org or the operator org.
+func ReadPaymentDetail(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadPaymentDetail")
+
+	// We expect 1 argument: the ID of the PaymentDetail to retrieve.
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	// The ID is not parsed: the details of erased users carry pseudonymous IDs.
+	pdHash, err := getPaymentDetailHash(stub, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+	if !isAuthorizedForPrivateData(stub, pdHash.OwnerMSPID) {
+		return statusResponse(StatusUnauthorized, "Caller is not authorized to read PaymentDetail with ID "+args[0]+".")
+	}
+
+	// Retrieve the paymentDetail from the private data collection.
+	pd, err := getPrivatePaymentDetail(stub, pdHash)
+	if err != nil {
+		return errorResponse(err)
+	}
+	paymentDetailAsBytes, _ := json.Marshal(pd)
+
+	fmt.Println("- end ReadPaymentDetail")
+	return shim.Success(paymentDetailAsBytes)
+}
+
+// ReadPaymentDetailHash returns the public salted hash of a PaymentDetail, which any
+// counterparty can use to verify a PaymentDetail shared with it off-chain.
+func ReadPaymentDetailHash(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadPaymentDetailHash")
+
+	// We expect 1 argument: the ID of the PaymentDetail.
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	pdHash, err := getPaymentDetailHash(stub, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	pdHashAsBytes, _ := json.Marshal(pdHash)
+
+	fmt.Println("- end ReadPaymentDetailHash")
+	return shim.Success(pdHashAsBytes)
+}
+
+// ReadPaymentsForOrder returns the payments recorded for an order.
+func ReadPaymentsForOrder(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadPaymentsForOrder")
+
+	// We expect 1 argument: the order ID.
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	payments, err := getIndexedPayments(stub, PaymentsByOrderIndex, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+	paymentsAsBytes, _ := json.Marshal(payments)
+
+	fmt.Println("- end ReadPaymentsForOrder")
+	return shim.Success(paymentsAsBytes)
+}
+
+// ReadPaymentsForBidMatch returns the payments recorded for a bid match.
+func ReadPaymentsForBidMatch(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadPaymentsForBidMatch")
+
+	// We expect 1 argument: the bid match ID.
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	payments, err := getIndexedPayments(stub, PaymentsByBidMatchIndex, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+	paymentsAsBytes, _ := json.Marshal(payments)
+
+	fmt.Println("- end ReadPaymentsForBidMatch")
+	return shim.Success(paymentsAsBytes)
+}
+
+// ReadPaymentsForUser returns the payments recorded for a user. Only identities of the user's
+// org and admins can list them.
+func ReadPaymentsForUser(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadPaymentsForUser")
+
+	// We expect 1 argument: the user ID.
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+	err := requireUserOrRole(stub, args[0], AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	payments, err := getIndexedPayments(stub, PaymentsByUserIndex, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+	paymentsAsBytes, _ := json.Marshal(payments)
+
+	fmt.Println("- end ReadPaymentsForUser")
+	return shim.Success(paymentsAsBytes)
+}
+
+// getIndexedPayments reads the payments listed in a payment index under the referenced ID
+func getIndexedPayments(stub shim.ChaincodeStubInterface, index string, referenceID string) ([]Payment, error) {
+	iterator, err := stub.GetStateByPartialCompositeKey(index, []string{referenceID})
+	if err != nil {
+		return nil, errors.New("Failed to query " + index + " index: " + err.Error())
+	}
+	defer iterator.Close()
+
+	payments := []Payment{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, errors.New("Failed to read " + index + " index: " + err.Error())
+		}
+		_, keyParts, err := stub.SplitCompositeKey(kv.Key)
+		if err != nil {
+			return nil, errors.New("Failed to split " + index + " index key: " + err.Error())
+		}
+
+		paymentAsBytes, err := stub.GetState("Payment_" + keyParts[1])
+		if err != nil {
+			return nil, errors.New("Error accessing state: " + err.Error())
+		}
+		if paymentAsBytes == nil {
+			continue
+		}
+		var payment Payment
+		err = json.Unmarshal(paymentAsBytes, &payment)
+		if err != nil {
+			return nil, errors.New("Failed to unmarshal payment: " + err.Error())
+		}
+		payments = append(payments, payment)
+	}
+	return payments, nil
+}
+
+// getPaymentDetailHash reads the public record of a private PaymentDetail
+func getPaymentDetailHash(stub shim.ChaincodeStubInterface, paymentDetailID string) (PrivateDataHash, error) {
+	var pdHash PrivateDataHash
+
+	pdHashAsBytes, err := stub.GetState("PaymentDetail_" + paymentDetailID)
+	if err != nil {
+		return pdHash, errors.New("Failed to fetch PaymentDetail with ID " + paymentDetailID + " from the ledger: " + err.Error())
+	}
+	if pdHashAsBytes == nil {
+		return pdHash, newStatusError(StatusNotFound, "PaymentDetail with ID "+paymentDetailID+" not found.")
+	}
+
+	err = json.Unmarshal(pdHashAsBytes, &pdHash)
+	if err != nil {
+		return pdHash, errors.New("Failed to unmarshal PaymentDetail hash: " + err.Error())
+	}
+	return pdHash, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                          Energy Bid Read Methods                           */
+/* -------------------------------------------------------------------------- */
+
+func ReadOrder(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadOrder")
+
+	// We expect 1 argument: the ID of the Order to retrieve.
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	// Parsing ID.
+	orderID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse Order ID: "+err.Error())
+	}
+
+	// Retrieve the order from state.
+	orderAsBytes, err := stub.GetState("Order_" + strconv.FormatInt(orderID, 10))
+	if err != nil {
+		return shim.Error("Failed to fetch Order with ID " + args[0] + " from the ledger: " + err.Error())
+	}
+
+	if orderAsBytes == nil {
+		return statusResponse(StatusNotFound, "Order with ID "+args[0]+" not found.")
+	}
+
+	fmt.Println("- end ReadOrder")
+	return shim.Success(orderAsBytes)
+}
+
+func ReadBidMatch(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadBidMatch")
+
+	// We expect 1 argument: the ID of the BidMatch to retrieve.
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	// Parsing ID.
+	bidMatchID := args[0]
+
+	// Retrieve the bidMatch from state.
+	bidMatchAsBytes, err := stub.GetState("BidMatch_" + bidMatchID)
+	if err != nil {
+		return shim.Error("Failed to fetch BidMatch with ID " + args[0] + " from the ledger: " + err.Error())
+	}
+
+	if bidMatchAsBytes == nil {
+		return statusResponse(StatusNotFound, "BidMatch with ID "+args[0]+" not found.")
+	}
+
+	fmt.Println("- end ReadBidMatch")
+	return shim.Success(bidMatchAsBytes)
+}
+
+// getOrder reads an Order from state
+func getOrder(stub shim.ChaincodeStubInterface, orderID string) (*Order, error) {
+	orderAsBytes, err := stub.GetState("Order_" + orderID)
+	if err != nil {
+		return nil, errors.New("Failed to fetch Order with ID " + orderID + " from the ledger: " + err.Error())
+	}
+	if orderAsBytes == nil {
+		return nil, newStatusError(StatusNotFound, "Order with ID "+orderID+" not found.")
+	}
+
+	var order Order
+	err = json.Unmarshal(orderAsBytes, &order)
+	if err != nil {
+		return nil, errors.New("Failed to unmarshal Order: " + err.Error())
+	}
+	return &order, nil
+}
+
+// getPayment reads a Payment from state
+func getPayment(stub shim.ChaincodeStubInterface, paymentID string) (*Payment, error) {
+	paymentAsBytes, err := stub.GetState("Payment_" + paymentID)
+	if err != nil {
+		return nil, errors.New("Failed to fetch Payment with ID " + paymentID + " from the ledger: " + err.Error())
+	}
+	if paymentAsBytes == nil {
+		return nil, newStatusError(StatusNotFound, "Payment with ID "+paymentID+" not found.")
+	}
+
+	var payment Payment
+	err = json.Unmarshal(paymentAsBytes, &payment)
+	if err != nil {
+		return nil, errors.New("Failed to unmarshal Payment: " + err.Error())
+	}
+	return &payment, nil
+}
+
+// getBidMatch reads a BidMatch from state
+func getBidMatch(stub shim.ChaincodeStubInterface, bidMatchID string) (*BidMatch, error) {
+	bidMatchAsBytes, err := stub.GetState("BidMatch_" + bidMatchID)
+	if err != nil {
+		return nil, errors.New("Failed to fetch BidMatch with ID " + bidMatchID + " from the ledger: " + err.Error())
+	}
+	if bidMatchAsBytes == nil {
+		return nil, newStatusError(StatusNotFound, "BidMatch with ID "+bidMatchID+" not found.")
+	}
+
+	var bidMatch BidMatch
+	err = json.Unmarshal(bidMatchAsBytes, &bidMatch)
+	if err != nil {
+		return nil, errors.New("Failed to unmarshal BidMatch: " + err.Error())
+	}
+	return &bidMatch, nil
+}
+
+func ReadEnergyBid(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadEnergyBid")
+
+	// We expect 1 argument: the ID of the EnergyBid to retrieve.
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	// Parsing ID.
+	energyBidID := args[0]
+
+	// Retrieve the energyBid from state.
+	energyBidAsBytes, err := stub.GetState("EnergyBid_" + energyBidID)
+	if err != nil {
+		return shim.Error("Failed to fetch EnergyBid with ID " + args[0] + " from the ledger: " + err.Error())
+	}
+
+	if energyBidAsBytes == nil {
+		return statusResponse(StatusNotFound, "EnergyBid with ID "+args[0]+" not found.")
+	}
+
+	fmt.Println("- end ReadEnergyBid")
+	return shim.Success(energyBidAsBytes)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                         Market Config Read Methods                         */
+/* -------------------------------------------------------------------------- */
+
+func ReadMarketConfig(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadMarketConfig")
+
+	// We expect no arguments.
+	if len(args) != 0 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 0.")
+	}
+
+	config, err := getMarketConfig(stub)
+	if err != nil {
+		return shim.Error("Failed to load market config: " + err.Error())
+	}
+
+	configAsBytes, _ := json.Marshal(config)
+
+	fmt.Println("- end ReadMarketConfig")
+	return shim.Success(configAsBytes)
+}
+
+// getMarketConfig returns the stored MarketConfig with defaults for the values never set
+func getMarketConfig(stub shim.ChaincodeStubInterface) (MarketConfig, error) {
+	config := MarketConfig{
+		GateClosureSeconds:         DefaultGateClosureSeconds,
+		GridOperatorMSPID:          DefaultGridOperatorMSPID,
+		Market:                     DefaultMarket,
+		MaxOrderBatchSize:          DefaultMaxOrderBatchSize,
+		OperatorMSPID:              DefaultOperatorMSPID,
+		ReliabilityHalfLifeSeconds: DefaultReliabilityHalfLifeSeconds,
+	}
+
+	configAsBytes, err := stub.GetState(MarketConfigKey)
+	if err != nil {
+		return config, err
+	}
+	if configAsBytes != nil {
+		err = json.Unmarshal(configAsBytes, &config)
+		if err != nil {
+			return config, err
+		}
+	}
+	return config, nil
+}
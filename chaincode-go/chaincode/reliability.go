@@ -0,0 +1,153 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                            Reliability Methods                             */
+/* -------------------------------------------------------------------------- */
+
+// ReadReliabilityScore returns the reliability score of a participant, with its weight decayed
+// to the time of the query. Participants without deliveries have no score and no weight.
+//
+// Inputs - Array of strings
+//
+//	0
+//	UserID
+func ReadReliabilityScore(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadReliabilityScore")
+
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	score, err := getReliabilityScore(stub, args[0])
+	if err != nil {
+		return errorResponse(err)
+	}
+	config, err := getMarketConfig(stub)
+	if err != nil {
+		return shim.Error("Failed to load market config: " + err.Error())
+	}
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	score.Weight = decayedWeight(score, now, config.ReliabilityHalfLifeSeconds)
+	scoreAsBytes, _ := json.Marshal(score)
+
+	fmt.Println("- end ReadReliabilityScore")
+	return shim.Success(scoreAsBytes)
+}
+
+// getReliabilityScore reads the reliability score of a participant, empty when it never delivered
+func getReliabilityScore(stub shim.ChaincodeStubInterface, userID string) (*ReliabilityScore, error) {
+	score := ReliabilityScore{UserID: userID}
+	scoreAsBytes, err := stub.GetState("Reliability_" + userID)
+	if err != nil {
+		return nil, errors.New("Error accessing state: " + err.Error())
+	}
+	if scoreAsBytes != nil {
+		err = json.Unmarshal(scoreAsBytes, &score)
+		if err != nil {
+			return nil, errors.New("Failed to unmarshal reliability score: " + err.Error())
+		}
+	}
+	return &score, nil
+}
+
+// decayedWeight halves the weight of a score every half-life since it was last updated
+func decayedWeight(score *ReliabilityScore, now int64, halfLife int64) float64 {
+	elapsed := now - score.UpdatedOn
+	if score.Weight == 0 || elapsed <= 0 {
+		return score.Weight
+	}
+	return score.Weight * math.Pow(0.5, float64(elapsed)/float64(halfLife))
+}
+
+// updateReliabilityScore adds the delivery ratio of an energy bid, the units sold to the buyer
+// out of the accepted units capped at 1, to the seller's score
+func updateReliabilityScore(stub shim.ChaincodeStubInterface, sellerID string, soldUnits float64, acceptedUnits float64) error {
+	if acceptedUnits <= 0 {
+		return nil
+	}
+	ratio := math.Max(math.Min(soldUnits/acceptedUnits, 1), 0)
+
+	score, err := getReliabilityScore(stub, sellerID)
+	if err != nil {
+		return err
+	}
+	config, err := getMarketConfig(stub)
+	if err != nil {
+		return errors.New("Failed to load market config: " + err.Error())
+	}
+	now, err := getTxTime(stub)
+	if err != nil {
+		return err
+	}
+
+	weight := decayedWeight(score, now, config.ReliabilityHalfLifeSeconds)
+	score.Score = (score.Score*weight + ratio) / (weight + 1)
+	score.Weight = weight + 1
+	score.Deliveries++
+	score.UpdatedOn = now
+
+	scoreAsBytes, _ := json.Marshal(score)
+	err = stub.PutState("Reliability_"+sellerID, scoreAsBytes)
+	if err != nil {
+		return errors.New("Could not store reliability score: " + err.Error())
+	}
+	return nil
+}
+
+// checkPremiumEligibility rejects sell orders for premium slots from sellers whose reliability
+// score is below the premium threshold. Sellers without deliveries are not eligible.
+func checkPremiumEligibility(stub shim.ChaincodeStubInterface, userID string, action string, slotID string) error {
+	if action != SellAction {
+		return nil
+	}
+	config, err := getMarketConfig(stub)
+	if err != nil {
+		return errors.New("Failed to load market config: " + err.Error())
+	}
+	if !containsString(config.PremiumSlots, slotID) {
+		return nil
+	}
+
+	score, err := getReliabilityScore(stub, userID)
+	if err != nil {
+		return err
+	}
+	if score.Deliveries == 0 || score.Score < config.PremiumMinReliability {
+		return newStatusError(StatusConflict, "User "+userID+" has a reliability score of "+strconv.FormatFloat(score.Score, 'f', 3, 64)+
+			", premium slot "+slotID+" requires "+strconv.FormatFloat(config.PremiumMinReliability, 'f', 3, 64)+".")
+	}
+	return nil
+}
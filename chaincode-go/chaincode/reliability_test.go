@@ -0,0 +1,62 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReliabilityScore(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	stub.Creator = newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+
+	enableTrading(t, stub, "110", BuyAction)
+	enableTrading(t, stub, "111", SellAction)
+	putBidMatch(t, stub, BidMatch{ID: "M1", BidSlot: "slot1", BidUnitPrice: 10, BuyerUserId: "110", OriginalBidUnits: 10, SellerUserId: "111"})
+
+	processEnergyBid := func(txID string, energyBidID string, accepted string, sold string) pb.Response {
+		return stub.MockInvoke(txID, [][]byte{[]byte("ProcessEnergyBid"), []byte(energyBidID), []byte("M1"), []byte(accepted),
+			[]byte(accepted), []byte("0"), []byte("0"), []byte(sold), []byte(sold), []byte("0"), []byte("0"), []byte("0"), []byte("")})
+	}
+	registerSellOrder := func(txID string, orderID string) pb.Response {
+		return stub.MockInvoke(txID, [][]byte{[]byte("RegisterOrder"), []byte(""), []byte("BidCreated"), []byte(orderID), []byte(""),
+			[]byte("0"), []byte(""), []byte("premium-1"), []byte("5"), []byte("10"), []byte("111"), []byte("0"), []byte(SellAction)})
+	}
+
+	// Test Case 1: Every new energy bid adds its delivery ratio to the seller's score
+	t.Run("Score", func(t *testing.T) {
+		assertOK(t, processEnergyBid("1", "E1", "10", "10"))
+		assertOK(t, processEnergyBid("2", "E2", "10", "5"))
+		assertOK(t, processEnergyBid("3", "E2", "10", "0"))
+
+		response := stub.MockInvoke("4", [][]byte{[]byte("ReadReliabilityScore"), []byte("111")})
+		assertOK(t, response)
+		var score ReliabilityScore
+		json.Unmarshal(response.GetPayload(), &score)
+		assert.Equal(t, 2, score.Deliveries, "Reprocessed energy bid counted again")
+		assert.InDelta(t, 0.75, score.Score, 0.001, "Score mismatch")
+	})
+
+	// Test Case 2: Past deliveries lose weight over time
+	t.Run("Decay", func(t *testing.T) {
+		score := &ReliabilityScore{Score: 0.5, UpdatedOn: 1000, Weight: 4}
+		assert.Equal(t, 2.0, decayedWeight(score, 1100, 100), "Weight not halved after one half-life")
+		assert.Equal(t, 4.0, decayedWeight(score, 1000, 100), "Weight decayed without time passing")
+	})
+
+	// Test Case 3: Premium slots require a minimum score
+	t.Run("Premium Threshold", func(t *testing.T) {
+		assertOK(t, stub.MockInvoke("5", [][]byte{[]byte("UpdateMarketConfig"),
+			[]byte(`{"premiumSlots": ["premium-1"], "premiumMinReliability": 0.9}`)}))
+
+		response := registerSellOrder("6", "S1")
+		assert.Equal(t, StatusConflict, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "premium slot premium-1 requires 0.900")
+
+		assertOK(t, stub.MockInvoke("7", [][]byte{[]byte("UpdateMarketConfig"), []byte(`{"premiumMinReliability": 0.7}`)}))
+		assertOK(t, registerSellOrder("8", "S1"))
+	})
+}
@@ -0,0 +1,369 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                      Trading Contract Lifecycle Methods                    */
+/* -------------------------------------------------------------------------- */
+
+// RenewTradingContract re-signs an active or expired trading contract, possibly on a newer
+// template version, and moves its expiry date. The signature covers the TradingContractTerms of the
+// contract's actions and the new expiry date. The contract is Active afterwards.
+//
+// Inputs - Array of strings
+//
+//	   0    ,     1      ,        2        ,     3     ,     4
+//	userID  , templateID , templateVersion , signature , expiresOn
+func RenewTradingContract(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting RenewTradingContract")
+
+	if len(args) != 5 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 5 (UserID, TemplateID, TemplateVersion, Signature, ExpiresOn)")
+	}
+
+	userID := args[0]
+	err := requireUserOrRole(stub, userID, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+	contract, err := getTradingContract(stub, userID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if contract.Status != ContractActive && contract.Status != ContractExpired {
+		return statusResponse(StatusConflict, "Only Active or Expired trading contracts can be renewed, contract of user "+userID+" is "+contract.Status+".")
+	}
+
+	expiresOn, err := strconv.ParseInt(args[4], 10, 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse ExpiresOn: "+err.Error())
+	}
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if expiresOn <= now || expiresOn < contract.ExpiresOn {
+		return statusResponse(StatusInvalidArgument, "ExpiresOn has to be in the future and not before the current expiry date.")
+	}
+
+	templateVersion, err := strconv.Atoi(args[2])
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse TemplateVersion: "+err.Error())
+	}
+	terms := TradingContractTerms(contract.Actions, expiresOn, len(contract.History))
+	template, err := verifyContractSignature(stub, userID, args[1], templateVersion, TradingContractType, args[3], terms...)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	contract.SignedContractHash = template.DocumentHash
+	contract.Signature = args[3]
+	contract.TemplateID = template.ID
+	contract.TemplateVersion = template.Version
+	contract.ExpiresOn = expiresOn
+	contract.UpdatedOn = now
+	addContractTransition(stub, contract, ContractActive, "Renewed", now)
+
+	err = putTradingContract(stub, contract)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end RenewTradingContract")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// SuspendTradingContract lets an admin suspend an active trading contract. The reason is
+// kept in the contract history.
+//
+// Inputs - Array of strings
+//
+//	   0    ,   1
+//	userID  , reason
+func SuspendTradingContract(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting SuspendTradingContract")
+
+	if len(args) != 2 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 2 (UserID, Reason)")
+	}
+	err := sanitize_arguments(args)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = requireRole(stub, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = transitionTradingContract(stub, args[0], ContractActive, ContractSuspended, args[1])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end SuspendTradingContract")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// ReinstateTradingContract lets an admin lift the suspension of a trading contract.
+//
+// Inputs - Array of strings
+//
+//	   0    ,   1
+//	userID  , reason
+func ReinstateTradingContract(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReinstateTradingContract")
+
+	if len(args) != 2 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 2 (UserID, Reason)")
+	}
+	err := sanitize_arguments(args)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = requireRole(stub, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = transitionTradingContract(stub, args[0], ContractSuspended, ContractActive, args[1])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end ReinstateTradingContract")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// RevokeTradingContract ends a trading contract for good, called by the user's org or an admin.
+// The user's open orders are cancelled in the same transaction. A revoked user has to sign a
+// new trading contract before trading again.
+//
+// Inputs - Array of strings
+//
+//	   0    ,   1
+//	userID  , reason
+func RevokeTradingContract(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting RevokeTradingContract")
+
+	if len(args) != 2 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 2 (UserID, Reason)")
+	}
+	err := sanitize_arguments(args)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	userID := args[0]
+	err = requireUserOrRole(stub, userID, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	contract, err := getTradingContract(stub, userID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if contract.Status == ContractRevoked {
+		return statusResponse(StatusConflict, "Trading contract of user "+userID+" is already revoked.")
+	}
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	contract.UpdatedOn = now
+	addContractTransition(stub, contract, ContractRevoked, args[1], now)
+
+	err = putTradingContract(stub, contract)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	cancelled, err := cancelOpenOrders(stub, userID, now)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	eventAsBytes, _ := json.Marshal(map[string]interface{}{"userId": userID, "cancelledOrderIds": cancelled})
+	err = stub.SetEvent("TradingContractRevoked", eventAsBytes)
+	if err != nil {
+		return shim.Error("Could not emit TradingContractRevoked event: " + err.Error())
+	}
+
+	fmt.Println("- end RevokeTradingContract")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// ExpireTradingContracts records the Expired state of every active trading contract whose
+// expiry date has passed. Trading is refused after the expiry date either way; this keeps
+// the stored status and history in line with it.
+func ExpireTradingContracts(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ExpireTradingContracts")
+
+	if len(args) != 0 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 0")
+	}
+
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	contracts, err := getStatesByPrefix(stub, "TradingContract_")
+	if err != nil {
+		return shim.Error("Failed to scan trading contracts: " + err.Error())
+	}
+
+	var expired []string
+	for _, kv := range contracts {
+		var contract TradingContract
+		err = json.Unmarshal(kv.Value, &contract)
+		if err != nil {
+			return shim.Error("Failed to unmarshal trading contract " + kv.Key + ": " + err.Error())
+		}
+		if contract.Status != ContractActive || contract.ExpiresOn > now {
+			continue
+		}
+		contract.UpdatedOn = now
+		addContractTransition(stub, &contract, ContractExpired, "Expiry date passed", now)
+		err = putTradingContract(stub, &contract)
+		if err != nil {
+			return errorResponse(err)
+		}
+		expired = append(expired, contract.UserID)
+	}
+
+	expiredAsBytes, _ := json.Marshal(expired)
+
+	fmt.Println("- end ExpireTradingContracts")
+	return shim.Success(expiredAsBytes)
+}
+
+// getTradingContractKey returns the state key of the user's trading contract
+func getTradingContractKey(userID string) string {
+	return "TradingContract_" + userID
+}
+
+// getTradingContract reads the user's trading contract from state
+func getTradingContract(stub shim.ChaincodeStubInterface, userID string) (*TradingContract, error) {
+	var contract TradingContract
+	found, err := getContract(stub, getTradingContractKey(userID), &contract)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, newStatusError(StatusNotFound, "Trading Contract for User with ID "+userID+" does not exist.")
+	}
+	return &contract, nil
+}
+
+// putTradingContract writes the trading contract to state
+func putTradingContract(stub shim.ChaincodeStubInterface, contract *TradingContract) error {
+	contractAsBytes, _ := json.Marshal(contract)
+	err := stub.PutState(getTradingContractKey(contract.UserID), contractAsBytes)
+	if err != nil {
+		return errors.New("Could not store trading contract: " + err.Error())
+	}
+	return nil
+}
+
+// addContractTransition moves the contract to the status and appends the change to its history
+func addContractTransition(stub shim.ChaincodeStubInterface, contract *TradingContract, status string, reason string, now int64) {
+	contract.History = append(contract.History, ContractTransition{
+		FromStatus: contract.Status,
+		Reason:     reason,
+		ToStatus:   status,
+		TxID:       stub.GetTxID(),
+		UpdatedOn:  now,
+	})
+	contract.Status = status
+}
+
+// transitionTradingContract moves the user's trading contract from one status to another
+func transitionTradingContract(stub shim.ChaincodeStubInterface, userID string, from string, to string, reason string) error {
+	contract, err := getTradingContract(stub, userID)
+	if err != nil {
+		return err
+	}
+	if contract.Status != from {
+		return newStatusError(StatusConflict, "Trading contract of user "+userID+" is "+contract.Status+", expected "+from+".")
+	}
+	now, err := getTxTime(stub)
+	if err != nil {
+		return err
+	}
+	contract.UpdatedOn = now
+	addContractTransition(stub, contract, to, reason, now)
+	return putTradingContract(stub, contract)
+}
+
+// validateTradingActions checks the actions a trading contract allows
+func validateTradingActions(actions []string) error {
+	if len(actions) == 0 {
+		return errors.New("A trading contract has to allow at least one action.")
+	}
+	for _, action := range actions {
+		if action != BuyAction && action != SellAction {
+			return errors.New("Invalid action " + action + ". It should be " + BuyAction + " or " + SellAction + ".")
+		}
+	}
+	return nil
+}
+
+// cancelOpenOrders cancels the user's orders that have not been executed yet and returns their IDs
+func cancelOpenOrders(stub shim.ChaincodeStubInterface, userID string, now int64) ([]string, error) {
+	orders, err := getStatesByPrefix(stub, "Order_")
+	if err != nil {
+		return nil, errors.New("Failed to scan orders: " + err.Error())
+	}
+
+	var cancelled []string
+	for _, kv := range orders {
+		var order Order
+		err = json.Unmarshal(kv.Value, &order)
+		if err != nil {
+			return nil, errors.New("Failed to unmarshal order " + kv.Key + ": " + err.Error())
+		}
+		if order.UserID != userID || !isOrderOpen(&order) {
+			continue
+		}
+		// The trading contract event lists the orders, so the OrderCancelled payload is not emitted.
+		cancelRemainingQuantity(&order, "RevokeTradingContract")
+		order.UpdatedOn = now
+		orderAsBytes, _ := json.Marshal(order)
+		err = stub.PutState(kv.Key, orderAsBytes)
+		if err != nil {
+			return nil, errors.New("Could not store order " + order.ID + ": " + err.Error())
+		}
+		cancelled = append(cancelled, order.ID)
+	}
+	return cancelled, nil
+}
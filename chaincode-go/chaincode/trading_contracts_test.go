@@ -0,0 +1,149 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// readTradingContract reads the user's trading contract from the stub
+func readTradingContract(t *testing.T, stub *shimtest.MockStub, userID string) TradingContract {
+	var contract TradingContract
+	contractAsBytes, err := stub.GetState(getTradingContractKey(userID))
+	if err != nil || contractAsBytes == nil {
+		t.Fatalf("Trading contract of user %s not found", userID)
+	}
+	err = json.Unmarshal(contractAsBytes, &contract)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal trading contract: %s", err.Error())
+	}
+	return contract
+}
+
+func TestTradingContractLifecycle(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	admin := newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+	member := newCreator(t, "Org1MSP", nil)
+
+	enableTrading(t, stub, "30", BuyAction)
+
+	registerOrder := func(txID string, orderID string) string {
+		response := stub.MockInvoke(txID, [][]byte{
+			[]byte("RegisterOrder"), []byte("1"), []byte("BidCreated"), []byte(orderID), []byte("0"), []byte("200"), []byte("payment5"),
+			[]byte("slot1"), []byte("300"), []byte("3.5"), []byte("30"), []byte("50"), []byte("Buy"),
+		})
+		return response.GetMessage()
+	}
+
+	// Test Case 1: Only admins can suspend
+	t.Run("Suspend Requires Admin", func(t *testing.T) {
+		stub.Creator = member
+		response := stub.MockInvoke("1", [][]byte{[]byte("SuspendTradingContract"), []byte("30"), []byte("Unpaid fees")})
+
+		assert.Equal(t, StatusUnauthorized, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not authorized")
+	})
+
+	// Test Case 2: A suspended contract blocks trading until it is reinstated
+	t.Run("Suspend and Reinstate", func(t *testing.T) {
+		stub.Creator = admin
+		response := stub.MockInvoke("2", [][]byte{[]byte("SuspendTradingContract"), []byte("30"), []byte("Unpaid fees")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		contract := readTradingContract(t, stub, "30")
+		assert.Equal(t, ContractSuspended, contract.Status, "Contract status mismatch")
+		assert.Equal(t, "Unpaid fees", contract.History[len(contract.History)-1].Reason, "Suspension reason mismatch")
+		assert.Contains(t, registerOrder("3", "31"), "Suspended")
+
+		response = stub.MockInvoke("4", [][]byte{[]byte("ReinstateTradingContract"), []byte("30"), []byte("Fees paid")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		assert.Empty(t, registerOrder("5", "31"))
+	})
+
+	// Test Case 3: Renewal moves the expiry date
+	t.Run("Renew", func(t *testing.T) {
+		// enableTrading registers a fresh key, so renew with a key registered here.
+		signingKey, publicKeyPEM := newSigningKey(t)
+		user := User{ID: "30", MSPID: "Org1MSP", PublicKey: publicKeyPEM}
+		userBytes, _ := json.Marshal(user)
+		stub.MockTransactionStart("setup")
+		_ = stub.PutState("30", userBytes)
+		stub.MockTransactionEnd("setup")
+
+		expiresOn := time.Now().AddDate(2, 0, 0).Unix()
+		revision := len(readTradingContract(t, stub, "30").History)
+		terms := TradingContractTerms([]string{BuyAction}, expiresOn, revision)
+		renewal := [][]byte{
+			[]byte("RenewTradingContract"), []byte("30"), []byte("trading-terms"), []byte("1"),
+			[]byte(signDocumentHash(t, signingKey, ContractSigningMessage("30", "trading-terms", 1, "trading-terms-hash", terms...))), []byte(strconv.FormatInt(expiresOn, 10)),
+		}
+
+		// Only the user's org or an admin can renew.
+		stub.Creator = newCreator(t, "Org2MSP", nil)
+		response := stub.MockInvoke("6", renewal)
+		assert.Equal(t, StatusUnauthorized, response.GetStatus(), "Function unexpectedly succeeded")
+
+		stub.Creator = member
+		response = stub.MockInvoke("6", renewal)
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		contract := readTradingContract(t, stub, "30")
+		assert.Equal(t, expiresOn, contract.ExpiresOn, "Expiry date mismatch")
+		assert.Equal(t, ContractActive, contract.Status, "Contract status mismatch")
+
+		// The renewed contract has a new revision, so the signature can't be replayed.
+		response = stub.MockInvoke("6.1", renewal)
+		assert.Equal(t, StatusInvalidArgument, response.GetStatus(), "Replayed renewal unexpectedly succeeded")
+	})
+
+	// Test Case 4: Revoking cancels the open orders and keeps the history
+	t.Run("Revoke Cancels Open Orders", func(t *testing.T) {
+		stub.Creator = admin
+		response := stub.MockInvoke("7", [][]byte{[]byte("RevokeTradingContract"), []byte("30"), []byte("Contract terminated")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		event := <-stub.ChaincodeEventsChannel
+		assert.Equal(t, "TradingContractRevoked", event.GetEventName(), "Event name mismatch")
+
+		orderAsBytes, _ := stub.GetState("Order_31")
+		var order Order
+		_ = json.Unmarshal(orderAsBytes, &order)
+		assert.Equal(t, OrderCancelledStatus, order.BidStatus, "Open order was not cancelled")
+		assert.Equal(t, 0.0, order.RemainingQuantity, "Remaining quantity was not cancelled")
+
+		contract := readTradingContract(t, stub, "30")
+		var statuses []string
+		for _, transition := range contract.History {
+			statuses = append(statuses, transition.ToStatus)
+		}
+		assert.Equal(t, []string{ContractActive, ContractSuspended, ContractActive, ContractActive, ContractRevoked}, statuses, "History mismatch")
+
+		response = stub.MockInvoke("8", [][]byte{[]byte("RenewTradingContract"), []byte("30"), []byte("trading-terms"), []byte("1"), []byte("sig"), []byte("1")})
+		assert.Equal(t, StatusConflict, response.GetStatus(), "Renewal of a revoked contract unexpectedly succeeded")
+	})
+
+	// Test Case 5: Contracts past their expiry date are marked Expired
+	t.Run("Expire Trading Contracts", func(t *testing.T) {
+		enableTrading(t, stub, "32", SellAction)
+		contract := readTradingContract(t, stub, "32")
+		contract.ExpiresOn = time.Now().Add(-time.Hour).Unix()
+		contractAsBytes, _ := json.Marshal(contract)
+		stub.MockTransactionStart("setup")
+		_ = stub.PutState(getTradingContractKey("32"), contractAsBytes)
+		stub.MockTransactionEnd("setup")
+
+		response := stub.MockInvoke("9", [][]byte{[]byte("ExpireTradingContracts")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var expired []string
+		_ = json.Unmarshal(response.GetPayload(), &expired)
+		assert.Equal(t, []string{"32"}, expired, "Expired contracts mismatch")
+		assert.Equal(t, ContractExpired, readTradingContract(t, stub, "32").Status, "Contract status mismatch")
+	})
+}
@@ -0,0 +1,1568 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	//	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// ============================================================================================================================
+// write() - genric write variable into ledger
+//
+// Shows Off PutState() - writting a key/value into the ledger
+//
+// Inputs - Array of strings
+//    0   ,    1
+//   key  ,  value
+//  "abc" , "test"
+// ============================================================================================================================
+func Write(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var key, value string
+	var err error
+	fmt.Println("starting write")
+
+	if len(args) != 2 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 2. key of the variable and value to set")
+	}
+
+	// input sanitation
+	err = sanitize_arguments(args)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	key = args[0] //rename for funsies
+	value = args[1]
+	err = stub.PutState(key, []byte(value)) //write the variable into the ledger
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end write")
+	return shim.Success(nil)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                             User Write Methods                             */
+/* -------------------------------------------------------------------------- */
+
+// UpdateUserProfile creates or updates a user. The location and contact data are personal data
+// and are passed in the transient map ("location", "contact" and "salt"), never as arguments.
+func UpdateUserProfile(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting UpdateUserProfile")
+
+	if len(args) != 5 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 5")
+	}
+
+	err := sanitize_arguments(args)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Invalid argument: "+err.Error())
+	}
+
+	userID := args[0]
+	existingUserAsBytes, err := stub.GetState(userID)
+
+	var user User
+	if err != nil || existingUserAsBytes == nil {
+		// New user creation
+		user.CreatedOn = time.Now().Unix()
+		user.UpdatedOn = user.CreatedOn
+	} else {
+		// Existing user update
+		err = json.Unmarshal(existingUserAsBytes, &user)
+		if err != nil {
+			return shim.Error("Failed to unmarshal user: " + err.Error())
+		}
+		user.UpdatedOn = time.Now().Unix()
+	}
+
+	user.ID = userID
+	if err != nil {
+		return shim.Error("Failed to convert user ID: " + err.Error())
+	}
+	user.Category = args[1]
+	user.MeterID = args[2]
+	user.Source = args[3]
+	isAdminStr := args[4]
+	isAdminBool, err := strconv.ParseBool(isAdminStr)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse IsAdmin Bool: "+err.Error())
+	} else {
+		user.IsAdmin = isAdminBool
+	}
+
+	// The user belongs to the org that registered it.
+	if user.MSPID == "" {
+		user.MSPID, err = getCallerMSPID(stub)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	// Keep the personal data in the private data collection and only its hashes on public state.
+	details, err := putUserPrivateDetails(stub, user.MSPID, user.ID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if details != nil {
+		user.LocationHash = details.hashOf(details.Location)
+		user.ContactHash = details.hashOf(details.Contact)
+	}
+	user.Location = ""
+	user.Contact = ""
+
+	// Store the user in ledger
+	userAsBytes, _ := json.Marshal(user)
+	err = stub.PutState(user.ID, userAsBytes)
+	if err != nil {
+		return shim.Error("Could not store user: " + err.Error())
+	}
+
+	// Only the user's org can endorse changes to a profile.
+	if existingUserAsBytes == nil {
+		err = setKeyEndorsement(stub, user.ID, user.MSPID)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	if existingUserAsBytes == nil {
+		fmt.Println("- end CreateUser")
+		return shim.Success([]byte(stub.GetTxID()))
+	} else {
+		fmt.Println("- end UpdateUser")
+		return shim.Success([]byte(stub.GetTxID()))
+	}
+}
+
+// UpdateEnterpriseUserProfile creates or updates an enterprise user. As for UpdateUserProfile,
+// the location and contact data are passed in the transient map.
+func UpdateEnterpriseUserProfile(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting UpdateEnterpriseUserProfile")
+
+	if len(args) != 5 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 5")
+	}
+
+	err := sanitize_arguments(args)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Invalid argument: "+err.Error())
+	}
+
+	userID := args[0]
+	existingUserAsBytes, err := stub.GetState(userID)
+
+	var user EnterpriseUser // Use EnterpriseUser struct
+	if err != nil || existingUserAsBytes == nil {
+		// New user creation
+		user.CreatedOn = time.Now().Unix()
+		user.UpdatedOn = user.CreatedOn
+	} else {
+		// Existing user update
+		err = json.Unmarshal(existingUserAsBytes, &user)
+		if err != nil {
+			return shim.Error("Failed to unmarshal user: " + err.Error())
+		}
+		user.UpdatedOn = time.Now().Unix()
+	}
+
+	user.ID = userID
+	user.Category = args[1]
+
+	// Parse MeterIDs from JSON array
+	var meterIDs []string
+	err = json.Unmarshal([]byte(args[2]), &meterIDs)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to unmarshal MeterIDs: "+err.Error())
+	}
+	user.MeterIDs = meterIDs // Assign parsed MeterIDs
+
+	user.Source = args[3]
+	isAdminStr := args[4]
+	isAdminBool, err := strconv.ParseBool(isAdminStr)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse IsAdmin Bool: "+err.Error())
+	} else {
+		user.IsAdmin = isAdminBool
+	}
+
+	// The user belongs to the org that registered it.
+	if user.MSPID == "" {
+		user.MSPID, err = getCallerMSPID(stub)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	// Keep the personal data in the private data collection and only its hashes on public state.
+	details, err := putUserPrivateDetails(stub, user.MSPID, user.ID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if details != nil {
+		user.LocationHash = details.hashOf(details.Location)
+		user.ContactHash = details.hashOf(details.Contact)
+	}
+	user.Location = ""
+	user.Contact = ""
+
+	// Store the user in ledger
+	userAsBytes, _ := json.Marshal(user)
+	err = stub.PutState(user.ID, userAsBytes)
+	if err != nil {
+		return shim.Error("Could not store user: " + err.Error())
+	}
+
+	// Only the user's org can endorse changes to a profile.
+	if existingUserAsBytes == nil {
+		err = setKeyEndorsement(stub, user.ID, user.MSPID)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	if existingUserAsBytes == nil {
+		fmt.Println("- end CreateEnterpriseUser")
+	} else {
+		fmt.Println("- end UpdateEnterpriseUser")
+	}
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// RegisterUserKey registers the PEM encoded public key a user signs contracts with.
+// Only identities of the user's own org can register the key.
+func RegisterUserKey(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting RegisterUserKey")
+
+	// We expect 2 arguments: the user ID and the PEM encoded public key.
+	if len(args) != 2 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 2 (UserID, PublicKey)")
+	}
+
+	userID := args[0]
+	existingUserAsBytes, err := stub.GetState(userID)
+	if err != nil || existingUserAsBytes == nil {
+		return statusResponse(StatusNotFound, "User with ID "+userID+" not found")
+	}
+
+	_, err = parsePublicKey(args[1])
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Invalid public key: "+err.Error())
+	}
+
+	var user User
+	err = json.Unmarshal(existingUserAsBytes, &user)
+	if err != nil {
+		return shim.Error("Failed to unmarshal user: " + err.Error())
+	}
+	callerMSPID, err := getCallerMSPID(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if callerMSPID != user.MSPID {
+		return statusResponse(StatusUnauthorized, "Only identities of "+user.MSPID+" can register the key of user "+userID)
+	}
+
+	// Work on the raw fields so enterprise profiles keep their own attributes.
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(existingUserAsBytes, &fields)
+	if err != nil {
+		return shim.Error("Failed to unmarshal user: " + err.Error())
+	}
+	fields["publicKey"], _ = json.Marshal(args[1])
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	fields["updatedOn"], _ = json.Marshal(now)
+
+	userAsBytes, _ := json.Marshal(fields)
+	err = stub.PutState(userID, userAsBytes)
+	if err != nil {
+		return shim.Error("Could not store user: " + err.Error())
+	}
+
+	fmt.Println("- end RegisterUserKey")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// SignPlatformContract records a user's acceptance of a platform ContractTemplate version.
+// The signature is the user's base64 encoded signature over the ContractSigningMessage of the
+// user and template version, made with the key registered through RegisterUserKey.
+//
+// Inputs - Array of strings
+//
+//	   0    ,     1      ,        2        ,     3
+//	userID  , templateID , templateVersion , signature
+func SignPlatformContract(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting SignPlatformContract")
+
+	if len(args) != 4 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 4 (UserID, TemplateID, TemplateVersion, Signature)")
+	}
+
+	// Check if user exists.
+	userID := args[0]
+	existingUserAsBytes, err := stub.GetState(userID)
+	if err != nil || existingUserAsBytes == nil {
+		return statusResponse(StatusNotFound, "User with ID "+userID+" not found")
+	}
+
+	templateVersion, err := strconv.Atoi(args[2])
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse TemplateVersion: "+err.Error())
+	}
+	template, err := verifyContractSignature(stub, userID, args[1], templateVersion, PlatformContractType, args[3])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// Creating a new platform contract for the user.
+	var contract PlatformContract
+	contract.UserID = userID
+	contract.SignedContractHash = template.DocumentHash
+	contract.Signature = args[3]
+	contract.TemplateID = template.ID
+	contract.TemplateVersion = template.Version
+	contract.CreatedOn, err = getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	contract.UpdatedOn = contract.CreatedOn
+
+	// Store the contract in the ledger using a composite key for uniqueness.
+	// This will use "PlatformContract" as a prefix followed by the user ID.
+	contractKey := "PlatformContract_" + userID
+
+	contractAsBytes, _ := json.Marshal(contract)
+	err = stub.PutState(contractKey, contractAsBytes)
+	if err != nil {
+		return shim.Error("Could not store platform contract: " + err.Error())
+	}
+
+	fmt.Println("- end SignPlatformContract")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// SignTradingContract records a user's acceptance of a trading ContractTemplate version, verified
+// the same way as SignPlatformContract with the TradingContractTerms added to the signed message.
+// The contract starts Active and allows the given trading
+// actions until it expires. A user holds a single trading contract; once it exists it is changed
+// through RenewTradingContract, and only a revoked contract can be replaced by signing again.
+//
+// Inputs - Array of strings
+//
+//	   0    ,     1      ,        2        ,     3     ,                4               ,     5
+//	userID  , templateID , templateVersion , signature , actions (JSON, ["Buy","Sell"]) , expiresOn
+func SignTradingContract(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting SignTradingContract")
+
+	if len(args) != 6 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 6 (UserID, TemplateID, TemplateVersion, Signature, Actions, ExpiresOn)")
+	}
+
+	// Check if user exists.
+	userID := args[0]
+	existingUserAsBytes, err := stub.GetState(userID)
+	if err != nil || existingUserAsBytes == nil {
+		return statusResponse(StatusNotFound, "User with ID "+userID+" not found")
+	}
+
+	var actions []string
+	err = json.Unmarshal([]byte(args[4]), &actions)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse Actions: "+err.Error())
+	}
+	err = validateTradingActions(actions)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	expiresOn, err := strconv.ParseInt(args[5], 10, 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse ExpiresOn: "+err.Error())
+	}
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if expiresOn <= now {
+		return statusResponse(StatusInvalidArgument, "ExpiresOn has to be in the future.")
+	}
+
+	// A user holds one trading contract, a revoked one keeps its history when replaced.
+	var contract TradingContract
+	found, err := getContract(stub, getTradingContractKey(userID), &contract)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if found && contract.Status != ContractRevoked {
+		return statusResponse(StatusConflict, "User "+userID+" already holds a "+contract.Status+" trading contract. Use RenewTradingContract instead.")
+	}
+
+	templateVersion, err := strconv.Atoi(args[2])
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse TemplateVersion: "+err.Error())
+	}
+	terms := TradingContractTerms(actions, expiresOn, len(contract.History))
+	template, err := verifyContractSignature(stub, userID, args[1], templateVersion, TradingContractType, args[3], terms...)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// Creating a new trading contract for the user.
+	contract.UserID = userID
+	contract.Actions = actions
+	contract.SignedContractHash = template.DocumentHash
+	contract.Signature = args[3]
+	contract.TemplateID = template.ID
+	contract.TemplateVersion = template.Version
+	contract.ExpiresOn = expiresOn
+	contract.CreatedOn = now
+	contract.UpdatedOn = contract.CreatedOn
+	addContractTransition(stub, &contract, ContractActive, "Signed", now)
+
+	err = putTradingContract(stub, &contract)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end SignTradingContract")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              Payment Methods                               */
+/* -------------------------------------------------------------------------- */
+
+// RecordPayment stores a payment for an order, and for the bid match the order was filled in
+// when one is given. The PaymentDetail (who was debited, fees, refunds) is passed in the transient
+// map as "paymentDetail" together with a "salt", and is written to the private data collection
+// of the paying user's org. Public state keeps only its salted hash.
+//
+// Inputs - Array of strings
+//
+//	    0     ,      1      ,      2      ,    3   ,        4        ,    5    ,           6
+//	paymentID , paymentType , totalAmount , userID , paymentDetailID , orderID , bidMatchID (may be empty)
+func RecordPayment(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting RecordPayment")
+
+	// Basic argument validation. We expect 7 arguments.
+	if len(args) != 7 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 7.")
+	}
+
+	// Extracting required arguments.
+	paymentID := args[0]
+	paymentType := args[1]
+	totalAmount, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse total amount: "+err.Error())
+	}
+	if totalAmount < 0 {
+		return statusResponse(StatusInvalidArgument, "Total amount can not be negative.")
+	}
+	// Refunds and adjustments are only created by RefundPayment and ResolveDispute.
+	if paymentType == RefundPaymentType || paymentType == AdjustmentPaymentType {
+		return statusResponse(StatusInvalidArgument, "Payment type "+paymentType+" is reserved and can not be recorded directly.")
+	}
+	userID := args[3]
+	paymentDetailID := args[4]
+	orderID := args[5]
+	bidMatchID := args[6]
+
+	// Payment IDs are indexed, so an existing payment is never overwritten.
+	existingPaymentAsBytes, err := stub.GetState("Payment_" + paymentID)
+	if err != nil {
+		return shim.Error("Error accessing state: " + err.Error())
+	}
+	if existingPaymentAsBytes != nil {
+		return statusResponse(StatusConflict, "Payment with ID "+paymentID+" already exists.")
+	}
+
+	err = validatePaymentReferences(stub, userID, orderID, bidMatchID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if bidMatchID != "" {
+		err = checkMatchNotDisputed(stub, bidMatchID)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	ownerMSPID, err := getUserMSPID(stub, userID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// Extracting the private payment detail from the transient map.
+	pdAsBytes, err := getTransientValue(stub, "paymentDetail")
+	if err != nil {
+		return errorResponse(err)
+	}
+	salt, err := getTransientValue(stub, "salt")
+	if err != nil {
+		return errorResponse(err)
+	}
+	if pdAsBytes == nil || salt == nil {
+		return statusResponse(StatusInvalidArgument, "Transient values paymentDetail and salt are required.")
+	}
+
+	var pd PrivatePaymentDetail
+	err = json.Unmarshal(pdAsBytes, &pd.PaymentDetail)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to unmarshal payment detail: "+err.Error())
+	}
+	pd.ID = paymentDetailID
+	pd.Salt = string(salt)
+	// Refunded totals start at zero and only grow through RefundPayment.
+	pd.BidRefundAmount = 0
+	pd.PlatformFeeRefundAmount = 0
+	pd.TokenAmountRefund = 0
+
+	// The platform fee comes from the fee schedule, not from the caller.
+	platformFee, feeScheduleVersion, err := computePlatformFee(stub, userID, totalAmount)
+	if err != nil {
+		return errorResponse(err)
+	}
+	pd.PlatformFee = platformFee
+
+	// Store the PaymentDetail in the private data collection and its hash on public state.
+	err = putPrivatePaymentDetail(stub, ownerMSPID, pd)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	txTime, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// Create and store the Payment entry, using the PaymentDetail ID.
+	p := Payment{
+		BidMatchID:         bidMatchID,
+		CreatedOn:          txTime,
+		FeeScheduleVersion: feeScheduleVersion,
+		ID:                 paymentID,
+		OrderID:            orderID,
+		PaymentDetailID:    pd.ID,
+		PaymentType:        paymentType,
+		TotalAmount:        totalAmount,
+		UserID:             userID,
+	}
+
+	pAsBytes, _ := json.Marshal(p)
+	err = stub.PutState("Payment_"+paymentID, pAsBytes)
+	if err != nil {
+		return shim.Error("Could not store payment: " + err.Error())
+	}
+	err = setSettlementEndorsement(stub, "Payment_"+paymentID, userID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = putPaymentIndexes(stub, &p)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end RecordPayment")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// RefundPayment refunds part or all of a recorded payment through a reversal payment. The amounts
+// are passed in the transient map as "refund", a JSON PaymentDetail holding bidRefundAmount,
+// platformFeeRefundAmount and tokenAmountRefund, together with a "salt" for the reversal's
+// PaymentDetail. Cumulative refunds can not exceed the unit cost, platform fee and token amount
+// of the original payment. Only admins can refund.
+//
+// Inputs - Array of strings
+//
+//	       0        ,        1          ,        2
+//	refundPaymentID , originalPaymentID , paymentDetailID
+func RefundPayment(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting RefundPayment")
+
+	if len(args) != 3 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 3 (RefundPaymentID, OriginalPaymentID, PaymentDetailID)")
+	}
+	err := sanitize_arguments(args)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	err = requireRole(stub, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	refundPaymentID := args[0]
+	existingPaymentAsBytes, err := stub.GetState("Payment_" + refundPaymentID)
+	if err != nil {
+		return shim.Error("Error accessing state: " + err.Error())
+	}
+	if existingPaymentAsBytes != nil {
+		return statusResponse(StatusConflict, "Payment with ID "+refundPaymentID+" already exists.")
+	}
+
+	original, err := getPayment(stub, args[1])
+	if err != nil {
+		return errorResponse(err)
+	}
+	if original.PaymentType == RefundPaymentType {
+		return statusResponse(StatusInvalidArgument, "Payment "+original.ID+" is a refund and can not be refunded.")
+	}
+	err = checkPaymentNotDisputed(stub, original.ID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if original.BidMatchID != "" {
+		err = checkMatchNotDisputed(stub, original.BidMatchID)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	refundAsBytes, err := getTransientValue(stub, "refund")
+	if err != nil {
+		return errorResponse(err)
+	}
+	salt, err := getTransientValue(stub, "salt")
+	if err != nil {
+		return errorResponse(err)
+	}
+	if refundAsBytes == nil || salt == nil {
+		return statusResponse(StatusInvalidArgument, "Transient values refund and salt are required.")
+	}
+	var refund PaymentDetail
+	err = json.Unmarshal(refundAsBytes, &refund)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to unmarshal refund: "+err.Error())
+	}
+	if refund.BidRefundAmount < 0 || refund.PlatformFeeRefundAmount < 0 || refund.TokenAmountRefund < 0 {
+		return statusResponse(StatusInvalidArgument, "Refund amounts can not be negative.")
+	}
+	refundAmount := refund.BidRefundAmount + refund.PlatformFeeRefundAmount + refund.TokenAmountRefund
+	if refundAmount <= 0 {
+		return statusResponse(StatusInvalidArgument, "A refund has to return a positive amount.")
+	}
+
+	// The refunded totals are kept on the private detail of the original payment.
+	pdHash, err := getPaymentDetailHash(stub, original.PaymentDetailID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	originalDetail, err := getPrivatePaymentDetail(stub, pdHash)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	originalDetail.BidRefundAmount += refund.BidRefundAmount
+	originalDetail.PlatformFeeRefundAmount += refund.PlatformFeeRefundAmount
+	originalDetail.TokenAmountRefund += refund.TokenAmountRefund
+	if exceedsAmount(originalDetail.BidRefundAmount, originalDetail.TotalUnitCost) ||
+		exceedsAmount(originalDetail.PlatformFeeRefundAmount, originalDetail.PlatformFee) ||
+		exceedsAmount(originalDetail.TokenAmountRefund, originalDetail.TokenAmount) ||
+		exceedsAmount(original.RefundedAmount+refundAmount, original.TotalAmount) {
+		return statusResponse(StatusConflict, "Refund exceeds the amounts left to refund on payment "+original.ID+".")
+	}
+	err = putPrivatePaymentDetail(stub, pdHash.OwnerMSPID, *originalDetail)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// The reversal moves the refunded amounts back from the payee to the payer.
+	var reversalDetail PrivatePaymentDetail
+	reversalDetail.ID = args[2]
+	reversalDetail.DebitedFrom = originalDetail.CreditedTo
+	reversalDetail.CreditedTo = originalDetail.DebitedFrom
+	reversalDetail.BidRefundAmount = refund.BidRefundAmount
+	reversalDetail.PlatformFeeRefundAmount = refund.PlatformFeeRefundAmount
+	reversalDetail.TokenAmountRefund = refund.TokenAmountRefund
+	reversalDetail.Salt = string(salt)
+	err = putPrivatePaymentDetail(stub, pdHash.OwnerMSPID, reversalDetail)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	txTime, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	reversal := Payment{
+		BidMatchID:        original.BidMatchID,
+		CreatedOn:         txTime,
+		ID:                refundPaymentID,
+		OrderID:           original.OrderID,
+		OriginalPaymentID: original.ID,
+		PaymentDetailID:   reversalDetail.ID,
+		PaymentType:       RefundPaymentType,
+		TotalAmount:       refundAmount,
+		UserID:            original.UserID,
+	}
+	reversalAsBytes, _ := json.Marshal(reversal)
+	err = stub.PutState("Payment_"+reversal.ID, reversalAsBytes)
+	if err != nil {
+		return shim.Error("Could not store payment: " + err.Error())
+	}
+	err = setSettlementEndorsement(stub, "Payment_"+reversal.ID, reversal.UserID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	err = putPaymentIndexes(stub, &reversal)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	original.RefundedAmount += refundAmount
+	original.RefundPaymentIDs = append(original.RefundPaymentIDs, reversal.ID)
+	original.UpdatedOn = reversal.CreatedOn
+	originalAsBytes, _ := json.Marshal(original)
+	err = stub.PutState("Payment_"+original.ID, originalAsBytes)
+	if err != nil {
+		return shim.Error("Could not store payment: " + err.Error())
+	}
+
+	fmt.Println("- end RefundPayment")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// exceedsAmount compares money amounts, ignoring float rounding below a thousandth of a cent
+func exceedsAmount(amount float64, limit float64) bool {
+	return amount-limit > 0.00001
+}
+
+// Secondary indexes of payments, stored as composite keys "<index>\x00<referenced ID>\x00<payment ID>"
+const PaymentsByOrderIndex = "order~payment"
+const PaymentsByBidMatchIndex = "bidMatch~payment"
+const PaymentsByUserIndex = "user~payment"
+
+// validatePaymentReferences checks that the order exists and that the bid match, when given,
+// exists and was made with the order's user as buyer or seller. The paying user has to be the
+// order's user or a party of the bid match.
+func validatePaymentReferences(stub shim.ChaincodeStubInterface, userID string, orderID string, bidMatchID string) error {
+	if orderID == "" {
+		return newStatusError(StatusInvalidArgument, "A payment has to reference an order.")
+	}
+	order, err := getOrder(stub, orderID)
+	if err != nil {
+		return err
+	}
+	if bidMatchID == "" {
+		if userID != order.UserID {
+			return newStatusError(StatusInvalidArgument, "User "+userID+" is not the user of Order "+orderID+".")
+		}
+		return nil
+	}
+	bidMatch, err := getBidMatch(stub, bidMatchID)
+	if err != nil {
+		return err
+	}
+	if bidMatch.BuyerUserId != order.UserID && bidMatch.SellerUserId != order.UserID {
+		return newStatusError(StatusInvalidArgument, "BidMatch "+bidMatchID+" does not involve the user of Order "+orderID+".")
+	}
+	if userID != order.UserID && userID != bidMatch.BuyerUserId && userID != bidMatch.SellerUserId {
+		return newStatusError(StatusInvalidArgument, "User "+userID+" is neither the user of Order "+orderID+" nor a party of BidMatch "+bidMatchID+".")
+	}
+	return nil
+}
+
+// putPaymentIndexes adds the payment to the order, bid match and user indexes
+func putPaymentIndexes(stub shim.ChaincodeStubInterface, p *Payment) error {
+	var indexes [][]string
+	if p.OrderID != "" {
+		indexes = append(indexes, []string{PaymentsByOrderIndex, p.OrderID})
+	}
+	if p.BidMatchID != "" {
+		indexes = append(indexes, []string{PaymentsByBidMatchIndex, p.BidMatchID})
+	}
+	if p.UserID != "" {
+		indexes = append(indexes, []string{PaymentsByUserIndex, p.UserID})
+	}
+	for _, index := range indexes {
+		indexKey, err := stub.CreateCompositeKey(index[0], []string{index[1], p.ID})
+		if err != nil {
+			return errors.New("Could not create " + index[0] + " index key: " + err.Error())
+		}
+		// Only the key is needed, the value can not be nil.
+		err = stub.PutState(indexKey, []byte{0x00})
+		if err != nil {
+			return errors.New("Could not store " + index[0] + " index: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// ReindexPayments adds every stored payment to the payment indexes. Payments recorded before an
+// index was introduced, such as the user index, are only listed by it once reindexed; running it
+// again changes nothing. Only admins can reindex.
+//
+// Inputs - none
+func ReindexPayments(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReindexPayments")
+
+	if len(args) != 0 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 0.")
+	}
+	err := requireRole(stub, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	payments, err := getStatesByPrefix(stub, "Payment_")
+	if err != nil {
+		return shim.Error("Failed to scan payments: " + err.Error())
+	}
+	for _, kv := range payments {
+		var payment Payment
+		err = json.Unmarshal(kv.Value, &payment)
+		if err != nil {
+			return shim.Error("Failed to unmarshal payment " + kv.Key + ": " + err.Error())
+		}
+		err = putPaymentIndexes(stub, &payment)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	fmt.Println("- end ReindexPayments")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+/* -------------------------------------------------------------------------- */
+/*                            Energy Bid  Methods                             */
+/* -------------------------------------------------------------------------- */
+
+func RegisterOrder(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting RegisterOrder")
+
+	// We expect 12 arguments, optionally followed by OrderType, TimeInForce and ProtectionPrice,
+	// and then by ReferencePriceID and MeterID.
+	if len(args) != 12 && len(args) != 15 && len(args) != 16 && len(args) != 17 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 12, 15, 16 or 17.")
+	}
+
+	// Parsing ID first to check existence.
+	orderID := args[2]
+
+	// Check if order with given ID already exists. Existing orders are only changed through
+	// AmendOrder and CancelOrder, which check the owner, the gate and the open quantity.
+	existingOrderAsBytes, err := stub.GetState("Order_" + orderID)
+	if err != nil {
+		return shim.Error("Error accessing state: " + err.Error())
+	}
+	if existingOrderAsBytes != nil {
+		return statusResponse(StatusConflict, "Order with ID "+orderID+" already exists.")
+	}
+
+	txTime, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	var order Order
+	order.CreatedOn = txTime
+	order.ID = orderID
+	order.PriorityTime = txTime
+
+	bidMatchID := args[0]
+
+	bidStatus := args[1]
+
+	// BidStatus check
+	err = validateNewOrderStatus(bidStatus)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	onMarketPrice := args[3]
+
+	orderCost, err := strconv.ParseFloat(args[4], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse OrderCost: "+err.Error())
+	}
+
+	paymentID := args[5]
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse PaymentID: "+err.Error())
+	}
+
+	slotID := args[6]
+	totalQuantity, err := strconv.ParseInt(args[7], 10, 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse TotalQuantity: "+err.Error())
+	}
+
+	unitCost, err := strconv.ParseFloat(args[8], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse UnitCost: "+err.Error())
+	}
+
+	userID := args[9]
+	err = requireUserOrRole(stub, userID, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	slotExecDate, err := strconv.ParseInt(args[10], 10, 64) // Add this line to parse SlotExecDate
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse SlotExecDate: "+err.Error())
+	}
+
+	action := args[11]
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse action: "+err.Error())
+	}
+
+	// Users can only trade under active platform and trading contracts.
+	err = checkTradingContracts(stub, userID, action)
+	if err != nil {
+		return errorResponse(err)
+	}
+	err = checkPremiumEligibility(stub, userID, action, slotID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// Assign parsed values to the order struct
+	order.BidMatchID = bidMatchID
+	order.BidStatus = bidStatus
+	order.OnMarketPrice = onMarketPrice
+	order.OrderCost = orderCost
+	order.PaymentID = paymentID
+	order.SlotID = slotID
+	order.TotalQuantity = totalQuantity
+	err = updateRemainingQuantity(&order)
+	if err != nil {
+		return errorResponse(err)
+	}
+	order.UnitCost = unitCost
+	order.UpdatedOn = txTime
+	order.UserID = userID
+	order.SlotExecDate = slotExecDate // Set the SlotExecDate
+	order.UserAction = action
+
+	// Without the optional arguments the order is a limit order.
+	if len(args) >= 15 {
+		order.OrderType = args[12]
+		order.TimeInForce = args[13]
+		order.ProtectionPrice, err = strconv.ParseFloat(args[14], 64)
+		if err != nil {
+			return statusResponse(StatusInvalidArgument, "Failed to parse ProtectionPrice: "+err.Error())
+		}
+	}
+	err = validateOrderType(&order)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if len(args) >= 16 {
+		order.ReferencePriceID = args[15]
+	}
+	err = checkReferencePrice(stub, order.ReferencePriceID, order.SlotID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if len(args) == 17 {
+		order.MeterID = args[16]
+	}
+	err = checkOrderMeter(stub, &order)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// FillOrKill orders the resting orders can not fill are cancelled on arrival.
+	if order.TimeInForce == FillOrKill {
+		fillable, err := isFillable(stub, &order)
+		if err != nil {
+			return errorResponse(err)
+		}
+		if !fillable {
+			eventAsBytes, _ := json.Marshal(cancelRemainingQuantity(&order, FillOrKill))
+			err = stub.SetEvent("OrderCancelled", eventAsBytes)
+			if err != nil {
+				return shim.Error("Could not emit OrderCancelled event: " + err.Error())
+			}
+		}
+	}
+
+	// Store the order back in the ledger.
+	orderAsBytes, _ := json.Marshal(order)
+	err = stub.PutState("Order_"+order.ID, orderAsBytes)
+	if err != nil {
+		return shim.Error("Could not store order " + order.ID + ": " + err.Error())
+	}
+
+	// Only the owner's org can endorse changes to the order.
+	err = setOwnerEndorsement(stub, "Order_"+order.ID, order.UserID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	fmt.Println("- end RegisterOrder")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// RegisterOrders registers a batch of orders in one transaction. args[0] is a JSON array of
+// Order objects. Every order is validated before any of them is written, so the batch is
+// either stored completely or not at all, and a single OrdersRegistered event is emitted.
+func RegisterOrders(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting RegisterOrders")
+
+	// We expect 1 argument: the JSON array of orders.
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON array of orders).")
+	}
+
+	var batch []Order
+	err := json.Unmarshal([]byte(args[0]), &batch)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to unmarshal orders: "+err.Error())
+	}
+	if len(batch) == 0 {
+		return statusResponse(StatusInvalidArgument, "Order batch must contain at least one order.")
+	}
+
+	config, err := getMarketConfig(stub)
+	if err != nil {
+		return shim.Error("Failed to load market config: " + err.Error())
+	}
+	if len(batch) > config.MaxOrderBatchSize {
+		return statusResponse(StatusInvalidArgument, "Order batch of "+strconv.Itoa(len(batch))+" exceeds the maximum batch size of "+strconv.Itoa(config.MaxOrderBatchSize)+".")
+	}
+
+	// Validate the whole batch before writing anything.
+	orders := make([]Order, len(batch))
+	seen := make(map[string]bool)
+	txTime, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	for i, item := range batch {
+		position := "Order at index " + strconv.Itoa(i)
+		if item.ID == "" {
+			return statusResponse(StatusInvalidArgument, position+" has no ID.")
+		}
+		if seen[item.ID] {
+			return statusResponse(StatusConflict, position+" duplicates order ID "+item.ID+" within the batch.")
+		}
+		seen[item.ID] = true
+
+		if item.UserID == "" || item.SlotID == "" {
+			return statusResponse(StatusInvalidArgument, position+" ("+item.ID+") must have a userId and a slotId.")
+		}
+		if item.TotalQuantity <= 0 {
+			return statusResponse(StatusInvalidArgument, position+" ("+item.ID+") must have a positive totalQuantity.")
+		}
+		err = requireUserOrRole(stub, item.UserID, AdminRole)
+		if err != nil {
+			return errorResponse(prefixError(position+" ("+item.ID+"): ", err))
+		}
+		err = checkTradingContracts(stub, item.UserID, item.UserAction)
+		if err != nil {
+			return errorResponse(prefixError(position+" ("+item.ID+"): ", err))
+		}
+		err = checkPremiumEligibility(stub, item.UserID, item.UserAction, item.SlotID)
+		if err != nil {
+			return errorResponse(prefixError(position+" ("+item.ID+"): ", err))
+		}
+
+		// Existing orders are only changed through AmendOrder and CancelOrder.
+		existingOrderAsBytes, err := stub.GetState("Order_" + item.ID)
+		if err != nil {
+			return shim.Error("Error accessing state: " + err.Error())
+		}
+		if existingOrderAsBytes != nil {
+			return statusResponse(StatusConflict, position+" ("+item.ID+") already exists.")
+		}
+		err = validateNewOrderStatus(item.BidStatus)
+		if err != nil {
+			return errorResponse(prefixError(position+" ("+item.ID+"): ", err))
+		}
+
+		var order Order
+		order.CreatedOn = txTime
+		order.ID = item.ID
+		order.PriorityTime = txTime
+
+		order.BidMatchID = item.BidMatchID
+		order.BidStatus = item.BidStatus
+		order.OnMarketPrice = item.OnMarketPrice
+		order.OrderCost = item.OrderCost
+		order.PaymentID = item.PaymentID
+		order.SlotID = item.SlotID
+		order.TotalQuantity = item.TotalQuantity
+		err = updateRemainingQuantity(&order)
+		if err != nil {
+			return errorResponse(prefixError(position+" ("+item.ID+"): ", err))
+		}
+		order.UnitCost = item.UnitCost
+		order.UpdatedOn = txTime
+		order.UserID = item.UserID
+		order.SlotExecDate = item.SlotExecDate
+		order.UserAction = item.UserAction
+		order.OrderType = item.OrderType
+		order.TimeInForce = item.TimeInForce
+		order.ProtectionPrice = item.ProtectionPrice
+		err = validateOrderType(&order)
+		if err != nil {
+			return errorResponse(prefixError(position+" ("+item.ID+"): ", err))
+		}
+		order.ReferencePriceID = item.ReferencePriceID
+		err = checkReferencePrice(stub, order.ReferencePriceID, order.SlotID)
+		if err != nil {
+			return errorResponse(prefixError(position+" ("+item.ID+"): ", err))
+		}
+		order.MeterID = item.MeterID
+		err = checkOrderMeter(stub, &order)
+		if err != nil {
+			return errorResponse(prefixError(position+" ("+item.ID+"): ", err))
+		}
+		orders[i] = order
+	}
+
+	result := OrderBatchResult{TxID: stub.GetTxID()}
+	// FillOrKill orders are checked against the orders resting before the batch.
+	for i := range orders {
+		order := &orders[i]
+		if order.TimeInForce != FillOrKill {
+			continue
+		}
+		fillable, err := isFillable(stub, order)
+		if err != nil {
+			return errorResponse(err)
+		}
+		if !fillable {
+			cancelRemainingQuantity(order, FillOrKill)
+			result.CancelledOrderIDs = append(result.CancelledOrderIDs, order.ID)
+		}
+	}
+	for _, order := range orders {
+		orderAsBytes, _ := json.Marshal(order)
+		err = stub.PutState("Order_"+order.ID, orderAsBytes)
+		if err != nil {
+			return shim.Error("Could not store order " + order.ID + ": " + err.Error())
+		}
+		err = setOwnerEndorsement(stub, "Order_"+order.ID, order.UserID)
+		if err != nil {
+			return errorResponse(err)
+		}
+		result.OrderIDs = append(result.OrderIDs, order.ID)
+	}
+
+	resultAsBytes, _ := json.Marshal(result)
+	err = stub.SetEvent("OrdersRegistered", resultAsBytes)
+	if err != nil {
+		return shim.Error("Could not emit OrdersRegistered event: " + err.Error())
+	}
+
+	fmt.Println("- end RegisterOrders")
+	return shim.Success(resultAsBytes)
+}
+
+// validateNewOrderStatus checks the status an order is allowed to be created with
+func validateNewOrderStatus(bidStatus string) error {
+	if bidStatus != "BidCreated" && bidStatus != "BidAccepted" {
+		return newStatusError(StatusInvalidArgument, "Invalid BidStatus provided for new Order. It should be BidCreated or BidAccepted.")
+	}
+	return nil
+}
+
+// isOrderOpen reports whether the order can still be matched
+func isOrderOpen(order *Order) bool {
+	return order.BidStatus == "BidCreated" || order.BidStatus == "BidAccepted"
+}
+
+func ProcessBidMatch(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ProcessBidMatch")
+
+	// We expect 10 arguments.
+	if len(args) != 11 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 11.")
+	}
+
+	// Parsing ID first to check existence.
+	bidMatchID := args[6]
+
+	// Check if EnergyBid with the given ID already exists.
+	existingBidMatchAsBytes, err := stub.GetState("BidMatch_" + bidMatchID)
+	if err != nil {
+		return shim.Error("Error accessing state: " + err.Error())
+	}
+
+	var bidMatch BidMatch
+	if existingBidMatchAsBytes != nil {
+		// BidMatch exists, so we will update it.
+		err = json.Unmarshal(existingBidMatchAsBytes, &bidMatch)
+		if err != nil {
+			return shim.Error("Failed to unmarshal existing BidMatch: " + err.Error())
+		}
+	} else {
+		// BidMatch doesn't exist, so we will create a new one.
+		bidMatch.ID = bidMatchID
+	}
+
+	bidMatchTms, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse BidStatus: "+err.Error())
+	}
+	bidSlot := args[1]
+	bidStatus := args[2]
+
+	bidUnitPrice, err := strconv.ParseInt(args[3], 10, 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse BidUnitPrice: "+err.Error())
+	}
+	buyerUserID := args[4]
+	deliveredBidUnits, err := strconv.ParseFloat(args[5], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse DeliveredBidUnits: "+err.Error())
+	}
+	originalBidUnits, err := strconv.ParseFloat(args[7], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse OriginalBidUnits: "+err.Error())
+	}
+	sellerUserID := args[8]
+	transactionBuyID := args[9]
+	transactionSellID := args[10]
+
+	// Both parties have to be allowed to trade their side of the match.
+	err = checkTradingContracts(stub, buyerUserID, BuyAction)
+	if err != nil {
+		return errorResponse(err)
+	}
+	err = checkTradingContracts(stub, sellerUserID, SellAction)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// The matched units are allocated to the orders of both sides, replacing the allocation
+	// of the previous version of the match.
+	var previous *BidMatch
+	if existingBidMatchAsBytes != nil {
+		previousBidMatch := bidMatch
+		previous = &previousBidMatch
+
+		err = checkMatchNotDisputed(stub, bidMatch.ID)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	// Assign parsed values to bidMatch
+	bidMatch.BidMatchTms = bidMatchTms
+	bidMatch.BidSlot = bidSlot
+	bidMatch.BidStatus = bidStatus
+	bidMatch.BidUnitPrice = bidUnitPrice
+	bidMatch.BuyerUserId = buyerUserID
+	bidMatch.DeliveredBidUnits = deliveredBidUnits
+	bidMatch.ID = bidMatchID
+	bidMatch.OriginalBidUnits = originalBidUnits
+	bidMatch.SellerUserId = sellerUserID
+	bidMatch.TransactionBuyID = transactionBuyID
+	bidMatch.TransactionSellID = transactionSellID
+
+	// Zones decide the network charge and the capacity the match uses, which may curtail
+	// the units allocated to the orders.
+	err = attachNetworkCharge(stub, &bidMatch)
+	if err != nil {
+		return errorResponse(err)
+	}
+	err = reserveZoneCapacity(stub, previous, &bidMatch)
+	if err != nil {
+		return errorResponse(err)
+	}
+	err = allocateBidMatch(stub, previous, &bidMatch)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// Store the bidMatch back in the ledger.
+	bidMatchAsBytes, _ := json.Marshal(bidMatch)
+	err = stub.PutState("BidMatch_"+bidMatch.ID, bidMatchAsBytes)
+	if err != nil {
+		return shim.Error("Could not store BidMatch: " + err.Error())
+	}
+
+	// Settlement records need the endorsement of both parties' orgs and the grid operator.
+	if existingBidMatchAsBytes == nil {
+		err = setSettlementEndorsement(stub, "BidMatch_"+bidMatch.ID, buyerUserID, sellerUserID)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	fmt.Println("- end ProcessBidMatch")
+	//return shim.Success(nil)
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+func ProcessEnergyBid(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ProcessEnergyBid")
+
+	// We expect 12 arguments, optionally followed by ReferencePriceID.
+	if len(args) != 12 && len(args) != 13 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 12 or 13.")
+	}
+
+	// Parsing ID first to check existence.
+	energyBidID := args[0]
+
+	// Check if EnergyBid with the given ID already exists.
+	existingEnergyBidAsBytes, err := stub.GetState("EnergyBid_" + energyBidID)
+	if err != nil {
+		return shim.Error("Error accessing state: " + err.Error())
+	}
+
+	var energyBid EnergyBid
+	if existingEnergyBidAsBytes != nil {
+		// EnergyBid exists, so we will update it.
+		err = json.Unmarshal(existingEnergyBidAsBytes, &energyBid)
+		if err != nil {
+			return shim.Error("Failed to unmarshal existing EnergyBid: " + err.Error())
+		}
+	} else {
+		// EnergyBid doesn't exist, so we will create a new one. Its ID names the certificate it
+		// mints, so it can not contain the separator of certificate splits.
+		if strings.Contains(energyBidID, CertificateSplitSeparator) {
+			return statusResponse(StatusInvalidArgument, "EnergyBid ID "+energyBidID+" can not contain "+CertificateSplitSeparator+".")
+		}
+		energyBid.ID = energyBidID
+	}
+
+	bidMatchID := args[1]
+
+	// Settlement needs both parties of the match to still hold active contracts.
+	bidMatch, err := getBidMatch(stub, bidMatchID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	err = checkTradingContracts(stub, bidMatch.BuyerUserId, BuyAction)
+	if err != nil {
+		return errorResponse(err)
+	}
+	err = checkTradingContracts(stub, bidMatch.SellerUserId, SellAction)
+	if err != nil {
+		return errorResponse(err)
+	}
+	err = checkMatchNotDisputed(stub, bidMatch.ID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	initialBidUnits, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse InitialBidUnits: "+err.Error())
+	}
+	acceptedBidUnits, err := strconv.ParseFloat(args[3], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse AcceptedBidUnits: "+err.Error())
+	}
+	buyerMeterUnit, err := strconv.ParseFloat(args[4], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse BuyerMeterUnit: "+err.Error())
+	}
+	sellerMeterUnit, err := strconv.ParseFloat(args[5], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse SellerMeterUnit: "+err.Error())
+	}
+	buyerBroughtUnitFromSeller, err := strconv.ParseFloat(args[6], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse BuyerBroughtUnitFromSeller: "+err.Error())
+	}
+	sellerSoldUnitToBuyer, err := strconv.ParseFloat(args[7], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse SellerSoldUnitToBuyer: "+err.Error())
+	}
+	sellerSoldUnitToGrid, err := strconv.ParseFloat(args[8], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse SellerSoldUnitToGrid: "+err.Error())
+	}
+	buyerSoldUnitToGrid, err := strconv.ParseFloat(args[9], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse BuyerSoldUnitToGrid: "+err.Error())
+	}
+	buyerBroughtUnitFromGrid, err := strconv.ParseFloat(args[10], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse BuyerBroughtUnitFromGrid: "+err.Error())
+	}
+	reason := args[11]
+
+	// The network charge of the match follows the units delivered to the buyer.
+	previousUnits := 0.0
+	if energyBid.BidMatchID == bidMatchID {
+		previousUnits = energyBid.BuyerBroughtUnitFromSeller
+	}
+	err = settleNetworkCharge(stub, bidMatch, previousUnits, buyerBroughtUnitFromSeller)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// Assign parsed values to energyBid
+	energyBid.BidMatchID = bidMatchID
+	energyBid.InitialBidUnits = initialBidUnits
+	energyBid.AcceptedBidUnits = acceptedBidUnits
+	energyBid.BuyerMeterUnit = buyerMeterUnit
+	energyBid.SellerMeterUnit = sellerMeterUnit
+	energyBid.BuyerBroughtUnitFromSeller = buyerBroughtUnitFromSeller
+	energyBid.SellerSoldUnitToBuyer = sellerSoldUnitToBuyer
+	energyBid.SellerSoldUnitToGrid = sellerSoldUnitToGrid
+	energyBid.BuyerSoldUnitToGrid = buyerSoldUnitToGrid
+	energyBid.BuyerBroughtUnitFromGrid = buyerBroughtUnitFromGrid
+	energyBid.Reason = reason
+	energyBid.CreatedOn = time.Now().Unix()
+	// Invoices bill the bid in the period of its first settlement, which a reprocess keeps.
+	if energyBid.SettledOn == 0 {
+		energyBid.SettledOn, err = getTxTime(stub)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	// Without a reference price of its own the settlement uses the one the buy order was priced against.
+	if len(args) == 13 {
+		energyBid.ReferencePriceID = args[12]
+	} else if energyBid.ReferencePriceID == "" {
+		energyBid.ReferencePriceID, err = getOrderReferencePriceID(stub, bidMatch.TransactionBuyID)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+	err = checkReferencePrice(stub, energyBid.ReferencePriceID, bidMatch.BidSlot)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// The buyer pays the platform fee on the energy delivered by the seller.
+	energyBid.PlatformFee, energyBid.FeeScheduleVersion, err = computePlatformFee(stub, bidMatch.BuyerUserId, sellerSoldUnitToBuyer*float64(bidMatch.BidUnitPrice))
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// The first settlement of an energy bid counts towards the seller's reliability.
+	if existingEnergyBidAsBytes == nil {
+		err = updateReliabilityScore(stub, bidMatch.SellerUserId, sellerSoldUnitToBuyer, acceptedBidUnits)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	// Energy delivered from a renewable source earns the buyer guarantees of origin.
+	err = mintCertificate(stub, &energyBid, bidMatch)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// Store the energyBid back in the ledger.
+	energyBidAsBytes, _ := json.Marshal(energyBid)
+	err = stub.PutState("EnergyBid_"+energyBid.ID, energyBidAsBytes)
+	if err != nil {
+		return shim.Error("Could not store EnergyBid: " + err.Error())
+	}
+	if existingEnergyBidAsBytes == nil {
+		err = setSettlementEndorsement(stub, "EnergyBid_"+energyBid.ID, bidMatch.BuyerUserId, bidMatch.SellerUserId)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	fmt.Println("- end ProcessEnergyBid")
+	//return shim.Success(nil)
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+/* -------------------------------------------------------------------------- */
+/*                           Market Config Methods                            */
+/* -------------------------------------------------------------------------- */
+
+// UpdateMarketConfig changes the market parameters. args[0] is a JSON document holding the
+// MarketConfig fields to change; fields that are left out keep their current value.
+func UpdateMarketConfig(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting UpdateMarketConfig")
+
+	// We expect 1 argument: the JSON document with the fields to update.
+	if len(args) != 1 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON market config).")
+	}
+
+	err := requireRole(stub, AdminRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	config, err := getMarketConfig(stub)
+	if err != nil {
+		return shim.Error("Failed to load market config: " + err.Error())
+	}
+
+	err = json.Unmarshal([]byte(args[0]), &config)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to unmarshal market config: "+err.Error())
+	}
+
+	if config.MaxOrderBatchSize < 1 || config.MaxOrderBatchSize > OrderBatchSizeCeiling {
+		return statusResponse(StatusInvalidArgument, "MaxOrderBatchSize must be between 1 and "+strconv.Itoa(OrderBatchSizeCeiling)+".")
+	}
+	if config.OperatorMSPID == "" {
+		return statusResponse(StatusInvalidArgument, "OperatorMSPID must be a non-empty string.")
+	}
+	if config.GridOperatorMSPID == "" {
+		return statusResponse(StatusInvalidArgument, "GridOperatorMSPID must be a non-empty string.")
+	}
+	if config.Market == "" {
+		return statusResponse(StatusInvalidArgument, "Market must be a non-empty string.")
+	}
+	if config.MaxPriceDeviation < 0 {
+		return statusResponse(StatusInvalidArgument, "MaxPriceDeviation can not be negative.")
+	}
+	if config.GateClosureSeconds < 0 {
+		return statusResponse(StatusInvalidArgument, "GateClosureSeconds can not be negative.")
+	}
+	if config.PremiumMinReliability < 0 || config.PremiumMinReliability > 1 {
+		return statusResponse(StatusInvalidArgument, "PremiumMinReliability must be between 0 and 1.")
+	}
+	if config.ReliabilityHalfLifeSeconds <= 0 {
+		return statusResponse(StatusInvalidArgument, "ReliabilityHalfLifeSeconds must be positive.")
+	}
+	config.UpdatedOn, err = getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	configAsBytes, _ := json.Marshal(config)
+	err = stub.PutState(MarketConfigKey, configAsBytes)
+	if err != nil {
+		return shim.Error("Could not store market config: " + err.Error())
+	}
+
+	fmt.Println("- end UpdateMarketConfig")
+	return shim.Success([]byte(stub.GetTxID()))
+}
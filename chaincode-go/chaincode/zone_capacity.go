@@ -0,0 +1,358 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                           Zone Capacity Methods                            */
+/* -------------------------------------------------------------------------- */
+
+// SetZoneCapacity sets the maximum import and export of a zone in a slot. It can only be called
+// by the grid operator. An empty SlotID sets the capacity of every slot without its own.
+//
+// Inputs - Array of strings
+//
+//	0         ,    1       ,    2         ,    3
+//	ZoneID    ,    SlotID  ,    MaxImport ,    MaxExport
+//	"feeder-7",    "slot1" ,    "250"     ,    "120"
+func SetZoneCapacity(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting SetZoneCapacity")
+
+	if len(args) != 4 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 4.")
+	}
+
+	err := requireRole(stub, GridOperatorRole)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if args[0] == "" {
+		return statusResponse(StatusInvalidArgument, "ZoneID must be a non-empty string.")
+	}
+	maxImport, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse MaxImport: "+err.Error())
+	}
+	maxExport, err := strconv.ParseFloat(args[3], 64)
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to parse MaxExport: "+err.Error())
+	}
+	if maxImport < 0 || maxExport < 0 {
+		return statusResponse(StatusInvalidArgument, "MaxImport and MaxExport can not be negative.")
+	}
+
+	now, err := getTxTime(stub)
+	if err != nil {
+		return errorResponse(err)
+	}
+	capacityKey, err := getZoneCapacityKey(stub, args[0], args[1])
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	capacity := ZoneCapacity{MaxExport: maxExport, MaxImport: maxImport, SlotID: args[1], UpdatedOn: now, ZoneID: args[0]}
+	capacityAsBytes, _ := json.Marshal(capacity)
+	err = stub.PutState(capacityKey, capacityAsBytes)
+	if err != nil {
+		return shim.Error("Could not store zone capacity: " + err.Error())
+	}
+
+	fmt.Println("- end SetZoneCapacity")
+	return shim.Success([]byte(stub.GetTxID()))
+}
+
+// ReadZoneUtilization returns the used and available capacity of a zone in a slot, or in every
+// slot the zone was used in when no slot is given.
+//
+// Inputs - Array of strings
+//
+//	0         ,    1
+//	ZoneID    ,    SlotID (optional)
+func ReadZoneUtilization(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("starting ReadZoneUtilization")
+
+	if len(args) != 1 && len(args) != 2 {
+		return statusResponse(StatusInvalidArgument, "Incorrect number of arguments. Expecting 1 or 2.")
+	}
+	zoneID := args[0]
+
+	if len(args) == 2 {
+		utilization, err := getZoneUtilization(stub, zoneID, args[1])
+		if err != nil {
+			return errorResponse(err)
+		}
+		report, err := getZoneUtilizationReport(stub, utilization)
+		if err != nil {
+			return errorResponse(err)
+		}
+		reportAsBytes, _ := json.Marshal(report)
+		fmt.Println("- end ReadZoneUtilization")
+		return shim.Success(reportAsBytes)
+	}
+
+	iterator, err := stub.GetStateByPartialCompositeKey("ZoneUtilization", []string{zoneID})
+	if err != nil {
+		return statusResponse(StatusInvalidArgument, "Failed to query zone utilization: "+err.Error())
+	}
+	defer iterator.Close()
+	reports := []ZoneUtilizationReport{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return shim.Error("Failed to scan zone utilization: " + err.Error())
+		}
+		var utilization ZoneUtilization
+		err = json.Unmarshal(kv.Value, &utilization)
+		if err != nil {
+			return shim.Error("Failed to unmarshal zone utilization " + kv.Key + ": " + err.Error())
+		}
+		report, err := getZoneUtilizationReport(stub, &utilization)
+		if err != nil {
+			return errorResponse(err)
+		}
+		reports = append(reports, *report)
+	}
+	reportsAsBytes, _ := json.Marshal(reports)
+
+	fmt.Println("- end ReadZoneUtilization")
+	return shim.Success(reportsAsBytes)
+}
+
+// getZoneCapacityKey returns the composite state key of the capacity of a zone in a slot
+func getZoneCapacityKey(stub shim.ChaincodeStubInterface, zoneID string, slotID string) (string, error) {
+	key, err := stub.CreateCompositeKey("ZoneCapacity", []string{zoneID, slotID})
+	if err != nil {
+		return "", newStatusError(StatusInvalidArgument, "Invalid zone or slot: "+err.Error())
+	}
+	return key, nil
+}
+
+// getZoneUtilizationKey returns the composite state key of the utilization of a zone in a slot,
+// so the utilization of all the slots of a zone can be queried by its partial key
+func getZoneUtilizationKey(stub shim.ChaincodeStubInterface, zoneID string, slotID string) (string, error) {
+	key, err := stub.CreateCompositeKey("ZoneUtilization", []string{zoneID, slotID})
+	if err != nil {
+		return "", newStatusError(StatusInvalidArgument, "Invalid zone or slot: "+err.Error())
+	}
+	return key, nil
+}
+
+// getZoneCapacity returns the capacity of a zone in a slot, falling back to the capacity of
+// every slot. It returns nil for a zone without capacity limits.
+func getZoneCapacity(stub shim.ChaincodeStubInterface, zoneID string, slotID string) (*ZoneCapacity, error) {
+	for _, slot := range []string{slotID, ""} {
+		key, err := getZoneCapacityKey(stub, zoneID, slot)
+		if err != nil {
+			return nil, err
+		}
+		capacityAsBytes, err := stub.GetState(key)
+		if err != nil {
+			return nil, errors.New("Error accessing state: " + err.Error())
+		}
+		if capacityAsBytes == nil {
+			continue
+		}
+		var capacity ZoneCapacity
+		err = json.Unmarshal(capacityAsBytes, &capacity)
+		if err != nil {
+			return nil, errors.New("Failed to unmarshal zone capacity: " + err.Error())
+		}
+		return &capacity, nil
+	}
+	return nil, nil
+}
+
+// getZoneUtilization returns the utilization of a zone in a slot, empty when it was never used
+func getZoneUtilization(stub shim.ChaincodeStubInterface, zoneID string, slotID string) (*ZoneUtilization, error) {
+	utilization := ZoneUtilization{SlotID: slotID, ZoneID: zoneID}
+	key, err := getZoneUtilizationKey(stub, zoneID, slotID)
+	if err != nil {
+		return nil, err
+	}
+	utilizationAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return nil, errors.New("Error accessing state: " + err.Error())
+	}
+	if utilizationAsBytes != nil {
+		err = json.Unmarshal(utilizationAsBytes, &utilization)
+		if err != nil {
+			return nil, errors.New("Failed to unmarshal zone utilization: " + err.Error())
+		}
+	}
+	return &utilization, nil
+}
+
+// getZoneUtilizationReport compares the utilization of a zone with its capacity
+func getZoneUtilizationReport(stub shim.ChaincodeStubInterface, utilization *ZoneUtilization) (*ZoneUtilizationReport, error) {
+	report := ZoneUtilizationReport{
+		AvailableExport: -1,
+		AvailableImport: -1,
+		Exported:        utilization.Exported,
+		Imported:        utilization.Imported,
+		MaxExport:       -1,
+		MaxImport:       -1,
+		SlotID:          utilization.SlotID,
+		ZoneID:          utilization.ZoneID,
+	}
+	capacity, err := getZoneCapacity(stub, utilization.ZoneID, utilization.SlotID)
+	if err != nil {
+		return nil, err
+	}
+	if capacity != nil {
+		report.MaxExport = capacity.MaxExport
+		report.MaxImport = capacity.MaxImport
+		report.AvailableExport = math.Max(capacity.MaxExport-utilization.Exported, 0)
+		report.AvailableImport = math.Max(capacity.MaxImport-utilization.Imported, 0)
+	}
+	return &report, nil
+}
+
+// isCrossZone reports whether energy flows between two known, different zones
+func isCrossZone(fromZone string, toZone string) bool {
+	return fromZone != "" && toZone != "" && fromZone != toZone
+}
+
+// getAvailableCapacity returns how much energy can still flow from one zone to another in a
+// slot: the smaller of the remaining export and import capacity, or -1 without limits.
+func getAvailableCapacity(stub shim.ChaincodeStubInterface, fromZone string, toZone string, slotID string) (float64, error) {
+	if !isCrossZone(fromZone, toZone) {
+		return -1, nil
+	}
+	exporter, err := getZoneUtilization(stub, fromZone, slotID)
+	if err != nil {
+		return 0, err
+	}
+	importer, err := getZoneUtilization(stub, toZone, slotID)
+	if err != nil {
+		return 0, err
+	}
+	return getRemainingCapacity(stub, exporter, importer)
+}
+
+// getRemainingCapacity returns the smaller of the remaining export capacity of the exporter and
+// the remaining import capacity of the importer, or -1 when neither is limited
+func getRemainingCapacity(stub shim.ChaincodeStubInterface, exporter *ZoneUtilization, importer *ZoneUtilization) (float64, error) {
+	available := -1.0
+	exportReport, err := getZoneUtilizationReport(stub, exporter)
+	if err != nil {
+		return 0, err
+	}
+	if exportReport.AvailableExport >= 0 {
+		available = exportReport.AvailableExport
+	}
+	importReport, err := getZoneUtilizationReport(stub, importer)
+	if err != nil {
+		return 0, err
+	}
+	if importReport.AvailableImport >= 0 && (available < 0 || importReport.AvailableImport < available) {
+		available = importReport.AvailableImport
+	}
+	return available, nil
+}
+
+// reserveZoneCapacity books the units of a cross-zone bid match against the export capacity of
+// the seller's zone and the import capacity of the buyer's zone, releasing the units booked by
+// the previous version of the match first. Matches exceeding the remaining capacity are
+// rejected, or curtailed to it when the market config allows.
+func reserveZoneCapacity(stub shim.ChaincodeStubInterface, previous *BidMatch, bidMatch *BidMatch) error {
+	utilizations := make(map[string]*ZoneUtilization)
+	var keys []string
+	loadUtilization := func(zoneID string, slotID string) (*ZoneUtilization, error) {
+		key, err := getZoneUtilizationKey(stub, zoneID, slotID)
+		if err != nil {
+			return nil, err
+		}
+		if utilization, ok := utilizations[key]; ok {
+			return utilization, nil
+		}
+		utilization, err := getZoneUtilization(stub, zoneID, slotID)
+		if err != nil {
+			return nil, err
+		}
+		utilizations[key] = utilization
+		keys = append(keys, key)
+		return utilization, nil
+	}
+
+	if previous != nil && isCrossZone(previous.SellerZoneID, previous.BuyerZoneID) {
+		exporter, err := loadUtilization(previous.SellerZoneID, previous.BidSlot)
+		if err != nil {
+			return err
+		}
+		importer, err := loadUtilization(previous.BuyerZoneID, previous.BidSlot)
+		if err != nil {
+			return err
+		}
+		exporter.Exported = math.Max(exporter.Exported-previous.OriginalBidUnits, 0)
+		importer.Imported = math.Max(importer.Imported-previous.OriginalBidUnits, 0)
+	}
+
+	bidMatch.CurtailedBidUnits = 0
+	if isCrossZone(bidMatch.SellerZoneID, bidMatch.BuyerZoneID) {
+		exporter, err := loadUtilization(bidMatch.SellerZoneID, bidMatch.BidSlot)
+		if err != nil {
+			return err
+		}
+		importer, err := loadUtilization(bidMatch.BuyerZoneID, bidMatch.BidSlot)
+		if err != nil {
+			return err
+		}
+		available, err := getRemainingCapacity(stub, exporter, importer)
+		if err != nil {
+			return err
+		}
+
+		if available >= 0 && bidMatch.OriginalBidUnits-available > fillTolerance {
+			config, err := getMarketConfig(stub)
+			if err != nil {
+				return err
+			}
+			if !config.CurtailOverCapacity || available <= fillTolerance {
+				return errors.New("BidMatch " + bidMatch.ID + " exceeds the zone capacity: " +
+					strconv.FormatFloat(available, 'f', -1, 64) + " units available from " + bidMatch.SellerZoneID +
+					" to " + bidMatch.BuyerZoneID + " in slot " + bidMatch.BidSlot + ".")
+			}
+			bidMatch.CurtailedBidUnits = bidMatch.OriginalBidUnits - available
+			bidMatch.OriginalBidUnits = available
+		}
+		exporter.Exported += bidMatch.OriginalBidUnits
+		importer.Imported += bidMatch.OriginalBidUnits
+	}
+
+	for _, key := range keys {
+		utilizationAsBytes, _ := json.Marshal(utilizations[key])
+		err := stub.PutState(key, utilizationAsBytes)
+		if err != nil {
+			return errors.New("Could not store zone utilization: " + err.Error())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZoneCapacity(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	admin := newCreator(t, "Org1MSP", map[string]string{RoleAttribute: AdminRole})
+	gridOperator := newCreator(t, "Org3MSP", map[string]string{RoleAttribute: GridOperatorRole})
+	stub.Creator = admin
+
+	enableTrading(t, stub, "80", BuyAction)
+	enableTrading(t, stub, "81", SellAction)
+
+	processBidMatch := func(txID string, bidMatchID string, units string) pb.Response {
+		return invoke(stub, txID, "ProcessBidMatch", "120", "slot3", "BidCreated", "10", "80", "0", bidMatchID, units, "81", "B1", "S1")
+	}
+
+	assertOK(t, invoke(stub, "1", "AssignGridZone", "80", "", "feeder-1"))
+	assertOK(t, invoke(stub, "2", "AssignGridZone", "81", "", "feeder-2"))
+	putOrder(t, stub, Order{ID: "B1", BidStatus: "BidCreated", SlotID: "slot3", TotalQuantity: 20, RemainingQuantity: 20, UserID: "80", UserAction: BuyAction})
+	putOrder(t, stub, Order{ID: "S1", BidStatus: "BidCreated", SlotID: "slot3", TotalQuantity: 20, RemainingQuantity: 20, UserID: "81", UserAction: SellAction})
+
+	// Test Case 1: Only the grid operator maintains zone capacity
+	t.Run("Unauthorized", func(t *testing.T) {
+		response := invoke(stub, "3", "SetZoneCapacity", "feeder-1", "", "5", "5")
+
+		assert.Equal(t, StatusUnauthorized, response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "role gridOperator required")
+	})
+
+	// Test Case 2: Matches beyond the remaining capacity are rejected
+	t.Run("Reject", func(t *testing.T) {
+		stub.Creator = gridOperator
+		assertOK(t, invoke(stub, "4", "SetZoneCapacity", "feeder-1", "", "8", "0"))
+		assertOK(t, invoke(stub, "5", "SetZoneCapacity", "feeder-2", "slot3", "0", "12"))
+
+		stub.Creator = admin
+		assertOK(t, processBidMatch("6", "M1", "6"))
+		response := processBidMatch("7", "M2", "4")
+
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "2 units available from feeder-2 to feeder-1")
+	})
+
+	// Test Case 3: With curtailment enabled the match is cut to the remaining capacity
+	t.Run("Curtail", func(t *testing.T) {
+		assertOK(t, invoke(stub, "8", "UpdateMarketConfig", `{"curtailOverCapacity": true}`))
+		assertOK(t, processBidMatch("9", "M2", "4"))
+
+		bidMatch, _ := getBidMatch(stub, "M2")
+		assert.Equal(t, 2.0, bidMatch.OriginalBidUnits, "Matched units mismatch")
+		assert.Equal(t, 2.0, bidMatch.CurtailedBidUnits, "Curtailed units mismatch")
+		order, _ := getOrder(stub, "B1")
+		assert.Equal(t, 8.0, order.FilledQuantity, "Curtailed units were allocated")
+	})
+
+	// Test Case 4: Utilization shows used versus available capacity
+	t.Run("Utilization", func(t *testing.T) {
+		response := invoke(stub, "10", "ReadZoneUtilization", "feeder-2", "slot3")
+		assertOK(t, response)
+
+		var report ZoneUtilizationReport
+		json.Unmarshal(response.GetPayload(), &report)
+		assert.Equal(t, 8.0, report.Exported, "Exported mismatch")
+		assert.Equal(t, 12.0, report.MaxExport, "Max export mismatch")
+		assert.Equal(t, 4.0, report.AvailableExport, "Available export mismatch")
+
+		response = invoke(stub, "11", "ReadZoneUtilization", "feeder-1")
+		assertOK(t, response)
+		var reports []ZoneUtilizationReport
+		json.Unmarshal(response.GetPayload(), &reports)
+		if assert.Len(t, reports, 1, "Report count mismatch") {
+			assert.Equal(t, 0.0, reports[0].AvailableImport, "Available import mismatch")
+		}
+	})
+
+	// Test Case 5: Zone and slot IDs containing underscores don't share a capacity
+	t.Run("Capacity Keys", func(t *testing.T) {
+		stub.Creator = gridOperator
+		assertOK(t, invoke(stub, "12", "SetZoneCapacity", "a", "b_c", "1", "1"))
+		assertOK(t, invoke(stub, "13", "SetZoneCapacity", "a_b", "c", "3", "3"))
+
+		response := invoke(stub, "14", "ReadZoneUtilization", "a", "b_c")
+		assertOK(t, response)
+		var report ZoneUtilizationReport
+		json.Unmarshal(response.GetPayload(), &report)
+		assert.Equal(t, 1.0, report.MaxImport, "Capacity was overwritten")
+	})
+}
@@ -0,0 +1,128 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Package client is a typed Go client of the energy trading chaincode. Client has a method for
+// every chaincode function; it encodes the positional arguments and transient data the
+// chaincode expects, decodes the results into the chaincode's own asset types and turns
+// chaincode errors into *Error values.
+//
+// Client runs the transactions through a Contract: GatewayContract talks to a peer's Fabric
+// Gateway service, MockLedger runs the chaincode in process for tests and local tools.
+//
+//	contract := client.NewGatewayContract(conn, identity, sign, "mychannel", "basic")
+//	c := client.New(contract)
+//	order, err := c.ReadOrder(ctx, "42")
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+)
+
+// Contract submits and evaluates transactions of the chaincode and streams its events.
+// Submit returns the result of the transaction once it is committed; Evaluate runs it on a
+// peer without ordering it. Transient data is passed to the chaincode out of the
+// transaction, as the private data functions require.
+type Contract interface {
+	Submit(ctx context.Context, function string, args []string, transient map[string][]byte) ([]byte, error)
+	Evaluate(ctx context.Context, function string, args []string) ([]byte, error)
+	ChaincodeEvents(ctx context.Context) (<-chan *ChaincodeEvent, error)
+}
+
+// Client is the typed client of the chaincode.
+type Client struct {
+	contract Contract
+}
+
+// New returns a Client running its transactions through contract.
+func New(contract Contract) *Client {
+	return &Client{contract: contract}
+}
+
+// Contract returns the Contract the client runs its transactions through.
+func (c *Client) Contract() Contract {
+	return c.contract
+}
+
+// submit submits a transaction and returns its result
+func (c *Client) submit(ctx context.Context, function string, args ...string) ([]byte, error) {
+	return c.submitTransient(ctx, function, nil, args...)
+}
+
+// submitTransient submits a transaction with transient data and returns its result
+func (c *Client) submitTransient(ctx context.Context, function string, transient map[string][]byte, args ...string) ([]byte, error) {
+	result, err := c.contract.Submit(ctx, function, args, transient)
+	if err != nil {
+		return nil, decodeError(function, err)
+	}
+	return result, nil
+}
+
+// submitTx submits a transaction whose result is its transaction ID
+func (c *Client) submitTx(ctx context.Context, function string, args ...string) (string, error) {
+	result, err := c.submit(ctx, function, args...)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// submitInto submits a transaction and decodes its JSON result into v
+func (c *Client) submitInto(ctx context.Context, v interface{}, function string, args ...string) error {
+	result, err := c.submit(ctx, function, args...)
+	if err != nil {
+		return err
+	}
+	return decodeResult(function, result, v)
+}
+
+// evaluateInto evaluates a transaction and decodes its JSON result into v
+func (c *Client) evaluateInto(ctx context.Context, v interface{}, function string, args ...string) error {
+	result, err := c.contract.Evaluate(ctx, function, args)
+	if err != nil {
+		return decodeError(function, err)
+	}
+	return decodeResult(function, result, v)
+}
+
+// decodeResult decodes the JSON result of a transaction
+func decodeResult(function string, result []byte, v interface{}) error {
+	err := json.Unmarshal(result, v)
+	if err != nil {
+		return &Error{Function: function, Kind: ErrInvalidResult, Message: "failed to decode result: " + err.Error()}
+	}
+	return nil
+}
+
+// marshalArg encodes a JSON argument
+func marshalArg(v interface{}) string {
+	valueAsBytes, _ := json.Marshal(v)
+	return string(valueAsBytes)
+}
+
+// formatFloat formats a number the way the chaincode parses it
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// formatInt formats an integer the way the chaincode parses it
+func formatInt(i int64) string {
+	return strconv.FormatInt(i, 10)
+}
@@ -0,0 +1,280 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+)
+
+// newMockClient returns a client of the ledger acting as an identity of an MSP
+func newMockClient(t *testing.T, ledger *MockLedger, mspID string, attrs map[string]string) *Client {
+	identity, err := NewMockIdentity(mspID, attrs)
+	require.NoError(t, err)
+	return New(ledger.Contract(identity))
+}
+
+// signHash returns the base64 encoded signature of a contract signing message
+func signHash(t *testing.T, key *ecdsa.PrivateKey, message string) string {
+	digest := sha256.Sum256([]byte(message))
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func TestClientOnMockLedger(t *testing.T) {
+	ctx := context.Background()
+	ledger := NewMockLedger()
+	admin := newMockClient(t, ledger, "Org1MSP", map[string]string{chaincode.RoleAttribute: chaincode.AdminRole})
+	user := newMockClient(t, ledger, "Org1MSP", nil)
+	stranger := newMockClient(t, ledger, "Org2MSP", nil)
+
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(&signingKey.PublicKey)
+	require.NoError(t, err)
+	publicKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER}))
+
+	// Test Case 1: Typed calls encode their arguments and decode the chaincode's records
+	t.Run("Users", func(t *testing.T) {
+		txID, err := user.UpdateUserProfile(ctx, chaincode.User{ID: "20", Category: "Prosumer", MeterID: "M20", Source: "Solar"},
+			&chaincode.UserPrivateDetails{Location: "Main Street 1", Salt: "pepper"})
+		require.NoError(t, err)
+		assert.NotEmpty(t, txID)
+		_, err = user.RegisterUserKey(ctx, "20", publicKeyPEM)
+		require.NoError(t, err)
+
+		profile, err := user.ReadUserProfile(ctx, "20")
+		require.NoError(t, err)
+		assert.Equal(t, "Org1MSP", profile.MSPID)
+		assert.Equal(t, "M20", profile.MeterID)
+		assert.Equal(t, "Main Street 1", profile.Location)
+		assert.NotEmpty(t, profile.LocationHash)
+	})
+
+	// Test Case 2: Chaincode errors are classified into kinds
+	t.Run("Errors", func(t *testing.T) {
+		_, err := user.ReadOrder(ctx, "999")
+		assert.True(t, errors.Is(err, ErrNotFound), err)
+		var clientErr *Error
+		require.True(t, errors.As(err, &clientErr))
+		assert.Equal(t, "ReadOrder", clientErr.Function)
+
+		_, err = stranger.RegisterUserKey(ctx, "20", publicKeyPEM)
+		assert.True(t, errors.Is(err, ErrUnauthorized), err)
+		_, err = user.PublishContractTemplate(ctx, chaincode.ContractTemplate{ID: "terms", ContractType: chaincode.PlatformContractType, Version: 1, DocumentHash: "hash"})
+		assert.True(t, errors.Is(err, ErrUnauthorized), err)
+		_, err = user.AmendOrder(ctx, "999", 1, 1)
+		assert.True(t, errors.Is(err, ErrNotFound), err)
+		_, err = user.ReadFeeSchedule(ctx, 7)
+		assert.Error(t, err)
+	})
+
+	// Test Case 3: Orders go through the trading contracts
+	slotExecDate := time.Now().AddDate(0, 0, 1).Unix()
+	t.Run("Orders", func(t *testing.T) {
+		for _, template := range []chaincode.ContractTemplate{
+			{ID: "platform-terms", ContractType: chaincode.PlatformContractType, Version: 1, DocumentHash: "platform-hash"},
+			{ID: "trading-terms", ContractType: chaincode.TradingContractType, Version: 1, DocumentHash: "trading-hash"},
+		} {
+			_, err := admin.PublishContractTemplate(ctx, template)
+			require.NoError(t, err)
+		}
+		_, err := user.SignPlatformContract(ctx, "20", "platform-terms", 1, signHash(t, signingKey, chaincode.ContractSigningMessage("20", "platform-terms", 1, "platform-hash")))
+		require.NoError(t, err)
+		expiresOn := time.Now().AddDate(1, 0, 0).Unix()
+		terms := chaincode.TradingContractTerms([]string{chaincode.BuyAction}, expiresOn, 0)
+		_, err = user.SignTradingContract(ctx, "20", "trading-terms", 1, signHash(t, signingKey, chaincode.ContractSigningMessage("20", "trading-terms", 1, "trading-hash", terms...)),
+			[]string{chaincode.BuyAction}, expiresOn)
+		require.NoError(t, err)
+		contract, err := user.ReadTradingContract(ctx, "20")
+		require.NoError(t, err)
+		assert.Equal(t, chaincode.ContractActive, contract.Status)
+
+		_, err = user.RegisterOrder(ctx, chaincode.Order{BidStatus: "BidCreated", ID: "1", OnMarketPrice: "0", OrderCost: 200,
+			PaymentID: "payment1", SlotID: "slot1", TotalQuantity: 300, UnitCost: 3.5, UserID: "20", SlotExecDate: slotExecDate, UserAction: chaincode.BuyAction})
+		require.NoError(t, err)
+		_, err = user.AmendOrder(ctx, "1", 250, 3.25)
+		require.NoError(t, err)
+
+		order, err := user.ReadOrder(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(250), order.TotalQuantity)
+		assert.Equal(t, 3.25, order.UnitCost)
+		assert.Equal(t, chaincode.LimitOrder, order.OrderType)
+	})
+
+	// Test Case 4: Evaluated and failed transactions leave no trace on the ledger
+	t.Run("Rollback", func(t *testing.T) {
+		_, err := user.Contract().Evaluate(ctx, "Write", []string{"scratch", "value"})
+		require.NoError(t, err)
+		assert.Nil(t, ledger.Stub().State["scratch"])
+
+		_, err = user.RegisterOrder(ctx, chaincode.Order{BidStatus: "BidCreated", ID: "2", OnMarketPrice: "0", PaymentID: "payment2",
+			SlotID: "slot1", TotalQuantity: 10, UnitCost: 3, UserID: "20", SlotExecDate: slotExecDate, UserAction: chaincode.SellAction})
+		assert.Error(t, err)
+		assert.Nil(t, ledger.Stub().State["Order_2"])
+
+		stub := ledger.Stub()
+		stub.MockTransactionStart("undo")
+		tx := &mockTxStub{MockStub: stub}
+		require.NoError(t, tx.PutState("20", []byte("{}")))
+		require.NoError(t, tx.PutState("scratch", []byte("value")))
+		require.NoError(t, tx.PutPrivateData("Org1MSPPrivateCollection", "scratch", []byte("value")))
+		require.NoError(t, tx.SetStateValidationParameter("scratch", []byte("policy")))
+		tx.rollback()
+		stub.MockTransactionEnd("undo")
+		profile, err := user.ReadUserProfile(ctx, "20")
+		require.NoError(t, err)
+		assert.Equal(t, "20", profile.ID)
+		assert.Nil(t, stub.State["scratch"])
+		assert.Nil(t, stub.PvtState["Org1MSPPrivateCollection"]["scratch"])
+		assert.Nil(t, stub.EndorsementPolicies[""]["scratch"])
+	})
+
+	// Test Case 5: A saved ledger loads with its state
+	t.Run("Save", func(t *testing.T) {
+		var saved bytes.Buffer
+		require.NoError(t, ledger.Save(&saved))
+		loaded, err := LoadMockLedger(&saved)
+		require.NoError(t, err)
+
+		order, err := New(loaded.Contract(&Identity{MSPID: "Org1MSP"})).ReadOrder(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(250), order.TotalQuantity)
+		assert.Equal(t, ledger.Stub().EndorsementPolicies, loaded.Stub().EndorsementPolicies)
+		assert.Equal(t, ledger.Stub().Keys.Len(), loaded.Stub().Keys.Len())
+	})
+
+	// Test Case 6: Committed transactions replay onto another ledger with their history
+	t.Run("Replay", func(t *testing.T) {
+		var committed []*MockTransaction
+		ledger.OnCommit(func(tx *MockTransaction) error {
+			committed = append(committed, tx)
+			return nil
+		})
+		defer ledger.OnCommit(nil)
+		require.NoError(t, user.Write(ctx, "Meter_9", "one"))
+		require.NoError(t, user.Write(ctx, "Meter_9", "two"))
+		require.Len(t, committed, 2)
+		assert.Equal(t, "Write", committed[0].Function)
+		assert.Equal(t, "Org1MSP", committed[0].MSPID)
+		assert.Equal(t, ledger.BlockNumber(), committed[1].BlockNumber)
+
+		replayed := NewMockLedger()
+		for _, tx := range committed {
+			replayed.Replay(tx)
+		}
+		assert.Equal(t, []byte("two"), replayed.State("Meter_9"))
+		assert.Equal(t, ledger.BlockNumber(), replayed.BlockNumber())
+		history := replayed.History("Meter_9")
+		require.Len(t, history, 2)
+		assert.Equal(t, committed[0].TransactionID, history[0].TxId)
+		assert.Equal(t, ledger.History("Meter_9"), history)
+
+		ledger.OnCommit(func(tx *MockTransaction) error {
+			return errors.New("disk full")
+		})
+		assert.EqualError(t, user.Write(ctx, "Meter_9", "three"), "Write: disk full")
+		assert.Equal(t, []byte("two"), ledger.State("Meter_9"))
+	})
+
+	// Test Case 7: Committed events are streamed and decoded
+	t.Run("Events", func(t *testing.T) {
+		eventsCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		events, err := user.Events(eventsCtx)
+		require.NoError(t, err)
+
+		txID, err := user.CancelOrder(ctx, "1")
+		require.NoError(t, err)
+
+		select {
+		case event := <-events:
+			assert.Equal(t, OrderCancelledEvent, event.EventName)
+			assert.Equal(t, txID, event.TransactionID)
+			payload, err := event.Decode()
+			require.NoError(t, err)
+			cancelled, ok := payload.(*OrderCancelled)
+			require.True(t, ok)
+			assert.Equal(t, "1", cancelled.OrderID)
+			assert.Equal(t, float64(250), cancelled.ReleasedQuantity)
+		case <-time.After(5 * time.Second):
+			t.Fatal("No OrderCancelled event received")
+		}
+
+		cancel()
+		for range events {
+		}
+	})
+}
+
+func TestDecodeError(t *testing.T) {
+	// Test Case 1: Chaincode response statuses decide the kind, whatever the message
+	for status, kind := range map[int32]error{
+		chaincode.StatusNotFound:        ErrNotFound,
+		chaincode.StatusUnauthorized:    ErrUnauthorized,
+		chaincode.StatusConflict:        ErrConflict,
+		chaincode.StatusInvalidArgument: ErrInvalidArgument,
+		500:                             ErrRejected,
+	} {
+		err := decodeError("Fn", &ChaincodeError{Message: "Order with ID 7 not found", Status: status})
+		assert.True(t, errors.Is(err, kind), "status %d", status)
+		assert.Equal(t, "Fn: Order with ID 7 not found", err.Error())
+	}
+
+	// Test Case 2: Validation codes of failed commits decide the kind
+	err := decodeError("Fn", &CommitError{Code: "MVCC_READ_CONFLICT", TransactionID: "tx1"})
+	assert.True(t, errors.Is(err, ErrConflict))
+	err = decodeError("Fn", &CommitError{Code: "ENDORSEMENT_POLICY_FAILURE", TransactionID: "tx1"})
+	assert.True(t, errors.Is(err, ErrUnauthorized))
+
+	// Test Case 3: Transport errors are unavailable and keep their cause
+	cause := errors.New("connection refused")
+	err = decodeError("Fn", cause)
+	assert.True(t, errors.Is(err, ErrUnavailable))
+	assert.True(t, errors.Is(err, cause))
+}
+
+func TestWallet(t *testing.T) {
+	wallet := NewWallet(t.TempDir())
+	identity, err := NewMockIdentity("Org1MSP", nil)
+	require.NoError(t, err)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	// Test Case 1: Stored identities sign with their private key
+	require.NoError(t, wallet.Put("appUser", identity, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})))
+	stored, sign, err := wallet.Get("appUser")
+	require.NoError(t, err)
+	assert.Equal(t, identity, stored)
+	digest := sha256.Sum256([]byte("message"))
+	signature, err := sign(digest[:])
+	require.NoError(t, err)
+	assert.True(t, ecdsa.VerifyASN1(&key.PublicKey, digest[:], signature))
+
+	labels, err := wallet.List()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"appUser"}, labels)
+
+	// Test Case 2: Unknown and invalid labels fail
+	_, _, err = wallet.Get("nobody")
+	assert.Error(t, err)
+	_, _, err = wallet.Get("../appUser")
+	assert.Error(t, err)
+}
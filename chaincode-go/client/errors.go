@@ -0,0 +1,128 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package client
+
+import (
+	"errors"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+)
+
+// Kinds of errors. Every error returned by Client is an *Error matching one of them with
+// errors.Is.
+var (
+	// ErrNotFound means a record the transaction refers to does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrUnauthorized means the identity may not run the transaction, or the transaction
+	// lacked the endorsements its keys require.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrInvalidArgument means the chaincode could not parse or accept the arguments.
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrConflict means the transaction collides with the current state, such as a record
+	// that already exists or a concurrent change of the same keys.
+	ErrConflict = errors.New("conflict")
+	// ErrRejected means the chaincode rejected the transaction under a business rule.
+	ErrRejected = errors.New("rejected")
+	// ErrInvalidResult means the result of the transaction could not be decoded.
+	ErrInvalidResult = errors.New("invalid result")
+	// ErrUnavailable means the transaction could not be run, Cause holds the reason.
+	ErrUnavailable = errors.New("unavailable")
+)
+
+// Error is an error of a chaincode function.
+type Error struct {
+	Cause    error
+	Function string
+	Kind     error
+	Message  string
+}
+
+func (e *Error) Error() string {
+	return e.Function + ": " + e.Message
+}
+
+// Is reports whether the error is of the kind target.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Unwrap returns the transport error the Error was caused by, if any.
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// ChaincodeError is returned by Contract implementations when the chaincode responded with an
+// error. Message and Status are the message and status of the chaincode's response.
+type ChaincodeError struct {
+	Message string
+	Status  int32
+}
+
+func (e *ChaincodeError) Error() string {
+	return e.Message
+}
+
+// CommitError is returned by Contract implementations when a transaction was ordered but
+// failed validation. Code is the name of the peer's transaction validation code.
+type CommitError struct {
+	Code          string
+	TransactionID string
+}
+
+func (e *CommitError) Error() string {
+	return "transaction " + e.TransactionID + " failed to commit with status code " + e.Code
+}
+
+// decodeError turns an error of a Contract into an *Error of the function
+func decodeError(function string, err error) error {
+	var chaincodeErr *ChaincodeError
+	if errors.As(err, &chaincodeErr) {
+		return &Error{Function: function, Kind: chaincodeErrorKind(chaincodeErr.Status), Message: chaincodeErr.Message}
+	}
+
+	var commitErr *CommitError
+	if errors.As(err, &commitErr) {
+		kind := ErrRejected
+		switch commitErr.Code {
+		case "MVCC_READ_CONFLICT", "PHANTOM_READ_CONFLICT", "DUPLICATE_TXID":
+			kind = ErrConflict
+		case "ENDORSEMENT_POLICY_FAILURE":
+			kind = ErrUnauthorized
+		}
+		return &Error{Cause: err, Function: function, Kind: kind, Message: commitErr.Error()}
+	}
+
+	return &Error{Cause: err, Function: function, Kind: ErrUnavailable, Message: err.Error()}
+}
+
+// chaincodeErrorKind classifies a chaincode error by its response status
+func chaincodeErrorKind(status int32) error {
+	switch status {
+	case chaincode.StatusInvalidArgument:
+		return ErrInvalidArgument
+	case chaincode.StatusUnauthorized:
+		return ErrUnauthorized
+	case chaincode.StatusNotFound:
+		return ErrNotFound
+	case chaincode.StatusConflict:
+		return ErrConflict
+	}
+	return ErrRejected
+}
@@ -0,0 +1,111 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+)
+
+// Names of the events emitted by the chaincode
+const (
+	DisputeResolvedEvent          = "DisputeResolved"
+	EndorsementPolicyRotatedEvent = "EndorsementPolicyRotated"
+	InvoiceGeneratedEvent         = "InvoiceGenerated"
+	OrderCancelledEvent           = "OrderCancelled"
+	OrdersRegisteredEvent         = "OrdersRegistered"
+	ParticipantDataErasedEvent    = "ParticipantDataErased"
+	ReferencePricePublishedEvent  = "ReferencePricePublished"
+	TradingContractRevokedEvent   = "TradingContractRevoked"
+)
+
+// ChaincodeEvent is an event emitted by a committed transaction of the chaincode.
+type ChaincodeEvent struct {
+	BlockNumber   uint64 `json:"blockNumber"`
+	EventName     string `json:"eventName"`
+	Payload       []byte `json:"payload"`
+	TransactionID string `json:"transactionId"`
+}
+
+// OrderCancelled is the payload of the OrderCancelled event.
+type OrderCancelled struct {
+	CancelledValue   float64 `json:"cancelledValue"`
+	OrderID          string  `json:"orderId"`
+	PaymentID        string  `json:"paymentId"`
+	Reason           string  `json:"reason"`
+	ReleasedQuantity float64 `json:"releasedQuantity"`
+}
+
+// TradingContractRevoked is the payload of the TradingContractRevoked event.
+type TradingContractRevoked struct {
+	CancelledOrderIDs []string `json:"cancelledOrderIds"`
+	UserID            string   `json:"userId"`
+}
+
+// DisputeResolved is the payload of the DisputeResolved event.
+type DisputeResolved struct {
+	AdjustmentPaymentIDs []string `json:"adjustmentPaymentIds"`
+	DisputeID            string   `json:"disputeId"`
+}
+
+// Decode decodes the payload of the event into its type: *chaincode.OrderBatchResult,
+// *OrderCancelled, *TradingContractRevoked, *DisputeResolved, *chaincode.PrivateDataHash (of an
+// invoice), *chaincode.ErasureCertificate, *chaincode.EndorsementPolicy or *chaincode.ReferencePrice.
+func (e *ChaincodeEvent) Decode() (interface{}, error) {
+	var v interface{}
+	switch e.EventName {
+	case OrdersRegisteredEvent:
+		v = &chaincode.OrderBatchResult{}
+	case OrderCancelledEvent:
+		v = &OrderCancelled{}
+	case TradingContractRevokedEvent:
+		v = &TradingContractRevoked{}
+	case DisputeResolvedEvent:
+		v = &DisputeResolved{}
+	case InvoiceGeneratedEvent:
+		v = &chaincode.PrivateDataHash{}
+	case ParticipantDataErasedEvent:
+		v = &chaincode.ErasureCertificate{}
+	case EndorsementPolicyRotatedEvent:
+		v = &chaincode.EndorsementPolicy{}
+	case ReferencePricePublishedEvent:
+		v = &chaincode.ReferencePrice{}
+	default:
+		return nil, errors.New("unknown event " + e.EventName)
+	}
+
+	err := json.Unmarshal(e.Payload, v)
+	if err != nil {
+		return nil, errors.New("failed to decode " + e.EventName + " event: " + err.Error())
+	}
+	return v, nil
+}
+
+// Events streams the events of transactions committed after the call until ctx is done.
+func (c *Client) Events(ctx context.Context) (<-chan *ChaincodeEvent, error) {
+	events, err := c.contract.ChaincodeEvents(ctx)
+	if err != nil {
+		return nil, decodeError("ChaincodeEvents", err)
+	}
+	return events, nil
+}
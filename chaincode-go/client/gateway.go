@@ -0,0 +1,372 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/gateway"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-protos-go/orderer"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                               Gateway Contract                             */
+/* -------------------------------------------------------------------------- */
+
+// Identity is the identity transactions are created by. It implements the Identity interface of
+// the Fabric Gateway client API (github.com/hyperledger/fabric-gateway/pkg/identity).
+type Identity struct {
+	Certificate []byte
+	MSPID       string
+}
+
+// MspID returns the ID of the MSP that issued the identity.
+func (id *Identity) MspID() string {
+	return id.MSPID
+}
+
+// Credentials returns the PEM encoded certificate of the identity.
+func (id *Identity) Credentials() []byte {
+	return id.Certificate
+}
+
+// NewX509Identity returns the identity of a PEM encoded X.509 certificate issued by an MSP.
+func NewX509Identity(mspID string, certificatePEM []byte) *Identity {
+	return &Identity{Certificate: certificatePEM, MSPID: mspID}
+}
+
+// Sign signs the SHA-256 digest of a message with the private key of an Identity.
+type Sign func(digest []byte) ([]byte, error)
+
+// NewPrivateKeySign returns a Sign using an ECDSA private key, with the low-S signatures
+// Fabric requires.
+func NewPrivateKeySign(key *ecdsa.PrivateKey) Sign {
+	return func(digest []byte) ([]byte, error) {
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+		if err != nil {
+			return nil, err
+		}
+		halfOrder := new(big.Int).Rsh(key.Params().N, 1)
+		if s.Cmp(halfOrder) > 0 {
+			s.Sub(key.Params().N, s)
+		}
+		return asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	}
+}
+
+// GatewayContract is a Contract running the transactions of a chaincode on a channel through
+// the Fabric Gateway service of a peer.
+//
+// It speaks the Gateway gRPC protocol the same way the Fabric Gateway client API does, but does
+// not import github.com/hyperledger/fabric-gateway/pkg/client: that client is generated from
+// fabric-protos-go-apiv2, which registers the same protobuf messages as the fabric-protos-go the
+// chaincode shim is built on, and a binary linking both panics at start. Switch to it once the
+// chaincode moves to a shim built on fabric-protos-go-apiv2.
+type GatewayContract struct {
+	chaincodeName string
+	channelName   string
+	client        gateway.GatewayClient
+	identity      *Identity
+	sign          Sign
+}
+
+// NewGatewayContract returns a GatewayContract for a chaincode on a channel, reached through
+// the gRPC connection conn to a peer and acting as identity.
+func NewGatewayContract(conn *grpc.ClientConn, identity *Identity, sign Sign, channelName string, chaincodeName string) *GatewayContract {
+	return &GatewayContract{
+		chaincodeName: chaincodeName,
+		channelName:   channelName,
+		client:        gateway.NewGatewayClient(conn),
+		identity:      identity,
+		sign:          sign,
+	}
+}
+
+// Evaluate runs a transaction on a peer and returns its result without ordering it.
+func (g *GatewayContract) Evaluate(ctx context.Context, function string, args []string) ([]byte, error) {
+	txID, proposal, err := g.newSignedProposal(function, args, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := g.client.Evaluate(ctx, &gateway.EvaluateRequest{TransactionId: txID, ChannelId: g.channelName, ProposedTransaction: proposal})
+	if err != nil {
+		return nil, gatewayError(err)
+	}
+	result := response.GetResult()
+	if result.GetStatus() >= shimErrorThreshold {
+		return nil, &ChaincodeError{Message: result.GetMessage(), Status: result.GetStatus()}
+	}
+	return result.GetPayload(), nil
+}
+
+// Submit endorses a transaction, orders it and waits for it to commit. It returns the result
+// the endorsing peers agreed on.
+func (g *GatewayContract) Submit(ctx context.Context, function string, args []string, transient map[string][]byte) ([]byte, error) {
+	txID, proposal, err := g.newSignedProposal(function, args, transient)
+	if err != nil {
+		return nil, err
+	}
+
+	endorsement, err := g.client.Endorse(ctx, &gateway.EndorseRequest{TransactionId: txID, ChannelId: g.channelName, ProposedTransaction: proposal})
+	if err != nil {
+		return nil, gatewayError(err)
+	}
+	envelope := endorsement.GetPreparedTransaction()
+	result, err := transactionResult(envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope.Signature, err = g.signMessage(envelope.GetPayload())
+	if err != nil {
+		return nil, err
+	}
+	_, err = g.client.Submit(ctx, &gateway.SubmitRequest{TransactionId: txID, ChannelId: g.channelName, PreparedTransaction: envelope})
+	if err != nil {
+		return nil, gatewayError(err)
+	}
+
+	statusRequest, err := proto.Marshal(&gateway.CommitStatusRequest{TransactionId: txID, ChannelId: g.channelName, Identity: g.creator()})
+	if err != nil {
+		return nil, err
+	}
+	signature, err := g.signMessage(statusRequest)
+	if err != nil {
+		return nil, err
+	}
+	commitStatus, err := g.client.CommitStatus(ctx, &gateway.SignedCommitStatusRequest{Request: statusRequest, Signature: signature})
+	if err != nil {
+		return nil, gatewayError(err)
+	}
+	if commitStatus.GetResult() != pb.TxValidationCode_VALID {
+		return nil, &CommitError{Code: commitStatus.GetResult().String(), TransactionID: txID}
+	}
+	return result, nil
+}
+
+// ChaincodeEvents streams the events of the chaincode committed from the next block on. The
+// channel is closed when ctx is done or the stream fails.
+func (g *GatewayContract) ChaincodeEvents(ctx context.Context) (<-chan *ChaincodeEvent, error) {
+	request, err := proto.Marshal(&gateway.ChaincodeEventsRequest{
+		ChannelId:     g.channelName,
+		ChaincodeId:   g.chaincodeName,
+		Identity:      g.creator(),
+		StartPosition: &orderer.SeekPosition{Type: &orderer.SeekPosition_NextCommit{NextCommit: &orderer.SeekNextCommit{}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	signature, err := g.signMessage(request)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := g.client.ChaincodeEvents(ctx, &gateway.SignedChaincodeEventsRequest{Request: request, Signature: signature})
+	if err != nil {
+		return nil, gatewayError(err)
+	}
+
+	events := make(chan *ChaincodeEvent)
+	go func() {
+		defer close(events)
+		for {
+			response, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			for _, event := range response.GetEvents() {
+				select {
+				case events <- &ChaincodeEvent{BlockNumber: response.GetBlockNumber(), EventName: event.GetEventName(), Payload: event.GetPayload(), TransactionID: event.GetTxId()}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
+// shimErrorThreshold is the lowest status of a chaincode error response
+const shimErrorThreshold = 400
+
+// creator returns the serialized identity of the contract
+func (g *GatewayContract) creator() []byte {
+	creator, _ := proto.Marshal(&msp.SerializedIdentity{Mspid: g.identity.MspID(), IdBytes: g.identity.Credentials()})
+	return creator
+}
+
+// signMessage signs the SHA-256 digest of a message
+func (g *GatewayContract) signMessage(message []byte) ([]byte, error) {
+	digest := sha256.Sum256(message)
+	return g.sign(digest[:])
+}
+
+// newSignedProposal builds and signs the proposal of a transaction and returns its ID
+func (g *GatewayContract) newSignedProposal(function string, args []string, transient map[string][]byte) (string, *pb.SignedProposal, error) {
+	nonce := make([]byte, 24)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", nil, err
+	}
+	creator := g.creator()
+	txIDHash := sha256.Sum256(append(append([]byte{}, nonce...), creator...))
+	txID := hex.EncodeToString(txIDHash[:])
+
+	chaincodeID := &pb.ChaincodeID{Name: g.chaincodeName}
+	extension, err := proto.Marshal(&pb.ChaincodeHeaderExtension{ChaincodeId: chaincodeID})
+	if err != nil {
+		return "", nil, err
+	}
+	channelHeader, err := proto.Marshal(&common.ChannelHeader{
+		Type:      int32(common.HeaderType_ENDORSER_TRANSACTION),
+		ChannelId: g.channelName,
+		TxId:      txID,
+		Timestamp: ptypes.TimestampNow(),
+		Extension: extension,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	signatureHeader, err := proto.Marshal(&common.SignatureHeader{Creator: creator, Nonce: nonce})
+	if err != nil {
+		return "", nil, err
+	}
+	header, err := proto.Marshal(&common.Header{ChannelHeader: channelHeader, SignatureHeader: signatureHeader})
+	if err != nil {
+		return "", nil, err
+	}
+
+	input := [][]byte{[]byte(function)}
+	for _, arg := range args {
+		input = append(input, []byte(arg))
+	}
+	invocation, err := proto.Marshal(&pb.ChaincodeInvocationSpec{ChaincodeSpec: &pb.ChaincodeSpec{
+		ChaincodeId: chaincodeID,
+		Input:       &pb.ChaincodeInput{Args: input},
+	}})
+	if err != nil {
+		return "", nil, err
+	}
+	payload, err := proto.Marshal(&pb.ChaincodeProposalPayload{Input: invocation, TransientMap: transient})
+	if err != nil {
+		return "", nil, err
+	}
+	proposal, err := proto.Marshal(&pb.Proposal{Header: header, Payload: payload})
+	if err != nil {
+		return "", nil, err
+	}
+	signature, err := g.signMessage(proposal)
+	if err != nil {
+		return "", nil, err
+	}
+	return txID, &pb.SignedProposal{ProposalBytes: proposal, Signature: signature}, nil
+}
+
+// transactionResult returns the chaincode result carried by an endorsed transaction
+func transactionResult(envelope *common.Envelope) ([]byte, error) {
+	var payload common.Payload
+	err := proto.Unmarshal(envelope.GetPayload(), &payload)
+	if err != nil {
+		return nil, errors.New("failed to unmarshal transaction payload: " + err.Error())
+	}
+	var transaction pb.Transaction
+	err = proto.Unmarshal(payload.GetData(), &transaction)
+	if err != nil {
+		return nil, errors.New("failed to unmarshal transaction: " + err.Error())
+	}
+	if len(transaction.GetActions()) == 0 {
+		return nil, errors.New("endorsed transaction has no actions")
+	}
+	var actionPayload pb.ChaincodeActionPayload
+	err = proto.Unmarshal(transaction.GetActions()[0].GetPayload(), &actionPayload)
+	if err != nil {
+		return nil, errors.New("failed to unmarshal chaincode action payload: " + err.Error())
+	}
+	var responsePayload pb.ProposalResponsePayload
+	err = proto.Unmarshal(actionPayload.GetAction().GetProposalResponsePayload(), &responsePayload)
+	if err != nil {
+		return nil, errors.New("failed to unmarshal proposal response payload: " + err.Error())
+	}
+	var action pb.ChaincodeAction
+	err = proto.Unmarshal(responsePayload.GetExtension(), &action)
+	if err != nil {
+		return nil, errors.New("failed to unmarshal chaincode action: " + err.Error())
+	}
+	return action.GetResponse().GetPayload(), nil
+}
+
+// gatewayError returns the chaincode error reported by the peers of a failed gateway call, or
+// the call's error when no chaincode responded with one
+func gatewayError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	for _, detailAny := range st.Proto().GetDetails() {
+		var detail gateway.ErrorDetail
+		if ptypes.UnmarshalAny(detailAny, &detail) != nil {
+			continue
+		}
+		chaincodeErr := parseChaincodeResponse(detail.GetMessage())
+		if chaincodeErr != nil {
+			return chaincodeErr
+		}
+	}
+	chaincodeErr := parseChaincodeResponse(st.Message())
+	if chaincodeErr != nil {
+		return chaincodeErr
+	}
+	return err
+}
+
+// parseChaincodeResponse parses the "chaincode response <status>, <message>" the peers report
+// chaincode errors with
+func parseChaincodeResponse(message string) *ChaincodeError {
+	const prefix = "chaincode response "
+	i := strings.Index(message, prefix)
+	if i < 0 {
+		return nil
+	}
+	statusAndMessage := strings.SplitN(message[i+len(prefix):], ", ", 2)
+	if len(statusAndMessage) != 2 {
+		return nil
+	}
+	responseStatus, err := strconv.ParseInt(statusAndMessage[0], 10, 32)
+	if err != nil {
+		return nil
+	}
+	return &ChaincodeError{Message: statusAndMessage[1], Status: int32(responseStatus)}
+}
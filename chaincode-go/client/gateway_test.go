@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/gateway"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+)
+
+// fakeGateway is a Gateway service checking the signatures of its requests and answering
+// with the arguments of the transactions
+type fakeGateway struct {
+	gateway.UnimplementedGatewayServer
+	t         *testing.T
+	committed map[string]pb.TxValidationCode
+}
+
+// invocation verifies a signed proposal and returns its transaction ID and arguments
+func (g *fakeGateway) invocation(signed *pb.SignedProposal) (string, []string) {
+	var proposal pb.Proposal
+	require.NoError(g.t, proto.Unmarshal(signed.ProposalBytes, &proposal))
+	var header common.Header
+	require.NoError(g.t, proto.Unmarshal(proposal.Header, &header))
+	var channelHeader common.ChannelHeader
+	require.NoError(g.t, proto.Unmarshal(header.ChannelHeader, &channelHeader))
+	var signatureHeader common.SignatureHeader
+	require.NoError(g.t, proto.Unmarshal(header.SignatureHeader, &signatureHeader))
+	g.verify(signatureHeader.Creator, signed.ProposalBytes, signed.Signature)
+	assert.Equal(g.t, "mychannel", channelHeader.ChannelId)
+
+	var payload pb.ChaincodeProposalPayload
+	require.NoError(g.t, proto.Unmarshal(proposal.Payload, &payload))
+	var invocation pb.ChaincodeInvocationSpec
+	require.NoError(g.t, proto.Unmarshal(payload.Input, &invocation))
+	assert.Equal(g.t, "energy", invocation.ChaincodeSpec.ChaincodeId.Name)
+	var args []string
+	for _, arg := range invocation.ChaincodeSpec.Input.Args {
+		args = append(args, string(arg))
+	}
+	return channelHeader.TxId, args
+}
+
+// verify checks the signature of a message by a serialized identity
+func (g *fakeGateway) verify(creator []byte, message []byte, signature []byte) {
+	var identity msp.SerializedIdentity
+	require.NoError(g.t, proto.Unmarshal(creator, &identity))
+	block, _ := pem.Decode(identity.IdBytes)
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(g.t, err)
+	digest := sha256.Sum256(message)
+	assert.True(g.t, ecdsa.VerifyASN1(certificate.PublicKey.(*ecdsa.PublicKey), digest[:], signature), "invalid signature")
+}
+
+// chaincodeError returns the error the gateway reports a failed chaincode call with
+func chaincodeError(responseStatus int32, message string) error {
+	st, _ := status.New(codes.Aborted, "failed to endorse transaction").WithDetails(&gateway.ErrorDetail{
+		Address: "peer0.org1.example.com:7051",
+		MspId:   "Org1MSP",
+		Message: "chaincode response " + strconv.Itoa(int(responseStatus)) + ", " + message,
+	})
+	return st.Err()
+}
+
+func (g *fakeGateway) Evaluate(ctx context.Context, request *gateway.EvaluateRequest) (*gateway.EvaluateResponse, error) {
+	_, args := g.invocation(request.ProposedTransaction)
+	if args[0] == "ReadOrder" && args[1] == "999" {
+		return nil, chaincodeError(chaincode.StatusNotFound, "Order with ID 999 not found")
+	}
+	return &gateway.EvaluateResponse{Result: &pb.Response{Status: 200, Payload: []byte(`{"id":"` + args[1] + `","userId":"20"}`)}}, nil
+}
+
+func (g *fakeGateway) Endorse(ctx context.Context, request *gateway.EndorseRequest) (*gateway.EndorseResponse, error) {
+	txID, args := g.invocation(request.ProposedTransaction)
+	if args[0] == "CancelOrder" {
+		g.committed[txID] = pb.TxValidationCode_MVCC_READ_CONFLICT
+	} else {
+		g.committed[txID] = pb.TxValidationCode_VALID
+	}
+
+	action, _ := proto.Marshal(&pb.ChaincodeAction{Response: &pb.Response{Status: 200, Payload: []byte(strings.Join(args, "|"))}})
+	responsePayload, _ := proto.Marshal(&pb.ProposalResponsePayload{Extension: action})
+	actionPayload, _ := proto.Marshal(&pb.ChaincodeActionPayload{Action: &pb.ChaincodeEndorsedAction{ProposalResponsePayload: responsePayload}})
+	transaction, _ := proto.Marshal(&pb.Transaction{Actions: []*pb.TransactionAction{{Payload: actionPayload}}})
+	payload, _ := proto.Marshal(&common.Payload{Data: transaction})
+	return &gateway.EndorseResponse{PreparedTransaction: &common.Envelope{Payload: payload}}, nil
+}
+
+func (g *fakeGateway) Submit(ctx context.Context, request *gateway.SubmitRequest) (*gateway.SubmitResponse, error) {
+	var payload common.Payload
+	require.NoError(g.t, proto.Unmarshal(request.PreparedTransaction.Payload, &payload))
+	assert.NotEmpty(g.t, request.PreparedTransaction.Signature)
+	return &gateway.SubmitResponse{}, nil
+}
+
+func (g *fakeGateway) CommitStatus(ctx context.Context, request *gateway.SignedCommitStatusRequest) (*gateway.CommitStatusResponse, error) {
+	var statusRequest gateway.CommitStatusRequest
+	require.NoError(g.t, proto.Unmarshal(request.Request, &statusRequest))
+	g.verify(statusRequest.Identity, request.Request, request.Signature)
+	return &gateway.CommitStatusResponse{Result: g.committed[statusRequest.TransactionId], BlockNumber: 7}, nil
+}
+
+func TestGatewayContract(t *testing.T) {
+	ctx := context.Background()
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	gateway.RegisterGatewayServer(server, &fakeGateway{t: t, committed: map[string]pb.TxValidationCode{}})
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	identity := NewX509Identity("Org1MSP", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}))
+	c := New(NewGatewayContract(conn, identity, NewPrivateKeySign(key), "mychannel", "energy"))
+
+	// Test Case 1: Evaluated transactions return the decoded result
+	order, err := c.ReadOrder(ctx, "7")
+	require.NoError(t, err)
+	assert.Equal(t, "7", order.ID)
+	assert.Equal(t, "20", order.UserID)
+
+	// Test Case 2: Chaincode errors reported by the gateway are decoded
+	_, err = c.ReadOrder(ctx, "999")
+	assert.True(t, errors.Is(err, ErrNotFound), err)
+	assert.Equal(t, "ReadOrder: Order with ID 999 not found", err.Error())
+
+	// Test Case 3: Submitted transactions return the endorsed result once committed
+	result, err := c.AmendOrder(ctx, "7", 250, 3.25)
+	require.NoError(t, err)
+	assert.Equal(t, "AmendOrder|7|250|3.25", result)
+
+	// Test Case 4: Transactions failing validation are conflicts
+	_, err = c.CancelOrder(ctx, "7")
+	assert.True(t, errors.Is(err, ErrConflict), err)
+	var commitErr *CommitError
+	require.True(t, errors.As(err, &commitErr))
+	assert.Equal(t, "MVCC_READ_CONFLICT", commitErr.Code)
+}
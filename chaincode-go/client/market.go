@@ -0,0 +1,234 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package client
+
+import (
+	"context"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                 Grid Zones                                 */
+/* -------------------------------------------------------------------------- */
+
+// AssignGridZone connects a participant, or one of its meters when meterID is set, to a grid
+// zone.
+func (c *Client) AssignGridZone(ctx context.Context, userID string, meterID string, zoneID string) (string, error) {
+	return c.submitTx(ctx, "AssignGridZone", userID, meterID, zoneID)
+}
+
+// SetNetworkTariff sets the network charge per unit between two zones.
+func (c *Client) SetNetworkTariff(ctx context.Context, fromZone string, toZone string, chargePerUnit float64) (string, error) {
+	return c.submitTx(ctx, "SetNetworkTariff", fromZone, toZone, formatFloat(chargePerUnit))
+}
+
+// ReadNetworkTariff reads the network tariff between two zones.
+func (c *Client) ReadNetworkTariff(ctx context.Context, fromZone string, toZone string) (*chaincode.NetworkTariff, error) {
+	var tariff chaincode.NetworkTariff
+	err := c.evaluateInto(ctx, &tariff, "ReadNetworkTariff", fromZone, toZone)
+	if err != nil {
+		return nil, err
+	}
+	return &tariff, nil
+}
+
+// SetZoneCapacity sets the maximum import and export of a zone in a slot, or in every slot
+// without its own capacity when slotID is empty.
+func (c *Client) SetZoneCapacity(ctx context.Context, zoneID string, slotID string, maxImport float64, maxExport float64) (string, error) {
+	return c.submitTx(ctx, "SetZoneCapacity", zoneID, slotID, formatFloat(maxImport), formatFloat(maxExport))
+}
+
+// ReadZoneUtilization reads the utilization of a zone in a slot.
+func (c *Client) ReadZoneUtilization(ctx context.Context, zoneID string, slotID string) (*chaincode.ZoneUtilizationReport, error) {
+	var report chaincode.ZoneUtilizationReport
+	err := c.evaluateInto(ctx, &report, "ReadZoneUtilization", zoneID, slotID)
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ReadZoneUtilizations reads the utilization of a zone in every slot it was used in.
+func (c *Client) ReadZoneUtilizations(ctx context.Context, zoneID string) ([]chaincode.ZoneUtilizationReport, error) {
+	var reports []chaincode.ZoneUtilizationReport
+	err := c.evaluateInto(ctx, &reports, "ReadZoneUtilization", zoneID)
+	if err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                          Certificates and Carbon                           */
+/* -------------------------------------------------------------------------- */
+
+// TransferCertificate moves a quantity of a certificate to another participant and returns
+// the ID of the transferred certificate.
+func (c *Client) TransferCertificate(ctx context.Context, certificateID string, toUserID string, quantity float64) (string, error) {
+	return c.submitTx(ctx, "TransferCertificate", certificateID, toUserID, formatFloat(quantity))
+}
+
+// RetireCertificate retires a certificate on behalf of its holder.
+func (c *Client) RetireCertificate(ctx context.Context, certificateID string) (string, error) {
+	return c.submitTx(ctx, "RetireCertificate", certificateID)
+}
+
+// ReadCertificate reads a certificate.
+func (c *Client) ReadCertificate(ctx context.Context, certificateID string) (*chaincode.Certificate, error) {
+	var certificate chaincode.Certificate
+	err := c.evaluateInto(ctx, &certificate, "ReadCertificate", certificateID)
+	if err != nil {
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+// ReadRetiredCertificates reads the certificates a user retired in a month (YYYY-MM).
+func (c *Client) ReadRetiredCertificates(ctx context.Context, userID string, period string) (*chaincode.RetirementReport, error) {
+	var report chaincode.RetirementReport
+	err := c.evaluateInto(ctx, &report, "ReadRetiredCertificates", userID, period)
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// SetEmissionFactor sets the emissions per unit of an energy source.
+func (c *Client) SetEmissionFactor(ctx context.Context, source string, kgCO2PerUnit float64) (string, error) {
+	return c.submitTx(ctx, "SetEmissionFactor", source, formatFloat(kgCO2PerUnit))
+}
+
+// ReadEmissionFactors reads the emission factors of all energy sources.
+func (c *Client) ReadEmissionFactors(ctx context.Context) ([]chaincode.EmissionFactor, error) {
+	var factors []chaincode.EmissionFactor
+	err := c.evaluateInto(ctx, &factors, "ReadEmissionFactors")
+	if err != nil {
+		return nil, err
+	}
+	return factors, nil
+}
+
+// ReadCarbonReport reads the emissions of a participant's purchases settled between from and
+// to (unix seconds, to exclusive).
+func (c *Client) ReadCarbonReport(ctx context.Context, userID string, from int64, to int64) (*chaincode.CarbonReport, error) {
+	var report chaincode.CarbonReport
+	err := c.evaluateInto(ctx, &report, "ReadCarbonReport", userID, formatInt(from), formatInt(to))
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                Price Oracles                               */
+/* -------------------------------------------------------------------------- */
+
+// RegisterPriceOracle registers, or re-registers and reactivates, a price oracle from its ID,
+// MSPID, PublicKey (PEM) and Markets.
+func (c *Client) RegisterPriceOracle(ctx context.Context, oracle chaincode.PriceOracle) (string, error) {
+	markets := oracle.Markets
+	if markets == nil {
+		markets = []string{}
+	}
+	return c.submitTx(ctx, "RegisterPriceOracle", oracle.ID, oracle.MSPID, oracle.PublicKey, marshalArg(markets))
+}
+
+// RevokePriceOracle revokes a price oracle.
+func (c *Client) RevokePriceOracle(ctx context.Context, oracleID string) (string, error) {
+	return c.submitTx(ctx, "RevokePriceOracle", oracleID)
+}
+
+// PublishReferencePrice publishes a reference price from its ID, OracleID, Market, SlotID,
+// Price and Signature.
+func (c *Client) PublishReferencePrice(ctx context.Context, price chaincode.ReferencePrice) (string, error) {
+	return c.submitTx(ctx, "PublishReferencePrice", price.ID, price.OracleID, price.Market, price.SlotID,
+		formatFloat(price.Price), price.Signature)
+}
+
+// ReadReferencePrice reads a reference price.
+func (c *Client) ReadReferencePrice(ctx context.Context, priceID string) (*chaincode.ReferencePrice, error) {
+	var price chaincode.ReferencePrice
+	err := c.evaluateInto(ctx, &price, "ReadReferencePrice", priceID)
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+// ReadLatestReferencePrice reads the latest reference price of a slot in a market.
+func (c *Client) ReadLatestReferencePrice(ctx context.Context, market string, slotID string) (*chaincode.ReferencePrice, error) {
+	var price chaincode.ReferencePrice
+	err := c.evaluateInto(ctx, &price, "ReadReferencePrice", market, slotID)
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+// ReadReferencePriceHistory reads the reference prices of a slot in a market, oldest first.
+func (c *Client) ReadReferencePriceHistory(ctx context.Context, market string, slotID string) ([]chaincode.ReferencePrice, error) {
+	var history []chaincode.ReferencePrice
+	err := c.evaluateInto(ctx, &history, "ReadReferencePriceHistory", market, slotID)
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                            Market Administration                           */
+/* -------------------------------------------------------------------------- */
+
+// RotateEndorsementPolicy replaces the orgs whose peers must endorse changes to a key.
+func (c *Client) RotateEndorsementPolicy(ctx context.Context, key string, orgs []string) (string, error) {
+	return c.submitTx(ctx, "RotateEndorsementPolicy", key, marshalArg(orgs))
+}
+
+// ReadEndorsementPolicy reads the endorsement policy of a key.
+func (c *Client) ReadEndorsementPolicy(ctx context.Context, key string) (*chaincode.EndorsementPolicy, error) {
+	var policy chaincode.EndorsementPolicy
+	err := c.evaluateInto(ctx, &policy, "ReadEndorsementPolicy", key)
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// UpdateMarketConfig replaces the market configuration.
+func (c *Client) UpdateMarketConfig(ctx context.Context, config chaincode.MarketConfig) (string, error) {
+	return c.submitTx(ctx, "UpdateMarketConfig", marshalArg(config))
+}
+
+// ReadMarketConfig reads the market configuration.
+func (c *Client) ReadMarketConfig(ctx context.Context) (*chaincode.MarketConfig, error) {
+	var config chaincode.MarketConfig
+	err := c.evaluateInto(ctx, &config, "ReadMarketConfig")
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Write writes a raw value to a key of the world state.
+func (c *Client) Write(ctx context.Context, key string, value string) error {
+	_, err := c.submit(ctx, "Write", key, value)
+	return err
+}
@@ -0,0 +1,566 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/attrmgr"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/msp"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                 Mock Ledger                                */
+/* -------------------------------------------------------------------------- */
+
+// MockLedger runs the chaincode in process on a shimtest.MockStub. Transactions run one at a
+// time; a submitted transaction whose chaincode response is an error is rolled back and emits
+// no events, and evaluated transactions are always rolled back, as on a peer.
+type MockLedger struct {
+	blockNumber   uint64
+	chaincode     *chaincode.SimpleChaincode
+	history       map[string][]*queryresult.KeyModification
+	mutex         sync.Mutex
+	onCommit      func(*MockTransaction) error
+	stub          *shimtest.MockStub
+	subscriptions map[*eventSubscription]struct{}
+}
+
+// MockTransaction is a transaction committed to a MockLedger, with the writes and events it
+// committed. Transient data is not kept.
+type MockTransaction struct {
+	Args          []string          `json:"args"`
+	BlockNumber   uint64            `json:"blockNumber"`
+	Events        []*ChaincodeEvent `json:"events"`
+	Function      string            `json:"function"`
+	MSPID         string            `json:"mspId"`
+	Timestamp     time.Time         `json:"timestamp"`
+	TransactionID string            `json:"transactionId"`
+	Writes        []MockWrite       `json:"writes"`
+}
+
+// MockWrite is a write of a MockTransaction: a key of the world state, a key of a private data
+// collection when Collection is set, or the endorsement policy of a key when Policy is set.
+type MockWrite struct {
+	Collection string `json:"collection,omitempty"`
+	Delete     bool   `json:"delete,omitempty"`
+	Key        string `json:"key"`
+	Policy     bool   `json:"policy,omitempty"`
+	Value      []byte `json:"value,omitempty"`
+}
+
+// NewMockLedger returns an empty MockLedger.
+func NewMockLedger() *MockLedger {
+	cc := new(chaincode.SimpleChaincode)
+	return &MockLedger{
+		chaincode:     cc,
+		history:       make(map[string][]*queryresult.KeyModification),
+		stub:          shimtest.NewMockStub("energy-trading", cc),
+		subscriptions: make(map[*eventSubscription]struct{}),
+	}
+}
+
+// Contract returns a Contract running the transactions of the ledger as identity.
+func (l *MockLedger) Contract(identity *Identity) Contract {
+	creator, _ := proto.Marshal(&msp.SerializedIdentity{Mspid: identity.MSPID, IdBytes: identity.Certificate})
+	return &mockContract{creator: creator, ledger: l, mspID: identity.MSPID}
+}
+
+// OnCommit sets a function called with every transaction about to be committed, before its
+// events are published. A transaction the function fails is rolled back and fails with its
+// error.
+func (l *MockLedger) OnCommit(onCommit func(*MockTransaction) error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.onCommit = onCommit
+}
+
+// Replay applies the writes of a committed transaction, such as one passed to OnCommit by an
+// earlier ledger, without running the chaincode or publishing its events.
+func (l *MockLedger) Replay(tx *MockTransaction) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.stub.MockTransactionStart(tx.TransactionID)
+	for _, write := range tx.Writes {
+		switch {
+		case write.Policy:
+			l.stub.SetStateValidationParameter(write.Key, write.Value)
+		case write.Collection != "" && write.Delete:
+			delete(l.stub.PvtState[write.Collection], write.Key)
+		case write.Collection != "":
+			l.stub.PutPrivateData(write.Collection, write.Key, write.Value)
+		case write.Delete:
+			l.stub.DelState(write.Key)
+		default:
+			l.stub.PutState(write.Key, write.Value)
+		}
+	}
+	l.stub.MockTransactionEnd(tx.TransactionID)
+	l.appendHistory(tx)
+	if tx.BlockNumber > l.blockNumber {
+		l.blockNumber = tx.BlockNumber
+	}
+}
+
+// BlockNumber returns the number of the last committed block, one per transaction.
+func (l *MockLedger) BlockNumber() uint64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.blockNumber
+}
+
+// State returns the committed value of a key of the world state, or nil if it is not set.
+func (l *MockLedger) State(key string) []byte {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.stub.State[key]
+}
+
+// History returns the committed modifications of a key of the world state, oldest first.
+func (l *MockLedger) History(key string) []*queryresult.KeyModification {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return append([]*queryresult.KeyModification{}, l.history[key]...)
+}
+
+// appendHistory records the world state writes of a committed transaction
+func (l *MockLedger) appendHistory(tx *MockTransaction) {
+	for _, write := range tx.Writes {
+		if write.Collection != "" || write.Policy {
+			continue
+		}
+		l.history[write.Key] = append(l.history[write.Key], &queryresult.KeyModification{
+			IsDelete:  write.Delete,
+			Timestamp: &timestamp.Timestamp{Seconds: tx.Timestamp.Unix(), Nanos: int32(tx.Timestamp.Nanosecond())},
+			TxId:      tx.TransactionID,
+			Value:     write.Value,
+		})
+	}
+}
+
+// Stub returns the MockStub holding the state of the ledger. It must not be used while
+// transactions run.
+func (l *MockLedger) Stub() *shimtest.MockStub {
+	return l.stub
+}
+
+// mockLedgerState is the saved form of a MockLedger
+type mockLedgerState struct {
+	BlockNumber         uint64                       `json:"blockNumber"`
+	EndorsementPolicies map[string]map[string][]byte `json:"endorsementPolicies"`
+	PrivateData         map[string]map[string][]byte `json:"privateData"`
+	State               map[string][]byte            `json:"state"`
+}
+
+// Save writes the world state, private data and key endorsement policies of the ledger as JSON.
+func (l *MockLedger) Save(w io.Writer) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(mockLedgerState{
+		BlockNumber:         l.blockNumber,
+		EndorsementPolicies: l.stub.EndorsementPolicies,
+		PrivateData:         l.stub.PvtState,
+		State:               l.stub.State,
+	})
+}
+
+// LoadMockLedger returns a MockLedger holding the state written by Save.
+func LoadMockLedger(r io.Reader) (*MockLedger, error) {
+	var saved mockLedgerState
+	err := json.NewDecoder(r).Decode(&saved)
+	if err != nil {
+		return nil, errors.New("failed to decode ledger: " + err.Error())
+	}
+
+	ledger := NewMockLedger()
+	ledger.blockNumber = saved.BlockNumber
+	ledger.stub.MockTransactionStart("load")
+	for key, value := range saved.State {
+		ledger.stub.PutState(key, value)
+	}
+	ledger.stub.MockTransactionEnd("load")
+	for collection, values := range saved.PrivateData {
+		ledger.stub.PvtState[collection] = values
+	}
+	for collection, policies := range saved.EndorsementPolicies {
+		ledger.stub.EndorsementPolicies[collection] = policies
+	}
+	return ledger, nil
+}
+
+// NewMockIdentity returns a self-signed identity of an MSP carrying Fabric CA attributes, such as
+// the role attribute the chaincode authorizes callers with.
+func NewMockIdentity(mspID string, attrs map[string]string) (*Identity, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: mspID + " client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(10 * 365 * 24 * time.Hour),
+	}
+	if len(attrs) > 0 {
+		attrsAsBytes, _ := json.Marshal(attrmgr.Attributes{Attrs: attrs})
+		template.ExtraExtensions = []pkix.Extension{{Id: attrmgr.AttrOID, Value: attrsAsBytes}}
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	return NewX509Identity(mspID, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})), nil
+}
+
+// invoke runs a transaction, keeping its changes and publishing its events only if commit is
+// set and the chaincode succeeded
+func (l *MockLedger) invoke(contract *mockContract, function string, args []string, transient map[string][]byte, commit bool) ([]byte, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	txIDBytes := make([]byte, 32)
+	_, err := rand.Read(txIDBytes)
+	if err != nil {
+		return nil, err
+	}
+	txID := hex.EncodeToString(txIDBytes)
+
+	input := [][]byte{[]byte(function)}
+	for _, arg := range args {
+		input = append(input, []byte(arg))
+	}
+	tx := &mockTxStub{MockStub: l.stub, args: input, ledger: l}
+	l.stub.Creator = contract.creator
+	l.stub.TransientMap = transient
+	l.stub.MockTransactionStart(txID)
+	defer func() {
+		l.stub.MockTransactionEnd(txID)
+		l.stub.Creator = nil
+		l.stub.TransientMap = nil
+	}()
+
+	response := l.chaincode.Invoke(tx)
+	if response.Status >= shimErrorThreshold {
+		tx.rollback()
+		return nil, &ChaincodeError{Message: response.Message, Status: response.Status}
+	}
+	if !commit {
+		tx.rollback()
+		return response.Payload, nil
+	}
+
+	committed := &MockTransaction{
+		Args:          args,
+		BlockNumber:   l.blockNumber + 1,
+		Events:        tx.events,
+		Function:      function,
+		MSPID:         contract.mspID,
+		Timestamp:     time.Unix(l.stub.TxTimestamp.GetSeconds(), int64(l.stub.TxTimestamp.GetNanos())).UTC(),
+		TransactionID: txID,
+		Writes:        tx.writes,
+	}
+	for _, event := range committed.Events {
+		event.BlockNumber = committed.BlockNumber
+		event.TransactionID = txID
+	}
+	if l.onCommit != nil {
+		err = l.onCommit(committed)
+		if err != nil {
+			tx.rollback()
+			return nil, err
+		}
+	}
+
+	l.blockNumber = committed.BlockNumber
+	l.appendHistory(committed)
+	for _, event := range committed.Events {
+		for subscription := range l.subscriptions {
+			subscription.push(event)
+		}
+	}
+	return response.Payload, nil
+}
+
+// subscribe streams the events committed from now on until ctx is done
+func (l *MockLedger) subscribe(ctx context.Context) <-chan *ChaincodeEvent {
+	subscription := &eventSubscription{notify: make(chan struct{}, 1)}
+	l.mutex.Lock()
+	l.subscriptions[subscription] = struct{}{}
+	l.mutex.Unlock()
+
+	events := make(chan *ChaincodeEvent)
+	go func() {
+		defer close(events)
+		defer func() {
+			l.mutex.Lock()
+			delete(l.subscriptions, subscription)
+			l.mutex.Unlock()
+		}()
+		for {
+			event := subscription.pop()
+			if event == nil {
+				select {
+				case <-subscription.notify:
+					continue
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events
+}
+
+// mockContract is the Contract of an identity on a MockLedger
+type mockContract struct {
+	creator []byte
+	ledger  *MockLedger
+	mspID   string
+}
+
+func (c *mockContract) Submit(ctx context.Context, function string, args []string, transient map[string][]byte) ([]byte, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+	return c.ledger.invoke(c, function, args, transient, true)
+}
+
+func (c *mockContract) Evaluate(ctx context.Context, function string, args []string) ([]byte, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+	return c.ledger.invoke(c, function, args, nil, false)
+}
+
+func (c *mockContract) ChaincodeEvents(ctx context.Context) (<-chan *ChaincodeEvent, error) {
+	return c.ledger.subscribe(ctx), nil
+}
+
+// eventSubscription queues the events of a subscriber so committing never waits on it
+type eventSubscription struct {
+	mutex  sync.Mutex
+	notify chan struct{}
+	queue  []*ChaincodeEvent
+}
+
+func (s *eventSubscription) push(event *ChaincodeEvent) {
+	s.mutex.Lock()
+	s.queue = append(s.queue, event)
+	s.mutex.Unlock()
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *eventSubscription) pop() *ChaincodeEvent {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.queue) == 0 {
+		return nil
+	}
+	event := s.queue[0]
+	s.queue = s.queue[1:]
+	return event
+}
+
+/* -------------------------------------------------------------------------- */
+/*                            Mock Transaction Stub                           */
+/* -------------------------------------------------------------------------- */
+
+// mockTxStub is the stub of one transaction on a MockLedger. It passes the arguments to the
+// chaincode, serves the history of the ledger, keeps the events and writes of the transaction
+// and records the previous value of every key it writes so the transaction can be rolled back.
+type mockTxStub struct {
+	*shimtest.MockStub
+	args   [][]byte
+	events []*ChaincodeEvent
+	ledger *MockLedger
+	undo   []func()
+	writes []MockWrite
+}
+
+func (s *mockTxStub) GetArgs() [][]byte {
+	return s.args
+}
+
+func (s *mockTxStub) GetStringArgs() []string {
+	args := make([]string, 0, len(s.args))
+	for _, arg := range s.args {
+		args = append(args, string(arg))
+	}
+	return args
+}
+
+func (s *mockTxStub) GetFunctionAndParameters() (string, []string) {
+	args := s.GetStringArgs()
+	if len(args) == 0 {
+		return "", []string{}
+	}
+	return args[0], args[1:]
+}
+
+func (s *mockTxStub) GetArgsSlice() ([]byte, error) {
+	var slice []byte
+	for _, arg := range s.args {
+		slice = append(slice, arg...)
+	}
+	return slice, nil
+}
+
+func (s *mockTxStub) PutState(key string, value []byte) error {
+	s.saveState(key)
+	s.writes = append(s.writes, MockWrite{Delete: len(value) == 0, Key: key, Value: value})
+	return s.MockStub.PutState(key, value)
+}
+
+func (s *mockTxStub) DelState(key string) error {
+	s.saveState(key)
+	s.writes = append(s.writes, MockWrite{Delete: true, Key: key})
+	return s.MockStub.DelState(key)
+}
+
+func (s *mockTxStub) PutPrivateData(collection string, key string, value []byte) error {
+	s.savePrivateData(collection, key)
+	s.writes = append(s.writes, MockWrite{Collection: collection, Key: key, Value: value})
+	return s.MockStub.PutPrivateData(collection, key, value)
+}
+
+func (s *mockTxStub) DelPrivateData(collection string, key string) error {
+	s.savePrivateData(collection, key)
+	s.writes = append(s.writes, MockWrite{Collection: collection, Delete: true, Key: key})
+	delete(s.PvtState[collection], key)
+	return nil
+}
+
+func (s *mockTxStub) PurgePrivateData(collection string, key string) error {
+	return s.DelPrivateData(collection, key)
+}
+
+func (s *mockTxStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	return &mockHistoryIterator{modifications: s.ledger.history[key]}, nil
+}
+
+func (s *mockTxStub) SetStateValidationParameter(key string, ep []byte) error {
+	previous, existed := s.EndorsementPolicies[""][key]
+	s.undo = append(s.undo, func() {
+		if existed {
+			s.EndorsementPolicies[""][key] = previous
+		} else {
+			delete(s.EndorsementPolicies[""], key)
+		}
+	})
+	s.writes = append(s.writes, MockWrite{Key: key, Policy: true, Value: ep})
+	return s.MockStub.SetStateValidationParameter(key, ep)
+}
+
+func (s *mockTxStub) SetEvent(name string, payload []byte) error {
+	s.events = append(s.events, &ChaincodeEvent{EventName: name, Payload: payload})
+	return nil
+}
+
+// saveState records how to restore a key of the world state
+func (s *mockTxStub) saveState(key string) {
+	previous, existed := s.State[key]
+	s.undo = append(s.undo, func() {
+		if existed {
+			s.MockStub.PutState(key, previous)
+		} else {
+			s.MockStub.DelState(key)
+		}
+	})
+}
+
+// savePrivateData records how to restore a key of a private data collection
+func (s *mockTxStub) savePrivateData(collection string, key string) {
+	previous, existed := s.PvtState[collection][key]
+	s.undo = append(s.undo, func() {
+		if existed {
+			s.MockStub.PutPrivateData(collection, key, previous)
+		} else {
+			delete(s.PvtState[collection], key)
+		}
+	})
+}
+
+// rollback undoes the writes of the transaction, latest first, and drops its events
+func (s *mockTxStub) rollback() {
+	for i := len(s.undo) - 1; i >= 0; i-- {
+		s.undo[i]()
+	}
+	s.undo = nil
+	s.events = nil
+	s.writes = nil
+}
+
+// mockHistoryIterator iterates over the modifications of a key
+type mockHistoryIterator struct {
+	modifications []*queryresult.KeyModification
+}
+
+func (i *mockHistoryIterator) HasNext() bool {
+	return len(i.modifications) > 0
+}
+
+func (i *mockHistoryIterator) Next() (*queryresult.KeyModification, error) {
+	if len(i.modifications) == 0 {
+		return nil, errors.New("no more modifications")
+	}
+	modification := i.modifications[0]
+	i.modifications = i.modifications[1:]
+	return modification, nil
+}
+
+func (i *mockHistoryIterator) Close() error {
+	return nil
+}
@@ -0,0 +1,125 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package client
+
+import (
+	"context"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                   Orders                                   */
+/* -------------------------------------------------------------------------- */
+
+// RegisterOrder creates an order; existing orders are changed with AmendOrder and CancelOrder.
+// The fill state (BidMatchIDs, FilledQuantity and the like) is kept by the chaincode and ignored.
+func (c *Client) RegisterOrder(ctx context.Context, order chaincode.Order) (string, error) {
+	return c.submitTx(ctx, "RegisterOrder", order.BidMatchID, order.BidStatus, order.ID, order.OnMarketPrice,
+		formatFloat(order.OrderCost), order.PaymentID, order.SlotID, formatInt(order.TotalQuantity),
+		formatFloat(order.UnitCost), order.UserID, formatInt(order.SlotExecDate), order.UserAction,
+		order.OrderType, order.TimeInForce, formatFloat(order.ProtectionPrice), order.ReferencePriceID, order.MeterID)
+}
+
+// RegisterOrders registers a batch of orders in one transaction.
+func (c *Client) RegisterOrders(ctx context.Context, orders []chaincode.Order) (*chaincode.OrderBatchResult, error) {
+	var result chaincode.OrderBatchResult
+	err := c.submitInto(ctx, &result, "RegisterOrders", marshalArg(orders))
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CancelOrder cancels the unmatched quantity of an order.
+func (c *Client) CancelOrder(ctx context.Context, orderID string) (string, error) {
+	return c.submitTx(ctx, "CancelOrder", orderID)
+}
+
+// AmendOrder changes the total quantity and unit cost of an order.
+func (c *Client) AmendOrder(ctx context.Context, orderID string, totalQuantity int64, unitCost float64) (string, error) {
+	return c.submitTx(ctx, "AmendOrder", orderID, formatInt(totalQuantity), formatFloat(unitCost))
+}
+
+// ReadOrder reads an order.
+func (c *Client) ReadOrder(ctx context.Context, orderID string) (*chaincode.Order, error) {
+	var order chaincode.Order
+	err := c.evaluateInto(ctx, &order, "ReadOrder", orderID)
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// ReadMatchCandidates returns the resting orders that can fill an order, best first.
+func (c *Client) ReadMatchCandidates(ctx context.Context, orderID string) ([]chaincode.MatchCandidate, error) {
+	var candidates []chaincode.MatchCandidate
+	err := c.evaluateInto(ctx, &candidates, "ReadMatchCandidates", orderID)
+	if err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                           Matches and Settlement                           */
+/* -------------------------------------------------------------------------- */
+
+// ProcessBidMatch creates or updates a bid match. Zone and network charge fields are set by
+// the chaincode and ignored.
+func (c *Client) ProcessBidMatch(ctx context.Context, bidMatch chaincode.BidMatch) (string, error) {
+	return c.submitTx(ctx, "ProcessBidMatch", formatInt(bidMatch.BidMatchTms), bidMatch.BidSlot, bidMatch.BidStatus,
+		formatInt(bidMatch.BidUnitPrice), bidMatch.BuyerUserId, formatFloat(bidMatch.DeliveredBidUnits), bidMatch.ID,
+		formatFloat(bidMatch.OriginalBidUnits), bidMatch.SellerUserId, bidMatch.TransactionBuyID, bidMatch.TransactionSellID)
+}
+
+// ReadBidMatch reads a bid match.
+func (c *Client) ReadBidMatch(ctx context.Context, bidMatchID string) (*chaincode.BidMatch, error) {
+	var bidMatch chaincode.BidMatch
+	err := c.evaluateInto(ctx, &bidMatch, "ReadBidMatch", bidMatchID)
+	if err != nil {
+		return nil, err
+	}
+	return &bidMatch, nil
+}
+
+// ProcessEnergyBid settles the delivery of a bid match. Without a ReferencePriceID the
+// settlement uses the reference price of the buy order.
+func (c *Client) ProcessEnergyBid(ctx context.Context, energyBid chaincode.EnergyBid) (string, error) {
+	args := []string{energyBid.ID, energyBid.BidMatchID, formatFloat(energyBid.InitialBidUnits),
+		formatFloat(energyBid.AcceptedBidUnits), formatFloat(energyBid.BuyerMeterUnit), formatFloat(energyBid.SellerMeterUnit),
+		formatFloat(energyBid.BuyerBroughtUnitFromSeller), formatFloat(energyBid.SellerSoldUnitToBuyer),
+		formatFloat(energyBid.SellerSoldUnitToGrid), formatFloat(energyBid.BuyerSoldUnitToGrid),
+		formatFloat(energyBid.BuyerBroughtUnitFromGrid), energyBid.Reason}
+	if energyBid.ReferencePriceID != "" {
+		args = append(args, energyBid.ReferencePriceID)
+	}
+	return c.submitTx(ctx, "ProcessEnergyBid", args...)
+}
+
+// ReadEnergyBid reads a settled energy bid.
+func (c *Client) ReadEnergyBid(ctx context.Context, energyBidID string) (*chaincode.EnergyBid, error) {
+	var energyBid chaincode.EnergyBid
+	err := c.evaluateInto(ctx, &energyBid, "ReadEnergyBid", energyBidID)
+	if err != nil {
+		return nil, err
+	}
+	return &energyBid, nil
+}
@@ -0,0 +1,217 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                  Payments                                  */
+/* -------------------------------------------------------------------------- */
+
+// RecordPayment records a payment from its ID, PaymentType, TotalAmount, UserID,
+// PaymentDetailID, OrderID and BidMatchID. The detail goes to the private data collection of
+// the user's org, salted with salt.
+func (c *Client) RecordPayment(ctx context.Context, payment chaincode.Payment, detail chaincode.PaymentDetail, salt string) (string, error) {
+	transient := map[string][]byte{"paymentDetail": []byte(marshalArg(detail)), "salt": []byte(salt)}
+	result, err := c.submitTransient(ctx, "RecordPayment", transient, payment.ID, payment.PaymentType,
+		formatFloat(payment.TotalAmount), payment.UserID, payment.PaymentDetailID, payment.OrderID, payment.BidMatchID)
+	return string(result), err
+}
+
+// RefundPayment refunds the amounts of refund (BidRefundAmount, PlatformFeeRefundAmount and
+// TokenAmountRefund) of a payment through the reversal payment refundPaymentID, whose
+// PaymentDetail paymentDetailID is salted with salt.
+func (c *Client) RefundPayment(ctx context.Context, refundPaymentID string, originalPaymentID string, paymentDetailID string, refund chaincode.PaymentDetail, salt string) (string, error) {
+	transient := map[string][]byte{"refund": []byte(marshalArg(refund)), "salt": []byte(salt)}
+	result, err := c.submitTransient(ctx, "RefundPayment", transient, refundPaymentID, originalPaymentID, paymentDetailID)
+	return string(result), err
+}
+
+// ReadPayment reads a payment.
+func (c *Client) ReadPayment(ctx context.Context, paymentID string) (*chaincode.Payment, error) {
+	var payment chaincode.Payment
+	err := c.evaluateInto(ctx, &payment, "ReadPayment", paymentID)
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// ReadPaymentDetail reads the private detail of a payment, which only the owner and operator
+// orgs can.
+func (c *Client) ReadPaymentDetail(ctx context.Context, paymentDetailID string) (*chaincode.PrivatePaymentDetail, error) {
+	var detail chaincode.PrivatePaymentDetail
+	err := c.evaluateInto(ctx, &detail, "ReadPaymentDetail", paymentDetailID)
+	if err != nil {
+		return nil, err
+	}
+	return &detail, nil
+}
+
+// ReadPaymentDetailHash reads the public salted hash of a payment detail.
+func (c *Client) ReadPaymentDetailHash(ctx context.Context, paymentDetailID string) (*chaincode.PrivateDataHash, error) {
+	var hash chaincode.PrivateDataHash
+	err := c.evaluateInto(ctx, &hash, "ReadPaymentDetailHash", paymentDetailID)
+	if err != nil {
+		return nil, err
+	}
+	return &hash, nil
+}
+
+// ReadPaymentsForOrder reads the payments of an order.
+func (c *Client) ReadPaymentsForOrder(ctx context.Context, orderID string) ([]chaincode.Payment, error) {
+	var payments []chaincode.Payment
+	err := c.evaluateInto(ctx, &payments, "ReadPaymentsForOrder", orderID)
+	if err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// ReadPaymentsForBidMatch reads the payments of a bid match.
+func (c *Client) ReadPaymentsForBidMatch(ctx context.Context, bidMatchID string) ([]chaincode.Payment, error) {
+	var payments []chaincode.Payment
+	err := c.evaluateInto(ctx, &payments, "ReadPaymentsForBidMatch", bidMatchID)
+	if err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// ReadPaymentsForUser reads the payments of a user.
+func (c *Client) ReadPaymentsForUser(ctx context.Context, userID string) ([]chaincode.Payment, error) {
+	var payments []chaincode.Payment
+	err := c.evaluateInto(ctx, &payments, "ReadPaymentsForUser", userID)
+	if err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// ReindexPayments adds every payment to the payment indexes, for payments recorded before an
+// index existed.
+func (c *Client) ReindexPayments(ctx context.Context) (string, error) {
+	return c.submitTx(ctx, "ReindexPayments")
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              Invoices and Fees                             */
+/* -------------------------------------------------------------------------- */
+
+// GenerateInvoice generates the invoice of a user for an ended month (YYYY-MM). The invoice is
+// private to the user's org; the public hash of its content is returned.
+func (c *Client) GenerateInvoice(ctx context.Context, userID string, period string) (*chaincode.PrivateDataHash, error) {
+	var hash chaincode.PrivateDataHash
+	err := c.submitInto(ctx, &hash, "GenerateInvoice", userID, period)
+	if err != nil {
+		return nil, err
+	}
+	return &hash, nil
+}
+
+// ReadInvoice reads the invoice of a user for a month (YYYY-MM).
+func (c *Client) ReadInvoice(ctx context.Context, userID string, period string) (*chaincode.Invoice, error) {
+	var invoice chaincode.Invoice
+	err := c.evaluateInto(ctx, &invoice, "ReadInvoice", userID, period)
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// ReadInvoiceHash reads the public content hash of the invoice of a user for a month (YYYY-MM).
+func (c *Client) ReadInvoiceHash(ctx context.Context, userID string, period string) (*chaincode.PrivateDataHash, error) {
+	var hash chaincode.PrivateDataHash
+	err := c.evaluateInto(ctx, &hash, "ReadInvoiceHash", userID, period)
+	if err != nil {
+		return nil, err
+	}
+	return &hash, nil
+}
+
+// PublishFeeSchedule publishes a version of the fee schedule.
+func (c *Client) PublishFeeSchedule(ctx context.Context, schedule chaincode.FeeSchedule) (string, error) {
+	return c.submitTx(ctx, "PublishFeeSchedule", marshalArg(schedule))
+}
+
+// ReadFeeSchedule reads a version of the fee schedule, the version in effect when version is 0.
+func (c *Client) ReadFeeSchedule(ctx context.Context, version int) (*chaincode.FeeSchedule, error) {
+	var args []string
+	if version != 0 {
+		args = append(args, strconv.Itoa(version))
+	}
+	var schedule chaincode.FeeSchedule
+	err := c.evaluateInto(ctx, &schedule, "ReadFeeSchedule", args...)
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                  Disputes                                  */
+/* -------------------------------------------------------------------------- */
+
+// RaiseDispute raises a dispute from its ID, RaisedBy, TargetType, TargetID and Reason, with
+// the SHA-256 hashes of the initial evidence.
+func (c *Client) RaiseDispute(ctx context.Context, dispute chaincode.Dispute, evidence []string) (string, error) {
+	if evidence == nil {
+		evidence = []string{}
+	}
+	return c.submitTx(ctx, "RaiseDispute", dispute.ID, dispute.RaisedBy, dispute.TargetType, dispute.TargetID, dispute.Reason, marshalArg(evidence))
+}
+
+// AddDisputeEvidence adds the SHA-256 hash of a piece of evidence to a dispute.
+func (c *Client) AddDisputeEvidence(ctx context.Context, disputeID string, userID string, hash string) (string, error) {
+	return c.submitTx(ctx, "AddDisputeEvidence", disputeID, userID, hash)
+}
+
+// ReviewDispute puts an open dispute under review.
+func (c *Client) ReviewDispute(ctx context.Context, disputeID string) (string, error) {
+	return c.submitTx(ctx, "ReviewDispute", disputeID)
+}
+
+// ResolveDispute resolves a dispute under review with adjustment payments.
+func (c *Client) ResolveDispute(ctx context.Context, disputeID string, resolution string, adjustments []chaincode.DisputeAdjustment) (string, error) {
+	if adjustments == nil {
+		adjustments = []chaincode.DisputeAdjustment{}
+	}
+	return c.submitTx(ctx, "ResolveDispute", disputeID, resolution, marshalArg(adjustments))
+}
+
+// RejectDispute rejects a dispute.
+func (c *Client) RejectDispute(ctx context.Context, disputeID string, resolution string) (string, error) {
+	return c.submitTx(ctx, "RejectDispute", disputeID, resolution)
+}
+
+// ReadDispute reads a dispute.
+func (c *Client) ReadDispute(ctx context.Context, disputeID string) (*chaincode.Dispute, error) {
+	var dispute chaincode.Dispute
+	err := c.evaluateInto(ctx, &dispute, "ReadDispute", disputeID)
+	if err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
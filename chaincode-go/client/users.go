@@ -0,0 +1,216 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                    Users                                   */
+/* -------------------------------------------------------------------------- */
+
+// UpdateUserProfile creates or updates a user from its ID, Category, MeterID, Source and
+// IsAdmin. The Location and Contact of details, when given, go to the private data collection
+// of the user's org salted with details.Salt; empty values keep their stored value.
+func (c *Client) UpdateUserProfile(ctx context.Context, user chaincode.User, details *chaincode.UserPrivateDetails) (string, error) {
+	result, err := c.submitTransient(ctx, "UpdateUserProfile", privateDetailsTransient(details),
+		user.ID, user.Category, user.MeterID, user.Source, strconv.FormatBool(user.IsAdmin))
+	return string(result), err
+}
+
+// UpdateEnterpriseUserProfile creates or updates an enterprise user from its ID, Category,
+// MeterIDs, Source and IsAdmin, with the private details as for UpdateUserProfile.
+func (c *Client) UpdateEnterpriseUserProfile(ctx context.Context, user chaincode.EnterpriseUser, details *chaincode.UserPrivateDetails) (string, error) {
+	meterIDs := user.MeterIDs
+	if meterIDs == nil {
+		meterIDs = []string{}
+	}
+	result, err := c.submitTransient(ctx, "UpdateEnterpriseUserProfile", privateDetailsTransient(details),
+		user.ID, user.Category, marshalArg(meterIDs), user.Source, strconv.FormatBool(user.IsAdmin))
+	return string(result), err
+}
+
+// privateDetailsTransient returns the transient data of the personal data of a user
+func privateDetailsTransient(details *chaincode.UserPrivateDetails) map[string][]byte {
+	if details == nil {
+		return nil
+	}
+	transient := map[string][]byte{"salt": []byte(details.Salt)}
+	if details.Location != "" {
+		transient["location"] = []byte(details.Location)
+	}
+	if details.Contact != "" {
+		transient["contact"] = []byte(details.Contact)
+	}
+	return transient
+}
+
+// RegisterUserKey registers the PEM encoded public key a user signs contracts with.
+func (c *Client) RegisterUserKey(ctx context.Context, userID string, publicKeyPEM string) (string, error) {
+	return c.submitTx(ctx, "RegisterUserKey", userID, publicKeyPEM)
+}
+
+// ReadUserProfile reads a user.
+func (c *Client) ReadUserProfile(ctx context.Context, userID string) (*chaincode.User, error) {
+	var user chaincode.User
+	err := c.evaluateInto(ctx, &user, "ReadUserProfile", userID)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ReadEnterpriseUserProfile reads an enterprise user.
+func (c *Client) ReadEnterpriseUserProfile(ctx context.Context, userID string) (*chaincode.EnterpriseUser, error) {
+	var user chaincode.EnterpriseUser
+	err := c.evaluateInto(ctx, &user, "ReadEnterpriseUserProfile", userID)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// EraseParticipantData erases the personal data of a participant and returns the certificate
+// of the erasure.
+func (c *Client) EraseParticipantData(ctx context.Context, userID string) (*chaincode.ErasureCertificate, error) {
+	var certificate chaincode.ErasureCertificate
+	err := c.submitInto(ctx, &certificate, "EraseParticipantData", userID)
+	if err != nil {
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+// ReadErasureCertificate reads the certificate of an erasure by the pseudonymous ID.
+func (c *Client) ReadErasureCertificate(ctx context.Context, pseudonymID string) (*chaincode.ErasureCertificate, error) {
+	var certificate chaincode.ErasureCertificate
+	err := c.evaluateInto(ctx, &certificate, "ReadErasureCertificate", pseudonymID)
+	if err != nil {
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+// ReadReliabilityScore reads the reliability score of a participant.
+func (c *Client) ReadReliabilityScore(ctx context.Context, userID string) (*chaincode.ReliabilityScore, error) {
+	var score chaincode.ReliabilityScore
+	err := c.evaluateInto(ctx, &score, "ReadReliabilityScore", userID)
+	if err != nil {
+		return nil, err
+	}
+	return &score, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                  Contracts                                 */
+/* -------------------------------------------------------------------------- */
+
+// PublishContractTemplate publishes a version of a contract template from its ID,
+// ContractType, Version, DocumentHash and EffectiveDate.
+func (c *Client) PublishContractTemplate(ctx context.Context, template chaincode.ContractTemplate) (string, error) {
+	return c.submitTx(ctx, "PublishContractTemplate", template.ID, template.ContractType,
+		strconv.Itoa(template.Version), template.DocumentHash, formatInt(template.EffectiveDate))
+}
+
+// ReadContractTemplate reads a version of a contract template, the version in effect when
+// version is 0.
+func (c *Client) ReadContractTemplate(ctx context.Context, templateID string, version int) (*chaincode.ContractTemplate, error) {
+	args := []string{templateID}
+	if version != 0 {
+		args = append(args, strconv.Itoa(version))
+	}
+	var template chaincode.ContractTemplate
+	err := c.evaluateInto(ctx, &template, "ReadContractTemplate", args...)
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// SignPlatformContract records the user's base64 signature over the
+// chaincode.ContractSigningMessage of a platform contract template version.
+func (c *Client) SignPlatformContract(ctx context.Context, userID string, templateID string, version int, signature string) (string, error) {
+	return c.submitTx(ctx, "SignPlatformContract", userID, templateID, strconv.Itoa(version), signature)
+}
+
+// SignTradingContract records the user's signature over a trading contract template version
+// allowing the trading actions until expiresOn. The signed message is the
+// chaincode.ContractSigningMessage with the chaincode.TradingContractTerms, whose revision is the
+// history length of the revoked contract being replaced, 0 for a first contract.
+func (c *Client) SignTradingContract(ctx context.Context, userID string, templateID string, version int, signature string, actions []string, expiresOn int64) (string, error) {
+	return c.submitTx(ctx, "SignTradingContract", userID, templateID, strconv.Itoa(version), signature, marshalArg(actions), formatInt(expiresOn))
+}
+
+// RenewTradingContract renews the trading contract of a user on a template version until
+// expiresOn. The signature covers the chaincode.TradingContractTerms of the contract's actions,
+// expiresOn and the contract's history length.
+func (c *Client) RenewTradingContract(ctx context.Context, userID string, templateID string, version int, signature string, expiresOn int64) (string, error) {
+	return c.submitTx(ctx, "RenewTradingContract", userID, templateID, strconv.Itoa(version), signature, formatInt(expiresOn))
+}
+
+// SuspendTradingContract suspends the trading contract of a user.
+func (c *Client) SuspendTradingContract(ctx context.Context, userID string, reason string) (string, error) {
+	return c.submitTx(ctx, "SuspendTradingContract", userID, reason)
+}
+
+// ReinstateTradingContract reinstates the suspended trading contract of a user.
+func (c *Client) ReinstateTradingContract(ctx context.Context, userID string, reason string) (string, error) {
+	return c.submitTx(ctx, "ReinstateTradingContract", userID, reason)
+}
+
+// RevokeTradingContract revokes the trading contract of a user, cancelling its open orders.
+func (c *Client) RevokeTradingContract(ctx context.Context, userID string, reason string) (string, error) {
+	return c.submitTx(ctx, "RevokeTradingContract", userID, reason)
+}
+
+// ExpireTradingContracts expires the active trading contracts past their expiry date and
+// returns the IDs of their users.
+func (c *Client) ExpireTradingContracts(ctx context.Context) ([]string, error) {
+	var expired []string
+	err := c.submitInto(ctx, &expired, "ExpireTradingContracts")
+	if err != nil {
+		return nil, err
+	}
+	return expired, nil
+}
+
+// ReadPlatformContract reads the platform contract of a user.
+func (c *Client) ReadPlatformContract(ctx context.Context, userID string) (*chaincode.PlatformContract, error) {
+	var contract chaincode.PlatformContract
+	err := c.evaluateInto(ctx, &contract, "ReadPlatformContract", userID)
+	if err != nil {
+		return nil, err
+	}
+	return &contract, nil
+}
+
+// ReadTradingContract reads the trading contract of a user.
+func (c *Client) ReadTradingContract(ctx context.Context, userID string) (*chaincode.TradingContract, error) {
+	var contract chaincode.TradingContract
+	err := c.evaluateInto(ctx, &contract, "ReadTradingContract", userID)
+	if err != nil {
+		return nil, err
+	}
+	return &contract, nil
+}
@@ -0,0 +1,139 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                   Wallet                                   */
+/* -------------------------------------------------------------------------- */
+
+// walletFileSuffix is the suffix of the identity files of a wallet directory
+const walletFileSuffix = ".id"
+
+// Wallet is a directory of X.509 identities in the file format of the Fabric SDKs, one
+// "<label>.id" file per identity.
+type Wallet struct {
+	dir string
+}
+
+// walletIdentity is the content of an identity file
+type walletIdentity struct {
+	Credentials struct {
+		Certificate string `json:"certificate"`
+		PrivateKey  string `json:"privateKey"`
+	} `json:"credentials"`
+	MSPID   string `json:"mspId"`
+	Type    string `json:"type"`
+	Version int    `json:"version"`
+}
+
+// NewWallet returns the wallet of a directory.
+func NewWallet(dir string) *Wallet {
+	return &Wallet{dir: dir}
+}
+
+// Get returns the identity with a label and a Sign using its private key.
+func (w *Wallet) Get(label string) (*Identity, Sign, error) {
+	if label == "" || strings.ContainsAny(label, `/\`) {
+		return nil, nil, errors.New("invalid wallet label " + label)
+	}
+	path := filepath.Join(w.dir, label+walletFileSuffix)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var stored walletIdentity
+	err = json.Unmarshal(content, &stored)
+	if err != nil {
+		return nil, nil, errors.New("failed to decode " + path + ": " + err.Error())
+	}
+	if stored.Type != "X.509" {
+		return nil, nil, errors.New(path + " is not an X.509 identity")
+	}
+	key, err := ParsePrivateKey([]byte(stored.Credentials.PrivateKey))
+	if err != nil {
+		return nil, nil, errors.New(path + ": " + err.Error())
+	}
+	return NewX509Identity(stored.MSPID, []byte(stored.Credentials.Certificate)), NewPrivateKeySign(key), nil
+}
+
+// Put stores an identity with its PEM encoded private key under a label.
+func (w *Wallet) Put(label string, identity *Identity, privateKeyPEM []byte) error {
+	if label == "" || strings.ContainsAny(label, `/\`) {
+		return errors.New("invalid wallet label " + label)
+	}
+	stored := walletIdentity{MSPID: identity.MSPID, Type: "X.509", Version: 1}
+	stored.Credentials.Certificate = string(identity.Certificate)
+	stored.Credentials.PrivateKey = string(privateKeyPEM)
+	content, _ := json.Marshal(stored)
+
+	err := os.MkdirAll(w.dir, 0o700)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(w.dir, label+walletFileSuffix), content, 0o600)
+}
+
+// List returns the labels of the identities in the wallet, in order.
+func (w *Wallet) List() ([]string, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, err
+	}
+	var labels []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), walletFileSuffix) {
+			labels = append(labels, strings.TrimSuffix(entry.Name(), walletFileSuffix))
+		}
+	}
+	sort.Strings(labels)
+	return labels, nil
+}
+
+// ParsePrivateKey parses a PEM encoded ECDSA private key, in SEC 1 or PKCS #8 form.
+func ParsePrivateKey(keyPEM []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("no PEM data in private key")
+	}
+	if block.Type == "EC PRIVATE KEY" {
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New("failed to parse private key: " + err.Error())
+	}
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an ECDSA key")
+	}
+	return ecdsaKey, nil
+}
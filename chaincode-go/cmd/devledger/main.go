@@ -0,0 +1,73 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Command devledger serves the chaincode from an in-process ledger kept in a local directory, so
+// applications can be developed without a Fabric network. Transactions are submitted and
+// evaluated over HTTP with the semantics of the gateway service: a submitted transaction commits
+// only if the chaincode succeeds, an evaluated one never does.
+//
+//	devledger -addr localhost:8080 -data .devledger
+//	curl -d '{"function":"ReadUser","args":["20"],"identity":{"mspId":"Org1MSP"}}' localhost:8080/transactions/evaluate
+//
+// Every committed transaction is appended to a log in the data directory, from which the world
+// state, key history and events are rebuilt when the ledger is opened again.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+)
+
+func main() {
+	err := run(os.Args[1:], os.Stderr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "devledger: "+err.Error())
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stderr io.Writer) error {
+	flags := flag.NewFlagSet("devledger", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	addr := flags.String("addr", "localhost:8080", "address to serve the HTTP API on")
+	data := flags.String("data", ".devledger", "directory of the ledger")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return errors.New("unexpected arguments")
+	}
+
+	store, err := openStore(*data)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	log.SetOutput(stderr)
+	log.Printf("serving ledger %s at block %d on %s", *data, store.ledger.BlockNumber(), *addr)
+	return http.ListenAndServe(*addr, newServer(store))
+}
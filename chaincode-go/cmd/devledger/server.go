@@ -0,0 +1,339 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/client"
+)
+
+// server is the HTTP API of a store
+type server struct {
+	identities  map[string]*client.Identity
+	mutex       sync.Mutex
+	mux         *http.ServeMux
+	store       *store
+	submitMutex sync.Mutex
+}
+
+// identityRequest is the identity a transaction is run as, generated on first use
+type identityRequest struct {
+	Attributes map[string]string `json:"attributes"`
+	MSPID      string            `json:"mspId"`
+}
+
+// transactionRequest is the body of a submit or evaluate request
+type transactionRequest struct {
+	Args      []string          `json:"args"`
+	Function  string            `json:"function"`
+	Identity  identityRequest   `json:"identity"`
+	Transient map[string][]byte `json:"transient"`
+}
+
+// transactionResponse is the result of a submitted or evaluated transaction
+type transactionResponse struct {
+	BlockNumber   uint64 `json:"blockNumber,omitempty"`
+	Result        string `json:"result"`
+	TransactionID string `json:"transactionId,omitempty"`
+}
+
+// keyModification is a committed write of a key
+type keyModification struct {
+	IsDelete      bool      `json:"isDelete"`
+	Timestamp     time.Time `json:"timestamp"`
+	TransactionID string    `json:"transactionId"`
+	Value         []byte    `json:"value,omitempty"`
+}
+
+// errorResponse is the body of a failed request. Status and Message are set for chaincode
+// errors, Error for others.
+type errorResponse struct {
+	Error   string `json:"error,omitempty"`
+	Message string `json:"message,omitempty"`
+	Status  int32  `json:"status,omitempty"`
+}
+
+func newServer(s *store) *server {
+	srv := &server{identities: make(map[string]*client.Identity), mux: http.NewServeMux(), store: s}
+	srv.mux.HandleFunc("/transactions/submit", srv.handleSubmit)
+	srv.mux.HandleFunc("/transactions/evaluate", srv.handleEvaluate)
+	srv.mux.HandleFunc("/transactions/", srv.handleTransaction)
+	srv.mux.HandleFunc("/state/", srv.handleState)
+	srv.mux.HandleFunc("/history/", srv.handleHistory)
+	srv.mux.HandleFunc("/events", srv.handleEvents)
+	return srv
+}
+
+func (srv *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	srv.mux.ServeHTTP(w, r)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                Transactions                                */
+/* -------------------------------------------------------------------------- */
+
+// handleSubmit runs a transaction and commits it if the chaincode succeeds
+func (srv *server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	req, contract, ok := srv.transactionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	// Submits are serialized so the last logged transaction is the one just committed.
+	srv.submitMutex.Lock()
+	defer srv.submitMutex.Unlock()
+	result, err := contract.Submit(r.Context(), req.Function, req.Args, req.Transient)
+	if err != nil {
+		writeTransactionError(w, err)
+		return
+	}
+	tx := srv.store.Last()
+	writeJSON(w, http.StatusOK, transactionResponse{BlockNumber: tx.BlockNumber, Result: string(result), TransactionID: tx.TransactionID})
+}
+
+// handleEvaluate runs a transaction without committing it
+func (srv *server) handleEvaluate(w http.ResponseWriter, r *http.Request) {
+	req, contract, ok := srv.transactionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := contract.Evaluate(r.Context(), req.Function, req.Args)
+	if err != nil {
+		writeTransactionError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, transactionResponse{Result: string(result)})
+}
+
+// handleTransaction returns a committed transaction with its writes and events
+func (srv *server) handleTransaction(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	txID := strings.TrimPrefix(r.URL.Path, "/transactions/")
+	tx := srv.store.Transaction(txID)
+	if tx == nil {
+		writeError(w, http.StatusNotFound, "transaction "+txID+" not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, tx)
+}
+
+// transactionRequest decodes a submit or evaluate request and returns the contract of its
+// identity, writing the error response if it fails
+func (srv *server) transactionRequest(w http.ResponseWriter, r *http.Request) (*transactionRequest, client.Contract, bool) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return nil, nil, false
+	}
+	var req transactionRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to decode request: "+err.Error())
+		return nil, nil, false
+	}
+	if req.Function == "" {
+		writeError(w, http.StatusBadRequest, "function is required")
+		return nil, nil, false
+	}
+	if req.Identity.MSPID == "" {
+		writeError(w, http.StatusBadRequest, "identity.mspId is required")
+		return nil, nil, false
+	}
+
+	identity, err := srv.identity(req.Identity)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return nil, nil, false
+	}
+	return &req, srv.store.ledger.Contract(identity), true
+}
+
+// identity returns the generated identity of an MSP with attributes, the same one every time
+func (srv *server) identity(req identityRequest) (*client.Identity, error) {
+	attrsAsBytes, _ := json.Marshal(req.Attributes)
+	id := req.MSPID + string(attrsAsBytes)
+
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+	identity, ok := srv.identities[id]
+	if ok {
+		return identity, nil
+	}
+	identity, err := client.NewMockIdentity(req.MSPID, req.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	srv.identities[id] = identity
+	return identity, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              State and History                             */
+/* -------------------------------------------------------------------------- */
+
+// handleState returns the committed value of a key of the world state
+func (srv *server) handleState(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/state/")
+	value := srv.store.ledger.State(key)
+	if value == nil {
+		writeError(w, http.StatusNotFound, "key "+key+" not found")
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(value)
+}
+
+// handleHistory returns the committed writes of a key of the world state, oldest first
+func (srv *server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/history/")
+	history := []keyModification{}
+	for _, modification := range srv.store.ledger.History(key) {
+		history = append(history, keyModification{
+			IsDelete:      modification.IsDelete,
+			Timestamp:     time.Unix(modification.Timestamp.GetSeconds(), int64(modification.Timestamp.GetNanos())).UTC(),
+			TransactionID: modification.TxId,
+			Value:         modification.Value,
+		})
+	}
+	writeJSON(w, http.StatusOK, history)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                   Events                                   */
+/* -------------------------------------------------------------------------- */
+
+// handleEvents returns the events committed from the fromBlock query parameter on. Requests
+// accepting text/event-stream keep receiving the events committed afterwards as server-sent
+// events.
+func (srv *server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	var fromBlock uint64
+	if value := r.URL.Query().Get("fromBlock"); value != "" {
+		var err error
+		fromBlock, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Failed to parse fromBlock: "+err.Error())
+			return
+		}
+	}
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		events, _ := srv.store.Events(fromBlock)
+		writeJSON(w, http.StatusOK, events)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusNotAcceptable, "streaming is not supported")
+		return
+	}
+
+	// Subscribing before reading the logged events leaves no gap between them.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	live, err := srv.store.ledger.Contract(&client.Identity{}).ChaincodeEvents(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	events, lastBlock := srv.store.Events(fromBlock)
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	for _, event := range events {
+		if writeEvent(w, event) != nil {
+			return
+		}
+	}
+	flusher.Flush()
+	for event := range live {
+		if event.BlockNumber <= lastBlock {
+			continue
+		}
+		if writeEvent(w, event) != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes a chaincode event as a server-sent event named after it
+func writeEvent(w io.Writer, event *client.ChaincodeEvent) error {
+	eventAsBytes, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "id: "+strconv.FormatUint(event.BlockNumber, 10)+"\nevent: "+event.EventName+"\ndata: "+string(eventAsBytes)+"\n\n")
+	return err
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                  Responses                                 */
+/* -------------------------------------------------------------------------- */
+
+// allowMethod writes a 405 response unless the request has the method
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, "method "+r.Method+" not allowed")
+	return false
+}
+
+// writeTransactionError writes the failure of a transaction. Chaincode errors are unprocessable
+// requests carrying the chaincode's status and message, as the gateway returns them.
+func writeTransactionError(w http.ResponseWriter, err error) {
+	var chaincodeErr *client.ChaincodeError
+	if errors.As(err, &chaincodeErr) {
+		writeJSON(w, http.StatusUnprocessableEntity, errorResponse{Message: chaincodeErr.Message, Status: chaincodeErr.Status})
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err.Error())
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
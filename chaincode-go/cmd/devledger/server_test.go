@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/client"
+)
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	s, err := openStore(dir)
+	require.NoError(t, err)
+	ts := httptest.NewServer(newServer(s))
+
+	invoke := func(ts *httptest.Server, mode string, attrs map[string]string, function string, args ...string) (int, []byte) {
+		body, _ := json.Marshal(transactionRequest{Args: args, Function: function, Identity: identityRequest{Attributes: attrs, MSPID: "Org1MSP"}})
+		resp, err := http.Post(ts.URL+"/transactions/"+mode, "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		respBody, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, respBody
+	}
+	get := func(ts *httptest.Server, path string) (int, []byte) {
+		resp, err := http.Get(ts.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, body
+	}
+	admin := map[string]string{chaincode.RoleAttribute: chaincode.AdminRole}
+
+	// Test Case 1: Submitted transactions commit in blocks, evaluated ones do not
+	var written transactionResponse
+	t.Run("Submit", func(t *testing.T) {
+		status, body := invoke(ts, "evaluate", nil, "Write", "Meter_1", "one")
+		require.Equal(t, http.StatusOK, status, string(body))
+		status, _ = get(ts, "/state/Meter_1")
+		assert.Equal(t, http.StatusNotFound, status)
+
+		status, body = invoke(ts, "submit", nil, "Write", "Meter_1", "one")
+		require.Equal(t, http.StatusOK, status, string(body))
+		require.NoError(t, json.Unmarshal(body, &written))
+		assert.Equal(t, uint64(1), written.BlockNumber)
+		assert.Len(t, written.TransactionID, 64)
+		status, body = invoke(ts, "submit", nil, "Write", "Meter_1", "two")
+		require.Equal(t, http.StatusOK, status, string(body))
+
+		status, body = get(ts, "/state/Meter_1")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "two", string(body))
+
+		var tx client.MockTransaction
+		status, body = get(ts, "/transactions/"+written.TransactionID)
+		require.Equal(t, http.StatusOK, status)
+		require.NoError(t, json.Unmarshal(body, &tx))
+		assert.Equal(t, "Write", tx.Function)
+		assert.Equal(t, "Org1MSP", tx.MSPID)
+		assert.Equal(t, []client.MockWrite{{Key: "Meter_1", Value: []byte("one")}}, tx.Writes)
+	})
+
+	// Test Case 2: Chaincode errors keep the status and message of the chaincode
+	t.Run("Errors", func(t *testing.T) {
+		status, body := invoke(ts, "submit", nil, "RotateEndorsementPolicy", "Meter_1", `["Org1MSP"]`)
+		require.Equal(t, http.StatusUnprocessableEntity, status)
+		var errResp errorResponse
+		require.NoError(t, json.Unmarshal(body, &errResp))
+		assert.Equal(t, chaincode.StatusUnauthorized, errResp.Status)
+		assert.Contains(t, errResp.Message, "not authorized")
+
+		status, _ = invoke(ts, "submit", nil, "")
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _ = get(ts, "/transactions/submit")
+		assert.Equal(t, http.StatusMethodNotAllowed, status)
+		status, _ = get(ts, "/transactions/unknown")
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	// Test Case 3: Events are listed from a block and streamed as they commit
+	t.Run("Events", func(t *testing.T) {
+		status, body := invoke(ts, "submit", admin, "RotateEndorsementPolicy", "Meter_1", `["Org1MSP"]`)
+		require.Equal(t, http.StatusOK, status, string(body))
+
+		var events []*client.ChaincodeEvent
+		status, body = get(ts, "/events?fromBlock=2")
+		require.Equal(t, http.StatusOK, status)
+		require.NoError(t, json.Unmarshal(body, &events))
+		require.Len(t, events, 1)
+		assert.Equal(t, "EndorsementPolicyRotated", events[0].EventName)
+		assert.Equal(t, uint64(3), events[0].BlockNumber)
+
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/events?fromBlock=3", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", "text/event-stream")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		reader := bufio.NewReader(resp.Body)
+		readEvent := func() string {
+			var lines []string
+			for {
+				line, err := reader.ReadString('\n')
+				require.NoError(t, err)
+				if line == "\n" {
+					return strings.Join(lines, "")
+				}
+				lines = append(lines, line)
+			}
+		}
+		assert.Contains(t, readEvent(), "id: 3\nevent: EndorsementPolicyRotated\n")
+
+		status, body = invoke(ts, "submit", admin, "RotateEndorsementPolicy", "Meter_1", `["Org1MSP","Org2MSP"]`)
+		require.Equal(t, http.StatusOK, status, string(body))
+		assert.Contains(t, readEvent(), "id: 4\nevent: EndorsementPolicyRotated\n")
+	})
+
+	// Test Case 4: Reopening the data directory restores state, history, policies and events
+	t.Run("Reopen", func(t *testing.T) {
+		ts.Close()
+		require.NoError(t, s.Close())
+		s, err = openStore(dir)
+		require.NoError(t, err)
+		defer s.Close()
+		ts = httptest.NewServer(newServer(s))
+		defer ts.Close()
+
+		status, body := get(ts, "/state/Meter_1")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "two", string(body))
+
+		var history []keyModification
+		status, body = get(ts, "/history/Meter_1")
+		require.Equal(t, http.StatusOK, status)
+		require.NoError(t, json.Unmarshal(body, &history))
+		require.Len(t, history, 2)
+		assert.Equal(t, written.TransactionID, history[0].TransactionID)
+		assert.Equal(t, "one", string(history[0].Value))
+
+		status, body = invoke(ts, "evaluate", nil, "ReadEndorsementPolicy", "Meter_1")
+		require.Equal(t, http.StatusOK, status, string(body))
+		assert.Contains(t, string(body), "Org2MSP")
+
+		var events []*client.ChaincodeEvent
+		_, body = get(ts, "/events")
+		require.NoError(t, json.Unmarshal(body, &events))
+		assert.Len(t, events, 2)
+
+		status, body = invoke(ts, "submit", nil, "Write", "Meter_2", "three")
+		require.Equal(t, http.StatusOK, status, string(body))
+		var resp transactionResponse
+		require.NoError(t, json.Unmarshal(body, &resp))
+		assert.Equal(t, uint64(5), resp.BlockNumber)
+	})
+}
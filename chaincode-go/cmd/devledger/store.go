@@ -0,0 +1,147 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/client"
+)
+
+// transactionLog is the file of a data directory holding one committed transaction per line
+const transactionLog = "transactions.jsonl"
+
+// store is a mock ledger whose committed transactions are logged to a data directory
+type store struct {
+	file         *os.File
+	ledger       *client.MockLedger
+	mutex        sync.RWMutex
+	transactions []*client.MockTransaction
+	txIndex      map[string]int
+}
+
+// openStore opens the ledger of a data directory, creating it if needed, and replays the
+// transactions logged in it
+func openStore(dir string) (*store, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, transactionLog)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &store{file: file, ledger: client.NewMockLedger(), txIndex: make(map[string]int)}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var tx client.MockTransaction
+		err = json.Unmarshal(scanner.Bytes(), &tx)
+		if err != nil {
+			file.Close()
+			return nil, errors.New(path + ":" + strconv.Itoa(line) + ": " + err.Error())
+		}
+		s.ledger.Replay(&tx)
+		s.add(&tx)
+	}
+	err = scanner.Err()
+	if err != nil {
+		file.Close()
+		return nil, errors.New(path + ": " + err.Error())
+	}
+
+	s.ledger.OnCommit(s.commit)
+	return s, nil
+}
+
+// commit logs a transaction before the ledger commits it
+func (s *store) commit(tx *client.MockTransaction) error {
+	line, err := json.Marshal(tx)
+	if err != nil {
+		return err
+	}
+	_, err = s.file.Write(append(line, '\n'))
+	if err != nil {
+		return errors.New("failed to log transaction: " + err.Error())
+	}
+	err = s.file.Sync()
+	if err != nil {
+		return errors.New("failed to log transaction: " + err.Error())
+	}
+	s.add(tx)
+	return nil
+}
+
+// add indexes a committed transaction
+func (s *store) add(tx *client.MockTransaction) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.txIndex[tx.TransactionID] = len(s.transactions)
+	s.transactions = append(s.transactions, tx)
+}
+
+// Transaction returns a committed transaction, or nil if there is none with the ID.
+func (s *store) Transaction(txID string) *client.MockTransaction {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	i, ok := s.txIndex[txID]
+	if !ok {
+		return nil
+	}
+	return s.transactions[i]
+}
+
+// Last returns the last committed transaction, or nil if there is none.
+func (s *store) Last() *client.MockTransaction {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if len(s.transactions) == 0 {
+		return nil
+	}
+	return s.transactions[len(s.transactions)-1]
+}
+
+// Events returns the events committed in blocks from fromBlock on, and the last block number.
+func (s *store) Events(fromBlock uint64) ([]*client.ChaincodeEvent, uint64) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	events := []*client.ChaincodeEvent{}
+	var last uint64
+	for _, tx := range s.transactions {
+		last = tx.BlockNumber
+		if tx.BlockNumber >= fromBlock {
+			events = append(events, tx.Events...)
+		}
+	}
+	return events, last
+}
+
+// Close closes the transaction log.
+func (s *store) Close() error {
+	return s.file.Close()
+}
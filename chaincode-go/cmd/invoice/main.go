@@ -0,0 +1,154 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Command invoice renders an Invoice returned by the ReadInvoice query as JSON or CSV, after
+// checking it against its content hash.
+//
+//	peer chaincode query -C mychannel -n basic -c '{"Args":["ReadInvoice","6","2024-05"]}' | invoice -format csv
+//	invoice -format json -o invoice.json invoice-6-2024-05.json
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Invoice and InvoiceLineItem mirror the chaincode assets of the same name.
+type Invoice struct {
+	ContentHash string            `json:"contentHash"`
+	CreatedOn   int64             `json:"createdOn"`
+	ID          string            `json:"id"`
+	LineItems   []InvoiceLineItem `json:"lineItems"`
+	Period      string            `json:"period"`
+	Total       float64           `json:"total"`
+	TxID        string            `json:"txId"`
+	UserID      string            `json:"userId"`
+}
+
+type InvoiceLineItem struct {
+	Amount    float64 `json:"amount"`
+	Quantity  float64 `json:"quantity"`
+	Reference string  `json:"reference"`
+	Type      string  `json:"type"`
+	UnitPrice float64 `json:"unitPrice"`
+}
+
+func main() {
+	format := flag.String("format", "json", "output format, json or csv")
+	output := flag.String("o", "", "output file (default stdout)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-format json|csv] [-o file] [invoice.json]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	err := run(*format, *output, flag.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invoice: "+err.Error())
+		os.Exit(1)
+	}
+}
+
+func run(format string, output string, args []string) error {
+	input := io.Reader(os.Stdin)
+	if len(args) > 1 {
+		return errors.New("expecting at most one invoice file")
+	}
+	if len(args) == 1 {
+		file, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+
+	var invoice Invoice
+	err := json.NewDecoder(input).Decode(&invoice)
+	if err != nil {
+		return errors.New("failed to parse invoice: " + err.Error())
+	}
+	if contentHash(&invoice) != invoice.ContentHash {
+		return errors.New("invoice " + invoice.ID + " does not match its content hash")
+	}
+
+	out := io.Writer(os.Stdout)
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(invoice)
+	case "csv":
+		return writeCSV(out, &invoice)
+	default:
+		return errors.New("unknown format " + format + ", expecting json or csv")
+	}
+}
+
+// contentHash hashes the billed content the same way the chaincode does
+func contentHash(invoice *Invoice) string {
+	content, _ := json.Marshal(struct {
+		UserID    string            `json:"userId"`
+		Period    string            `json:"period"`
+		LineItems []InvoiceLineItem `json:"lineItems"`
+		Total     float64           `json:"total"`
+	}{invoice.UserID, invoice.Period, invoice.LineItems, invoice.Total})
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
+
+// writeCSV writes one row per line item followed by a total row
+func writeCSV(out io.Writer, invoice *Invoice) error {
+	formatAmount := func(amount float64) string {
+		return strconv.FormatFloat(amount, 'f', 2, 64)
+	}
+
+	writer := csv.NewWriter(out)
+	rows := [][]string{{"invoiceId", "userId", "period", "type", "reference", "quantity", "unitPrice", "amount"}}
+	for _, item := range invoice.LineItems {
+		rows = append(rows, []string{
+			invoice.ID, invoice.UserID, invoice.Period, item.Type, item.Reference,
+			strconv.FormatFloat(item.Quantity, 'f', -1, 64), formatAmount(item.UnitPrice), formatAmount(item.Amount),
+		})
+	}
+	rows = append(rows, []string{invoice.ID, invoice.UserID, invoice.Period, "Total", invoice.ContentHash, "", "", formatAmount(invoice.Total)})
+
+	err := writer.WriteAll(rows)
+	if err != nil {
+		return errors.New("failed to write csv: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderInvoice(t *testing.T) {
+	invoice := Invoice{
+		ID:     "Invoice_6_2024-05",
+		UserID: "6",
+		Period: "2024-05",
+		LineItems: []InvoiceLineItem{
+			{Amount: 50, Quantity: 5, Reference: "EnergyBid1", Type: "EnergyPurchase", UnitPrice: 10},
+			{Amount: -2, Quantity: 1, Reference: "R1", Type: "Refund", UnitPrice: 2},
+		},
+		Total: 48,
+	}
+	invoice.ContentHash = contentHash(&invoice)
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "invoice.json")
+	invoiceAsBytes, _ := json.Marshal(invoice)
+	assert.NoError(t, os.WriteFile(input, invoiceAsBytes, 0o600))
+
+	// Test Case 1: CSV has a row per line item and a total row
+	t.Run("Render CSV", func(t *testing.T) {
+		output := filepath.Join(dir, "invoice.csv")
+		assert.NoError(t, run("csv", output, []string{input}))
+
+		csvAsBytes, _ := os.ReadFile(output)
+		assert.Equal(t, "invoiceId,userId,period,type,reference,quantity,unitPrice,amount\n"+
+			"Invoice_6_2024-05,6,2024-05,EnergyPurchase,EnergyBid1,5,10.00,50.00\n"+
+			"Invoice_6_2024-05,6,2024-05,Refund,R1,1,2.00,-2.00\n"+
+			"Invoice_6_2024-05,6,2024-05,Total,"+invoice.ContentHash+",,,48.00\n", string(csvAsBytes))
+	})
+
+	// Test Case 2: An altered invoice is refused
+	t.Run("Content Hash Mismatch", func(t *testing.T) {
+		invoice.Total = 1
+		altered := filepath.Join(dir, "altered.json")
+		invoiceAsBytes, _ := json.Marshal(invoice)
+		assert.NoError(t, os.WriteFile(altered, invoiceAsBytes, 0o600))
+
+		err := run("json", "", []string{altered})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "content hash")
+	})
+
+	// Test Case 3: Unknown formats are refused
+	t.Run("Unknown Format", func(t *testing.T) {
+		assert.Error(t, run("xml", filepath.Join(dir, "out"), []string{input}))
+	})
+}
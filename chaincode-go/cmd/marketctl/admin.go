@@ -0,0 +1,284 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/client"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                               Market Settings                              */
+/* -------------------------------------------------------------------------- */
+
+func getMarketConfig(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadMarketConfig(ctx)
+}
+
+// setMarketConfig updates the fields of the market configuration given in -file, keeping the
+// others
+func setMarketConfig(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	file := flags.String("file", "", "JSON file of the market configuration fields to change")
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "file")
+	if err != nil {
+		return nil, err
+	}
+	config, err := c.ReadMarketConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = readJSON(*file, config)
+	if err != nil {
+		return nil, err
+	}
+	return c.UpdateMarketConfig(ctx, *config)
+}
+
+func getFeeSchedule(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	version := flags.Int("version", 0, "fee schedule version, the version in effect when 0")
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadFeeSchedule(ctx, *version)
+}
+
+func publishFeeSchedule(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	var schedule chaincode.FeeSchedule
+	flags.IntVar(&schedule.Version, "version", 0, "fee schedule version")
+	flags.Int64Var(&schedule.EffectiveDate, "effective", 0, "time the version takes effect (unix seconds)")
+	flags.Float64Var(&schedule.Percentage, "percentage", 0, "fee percentage of the trade value")
+	flags.Float64Var(&schedule.FlatFee, "flat-fee", 0, "flat fee per trade")
+	flags.Float64Var(&schedule.MinFee, "min-fee", 0, "minimum fee")
+	flags.Float64Var(&schedule.MaxFee, "max-fee", 0, "maximum fee, unbounded when 0")
+	err := parseInput(flags, args, &schedule)
+	if err != nil {
+		return nil, err
+	}
+	return c.PublishFeeSchedule(ctx, schedule)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                    Grid                                    */
+/* -------------------------------------------------------------------------- */
+
+func getNetworkTariff(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	fromZone := flags.String("from", "", "zone of the seller")
+	toZone := flags.String("to", "", "zone of the buyer")
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "from", "to")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadNetworkTariff(ctx, *fromZone, *toZone)
+}
+
+func setNetworkTariff(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	fromZone := flags.String("from", "", "zone of the seller")
+	toZone := flags.String("to", "", "zone of the buyer")
+	charge := flags.Float64("charge", 0, "network charge per unit")
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "from", "to")
+	if err != nil {
+		return nil, err
+	}
+	return c.SetNetworkTariff(ctx, *fromZone, *toZone, *charge)
+}
+
+func setZoneCapacity(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	zoneID := flags.String("zone", "", "zone ID")
+	slotID := flags.String("slot", "", "slot ID, every slot without its own capacity when empty")
+	maxImport := flags.Float64("import", 0, "maximum import")
+	maxExport := flags.Float64("export", 0, "maximum export")
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "zone")
+	if err != nil {
+		return nil, err
+	}
+	return c.SetZoneCapacity(ctx, *zoneID, *slotID, *maxImport, *maxExport)
+}
+
+func getZoneUtilization(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	slotID := flags.String("slot", "", "slot ID, every slot the zone was used in when empty")
+	zoneID, err := stringArg(flags, args, "zone", "zone ID")
+	if err != nil {
+		return nil, err
+	}
+	if *slotID == "" {
+		return c.ReadZoneUtilizations(ctx, zoneID)
+	}
+	return c.ReadZoneUtilization(ctx, zoneID, *slotID)
+}
+
+func getEmissionFactors(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadEmissionFactors(ctx)
+}
+
+func setEmissionFactor(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	kgCO2PerUnit := flags.Float64("kg", 0, "kg of CO2 per unit")
+	source, err := stringArg(flags, args, "source", "energy source")
+	if err != nil {
+		return nil, err
+	}
+	return c.SetEmissionFactor(ctx, source, *kgCO2PerUnit)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                           Oracles and Endorsement                          */
+/* -------------------------------------------------------------------------- */
+
+func registerOracle(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	var oracle chaincode.PriceOracle
+	flags.StringVar(&oracle.ID, "id", "", "oracle ID")
+	flags.StringVar(&oracle.MSPID, "msp", "", "MSP ID of the oracle")
+	flags.Var((*listFlag)(&oracle.Markets), "markets", "comma separated markets the oracle publishes for")
+	publicKey := flags.String("public-key", "", "PEM file of the oracle's public key")
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "id", "msp", "markets", "public-key")
+	if err != nil {
+		return nil, err
+	}
+	publicKeyPEM, err := os.ReadFile(*publicKey)
+	if err != nil {
+		return nil, err
+	}
+	oracle.PublicKey = string(publicKeyPEM)
+	return c.RegisterPriceOracle(ctx, oracle)
+}
+
+func revokeOracle(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	oracleID, err := stringArg(flags, args, "id", "oracle ID")
+	if err != nil {
+		return nil, err
+	}
+	return c.RevokePriceOracle(ctx, oracleID)
+}
+
+func getEndorsementPolicy(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	key, err := stringArg(flags, args, "key", "ledger key")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadEndorsementPolicy(ctx, key)
+}
+
+func rotateEndorsementPolicy(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	var orgs listFlag
+	flags.Var(&orgs, "orgs", "comma separated MSP IDs whose peers must endorse the key")
+	key, err := stringArg(flags, args, "key", "ledger key")
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "orgs")
+	if err != nil {
+		return nil, err
+	}
+	return c.RotateEndorsementPolicy(ctx, key, orgs)
+}
+
+func reindexPayments(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	return c.ReindexPayments(ctx)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                  Disputes                                  */
+/* -------------------------------------------------------------------------- */
+
+func getDispute(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	disputeID, err := stringArg(flags, args, "id", "dispute ID")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadDispute(ctx, disputeID)
+}
+
+func reviewDispute(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	disputeID, err := stringArg(flags, args, "id", "dispute ID")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReviewDispute(ctx, disputeID)
+}
+
+func resolveDispute(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	resolution := flags.String("resolution", "", "resolution of the dispute")
+	adjustmentsFile := flags.String("adjustments", "", "JSON file of the adjustment payments")
+	disputeID, err := stringArg(flags, args, "id", "dispute ID")
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "resolution")
+	if err != nil {
+		return nil, err
+	}
+	var adjustments []chaincode.DisputeAdjustment
+	if *adjustmentsFile != "" {
+		err = readJSON(*adjustmentsFile, &adjustments)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.ResolveDispute(ctx, disputeID, *resolution, adjustments)
+}
+
+func rejectDispute(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	resolution := flags.String("resolution", "", "reason of the rejection")
+	disputeID, err := stringArg(flags, args, "id", "dispute ID")
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "resolution")
+	if err != nil {
+		return nil, err
+	}
+	return c.RejectDispute(ctx, disputeID, *resolution)
+}
@@ -0,0 +1,859 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/client"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                  Commands                                  */
+/* -------------------------------------------------------------------------- */
+
+// command runs a command of a domain. It parses the command's flags and returns the result to
+// print.
+type command func(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error)
+
+// domains are the commands of marketctl by domain
+var domains = map[string]map[string]command{
+	"users": {
+		"register":            registerUser,
+		"register-enterprise": registerEnterpriseUser,
+		"get":                 getUser,
+		"register-key":        registerUserKey,
+		"erase":               eraseUser,
+		"reliability":         getReliability,
+	},
+	"meters": {
+		"list":        listMeters,
+		"assign-zone": assignZone,
+	},
+	"orders": {
+		"register":   registerOrders,
+		"get":        getOrder,
+		"cancel":     cancelOrder,
+		"amend":      amendOrder,
+		"candidates": getMatchCandidates,
+		"payments":   getOrderPayments,
+	},
+	"matches": {
+		"process":  processBidMatch,
+		"get":      getBidMatch,
+		"payments": getBidMatchPayments,
+	},
+	"energy-bids": {
+		"process": processEnergyBid,
+		"get":     getEnergyBid,
+	},
+	"payments": {
+		"record":  recordPayment,
+		"refund":  refundPayment,
+		"get":     getPayment,
+		"list":    listUserPayments,
+		"detail":  getPaymentDetail,
+		"hash":    getPaymentDetailHash,
+		"invoice": getInvoice,
+	},
+	"contracts": {
+		"publish-template": publishTemplate,
+		"template":         getTemplate,
+		"sign-platform":    signPlatformContract,
+		"sign-trading":     signTradingContract,
+		"renew":            renewTradingContract,
+		"suspend":          transitionTradingContract("suspend"),
+		"reinstate":        transitionTradingContract("reinstate"),
+		"revoke":           transitionTradingContract("revoke"),
+		"expire":           expireTradingContracts,
+		"platform":         getPlatformContract,
+		"trading":          getTradingContract,
+	},
+	"admin": {
+		"config":              getMarketConfig,
+		"set-config":          setMarketConfig,
+		"fees":                getFeeSchedule,
+		"publish-fees":        publishFeeSchedule,
+		"tariff":              getNetworkTariff,
+		"set-tariff":          setNetworkTariff,
+		"set-capacity":        setZoneCapacity,
+		"utilization":         getZoneUtilization,
+		"emission-factors":    getEmissionFactors,
+		"set-emission-factor": setEmissionFactor,
+		"register-oracle":     registerOracle,
+		"revoke-oracle":       revokeOracle,
+		"endorsement":         getEndorsementPolicy,
+		"rotate-endorsement":  rotateEndorsementPolicy,
+		"reindex-payments":    reindexPayments,
+		"dispute":             getDispute,
+		"review-dispute":      reviewDispute,
+		"resolve-dispute":     resolveDispute,
+		"reject-dispute":      rejectDispute,
+	},
+}
+
+// newCommandFlags returns the flag set of a command
+func newCommandFlags(name string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	return flags
+}
+
+// parseInput parses the flags of a command whose input v can also be read from the JSON file
+// of -file. Flags given on the command line override the values of the file.
+func parseInput(flags *flag.FlagSet, args []string, v interface{}) error {
+	file := flags.String("file", "", "JSON file of the input, overridden by the flags given")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *file == "" {
+		return nil
+	}
+	err = readJSON(*file, v)
+	if err != nil {
+		return err
+	}
+	return flags.Parse(args)
+}
+
+// readJSON decodes a JSON file into v
+func readJSON(path string, v interface{}) error {
+	valueAsBytes, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(valueAsBytes, v)
+	if err != nil {
+		return errors.New("failed to parse " + path + ": " + err.Error())
+	}
+	return nil
+}
+
+// required checks that flags of a command are set
+func required(flags *flag.FlagSet, names ...string) error {
+	for _, name := range names {
+		if flags.Lookup(name).Value.String() == "" {
+			return errors.New(flags.Name() + ": -" + name + " is required")
+		}
+	}
+	return nil
+}
+
+// listFlag is a comma separated list flag
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = nil
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// stringArg parses the flags of a command taking one required string flag
+func stringArg(flags *flag.FlagSet, args []string, name string, usage string) (string, error) {
+	value := flags.String(name, "", usage)
+	err := flags.Parse(args)
+	if err != nil {
+		return "", err
+	}
+	return *value, required(flags, name)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                    Users                                   */
+/* -------------------------------------------------------------------------- */
+
+// userInput is the input of users register; Location and Contact go to the private data
+// collection salted with Salt
+type userInput struct {
+	chaincode.User
+	Salt string `json:"salt"`
+}
+
+func registerUser(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	var input userInput
+	flags.StringVar(&input.ID, "id", "", "user ID")
+	flags.StringVar(&input.Category, "category", "", "user category")
+	flags.StringVar(&input.MeterID, "meter", "", "meter ID")
+	flags.StringVar(&input.Source, "source", "", "energy source")
+	flags.BoolVar(&input.IsAdmin, "admin", false, "whether the user is an admin")
+	flags.StringVar(&input.Location, "location", "", "location, kept in private data")
+	flags.StringVar(&input.Contact, "contact", "", "contact, kept in private data")
+	flags.StringVar(&input.Salt, "salt", "", "salt of the private data hashes")
+	err := parseInput(flags, args, &input)
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "id", "category", "meter", "source")
+	if err != nil {
+		return nil, err
+	}
+	return c.UpdateUserProfile(ctx, input.User, privateDetails(input.Location, input.Contact, input.Salt))
+}
+
+// enterpriseUserInput is the input of users register-enterprise
+type enterpriseUserInput struct {
+	chaincode.EnterpriseUser
+	Salt string `json:"salt"`
+}
+
+func registerEnterpriseUser(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	var input enterpriseUserInput
+	flags.StringVar(&input.ID, "id", "", "user ID")
+	flags.StringVar(&input.Category, "category", "", "user category")
+	flags.Var((*listFlag)(&input.MeterIDs), "meters", "comma separated meter IDs")
+	flags.StringVar(&input.Source, "source", "", "energy source")
+	flags.BoolVar(&input.IsAdmin, "admin", false, "whether the user is an admin")
+	flags.StringVar(&input.Location, "location", "", "location, kept in private data")
+	flags.StringVar(&input.Contact, "contact", "", "contact, kept in private data")
+	flags.StringVar(&input.Salt, "salt", "", "salt of the private data hashes")
+	err := parseInput(flags, args, &input)
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "id", "category", "meters", "source")
+	if err != nil {
+		return nil, err
+	}
+	return c.UpdateEnterpriseUserProfile(ctx, input.EnterpriseUser, privateDetails(input.Location, input.Contact, input.Salt))
+}
+
+// privateDetails returns the private details of a user, nil when none are given
+func privateDetails(location string, contact string, salt string) *chaincode.UserPrivateDetails {
+	if location == "" && contact == "" && salt == "" {
+		return nil
+	}
+	return &chaincode.UserPrivateDetails{Contact: contact, Location: location, Salt: salt}
+}
+
+func getUser(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	enterprise := flags.Bool("enterprise", false, "read an enterprise user")
+	userID, err := stringArg(flags, args, "id", "user ID")
+	if err != nil {
+		return nil, err
+	}
+	if *enterprise {
+		return c.ReadEnterpriseUserProfile(ctx, userID)
+	}
+	return c.ReadUserProfile(ctx, userID)
+}
+
+func registerUserKey(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	userID := flags.String("id", "", "user ID")
+	publicKey := flags.String("public-key", "", "PEM file of the public key")
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "id", "public-key")
+	if err != nil {
+		return nil, err
+	}
+	publicKeyPEM, err := os.ReadFile(*publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return c.RegisterUserKey(ctx, *userID, string(publicKeyPEM))
+}
+
+func eraseUser(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	userID, err := stringArg(flags, args, "id", "user ID")
+	if err != nil {
+		return nil, err
+	}
+	return c.EraseParticipantData(ctx, userID)
+}
+
+func getReliability(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	userID, err := stringArg(flags, args, "id", "user ID")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadReliabilityScore(ctx, userID)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                   Meters                                   */
+/* -------------------------------------------------------------------------- */
+
+// meterRow is a meter of a user
+type meterRow struct {
+	MeterID string `json:"meterId"`
+	UserID  string `json:"userId"`
+	ZoneID  string `json:"zoneId"`
+}
+
+func listMeters(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	userID, err := stringArg(flags, args, "user", "user ID")
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []meterRow{}
+	enterprise, err := c.ReadEnterpriseUserProfile(ctx, userID)
+	if err == nil && len(enterprise.MeterIDs) > 0 {
+		for _, meterID := range enterprise.MeterIDs {
+			rows = append(rows, meterRow{MeterID: meterID, UserID: userID, ZoneID: enterprise.ZoneID})
+		}
+		return rows, nil
+	}
+	user, err := c.ReadUserProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MeterID != "" {
+		rows = append(rows, meterRow{MeterID: user.MeterID, UserID: userID, ZoneID: user.ZoneID})
+	}
+	return rows, nil
+}
+
+func assignZone(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	userID := flags.String("user", "", "user ID")
+	meterID := flags.String("meter", "", "meter ID, the whole user when empty")
+	zoneID := flags.String("zone", "", "grid zone ID")
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "user", "zone")
+	if err != nil {
+		return nil, err
+	}
+	return c.AssignGridZone(ctx, *userID, *meterID, *zoneID)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                   Orders                                   */
+/* -------------------------------------------------------------------------- */
+
+func registerOrders(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	order := chaincode.Order{BidStatus: "BidCreated", OnMarketPrice: "0"}
+	flags.StringVar(&order.ID, "id", "", "order ID")
+	flags.StringVar(&order.UserID, "user", "", "user ID")
+	flags.StringVar(&order.UserAction, "action", "", "Buy or Sell")
+	flags.StringVar(&order.SlotID, "slot", "", "slot ID")
+	flags.Int64Var(&order.SlotExecDate, "exec-date", 0, "execution time of the slot (unix seconds)")
+	flags.Int64Var(&order.TotalQuantity, "quantity", 0, "total quantity")
+	flags.Float64Var(&order.UnitCost, "unit-cost", 0, "unit cost")
+	flags.Float64Var(&order.OrderCost, "cost", 0, "order cost")
+	flags.StringVar(&order.PaymentID, "payment", "", "payment ID")
+	flags.StringVar(&order.BidStatus, "status", order.BidStatus, "BidCreated or BidAccepted")
+	flags.StringVar(&order.BidMatchID, "bid-match", "", "bid match ID")
+	flags.StringVar(&order.OnMarketPrice, "on-market-price", order.OnMarketPrice, "on market price")
+	flags.StringVar(&order.OrderType, "type", "", "Limit or Market")
+	flags.StringVar(&order.TimeInForce, "time-in-force", "", "GoodTillSlot, FillOrKill or AllOrNone")
+	flags.Float64Var(&order.ProtectionPrice, "protection-price", 0, "protection price of market orders")
+	flags.StringVar(&order.ReferencePriceID, "reference-price", "", "reference price ID")
+	flags.StringVar(&order.MeterID, "meter", "", "meter ID")
+
+	// A file holding a JSON array registers the orders as a batch.
+	file := flags.String("file", "", "JSON file of the order, or of an array of orders to register as a batch")
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	if *file != "" {
+		orderAsBytes, err := os.ReadFile(*file)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.HasPrefix(bytes.TrimSpace(orderAsBytes), []byte("[")) {
+			var orders []chaincode.Order
+			err = json.Unmarshal(orderAsBytes, &orders)
+			if err != nil {
+				return nil, errors.New("failed to parse " + *file + ": " + err.Error())
+			}
+			return c.RegisterOrders(ctx, orders)
+		}
+		err = json.Unmarshal(orderAsBytes, &order)
+		if err != nil {
+			return nil, errors.New("failed to parse " + *file + ": " + err.Error())
+		}
+		err = flags.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = required(flags, "id", "user", "action", "slot", "payment")
+	if err != nil {
+		return nil, err
+	}
+	return c.RegisterOrder(ctx, order)
+}
+
+func getOrder(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	orderID, err := stringArg(flags, args, "id", "order ID")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadOrder(ctx, orderID)
+}
+
+func cancelOrder(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	orderID, err := stringArg(flags, args, "id", "order ID")
+	if err != nil {
+		return nil, err
+	}
+	return c.CancelOrder(ctx, orderID)
+}
+
+func amendOrder(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	orderID := flags.String("id", "", "order ID")
+	quantity := flags.Int64("quantity", 0, "new total quantity")
+	unitCost := flags.Float64("unit-cost", 0, "new unit cost")
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "id")
+	if err != nil {
+		return nil, err
+	}
+	return c.AmendOrder(ctx, *orderID, *quantity, *unitCost)
+}
+
+func getMatchCandidates(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	orderID, err := stringArg(flags, args, "id", "order ID")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadMatchCandidates(ctx, orderID)
+}
+
+func getOrderPayments(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	orderID, err := stringArg(flags, args, "id", "order ID")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadPaymentsForOrder(ctx, orderID)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                           Matches and Energy Bids                          */
+/* -------------------------------------------------------------------------- */
+
+func processBidMatch(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	bidMatch := chaincode.BidMatch{BidMatchTms: time.Now().Unix()}
+	flags.StringVar(&bidMatch.ID, "id", "", "bid match ID")
+	flags.Int64Var(&bidMatch.BidMatchTms, "tms", bidMatch.BidMatchTms, "match time (unix seconds)")
+	flags.StringVar(&bidMatch.BidSlot, "slot", "", "slot ID")
+	flags.StringVar(&bidMatch.BidStatus, "status", "", "bid status")
+	flags.Int64Var(&bidMatch.BidUnitPrice, "unit-price", 0, "unit price")
+	flags.StringVar(&bidMatch.BuyerUserId, "buyer", "", "buyer user ID")
+	flags.StringVar(&bidMatch.SellerUserId, "seller", "", "seller user ID")
+	flags.StringVar(&bidMatch.TransactionBuyID, "buy-order", "", "buy order ID")
+	flags.StringVar(&bidMatch.TransactionSellID, "sell-order", "", "sell order ID")
+	flags.Float64Var(&bidMatch.OriginalBidUnits, "units", 0, "matched units")
+	flags.Float64Var(&bidMatch.DeliveredBidUnits, "delivered-units", 0, "delivered units")
+	err := parseInput(flags, args, &bidMatch)
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "id", "slot", "status", "buyer", "seller", "buy-order", "sell-order")
+	if err != nil {
+		return nil, err
+	}
+	return c.ProcessBidMatch(ctx, bidMatch)
+}
+
+func getBidMatch(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	bidMatchID, err := stringArg(flags, args, "id", "bid match ID")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadBidMatch(ctx, bidMatchID)
+}
+
+func getBidMatchPayments(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	bidMatchID, err := stringArg(flags, args, "id", "bid match ID")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadPaymentsForBidMatch(ctx, bidMatchID)
+}
+
+func processEnergyBid(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	var energyBid chaincode.EnergyBid
+	flags.StringVar(&energyBid.ID, "id", "", "energy bid ID")
+	flags.StringVar(&energyBid.BidMatchID, "bid-match", "", "bid match ID")
+	flags.Float64Var(&energyBid.InitialBidUnits, "initial-units", 0, "initial bid units")
+	flags.Float64Var(&energyBid.AcceptedBidUnits, "accepted-units", 0, "accepted bid units")
+	flags.Float64Var(&energyBid.BuyerMeterUnit, "buyer-meter", 0, "buyer meter reading")
+	flags.Float64Var(&energyBid.SellerMeterUnit, "seller-meter", 0, "seller meter reading")
+	flags.Float64Var(&energyBid.BuyerBroughtUnitFromSeller, "buyer-from-seller", 0, "units the buyer bought from the seller")
+	flags.Float64Var(&energyBid.SellerSoldUnitToBuyer, "seller-to-buyer", 0, "units the seller sold to the buyer")
+	flags.Float64Var(&energyBid.SellerSoldUnitToGrid, "seller-to-grid", 0, "units the seller sold to the grid")
+	flags.Float64Var(&energyBid.BuyerSoldUnitToGrid, "buyer-to-grid", 0, "units the buyer sold to the grid")
+	flags.Float64Var(&energyBid.BuyerBroughtUnitFromGrid, "buyer-from-grid", 0, "units the buyer bought from the grid")
+	flags.StringVar(&energyBid.Reason, "reason", "", "reason of a delivery shortfall")
+	flags.StringVar(&energyBid.ReferencePriceID, "reference-price", "", "reference price ID, the buy order's when empty")
+	err := parseInput(flags, args, &energyBid)
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "id", "bid-match")
+	if err != nil {
+		return nil, err
+	}
+	return c.ProcessEnergyBid(ctx, energyBid)
+}
+
+func getEnergyBid(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	energyBidID, err := stringArg(flags, args, "id", "energy bid ID")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadEnergyBid(ctx, energyBidID)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                  Payments                                  */
+/* -------------------------------------------------------------------------- */
+
+// paymentInput is the input of payments record
+type paymentInput struct {
+	chaincode.Payment
+	Detail chaincode.PaymentDetail `json:"detail"`
+	Salt   string                  `json:"salt"`
+}
+
+func recordPayment(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	var input paymentInput
+	flags.StringVar(&input.ID, "id", "", "payment ID")
+	flags.StringVar(&input.PaymentType, "type", "", "payment type")
+	flags.Float64Var(&input.TotalAmount, "amount", 0, "total amount")
+	flags.StringVar(&input.UserID, "user", "", "user ID")
+	flags.StringVar(&input.PaymentDetailID, "detail-id", "", "payment detail ID")
+	flags.StringVar(&input.OrderID, "order", "", "order ID")
+	flags.StringVar(&input.BidMatchID, "bid-match", "", "bid match ID")
+	flags.StringVar(&input.Detail.DebitedFrom, "debited-from", "", "debited account, kept in private data")
+	flags.StringVar(&input.Detail.CreditedTo, "credited-to", "", "credited account, kept in private data")
+	flags.Float64Var(&input.Detail.TotalUnitCost, "unit-cost", 0, "total unit cost")
+	flags.Float64Var(&input.Detail.PlatformFee, "platform-fee", 0, "platform fee")
+	flags.Float64Var(&input.Detail.TokenAmount, "token-amount", 0, "token amount")
+	flags.StringVar(&input.Salt, "salt", "", "salt of the private data hash")
+	err := parseInput(flags, args, &input)
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "id", "type", "user", "detail-id", "salt")
+	if err != nil {
+		return nil, err
+	}
+	input.Detail.ID = input.PaymentDetailID
+	return c.RecordPayment(ctx, input.Payment, input.Detail, input.Salt)
+}
+
+func refundPayment(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	var refund chaincode.PaymentDetail
+	refundPaymentID := flags.String("id", "", "refund payment ID")
+	paymentID := flags.String("payment", "", "ID of the refunded payment")
+	flags.StringVar(&refund.ID, "detail-id", "", "payment detail ID of the refund")
+	flags.Float64Var(&refund.BidRefundAmount, "bid-refund", 0, "refunded bid amount")
+	flags.Float64Var(&refund.PlatformFeeRefundAmount, "fee-refund", 0, "refunded platform fee")
+	flags.Float64Var(&refund.TokenAmountRefund, "token-refund", 0, "refunded token amount")
+	salt := flags.String("salt", "", "salt of the private data hash")
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "id", "payment", "detail-id", "salt")
+	if err != nil {
+		return nil, err
+	}
+	return c.RefundPayment(ctx, *refundPaymentID, *paymentID, refund.ID, refund, *salt)
+}
+
+func getPayment(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	paymentID, err := stringArg(flags, args, "id", "payment ID")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadPayment(ctx, paymentID)
+}
+
+func listUserPayments(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	userID, err := stringArg(flags, args, "user", "user ID")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadPaymentsForUser(ctx, userID)
+}
+
+func getPaymentDetail(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	detailID, err := stringArg(flags, args, "id", "payment detail ID")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadPaymentDetail(ctx, detailID)
+}
+
+func getPaymentDetailHash(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	detailID, err := stringArg(flags, args, "id", "payment detail ID")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadPaymentDetailHash(ctx, detailID)
+}
+
+func getInvoice(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	userID := flags.String("user", "", "user ID")
+	period := flags.String("period", "", "month of the invoice (YYYY-MM)")
+	generate := flags.Bool("generate", false, "generate the invoice before reading it")
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "user", "period")
+	if err != nil {
+		return nil, err
+	}
+	if *generate {
+		_, err = c.GenerateInvoice(ctx, *userID, *period)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.ReadInvoice(ctx, *userID, *period)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                  Contracts                                 */
+/* -------------------------------------------------------------------------- */
+
+func publishTemplate(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	var template chaincode.ContractTemplate
+	flags.StringVar(&template.ID, "id", "", "template ID")
+	flags.StringVar(&template.ContractType, "type", "", "Platform or Trading")
+	flags.IntVar(&template.Version, "version", 0, "template version")
+	flags.StringVar(&template.DocumentHash, "hash", "", "hash of the contract document")
+	flags.Int64Var(&template.EffectiveDate, "effective", 0, "time the version takes effect (unix seconds)")
+	err := parseInput(flags, args, &template)
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "id", "type", "hash")
+	if err != nil {
+		return nil, err
+	}
+	return c.PublishContractTemplate(ctx, template)
+}
+
+func getTemplate(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	version := flags.Int("version", 0, "template version, the version in effect when 0")
+	templateID, err := stringArg(flags, args, "id", "template ID")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadContractTemplate(ctx, templateID, *version)
+}
+
+// signatureFlags are the flags of the commands signing a template version
+type signatureFlags struct {
+	signature  *string
+	signingKey *string
+	templateID *string
+	userID     *string
+	version    *int
+}
+
+func newSignatureFlags(flags *flag.FlagSet) *signatureFlags {
+	return &signatureFlags{
+		signature:  flags.String("signature", "", "base64 signature of the contract signing message"),
+		signingKey: flags.String("signing-key", "", "PEM private key of the user to sign the contract signing message with"),
+		templateID: flags.String("template", "", "template ID"),
+		userID:     flags.String("user", "", "user ID"),
+		version:    flags.Int("version", 0, "template version, the version in effect when 0"),
+	}
+}
+
+// sign returns the signature of the template version, signing its contract signing message and
+// the terms with the user's key unless a signature is given
+func (s *signatureFlags) sign(ctx context.Context, c *client.Client, flags *flag.FlagSet, terms ...string) (int, string, error) {
+	err := required(flags, "user", "template")
+	if err != nil {
+		return 0, "", err
+	}
+	template, err := c.ReadContractTemplate(ctx, *s.templateID, *s.version)
+	if err != nil {
+		return 0, "", err
+	}
+	if *s.signature != "" {
+		return template.Version, *s.signature, nil
+	}
+	if *s.signingKey == "" {
+		return 0, "", errors.New(flags.Name() + ": -signature or -signing-key is required")
+	}
+
+	key, err := readPrivateKey(*s.signingKey)
+	if err != nil {
+		return 0, "", err
+	}
+	message := chaincode.ContractSigningMessage(*s.userID, template.ID, template.Version, template.DocumentHash, terms...)
+	signature, err := signMessage(key, message)
+	if err != nil {
+		return 0, "", err
+	}
+	return template.Version, signature, nil
+}
+
+// signMessage returns the base64 encoded signature of a message
+func signMessage(key *ecdsa.PrivateKey, message string) (string, error) {
+	digest := sha256.Sum256([]byte(message))
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+func signPlatformContract(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	signature := newSignatureFlags(flags)
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	version, sig, err := signature.sign(ctx, c, flags)
+	if err != nil {
+		return nil, err
+	}
+	return c.SignPlatformContract(ctx, *signature.userID, *signature.templateID, version, sig)
+}
+
+func signTradingContract(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	signature := newSignatureFlags(flags)
+	var actions listFlag
+	flags.Var(&actions, "actions", "comma separated trading actions (Buy, Sell)")
+	expiresOn := flags.Int64("expires", time.Now().AddDate(1, 0, 0).Unix(), "expiry of the contract (unix seconds)")
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "actions", "user")
+	if err != nil {
+		return nil, err
+	}
+	// A revoked contract being replaced gives the revision the signature covers.
+	revision := 0
+	contract, err := c.ReadTradingContract(ctx, *signature.userID)
+	if err == nil {
+		revision = len(contract.History)
+	} else if !errors.Is(err, client.ErrNotFound) {
+		return nil, err
+	}
+	version, sig, err := signature.sign(ctx, c, flags, chaincode.TradingContractTerms(actions, *expiresOn, revision)...)
+	if err != nil {
+		return nil, err
+	}
+	return c.SignTradingContract(ctx, *signature.userID, *signature.templateID, version, sig, actions, *expiresOn)
+}
+
+func renewTradingContract(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	signature := newSignatureFlags(flags)
+	expiresOn := flags.Int64("expires", time.Now().AddDate(1, 0, 0).Unix(), "expiry of the contract (unix seconds)")
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	err = required(flags, "user")
+	if err != nil {
+		return nil, err
+	}
+	contract, err := c.ReadTradingContract(ctx, *signature.userID)
+	if err != nil {
+		return nil, err
+	}
+	version, sig, err := signature.sign(ctx, c, flags, chaincode.TradingContractTerms(contract.Actions, *expiresOn, len(contract.History))...)
+	if err != nil {
+		return nil, err
+	}
+	return c.RenewTradingContract(ctx, *signature.userID, *signature.templateID, version, sig, *expiresOn)
+}
+
+// transitionTradingContract returns the run function of the suspend, reinstate and revoke
+// commands
+func transitionTradingContract(transition string) command {
+	return func(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+		userID := flags.String("user", "", "user ID")
+		reason := flags.String("reason", "", "reason of the transition")
+		err := flags.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+		err = required(flags, "user", "reason")
+		if err != nil {
+			return nil, err
+		}
+		switch transition {
+		case "suspend":
+			return c.SuspendTradingContract(ctx, *userID, *reason)
+		case "reinstate":
+			return c.ReinstateTradingContract(ctx, *userID, *reason)
+		default:
+			return c.RevokeTradingContract(ctx, *userID, *reason)
+		}
+	}
+}
+
+func expireTradingContracts(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	return c.ExpireTradingContracts(ctx)
+}
+
+func getPlatformContract(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	userID, err := stringArg(flags, args, "user", "user ID")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadPlatformContract(ctx, userID)
+}
+
+func getTradingContract(ctx context.Context, c *client.Client, flags *flag.FlagSet, args []string) (interface{}, error) {
+	userID, err := stringArg(flags, args, "user", "user ID")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadTradingContract(ctx, userID)
+}
@@ -0,0 +1,262 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Command marketctl invokes, queries and administers the energy trading chaincode. Commands are
+// grouped by domain; their inputs come from flags or, with -file, from a JSON file whose values
+// the flags override. Results print as tables or, with -output json, as JSON.
+//
+//	marketctl -peer localhost:7051 -tls-ca ca.pem -msp Org1MSP -cert cert.pem -key key.pem orders get -id 42
+//	marketctl --local -role admin contracts publish-template -id trading-terms -type Trading -version 1 -hash 4f1c...
+//	marketctl --local users register -file user.json
+//
+// With --local the commands run against a mock ledger kept in a file (-ledger), acting as a
+// generated identity of -msp with the Fabric CA role attribute -role.
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/client"
+)
+
+// options are the global flags of marketctl
+type options struct {
+	cert       string
+	channel    string
+	chaincode  string
+	key        string
+	ledger     string
+	local      bool
+	msp        string
+	output     string
+	peer       string
+	role       string
+	serverName string
+	timeout    time.Duration
+	tlsCA      string
+	verbose    bool
+}
+
+func main() {
+	err := run(os.Args[1:], os.Stdout, os.Stderr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "marketctl: "+err.Error())
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer, stderr io.Writer) error {
+	var opts options
+	flags := flag.NewFlagSet("marketctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.BoolVar(&opts.local, "local", false, "run against the file-backed mock ledger instead of a Fabric network")
+	flags.StringVar(&opts.ledger, "ledger", "ledger.json", "mock ledger file of --local")
+	flags.StringVar(&opts.msp, "msp", "Org1MSP", "MSP ID of the identity")
+	flags.StringVar(&opts.role, "role", "", "role attribute of the --local identity (admin, gridOperator, arbiter, oracle)")
+	flags.StringVar(&opts.cert, "cert", "", "PEM certificate of the identity")
+	flags.StringVar(&opts.key, "key", "", "PEM private key of the identity")
+	flags.StringVar(&opts.peer, "peer", "localhost:7051", "address of the peer's gateway service")
+	flags.StringVar(&opts.tlsCA, "tls-ca", "", "PEM CA certificate of the peer's TLS certificate")
+	flags.StringVar(&opts.serverName, "server-name", "", "TLS server name of the peer")
+	flags.StringVar(&opts.channel, "channel", "mychannel", "channel name")
+	flags.StringVar(&opts.chaincode, "chaincode", "basic", "chaincode name")
+	flags.DurationVar(&opts.timeout, "timeout", time.Minute, "timeout of a command")
+	flags.StringVar(&opts.output, "output", "table", "output format, table or json")
+	flags.BoolVar(&opts.verbose, "v", false, "print the chaincode log of --local to stderr")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: marketctl [flags] <domain> <command> [command flags]")
+		fmt.Fprintln(stderr, "\nDomains and commands:")
+		for _, domain := range sortedDomains() {
+			fmt.Fprintf(stderr, "  %-12s %s\n", domain, strings.Join(sortedCommands(domains[domain]), ", "))
+		}
+		fmt.Fprintln(stderr, "\nFlags:")
+		flags.PrintDefaults()
+	}
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if opts.output != "table" && opts.output != "json" {
+		return errors.New("unknown output " + opts.output + ", expecting table or json")
+	}
+
+	args = flags.Args()
+	if len(args) < 2 {
+		flags.Usage()
+		return errors.New("expecting a domain and a command")
+	}
+	commands, ok := domains[args[0]]
+	if !ok {
+		return errors.New("unknown domain " + args[0])
+	}
+	cmd, ok := commands[args[1]]
+	if !ok {
+		return errors.New("unknown command " + args[1] + " of " + args[0] + ", expecting one of " + strings.Join(sortedCommands(commands), ", "))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	defer cancel()
+
+	var result interface{}
+	if opts.local {
+		result, err = runLocal(ctx, &opts, cmd, args[0]+" "+args[1], args[2:], stderr)
+	} else {
+		result, err = runGateway(ctx, &opts, cmd, args[0]+" "+args[1], args[2:], stderr)
+	}
+	if err != nil {
+		return err
+	}
+	return render(stdout, opts.output, result)
+}
+
+// runLocal runs a command against the mock ledger file and saves the ledger afterwards
+func runLocal(ctx context.Context, opts *options, cmd command, name string, args []string, stderr io.Writer) (interface{}, error) {
+	ledger := client.NewMockLedger()
+	file, err := os.Open(opts.ledger)
+	if err == nil {
+		ledger, err = client.LoadMockLedger(file)
+		file.Close()
+		if err != nil {
+			return nil, errors.New(opts.ledger + ": " + err.Error())
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var attrs map[string]string
+	if opts.role != "" {
+		attrs = map[string]string{chaincode.RoleAttribute: opts.role}
+	}
+	identity, err := client.NewMockIdentity(opts.msp, attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	// The chaincode logs to stdout, which is kept for the results.
+	stdout := os.Stdout
+	log := os.Stderr
+	if !opts.verbose {
+		log, err = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		if err != nil {
+			return nil, err
+		}
+		defer log.Close()
+	}
+	os.Stdout = log
+	result, err := cmd(ctx, client.New(ledger.Contract(identity)), newCommandFlags(name, stderr), args)
+	os.Stdout = stdout
+	if err != nil {
+		return nil, err
+	}
+
+	file, err = os.Create(opts.ledger)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	err = ledger.Save(file)
+	if err != nil {
+		return nil, errors.New("failed to save " + opts.ledger + ": " + err.Error())
+	}
+	return result, nil
+}
+
+// runGateway runs a command against the chaincode through the gateway service of a peer
+func runGateway(ctx context.Context, opts *options, cmd command, name string, args []string, stderr io.Writer) (interface{}, error) {
+	if opts.cert == "" || opts.key == "" {
+		return nil, errors.New("-cert and -key are required without --local")
+	}
+	certificatePEM, err := os.ReadFile(opts.cert)
+	if err != nil {
+		return nil, err
+	}
+	key, err := readPrivateKey(opts.key)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := grpc.WithInsecure()
+	if opts.tlsCA != "" {
+		caPEM, err := os.ReadFile(opts.tlsCA)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("no certificates in " + opts.tlsCA)
+		}
+		transport = grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: pool, ServerName: opts.serverName}))
+	}
+	conn, err := grpc.DialContext(ctx, opts.peer, transport)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	contract := client.NewGatewayContract(conn, client.NewX509Identity(opts.msp, certificatePEM), client.NewPrivateKeySign(key), opts.channel, opts.chaincode)
+	return cmd(ctx, client.New(contract), newCommandFlags(name, stderr), args)
+}
+
+// readPrivateKey reads a PEM encoded ECDSA private key
+func readPrivateKey(path string) (*ecdsa.PrivateKey, error) {
+	keyPEM, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := client.ParsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, errors.New(path + ": " + err.Error())
+	}
+	return key, nil
+}
+
+// sortedDomains returns the names of the domains in order
+func sortedDomains() []string {
+	names := make([]string, 0, len(domains))
+	for name := range domains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedCommands returns the names of the commands of a domain in order
+func sortedCommands(commands map[string]command) []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
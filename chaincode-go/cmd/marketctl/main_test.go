@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+)
+
+func TestLocalCommands(t *testing.T) {
+	dir := t.TempDir()
+	ledger := filepath.Join(dir, "ledger.json")
+	marketctl := func(args ...string) (string, error) {
+		var stdout, stderr bytes.Buffer
+		err := run(append([]string{"--local", "-ledger", ledger}, args...), &stdout, &stderr)
+		return stdout.String(), err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	keyFile := filepath.Join(dir, "key.pem")
+	publicKeyFile := filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(t, os.WriteFile(publicKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER}), 0o600))
+
+	// Test Case 1: Inputs come from JSON files overridden by flags, and the ledger file keeps them
+	t.Run("Register User", func(t *testing.T) {
+		userFile := filepath.Join(dir, "user.json")
+		require.NoError(t, os.WriteFile(userFile, []byte(`{"id":"20","category":"Prosumer","meterId":"M1","source":"Solar","location":"Main Street 1","salt":"pepper"}`), 0o600))
+		txID, err := marketctl("users", "register", "-file", userFile, "-meter", "M20")
+		require.NoError(t, err)
+		assert.Len(t, strings.TrimSpace(txID), 64)
+		_, err = marketctl("users", "register-key", "-id", "20", "-public-key", publicKeyFile)
+		require.NoError(t, err)
+
+		output, err := marketctl("-output", "json", "users", "get", "-id", "20")
+		require.NoError(t, err)
+		var user chaincode.User
+		require.NoError(t, json.Unmarshal([]byte(output), &user))
+		assert.Equal(t, "M20", user.MeterID)
+		assert.Equal(t, "Main Street 1", user.Location)
+		assert.NotEmpty(t, user.PublicKey)
+	})
+
+	// Test Case 2: Records print one field per row and lists one record per row
+	t.Run("Tables", func(t *testing.T) {
+		output, err := marketctl("meters", "list", "-user", "20")
+		require.NoError(t, err)
+		assert.Equal(t, "METERID  USERID  ZONEID\nM20      20      \n", output)
+
+		output, err = marketctl("users", "get", "-id", "20")
+		require.NoError(t, err)
+		assert.Contains(t, output, "meterId       M20\n")
+	})
+
+	// Test Case 3: Contracts are signed with the user's key over the template's document hash
+	t.Run("Sign Contracts", func(t *testing.T) {
+		_, err := marketctl("contracts", "publish-template", "-id", "platform-terms", "-type", chaincode.PlatformContractType, "-version", "1", "-hash", "platform-hash")
+		assert.Error(t, err)
+		_, err = marketctl("-role", chaincode.AdminRole, "contracts", "publish-template", "-id", "platform-terms", "-type", chaincode.PlatformContractType, "-version", "1", "-hash", "platform-hash")
+		require.NoError(t, err)
+
+		_, err = marketctl("contracts", "sign-platform", "-user", "20", "-template", "platform-terms", "-signing-key", keyFile)
+		require.NoError(t, err)
+		output, err := marketctl("-output", "json", "contracts", "platform", "-user", "20")
+		require.NoError(t, err)
+		var contract chaincode.PlatformContract
+		require.NoError(t, json.Unmarshal([]byte(output), &contract))
+		assert.Equal(t, "platform-hash", contract.SignedContractHash)
+		assert.Equal(t, 1, contract.TemplateVersion)
+	})
+
+	// Test Case 4: Missing flags, unknown commands and chaincode errors are reported
+	t.Run("Errors", func(t *testing.T) {
+		_, err := marketctl("orders", "get")
+		assert.EqualError(t, err, "orders get: -id is required")
+		_, err = marketctl("orders", "fetch", "-id", "1")
+		assert.Error(t, err)
+		_, err = marketctl("orders", "get", "-id", "9")
+		assert.EqualError(t, err, "ReadOrder: Order with ID 9 not found.")
+		_, err = marketctl("-output", "xml", "orders", "get", "-id", "9")
+		assert.Error(t, err)
+	})
+
+	// Test Case 5: The payments of a user are listed from the user index
+	t.Run("List User Payments", func(t *testing.T) {
+		_, err := marketctl("payments", "list")
+		assert.EqualError(t, err, "payments list: -user is required")
+
+		output, err := marketctl("-output", "json", "-role", chaincode.AdminRole, "payments", "list", "-user", "20")
+		require.NoError(t, err)
+		var payments []chaincode.Payment
+		require.NoError(t, json.Unmarshal([]byte(output), &payments))
+		assert.Empty(t, payments)
+	})
+}
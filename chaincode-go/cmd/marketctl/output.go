@@ -0,0 +1,132 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                                   Output                                   */
+/* -------------------------------------------------------------------------- */
+
+// render prints the result of a command as JSON or as a table. Transaction IDs and other plain
+// strings print as they are; a record prints one field per row and a list one record per row.
+func render(out io.Writer, format string, result interface{}) error {
+	if format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	value := reflect.ValueOf(result)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	switch {
+	case !value.IsValid() || value.Kind() == reflect.Ptr:
+		return nil
+	case value.Kind() == reflect.String:
+		fmt.Fprintln(out, value.String())
+		return nil
+	case value.Kind() == reflect.Struct:
+		names, fields := columns(value.Type())
+		for i, name := range names {
+			fmt.Fprintf(writer, "%s\t%s\n", name, cell(value.Field(fields[i])))
+		}
+	case value.Kind() == reflect.Slice && elemType(value.Type()).Kind() == reflect.Struct:
+		names, fields := columns(elemType(value.Type()))
+		fmt.Fprintln(writer, strings.ToUpper(strings.Join(names, "\t")))
+		for i := 0; i < value.Len(); i++ {
+			row := reflect.Indirect(value.Index(i))
+			cells := make([]string, 0, len(fields))
+			for _, field := range fields {
+				cells = append(cells, cell(row.Field(field)))
+			}
+			fmt.Fprintln(writer, strings.Join(cells, "\t"))
+		}
+	case value.Kind() == reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			fmt.Fprintln(writer, cell(value.Index(i)))
+		}
+	default:
+		return errors.New("can not render " + value.Type().String() + " as a table")
+	}
+	return writer.Flush()
+}
+
+// elemType returns the struct type of the elements of a slice of structs or struct pointers
+func elemType(sliceType reflect.Type) reflect.Type {
+	elem := sliceType.Elem()
+	if elem.Kind() == reflect.Ptr {
+		return elem.Elem()
+	}
+	return elem
+}
+
+// columns returns the JSON names and indexes of the exported fields of a struct
+func columns(structType reflect.Type) ([]string, []int) {
+	var names []string
+	var fields []int
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+		fields = append(fields, i)
+	}
+	return names, fields
+}
+
+// cell formats a value for a table cell, nested values as compact JSON
+func cell(value reflect.Value) string {
+	switch value.Kind() {
+	case reflect.String:
+		return value.String()
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool())
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10)
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 64)
+	default:
+		valueAsBytes, _ := json.Marshal(value.Interface())
+		return string(valueAsBytes)
+	}
+}
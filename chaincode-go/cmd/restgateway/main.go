@@ -0,0 +1,163 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Command restgateway serves the energy trading chaincode as a REST API, so web and mobile
+// applications need no Fabric SDK. Each chaincode function is a resource-oriented endpoint,
+// such as POST /orders or GET /users/{id}/payments; the OpenAPI document of the API is served
+// at /openapi.json.
+//
+//	restgateway -wallet wallet -api-keys api-keys.json -peer localhost:7051 -tls-ca ca.pem
+//	curl -H "Authorization: Bearer $API_KEY" localhost:8080/orders/42
+//
+// Requests authenticate with a bearer API key. The -api-keys file maps the hex SHA-256 hash of
+// every key to the label of an identity in the -wallet directory, which holds identities in
+// the file format of the Fabric SDKs; the request runs as that identity. With --local the
+// chaincode runs in process on an empty in-memory ledger instead of a Fabric network.
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/client"
+)
+
+func main() {
+	err := run(os.Args[1:], os.Stderr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "restgateway: "+err.Error())
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stderr io.Writer) error {
+	flags := flag.NewFlagSet("restgateway", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	addr := flags.String("addr", "localhost:8080", "address to serve the REST API on")
+	walletDir := flags.String("wallet", "wallet", "wallet directory of the identities requests run as")
+	apiKeysFile := flags.String("api-keys", "api-keys.json", "JSON file mapping the hex SHA-256 hash of every API key to a wallet label")
+	local := flags.Bool("local", false, "run the chaincode on an in-memory mock ledger instead of a Fabric network")
+	peer := flags.String("peer", "localhost:7051", "address of the peer's gateway service")
+	tlsCA := flags.String("tls-ca", "", "PEM CA certificate of the peer's TLS certificate")
+	serverName := flags.String("server-name", "", "TLS server name of the peer")
+	channel := flags.String("channel", "mychannel", "channel name")
+	chaincodeName := flags.String("chaincode", "basic", "chaincode name")
+	timeout := flags.Duration("timeout", time.Minute, "timeout of a request")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return errors.New("unexpected arguments")
+	}
+
+	wallet := client.NewWallet(*walletDir)
+	apiKeys, err := readAPIKeys(*apiKeysFile, wallet)
+	if err != nil {
+		return err
+	}
+
+	var contract func(label string) (client.Contract, error)
+	if *local {
+		ledger := client.NewMockLedger()
+		contract = func(label string) (client.Contract, error) {
+			identity, _, err := wallet.Get(label)
+			if err != nil {
+				return nil, err
+			}
+			return ledger.Contract(identity), nil
+		}
+	} else {
+		conn, err := dialPeer(*peer, *tlsCA, *serverName)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		contract = func(label string) (client.Contract, error) {
+			identity, sign, err := wallet.Get(label)
+			if err != nil {
+				return nil, err
+			}
+			return client.NewGatewayContract(conn, identity, sign, *channel, *chaincodeName), nil
+		}
+	}
+
+	srv, err := newServer(apiKeys, contract)
+	if err != nil {
+		return err
+	}
+	srv.timeout = *timeout
+
+	log.SetOutput(stderr)
+	log.Printf("serving %d routes for %d API keys on %s", len(srv.routes), len(apiKeys), *addr)
+	return http.ListenAndServe(*addr, srv)
+}
+
+// readAPIKeys reads the API keys file and checks that the wallet holds every identity it maps to
+func readAPIKeys(path string, wallet *client.Wallet) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var apiKeys map[string]string
+	err = json.Unmarshal(content, &apiKeys)
+	if err != nil {
+		return nil, errors.New("failed to decode " + path + ": " + err.Error())
+	}
+	for hash, label := range apiKeys {
+		if len(hash) != 64 {
+			return nil, errors.New(path + ": " + hash + " is not a hex SHA-256 hash")
+		}
+		_, _, err = wallet.Get(label)
+		if err != nil {
+			return nil, errors.New(path + ": identity " + label + ": " + err.Error())
+		}
+	}
+	return apiKeys, nil
+}
+
+// dialPeer connects to the gateway service of a peer, over TLS if tlsCA is set
+func dialPeer(peer string, tlsCA string, serverName string) (*grpc.ClientConn, error) {
+	transport := grpc.WithInsecure()
+	if tlsCA != "" {
+		caPEM, err := os.ReadFile(tlsCA)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("no certificates in " + tlsCA)
+		}
+		transport = grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: pool, ServerName: serverName}))
+	}
+	return grpc.Dial(peer, transport)
+}
@@ -0,0 +1,207 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Error statuses documented for every operation
+var documentedErrorStatuses = []int{
+	http.StatusBadRequest,
+	http.StatusUnauthorized,
+	http.StatusForbidden,
+	http.StatusNotFound,
+	http.StatusConflict,
+	http.StatusUnprocessableEntity,
+	http.StatusServiceUnavailable,
+}
+
+// openAPIDocument generates the OpenAPI 3 document of the routes, with the schemas of their
+// bodies derived from the JSON encoding of the Go types
+func openAPIDocument(routes []*route) map[string]interface{} {
+	schemas := map[string]interface{}{}
+	errorResp := map[string]interface{}{
+		"description": "The request failed. Chaincode errors carry the function and the kind of the error.",
+		"content":     jsonContent(schemaOf(reflect.TypeOf(errorResponse{}), schemas)),
+	}
+
+	paths := map[string]interface{}{}
+	for _, rt := range routes {
+		operations, ok := paths[rt.path].(map[string]interface{})
+		if !ok {
+			operations = map[string]interface{}{}
+			paths[rt.path] = operations
+		}
+
+		status := http.StatusOK
+		if rt.created {
+			status = http.StatusCreated
+		}
+		responses := map[string]interface{}{
+			strconv.Itoa(status): map[string]interface{}{
+				"description": http.StatusText(status),
+				"content":     jsonContent(schemaOf(reflect.TypeOf(rt.result), schemas)),
+			},
+		}
+		for _, errorStatus := range documentedErrorStatuses {
+			responses[strconv.Itoa(errorStatus)] = map[string]interface{}{"$ref": "#/components/responses/Error"}
+		}
+
+		operation := map[string]interface{}{
+			"operationId": rt.function,
+			"summary":     rt.summary,
+			"description": "Runs the chaincode function " + rt.function + ".",
+			"tags":        []string{strings.SplitN(strings.Trim(rt.path, "/"), "/", 2)[0]},
+			"responses":   responses,
+		}
+		parameters := pathParameters(rt.path)
+		for _, param := range rt.query {
+			schema := map[string]interface{}{"type": "string"}
+			if param.integer {
+				schema = map[string]interface{}{"type": "integer", "format": "int64"}
+			}
+			parameters = append(parameters, map[string]interface{}{
+				"name":        param.name,
+				"in":          "query",
+				"description": param.description,
+				"schema":      schema,
+			})
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+		if rt.body != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(schemaOf(reflect.TypeOf(rt.body), schemas)),
+			}
+		}
+		operations[strings.ToLower(rt.method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Energy Trading API",
+			"description": "REST API of the energy trading chaincode. Requests run as the Fabric identity their API key maps to.",
+			"version":     "1.0.0",
+		},
+		"paths":    paths,
+		"security": []interface{}{map[string]interface{}{"apiKey": []string{}}},
+		"components": map[string]interface{}{
+			"schemas":   schemas,
+			"responses": map[string]interface{}{"Error": errorResp},
+			"securitySchemes": map[string]interface{}{
+				"apiKey": map[string]interface{}{"type": "http", "scheme": "bearer", "description": "API key of a wallet identity"},
+			},
+		},
+	}
+}
+
+// pathParameters returns the parameters of the {name} segments of a path template
+func pathParameters(path string) []interface{} {
+	var parameters []interface{}
+	for _, part := range strings.Split(path, "/") {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			parameters = append(parameters, map[string]interface{}{
+				"name":     part[1 : len(part)-1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string"},
+			})
+		}
+	}
+	return parameters
+}
+
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+// schemaOf returns the schema of the JSON encoding of a type. Named structs are added to
+// schemas and referenced.
+func schemaOf(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaOf(t.Elem(), schemas)
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, schemas)
+		}
+		name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+		if _, ok := schemas[name]; !ok {
+			// The name is reserved first so recursive types end.
+			schemas[name] = nil
+			schemas[name] = structSchema(t, schemas)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]interface{}{}
+}
+
+// structSchema returns the object schema of a struct, with the fields of embedded structs
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	addStructProperties(t, properties, schemas)
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+func addStructProperties(t reflect.Type, properties map[string]interface{}, schemas map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addStructProperties(field.Type, properties, schemas)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = schemaOf(field.Type, schemas)
+	}
+}
@@ -0,0 +1,721 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"context"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/client"
+)
+
+// route maps a REST endpoint to a chaincode function. Path parameters are written {name};
+// body and result are zero values of the request and response bodies, from which the
+// OpenAPI document is generated.
+type route struct {
+	body     interface{}
+	created  bool
+	function string
+	handle   func(ctx context.Context, c *client.Client, r *request) (interface{}, error)
+	method   string
+	path     string
+	query    []queryParam
+	result   interface{}
+	summary  string
+}
+
+// queryParam is an optional query parameter of a route
+type queryParam struct {
+	description string
+	integer     bool
+	name        string
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                Request Bodies                              */
+/* -------------------------------------------------------------------------- */
+
+// userRequest creates or updates a user. Location, Contact and Salt go to the private data
+// collection of the user's org.
+type userRequest struct {
+	Category string `json:"category"`
+	Contact  string `json:"contact,omitempty"`
+	IsAdmin  bool   `json:"isAdmin"`
+	Location string `json:"location,omitempty"`
+	MeterID  string `json:"meterId"`
+	Salt     string `json:"salt,omitempty"`
+	Source   string `json:"source"`
+}
+
+// enterpriseUserRequest creates or updates an enterprise user
+type enterpriseUserRequest struct {
+	Category string   `json:"category"`
+	Contact  string   `json:"contact,omitempty"`
+	IsAdmin  bool     `json:"isAdmin"`
+	Location string   `json:"location,omitempty"`
+	MeterIDs []string `json:"meterIds"`
+	Salt     string   `json:"salt,omitempty"`
+	Source   string   `json:"source"`
+}
+
+type publicKeyRequest struct {
+	PublicKey string `json:"publicKey"`
+}
+
+// contractSignatureRequest signs or renews a contract. Actions only apply to trading
+// contracts, ExpiresOn to trading contracts and renewals.
+type contractSignatureRequest struct {
+	Actions    []string `json:"actions,omitempty"`
+	ExpiresOn  int64    `json:"expiresOn,omitempty"`
+	Signature  string   `json:"signature"`
+	TemplateID string   `json:"templateId"`
+	Version    int      `json:"version"`
+}
+
+type reasonRequest struct {
+	Reason string `json:"reason"`
+}
+
+type amendOrderRequest struct {
+	TotalQuantity int64   `json:"totalQuantity"`
+	UnitCost      float64 `json:"unitCost"`
+}
+
+// paymentRequest records a payment. Detail and Salt go to the private data collection of the
+// caller's org.
+type paymentRequest struct {
+	Detail  chaincode.PaymentDetail `json:"detail"`
+	Payment chaincode.Payment       `json:"payment"`
+	Salt    string                  `json:"salt"`
+}
+
+type refundRequest struct {
+	PaymentDetailID string                  `json:"paymentDetailId"`
+	Refund          chaincode.PaymentDetail `json:"refund"`
+	RefundPaymentID string                  `json:"refundPaymentId"`
+	Salt            string                  `json:"salt"`
+}
+
+// disputeRequest raises a dispute with the hashes of its initial evidence
+type disputeRequest struct {
+	Dispute  chaincode.Dispute `json:"dispute"`
+	Evidence []string          `json:"evidence"`
+}
+
+type evidenceRequest struct {
+	Hash   string `json:"hash"`
+	UserID string `json:"userId"`
+}
+
+// resolutionRequest resolves or rejects a dispute. Adjustments only apply to resolutions.
+type resolutionRequest struct {
+	Adjustments []chaincode.DisputeAdjustment `json:"adjustments,omitempty"`
+	Resolution  string                        `json:"resolution"`
+}
+
+type zoneAssignmentRequest struct {
+	UserID string `json:"userId"`
+	ZoneID string `json:"zoneId"`
+}
+
+type tariffRequest struct {
+	ChargePerUnit float64 `json:"chargePerUnit"`
+}
+
+type capacityRequest struct {
+	MaxExport float64 `json:"maxExport"`
+	MaxImport float64 `json:"maxImport"`
+}
+
+type transferRequest struct {
+	Quantity float64 `json:"quantity"`
+	ToUserID string  `json:"toUserId"`
+}
+
+type emissionFactorRequest struct {
+	KgCO2PerUnit float64 `json:"kgCo2PerUnit"`
+}
+
+type endorsementPolicyRequest struct {
+	Orgs []string `json:"orgs"`
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                    Routes                                  */
+/* -------------------------------------------------------------------------- */
+
+// routes are the endpoints of the API, in the order they are matched and documented
+var routes = []*route{
+	// Users
+	{method: "PUT", path: "/users/{id}", function: "UpdateUserProfile", summary: "Create or update a user",
+		body: userRequest{}, result: transactionResult{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var req userRequest
+			err := r.decode(&req)
+			if err != nil {
+				return nil, err
+			}
+			user := chaincode.User{Category: req.Category, ID: r.params["id"], IsAdmin: req.IsAdmin, MeterID: req.MeterID, Source: req.Source}
+			return transaction(c.UpdateUserProfile(ctx, user, privateDetails(req.Location, req.Contact, req.Salt)))
+		}},
+	{method: "GET", path: "/users/{id}", function: "ReadUserProfile", summary: "Read a user",
+		result: chaincode.User{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadUserProfile(ctx, r.params["id"])
+		}},
+	{method: "PUT", path: "/users/{id}/public-key", function: "RegisterUserKey", summary: "Register the PEM encoded public key a user signs contracts with",
+		body: publicKeyRequest{}, result: transactionResult{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var req publicKeyRequest
+			err := r.decode(&req)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.RegisterUserKey(ctx, r.params["id"], req.PublicKey))
+		}},
+	{method: "DELETE", path: "/users/{id}/personal-data", function: "EraseParticipantData", summary: "Erase the personal data of a user, moving its records to a pseudonymous ID",
+		result: chaincode.ErasureCertificate{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.EraseParticipantData(ctx, r.params["id"])
+		}},
+	{method: "GET", path: "/users/{id}/reliability", function: "ReadReliabilityScore", summary: "Read the delivery reliability score of a user",
+		result: chaincode.ReliabilityScore{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadReliabilityScore(ctx, r.params["id"])
+		}},
+	{method: "GET", path: "/users/{id}/payments", function: "ReadPaymentsForUser", summary: "List the payments of a user",
+		result: []chaincode.Payment{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadPaymentsForUser(ctx, r.params["id"])
+		}},
+	{method: "POST", path: "/users/{id}/invoices/{period}", function: "GenerateInvoice", summary: "Generate the invoice of a user for an ended month (YYYY-MM)",
+		result: chaincode.PrivateDataHash{}, created: true,
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.GenerateInvoice(ctx, r.params["id"], r.params["period"])
+		}},
+	{method: "GET", path: "/users/{id}/invoices/{period}/hash", function: "ReadInvoiceHash", summary: "Read the public content hash of the invoice of a user for a month (YYYY-MM)",
+		result: chaincode.PrivateDataHash{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadInvoiceHash(ctx, r.params["id"], r.params["period"])
+		}},
+	{method: "GET", path: "/users/{id}/invoices/{period}", function: "ReadInvoice", summary: "Read the invoice of a user for a month (YYYY-MM)",
+		result: chaincode.Invoice{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadInvoice(ctx, r.params["id"], r.params["period"])
+		}},
+	{method: "GET", path: "/users/{id}/carbon-report", function: "ReadCarbonReport", summary: "Read the emissions of the energy a user bought",
+		query:  []queryParam{{name: "from", integer: true, description: "Start of the report, in unix seconds"}, {name: "to", integer: true, description: "End of the report, in unix seconds"}},
+		result: chaincode.CarbonReport{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			from, err := r.queryInt("from")
+			if err != nil {
+				return nil, err
+			}
+			to, err := r.queryInt("to")
+			if err != nil {
+				return nil, err
+			}
+			return c.ReadCarbonReport(ctx, r.params["id"], from, to)
+		}},
+	{method: "GET", path: "/users/{id}/retired-certificates/{period}", function: "ReadRetiredCertificates", summary: "List the certificates a user retired in a period",
+		result: chaincode.RetirementReport{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadRetiredCertificates(ctx, r.params["id"], r.params["period"])
+		}},
+	{method: "PUT", path: "/enterprise-users/{id}", function: "UpdateEnterpriseUserProfile", summary: "Create or update an enterprise user",
+		body: enterpriseUserRequest{}, result: transactionResult{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var req enterpriseUserRequest
+			err := r.decode(&req)
+			if err != nil {
+				return nil, err
+			}
+			user := chaincode.EnterpriseUser{Category: req.Category, ID: r.params["id"], IsAdmin: req.IsAdmin, MeterIDs: req.MeterIDs, Source: req.Source}
+			return transaction(c.UpdateEnterpriseUserProfile(ctx, user, privateDetails(req.Location, req.Contact, req.Salt)))
+		}},
+	{method: "GET", path: "/enterprise-users/{id}", function: "ReadEnterpriseUserProfile", summary: "Read an enterprise user",
+		result: chaincode.EnterpriseUser{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadEnterpriseUserProfile(ctx, r.params["id"])
+		}},
+	{method: "GET", path: "/erasure-certificates/{id}", function: "ReadErasureCertificate", summary: "Read the erasure certificate of a pseudonymous ID",
+		result: chaincode.ErasureCertificate{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadErasureCertificate(ctx, r.params["id"])
+		}},
+
+	// Contracts
+	{method: "POST", path: "/contract-templates", function: "PublishContractTemplate", summary: "Publish a version of a contract template",
+		body: chaincode.ContractTemplate{}, result: transactionResult{}, created: true,
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var template chaincode.ContractTemplate
+			err := r.decode(&template)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.PublishContractTemplate(ctx, template))
+		}},
+	{method: "GET", path: "/contract-templates/{id}", function: "ReadContractTemplate", summary: "Read a version of a contract template",
+		query:  []queryParam{{name: "version", integer: true, description: "Version of the template, the version in effect if not set"}},
+		result: chaincode.ContractTemplate{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			version, err := r.queryInt("version")
+			if err != nil {
+				return nil, err
+			}
+			return c.ReadContractTemplate(ctx, r.params["id"], int(version))
+		}},
+	{method: "PUT", path: "/users/{id}/platform-contract", function: "SignPlatformContract", summary: "Sign the platform contract of a user",
+		body: contractSignatureRequest{}, result: transactionResult{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var req contractSignatureRequest
+			err := r.decode(&req)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.SignPlatformContract(ctx, r.params["id"], req.TemplateID, req.Version, req.Signature))
+		}},
+	{method: "GET", path: "/users/{id}/platform-contract", function: "ReadPlatformContract", summary: "Read the platform contract of a user",
+		result: chaincode.PlatformContract{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadPlatformContract(ctx, r.params["id"])
+		}},
+	{method: "PUT", path: "/users/{id}/trading-contract", function: "SignTradingContract", summary: "Sign the trading contract of a user",
+		body: contractSignatureRequest{}, result: transactionResult{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var req contractSignatureRequest
+			err := r.decode(&req)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.SignTradingContract(ctx, r.params["id"], req.TemplateID, req.Version, req.Signature, req.Actions, req.ExpiresOn))
+		}},
+	{method: "GET", path: "/users/{id}/trading-contract", function: "ReadTradingContract", summary: "Read the trading contract of a user",
+		result: chaincode.TradingContract{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadTradingContract(ctx, r.params["id"])
+		}},
+	{method: "POST", path: "/users/{id}/trading-contract/renewal", function: "RenewTradingContract", summary: "Renew the trading contract of a user",
+		body: contractSignatureRequest{}, result: transactionResult{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var req contractSignatureRequest
+			err := r.decode(&req)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.RenewTradingContract(ctx, r.params["id"], req.TemplateID, req.Version, req.Signature, req.ExpiresOn))
+		}},
+	{method: "POST", path: "/users/{id}/trading-contract/suspension", function: "SuspendTradingContract", summary: "Suspend the trading contract of a user",
+		body: reasonRequest{}, result: transactionResult{},
+		handle: contractTransition((*client.Client).SuspendTradingContract)},
+	{method: "POST", path: "/users/{id}/trading-contract/reinstatement", function: "ReinstateTradingContract", summary: "Reinstate the suspended trading contract of a user",
+		body: reasonRequest{}, result: transactionResult{},
+		handle: contractTransition((*client.Client).ReinstateTradingContract)},
+	{method: "POST", path: "/users/{id}/trading-contract/revocation", function: "RevokeTradingContract", summary: "Revoke the trading contract of a user",
+		body: reasonRequest{}, result: transactionResult{},
+		handle: contractTransition((*client.Client).RevokeTradingContract)},
+	{method: "POST", path: "/trading-contracts/expiry", function: "ExpireTradingContracts", summary: "Expire the trading contracts past their expiry date, returning their user IDs",
+		result: []string{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ExpireTradingContracts(ctx)
+		}},
+
+	// Orders
+	{method: "POST", path: "/orders", function: "RegisterOrder", summary: "Register an order",
+		body: chaincode.Order{}, result: transactionResult{}, created: true,
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var order chaincode.Order
+			err := r.decode(&order)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.RegisterOrder(ctx, order))
+		}},
+	{method: "POST", path: "/orders/batch", function: "RegisterOrders", summary: "Register a batch of orders in one transaction",
+		body: []chaincode.Order{}, result: chaincode.OrderBatchResult{}, created: true,
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var orders []chaincode.Order
+			err := r.decode(&orders)
+			if err != nil {
+				return nil, err
+			}
+			return c.RegisterOrders(ctx, orders)
+		}},
+	{method: "GET", path: "/orders/{id}", function: "ReadOrder", summary: "Read an order",
+		result: chaincode.Order{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadOrder(ctx, r.params["id"])
+		}},
+	{method: "PATCH", path: "/orders/{id}", function: "AmendOrder", summary: "Change the total quantity and unit cost of an order",
+		body: amendOrderRequest{}, result: transactionResult{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var req amendOrderRequest
+			err := r.decode(&req)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.AmendOrder(ctx, r.params["id"], req.TotalQuantity, req.UnitCost))
+		}},
+	{method: "DELETE", path: "/orders/{id}", function: "CancelOrder", summary: "Cancel the unmatched quantity of an order",
+		result: transactionResult{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return transaction(c.CancelOrder(ctx, r.params["id"]))
+		}},
+	{method: "GET", path: "/orders/{id}/match-candidates", function: "ReadMatchCandidates", summary: "List the orders an order can be matched with, best first",
+		result: []chaincode.MatchCandidate{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadMatchCandidates(ctx, r.params["id"])
+		}},
+	{method: "GET", path: "/orders/{id}/payments", function: "ReadPaymentsForOrder", summary: "List the payments of an order",
+		result: []chaincode.Payment{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadPaymentsForOrder(ctx, r.params["id"])
+		}},
+
+	// Bid matches and energy bids
+	{method: "POST", path: "/bid-matches", function: "ProcessBidMatch", summary: "Match a buy and a sell order",
+		body: chaincode.BidMatch{}, result: transactionResult{}, created: true,
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var bidMatch chaincode.BidMatch
+			err := r.decode(&bidMatch)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.ProcessBidMatch(ctx, bidMatch))
+		}},
+	{method: "GET", path: "/bid-matches/{id}", function: "ReadBidMatch", summary: "Read a bid match",
+		result: chaincode.BidMatch{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadBidMatch(ctx, r.params["id"])
+		}},
+	{method: "GET", path: "/bid-matches/{id}/payments", function: "ReadPaymentsForBidMatch", summary: "List the payments of a bid match",
+		result: []chaincode.Payment{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadPaymentsForBidMatch(ctx, r.params["id"])
+		}},
+	{method: "POST", path: "/bid-matches/{id}/energy-bids", function: "ProcessEnergyBid", summary: "Settle the metered delivery of a bid match",
+		body: chaincode.EnergyBid{}, result: transactionResult{}, created: true,
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var energyBid chaincode.EnergyBid
+			err := r.decode(&energyBid)
+			if err != nil {
+				return nil, err
+			}
+			energyBid.BidMatchID = r.params["id"]
+			return transaction(c.ProcessEnergyBid(ctx, energyBid))
+		}},
+	{method: "GET", path: "/energy-bids/{id}", function: "ReadEnergyBid", summary: "Read an energy bid",
+		result: chaincode.EnergyBid{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadEnergyBid(ctx, r.params["id"])
+		}},
+
+	// Payments
+	{method: "POST", path: "/payments", function: "RecordPayment", summary: "Record a payment",
+		body: paymentRequest{}, result: transactionResult{}, created: true,
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var req paymentRequest
+			err := r.decode(&req)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.RecordPayment(ctx, req.Payment, req.Detail, req.Salt))
+		}},
+	{method: "GET", path: "/payments/{id}", function: "ReadPayment", summary: "Read a payment",
+		result: chaincode.Payment{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadPayment(ctx, r.params["id"])
+		}},
+	{method: "POST", path: "/payments/{id}/refunds", function: "RefundPayment", summary: "Refund part or all of a payment",
+		body: refundRequest{}, result: transactionResult{}, created: true,
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var req refundRequest
+			err := r.decode(&req)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.RefundPayment(ctx, req.RefundPaymentID, r.params["id"], req.PaymentDetailID, req.Refund, req.Salt))
+		}},
+	{method: "GET", path: "/payment-details/{id}", function: "ReadPaymentDetail", summary: "Read a payment detail from the private data of the caller's org",
+		result: chaincode.PrivatePaymentDetail{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadPaymentDetail(ctx, r.params["id"])
+		}},
+	{method: "GET", path: "/payment-details/{id}/hash", function: "ReadPaymentDetailHash", summary: "Read the public salted hash of a payment detail",
+		result: chaincode.PrivateDataHash{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadPaymentDetailHash(ctx, r.params["id"])
+		}},
+	{method: "POST", path: "/fee-schedules", function: "PublishFeeSchedule", summary: "Publish a version of the fee schedule",
+		body: chaincode.FeeSchedule{}, result: transactionResult{}, created: true,
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var schedule chaincode.FeeSchedule
+			err := r.decode(&schedule)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.PublishFeeSchedule(ctx, schedule))
+		}},
+	{method: "GET", path: "/fee-schedules", function: "ReadFeeSchedule", summary: "Read a version of the fee schedule",
+		query:  []queryParam{{name: "version", integer: true, description: "Version of the schedule, the version in effect if not set"}},
+		result: chaincode.FeeSchedule{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			version, err := r.queryInt("version")
+			if err != nil {
+				return nil, err
+			}
+			return c.ReadFeeSchedule(ctx, int(version))
+		}},
+
+	// Disputes
+	{method: "POST", path: "/disputes", function: "RaiseDispute", summary: "Raise a dispute with the hashes of its evidence",
+		body: disputeRequest{}, result: transactionResult{}, created: true,
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var req disputeRequest
+			err := r.decode(&req)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.RaiseDispute(ctx, req.Dispute, req.Evidence))
+		}},
+	{method: "GET", path: "/disputes/{id}", function: "ReadDispute", summary: "Read a dispute",
+		result: chaincode.Dispute{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadDispute(ctx, r.params["id"])
+		}},
+	{method: "POST", path: "/disputes/{id}/evidence", function: "AddDisputeEvidence", summary: "Add the hash of a document to a dispute",
+		body: evidenceRequest{}, result: transactionResult{}, created: true,
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var req evidenceRequest
+			err := r.decode(&req)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.AddDisputeEvidence(ctx, r.params["id"], req.UserID, req.Hash))
+		}},
+	{method: "POST", path: "/disputes/{id}/review", function: "ReviewDispute", summary: "Take a dispute under review",
+		result: transactionResult{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return transaction(c.ReviewDispute(ctx, r.params["id"]))
+		}},
+	{method: "POST", path: "/disputes/{id}/resolution", function: "ResolveDispute", summary: "Resolve a dispute, paying its adjustments",
+		body: resolutionRequest{}, result: transactionResult{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var req resolutionRequest
+			err := r.decode(&req)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.ResolveDispute(ctx, r.params["id"], req.Resolution, req.Adjustments))
+		}},
+	{method: "POST", path: "/disputes/{id}/rejection", function: "RejectDispute", summary: "Reject a dispute",
+		body: resolutionRequest{}, result: transactionResult{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var req resolutionRequest
+			err := r.decode(&req)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.RejectDispute(ctx, r.params["id"], req.Resolution))
+		}},
+
+	// Grid
+	{method: "PUT", path: "/meters/{id}/zone", function: "AssignGridZone", summary: "Assign the meter of a user to a grid zone",
+		body: zoneAssignmentRequest{}, result: transactionResult{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var req zoneAssignmentRequest
+			err := r.decode(&req)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.AssignGridZone(ctx, req.UserID, r.params["id"], req.ZoneID))
+		}},
+	{method: "PUT", path: "/tariffs/{fromZone}/{toZone}", function: "SetNetworkTariff", summary: "Set the network tariff between two zones",
+		body: tariffRequest{}, result: transactionResult{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var req tariffRequest
+			err := r.decode(&req)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.SetNetworkTariff(ctx, r.params["fromZone"], r.params["toZone"], req.ChargePerUnit))
+		}},
+	{method: "GET", path: "/tariffs/{fromZone}/{toZone}", function: "ReadNetworkTariff", summary: "Read the network tariff between two zones",
+		result: chaincode.NetworkTariff{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadNetworkTariff(ctx, r.params["fromZone"], r.params["toZone"])
+		}},
+	{method: "PUT", path: "/zones/{id}/capacity/{slotId}", function: "SetZoneCapacity", summary: "Set the capacity of a zone in a slot",
+		body: capacityRequest{}, result: transactionResult{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var req capacityRequest
+			err := r.decode(&req)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.SetZoneCapacity(ctx, r.params["id"], r.params["slotId"], req.MaxImport, req.MaxExport))
+		}},
+	{method: "GET", path: "/zones/{id}/utilization", function: "ReadZoneUtilizations", summary: "List the utilization of a zone in every slot",
+		result: []chaincode.ZoneUtilizationReport{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadZoneUtilizations(ctx, r.params["id"])
+		}},
+	{method: "GET", path: "/zones/{id}/utilization/{slotId}", function: "ReadZoneUtilization", summary: "Read the utilization of a zone in a slot",
+		result: chaincode.ZoneUtilizationReport{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadZoneUtilization(ctx, r.params["id"], r.params["slotId"])
+		}},
+
+	// Certificates and carbon
+	{method: "GET", path: "/certificates/{id}", function: "ReadCertificate", summary: "Read an energy certificate",
+		result: chaincode.Certificate{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadCertificate(ctx, r.params["id"])
+		}},
+	{method: "POST", path: "/certificates/{id}/transfers", function: "TransferCertificate", summary: "Transfer a quantity of a certificate to another user",
+		body: transferRequest{}, result: transactionResult{}, created: true,
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var req transferRequest
+			err := r.decode(&req)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.TransferCertificate(ctx, r.params["id"], req.ToUserID, req.Quantity))
+		}},
+	{method: "POST", path: "/certificates/{id}/retirement", function: "RetireCertificate", summary: "Retire a certificate",
+		result: transactionResult{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return transaction(c.RetireCertificate(ctx, r.params["id"]))
+		}},
+	{method: "GET", path: "/emission-factors", function: "ReadEmissionFactors", summary: "List the emission factors of the energy sources",
+		result: []chaincode.EmissionFactor{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadEmissionFactors(ctx)
+		}},
+	{method: "PUT", path: "/emission-factors/{source}", function: "SetEmissionFactor", summary: "Set the emission factor of an energy source",
+		body: emissionFactorRequest{}, result: transactionResult{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var req emissionFactorRequest
+			err := r.decode(&req)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.SetEmissionFactor(ctx, r.params["source"], req.KgCO2PerUnit))
+		}},
+
+	// Price oracles
+	{method: "POST", path: "/price-oracles", function: "RegisterPriceOracle", summary: "Register a price oracle",
+		body: chaincode.PriceOracle{}, result: transactionResult{}, created: true,
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var oracle chaincode.PriceOracle
+			err := r.decode(&oracle)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.RegisterPriceOracle(ctx, oracle))
+		}},
+	{method: "DELETE", path: "/price-oracles/{id}", function: "RevokePriceOracle", summary: "Revoke a price oracle",
+		result: transactionResult{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return transaction(c.RevokePriceOracle(ctx, r.params["id"]))
+		}},
+	{method: "POST", path: "/reference-prices", function: "PublishReferencePrice", summary: "Publish a signed reference price",
+		body: chaincode.ReferencePrice{}, result: transactionResult{}, created: true,
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var price chaincode.ReferencePrice
+			err := r.decode(&price)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.PublishReferencePrice(ctx, price))
+		}},
+	{method: "GET", path: "/reference-prices/{id}", function: "ReadReferencePrice", summary: "Read a reference price",
+		result: chaincode.ReferencePrice{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadReferencePrice(ctx, r.params["id"])
+		}},
+	{method: "GET", path: "/markets/{market}/slots/{slotId}/reference-price", function: "ReadLatestReferencePrice", summary: "Read the latest reference price of a market slot",
+		result: chaincode.ReferencePrice{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadLatestReferencePrice(ctx, r.params["market"], r.params["slotId"])
+		}},
+	{method: "GET", path: "/markets/{market}/slots/{slotId}/reference-prices", function: "ReadReferencePriceHistory", summary: "List the reference prices of a market slot",
+		result: []chaincode.ReferencePrice{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadReferencePriceHistory(ctx, r.params["market"], r.params["slotId"])
+		}},
+
+	// Market administration
+	{method: "GET", path: "/market-config", function: "ReadMarketConfig", summary: "Read the market configuration",
+		result: chaincode.MarketConfig{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadMarketConfig(ctx)
+		}},
+	{method: "PUT", path: "/market-config", function: "UpdateMarketConfig", summary: "Replace the market configuration",
+		body: chaincode.MarketConfig{}, result: transactionResult{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var config chaincode.MarketConfig
+			err := r.decode(&config)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.UpdateMarketConfig(ctx, config))
+		}},
+	{method: "GET", path: "/endorsement-policies/{key}", function: "ReadEndorsementPolicy", summary: "Read the endorsement policy of a ledger key",
+		result: chaincode.EndorsementPolicy{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return c.ReadEndorsementPolicy(ctx, r.params["key"])
+		}},
+	{method: "PUT", path: "/endorsement-policies/{key}", function: "RotateEndorsementPolicy", summary: "Replace the endorsement policy of a ledger key",
+		body: endorsementPolicyRequest{}, result: transactionResult{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			var req endorsementPolicyRequest
+			err := r.decode(&req)
+			if err != nil {
+				return nil, err
+			}
+			return transaction(c.RotateEndorsementPolicy(ctx, r.params["key"], req.Orgs))
+		}},
+	{method: "POST", path: "/payment-indexes", function: "ReindexPayments", summary: "Add every payment to the payment indexes",
+		result: transactionResult{},
+		handle: func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+			return transaction(c.ReindexPayments(ctx))
+		}},
+}
+
+// privateDetails returns the private details of a user request, nil if it has none
+func privateDetails(location string, contact string, salt string) *chaincode.UserPrivateDetails {
+	if location == "" && contact == "" && salt == "" {
+		return nil
+	}
+	return &chaincode.UserPrivateDetails{Contact: contact, Location: location, Salt: salt}
+}
+
+// contractTransition returns the handler of a trading contract transition taking a reason
+func contractTransition(transition func(c *client.Client, ctx context.Context, userID string, reason string) (string, error)) func(context.Context, *client.Client, *request) (interface{}, error) {
+	return func(ctx context.Context, c *client.Client, r *request) (interface{}, error) {
+		var req reasonRequest
+		err := r.decode(&req)
+		if err != nil {
+			return nil, err
+		}
+		return transaction(transition(c, ctx, r.params["id"], req.Reason))
+	}
+}
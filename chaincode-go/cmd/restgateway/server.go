@@ -0,0 +1,315 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/client"
+)
+
+// maxBodySize is the largest request body accepted
+const maxBodySize = 1 << 20
+
+// server serves the REST API of the chaincode, running each request as the wallet identity
+// its API key maps to
+type server struct {
+	apiKeys  map[string]string
+	clients  map[string]*client.Client
+	contract func(label string) (client.Contract, error)
+	mutex    sync.Mutex
+	openAPI  []byte
+	routes   []*route
+	timeout  time.Duration
+}
+
+// request is a matched API request
+type request struct {
+	body   []byte
+	params map[string]string
+	query  url.Values
+}
+
+// httpError is an error of a request with its HTTP status
+type httpError struct {
+	message string
+	status  int
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+// errorResponse is the body of a failed request. Kind is the kind of a chaincode error, such
+// as "not found", and Function the chaincode function that failed.
+type errorResponse struct {
+	Function string `json:"function,omitempty"`
+	Kind     string `json:"kind,omitempty"`
+	Message  string `json:"message"`
+}
+
+// transactionResult is the result of a transaction that returns its ID only
+type transactionResult struct {
+	TransactionID string `json:"transactionId"`
+}
+
+// apiKeyHash returns the hex SHA-256 hash API keys are configured by
+func apiKeyHash(apiKey string) string {
+	hash := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(hash[:])
+}
+
+// newServer returns the server of the routes. apiKeys maps the hex SHA-256 hash of every API
+// key to the wallet label of its identity, and contract returns the Contract of a label.
+func newServer(apiKeys map[string]string, contract func(label string) (client.Contract, error)) (*server, error) {
+	openAPI, err := json.MarshalIndent(openAPIDocument(routes), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &server{
+		apiKeys:  apiKeys,
+		clients:  make(map[string]*client.Client),
+		contract: contract,
+		openAPI:  openAPI,
+		routes:   routes,
+	}, nil
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/openapi.json" && r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(s.openAPI)
+		return
+	}
+
+	rt, params, allowed := s.match(r.Method, r.URL.Path)
+	if rt == nil {
+		if len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			writeError(w, &httpError{message: "method " + r.Method + " not allowed", status: http.StatusMethodNotAllowed})
+			return
+		}
+		writeError(w, &httpError{message: "no resource at " + r.URL.Path, status: http.StatusNotFound})
+		return
+	}
+
+	c, err := s.authenticate(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeError(w, &httpError{message: "failed to read request body: " + err.Error(), status: http.StatusBadRequest})
+		return
+	}
+
+	ctx := r.Context()
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	result, err := rt.handle(ctx, c, &request{body: body, params: params, query: r.URL.Query()})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	status := http.StatusOK
+	if rt.created {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, result)
+}
+
+// match returns the route of a request with its path parameters, or the methods the path
+// allows if no route matches the method
+func (s *server) match(method string, path string) (*route, map[string]string, []string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	var allowed []string
+	for _, rt := range s.routes {
+		params, ok := matchPath(rt.path, segments)
+		if !ok {
+			continue
+		}
+		if rt.method == method {
+			return rt, params, nil
+		}
+		allowed = append(allowed, rt.method)
+	}
+	return nil, nil, allowed
+}
+
+// matchPath matches the segments of a request path against a path template with {name}
+// parameters
+func matchPath(template string, segments []string) (map[string]string, bool) {
+	parts := strings.Split(strings.Trim(template, "/"), "/")
+	if len(parts) != len(segments) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[part[1:len(part)-1]] = segments[i]
+		} else if part != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// authenticate returns the client of the wallet identity the bearer API key of a request
+// maps to
+func (s *server) authenticate(r *http.Request) (*client.Client, error) {
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return nil, &httpError{message: "a bearer API key is required", status: http.StatusUnauthorized}
+	}
+	label, ok := s.apiKeys[apiKeyHash(strings.TrimPrefix(authorization, "Bearer "))]
+	if !ok {
+		return nil, &httpError{message: "unknown API key", status: http.StatusUnauthorized}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	c, ok := s.clients[label]
+	if ok {
+		return c, nil
+	}
+	contract, err := s.contract(label)
+	if err != nil {
+		return nil, errors.New("failed to load identity " + label + ": " + err.Error())
+	}
+	c = client.New(contract)
+	s.clients[label] = c
+	return c, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                  Requests                                  */
+/* -------------------------------------------------------------------------- */
+
+// decode decodes the JSON body of the request into v
+func (r *request) decode(v interface{}) error {
+	if len(r.body) == 0 {
+		return &httpError{message: "a JSON request body is required", status: http.StatusBadRequest}
+	}
+	err := json.Unmarshal(r.body, v)
+	if err != nil {
+		return &httpError{message: "failed to decode request body: " + err.Error(), status: http.StatusBadRequest}
+	}
+	return nil
+}
+
+// queryInt returns an integer query parameter, 0 if it is not set
+func (r *request) queryInt(name string) (int64, error) {
+	value := r.query.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, &httpError{message: "query parameter " + name + " must be an integer", status: http.StatusBadRequest}
+	}
+	return i, nil
+}
+
+// pathInt returns an integer path parameter
+func (r *request) pathInt(name string) (int, error) {
+	i, err := strconv.Atoi(r.params[name])
+	if err != nil {
+		return 0, &httpError{message: "path parameter " + name + " must be an integer", status: http.StatusBadRequest}
+	}
+	return i, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                  Responses                                 */
+/* -------------------------------------------------------------------------- */
+
+// Statuses of the kinds of chaincode errors
+var errorKindStatuses = []struct {
+	kind   error
+	status int
+}{
+	{client.ErrNotFound, http.StatusNotFound},
+	{client.ErrUnauthorized, http.StatusForbidden},
+	{client.ErrInvalidArgument, http.StatusBadRequest},
+	{client.ErrConflict, http.StatusConflict},
+	{client.ErrRejected, http.StatusUnprocessableEntity},
+	{client.ErrInvalidResult, http.StatusBadGateway},
+	{client.ErrUnavailable, http.StatusServiceUnavailable},
+}
+
+// errorStatus returns the HTTP status of an error and the kind of a chaincode error
+func errorStatus(err error) (int, string) {
+	var reqErr *httpError
+	if errors.As(err, &reqErr) {
+		return reqErr.status, ""
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout, client.ErrUnavailable.Error()
+	}
+	for _, entry := range errorKindStatuses {
+		if errors.Is(err, entry.kind) {
+			return entry.status, entry.kind.Error()
+		}
+	}
+	return http.StatusInternalServerError, ""
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status, kind := errorStatus(err)
+	response := errorResponse{Kind: kind, Message: err.Error()}
+	var clientErr *client.Error
+	if errors.As(err, &clientErr) {
+		response.Function = clientErr.Function
+		response.Message = clientErr.Message
+	}
+	writeJSON(w, status, response)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// transaction returns the result of a transaction that returns its ID only
+func transaction(txID string, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	return transactionResult{TransactionID: txID}, nil
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/client"
+)
+
+// putWalletIdentity stores a generated identity with role attributes in a wallet
+func putWalletIdentity(t *testing.T, wallet *client.Wallet, label string, attrs map[string]string) {
+	identity, err := client.NewMockIdentity("Org1MSP", attrs)
+	require.NoError(t, err)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	require.NoError(t, wallet.Put(label, identity, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})))
+}
+
+func TestRESTGateway(t *testing.T) {
+	dir := t.TempDir()
+	wallet := client.NewWallet(filepath.Join(dir, "wallet"))
+	putWalletIdentity(t, wallet, "appUser", nil)
+	putWalletIdentity(t, wallet, "operator", map[string]string{chaincode.RoleAttribute: chaincode.AdminRole})
+	apiKeysFile := filepath.Join(dir, "api-keys.json")
+	apiKeysAsBytes, _ := json.Marshal(map[string]string{apiKeyHash("user-key"): "appUser", apiKeyHash("admin-key"): "operator"})
+	require.NoError(t, os.WriteFile(apiKeysFile, apiKeysAsBytes, 0o600))
+
+	apiKeys, err := readAPIKeys(apiKeysFile, wallet)
+	require.NoError(t, err)
+	ledger := client.NewMockLedger()
+	srv, err := newServer(apiKeys, func(label string) (client.Contract, error) {
+		identity, _, err := wallet.Get(label)
+		if err != nil {
+			return nil, err
+		}
+		return ledger.Contract(identity), nil
+	})
+	require.NoError(t, err)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	call := func(apiKey string, method string, path string, body interface{}) (int, []byte) {
+		var reader io.Reader
+		if body != nil {
+			bodyAsBytes, err := json.Marshal(body)
+			require.NoError(t, err)
+			reader = bytes.NewReader(bodyAsBytes)
+		}
+		req, err := http.NewRequest(method, ts.URL+path, reader)
+		require.NoError(t, err)
+		if apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		respBody, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, respBody
+	}
+	errorOf := func(body []byte) errorResponse {
+		var errResp errorResponse
+		require.NoError(t, json.Unmarshal(body, &errResp))
+		return errResp
+	}
+
+	// Test Case 1: Requests need an API key mapped to a wallet identity
+	t.Run("Authentication", func(t *testing.T) {
+		status, _ := call("", "GET", "/users/20", nil)
+		assert.Equal(t, http.StatusUnauthorized, status)
+		status, _ = call("stolen-key", "GET", "/users/20", nil)
+		assert.Equal(t, http.StatusUnauthorized, status)
+
+		require.NoError(t, os.WriteFile(apiKeysFile, []byte(`{"`+apiKeyHash("key")+`":"nobody"}`), 0o600))
+		_, err := readAPIKeys(apiKeysFile, wallet)
+		assert.Error(t, err)
+	})
+
+	// Test Case 2: Chaincode errors map to HTTP statuses by their kind
+	t.Run("Errors", func(t *testing.T) {
+		status, body := call("user-key", "GET", "/orders/999", nil)
+		assert.Equal(t, http.StatusNotFound, status)
+		errResp := errorOf(body)
+		assert.Equal(t, "ReadOrder", errResp.Function)
+		assert.Equal(t, client.ErrNotFound.Error(), errResp.Kind)
+
+		status, body = call("user-key", "POST", "/contract-templates", chaincode.ContractTemplate{ID: "terms", ContractType: chaincode.PlatformContractType, Version: 1, DocumentHash: "hash"})
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.Equal(t, client.ErrUnauthorized.Error(), errorOf(body).Kind)
+
+		status, _ = call("user-key", "POST", "/orders", nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _ = call("user-key", "GET", "/carbon", nil)
+		assert.Equal(t, http.StatusNotFound, status)
+
+		req, err := http.NewRequest("DELETE", ts.URL+"/users/20", nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+		assert.Equal(t, "PUT, GET", resp.Header.Get("Allow"))
+	})
+
+	// Test Case 3: Resources are created and read through their endpoints
+	t.Run("Resources", func(t *testing.T) {
+		status, body := call("user-key", "PUT", "/users/20", userRequest{Category: "Prosumer", MeterID: "M20", Source: "Solar", Location: "Main Street 1", Salt: "pepper"})
+		require.Equal(t, http.StatusOK, status, string(body))
+		var tx transactionResult
+		require.NoError(t, json.Unmarshal(body, &tx))
+		assert.NotEmpty(t, tx.TransactionID)
+
+		var user chaincode.User
+		status, body = call("user-key", "GET", "/users/20", nil)
+		require.Equal(t, http.StatusOK, status, string(body))
+		require.NoError(t, json.Unmarshal(body, &user))
+		assert.Equal(t, "M20", user.MeterID)
+		assert.Equal(t, "Main Street 1", user.Location)
+
+		signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		publicKeyDER, err := x509.MarshalPKIXPublicKey(&signingKey.PublicKey)
+		require.NoError(t, err)
+		status, body = call("user-key", "PUT", "/users/20/public-key", publicKeyRequest{PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER}))})
+		require.Equal(t, http.StatusOK, status, string(body))
+		sign := func(message string) string {
+			digest := sha256.Sum256([]byte(message))
+			signature, err := ecdsa.SignASN1(rand.Reader, signingKey, digest[:])
+			require.NoError(t, err)
+			return base64.StdEncoding.EncodeToString(signature)
+		}
+
+		for _, template := range []chaincode.ContractTemplate{
+			{ID: "platform-terms", ContractType: chaincode.PlatformContractType, Version: 1, DocumentHash: "platform-hash"},
+			{ID: "trading-terms", ContractType: chaincode.TradingContractType, Version: 1, DocumentHash: "trading-hash"},
+		} {
+			status, body = call("admin-key", "POST", "/contract-templates", template)
+			require.Equal(t, http.StatusCreated, status, string(body))
+		}
+		status, body = call("user-key", "PUT", "/users/20/platform-contract", contractSignatureRequest{TemplateID: "platform-terms", Version: 1, Signature: sign(chaincode.ContractSigningMessage("20", "platform-terms", 1, "platform-hash"))})
+		require.Equal(t, http.StatusOK, status, string(body))
+		expiresOn := time.Now().AddDate(1, 0, 0).Unix()
+		terms := chaincode.TradingContractTerms([]string{chaincode.BuyAction}, expiresOn, 0)
+		status, body = call("user-key", "PUT", "/users/20/trading-contract", contractSignatureRequest{TemplateID: "trading-terms", Version: 1,
+			Signature: sign(chaincode.ContractSigningMessage("20", "trading-terms", 1, "trading-hash", terms...)), Actions: []string{chaincode.BuyAction}, ExpiresOn: expiresOn})
+		require.Equal(t, http.StatusOK, status, string(body))
+
+		order := chaincode.Order{BidStatus: "BidCreated", ID: "1", OnMarketPrice: "0", OrderCost: 200, PaymentID: "payment1", SlotID: "slot1",
+			TotalQuantity: 300, UnitCost: 3.5, UserID: "20", SlotExecDate: time.Now().AddDate(0, 0, 1).Unix(), UserAction: chaincode.BuyAction}
+		status, body = call("user-key", "POST", "/orders", order)
+		require.Equal(t, http.StatusCreated, status, string(body))
+		status, body = call("user-key", "PATCH", "/orders/1", amendOrderRequest{TotalQuantity: 250, UnitCost: 3.25})
+		require.Equal(t, http.StatusOK, status, string(body))
+		status, body = call("user-key", "GET", "/orders/1", nil)
+		require.Equal(t, http.StatusOK, status, string(body))
+		require.NoError(t, json.Unmarshal(body, &order))
+		assert.Equal(t, int64(250), order.TotalQuantity)
+
+		payment := paymentRequest{
+			Detail:  chaincode.PaymentDetail{ID: "detail1", DebitedFrom: "20", CreditedTo: "grid", TotalUnitCost: 812.5},
+			Payment: chaincode.Payment{ID: "payment1", OrderID: "1", PaymentDetailID: "detail1", PaymentType: "Buy", TotalAmount: 812.5, UserID: "20"},
+			Salt:    "salt1",
+		}
+		status, body = call("user-key", "POST", "/payments", payment)
+		require.Equal(t, http.StatusCreated, status, string(body))
+		status, body = call("user-key", "POST", "/payments", payment)
+		assert.Equal(t, http.StatusConflict, status, string(body))
+
+		var payments []chaincode.Payment
+		status, body = call("user-key", "GET", "/users/20/payments", nil)
+		require.Equal(t, http.StatusOK, status, string(body))
+		require.NoError(t, json.Unmarshal(body, &payments))
+		require.Len(t, payments, 1)
+		assert.Equal(t, "payment1", payments[0].ID)
+
+		status, body = call("user-key", "POST", "/bid-matches/unknown/energy-bids", chaincode.EnergyBid{ID: "bid1"})
+		assert.Equal(t, http.StatusNotFound, status, string(body))
+	})
+
+	// Test Case 4: The OpenAPI document describes every route with the schemas of its bodies
+	t.Run("OpenAPI", func(t *testing.T) {
+		status, body := call("", "GET", "/openapi.json", nil)
+		require.Equal(t, http.StatusOK, status)
+		var document struct {
+			Components struct {
+				Schemas map[string]struct {
+					Properties map[string]interface{} `json:"properties"`
+				} `json:"schemas"`
+			} `json:"components"`
+			OpenAPI string                                       `json:"openapi"`
+			Paths   map[string]map[string]map[string]interface{} `json:"paths"`
+		}
+		require.NoError(t, json.Unmarshal(body, &document))
+		assert.Equal(t, "3.0.3", document.OpenAPI)
+
+		operations := 0
+		for _, methods := range document.Paths {
+			operations += len(methods)
+		}
+		assert.Equal(t, len(routes), operations)
+		operation := document.Paths["/bid-matches/{id}/energy-bids"]["post"]
+		assert.Equal(t, "ProcessEnergyBid", operation["operationId"])
+		assert.Contains(t, operation["responses"], strconv.Itoa(http.StatusCreated))
+		assert.Contains(t, document.Components.Schemas["Order"].Properties, "slotExecDate")
+		assert.Contains(t, document.Components.Schemas["PaymentRequest"].Properties, "detail")
+	})
+}
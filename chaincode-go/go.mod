@@ -3,10 +3,12 @@ module github.com/nidish-r/battery-swapping-basic/chaincode-go
 go 1.17
 
 require (
+	github.com/golang/protobuf v1.5.2
 	github.com/hyperledger/fabric-chaincode-go v0.0.0-20220720122508-9207360bbddd
 	github.com/hyperledger/fabric-contract-api-go v1.2.0
 	github.com/hyperledger/fabric-protos-go v0.0.0-20220613214546-bf864f01d75e
 	github.com/stretchr/testify v1.8.0
+	google.golang.org/grpc v1.48.0
 )
 
 require (
@@ -18,7 +20,6 @@ require (
 	github.com/gobuffalo/envy v1.10.1 // indirect
 	github.com/gobuffalo/packd v1.0.1 // indirect
 	github.com/gobuffalo/packr v1.30.1 // indirect
-	github.com/golang/protobuf v1.5.2 // indirect
 	github.com/joho/godotenv v1.4.0 // indirect
 	github.com/josharian/intern v1.0.0 // indirect
 	github.com/mailru/easyjson v0.7.7 // indirect
@@ -31,7 +32,6 @@ require (
 	golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
 	golang.org/x/text v0.3.7 // indirect
 	google.golang.org/genproto v0.0.0-20220719170305-83ca9fad585f // indirect
-	google.golang.org/grpc v1.48.0 // indirect
 	google.golang.org/protobuf v1.28.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect